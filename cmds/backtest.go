@@ -0,0 +1,137 @@
+// 回测报告输出及导出
+
+package cmds
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/axiaoxin-com/investool/core"
+	"github.com/olekukonko/tablewriter"
+)
+
+// backtestMetricsRows 策略与基准指标对比行
+func backtestMetricsRows(report core.BacktestReport) [][]string {
+	return [][]string{
+		{"累计收益率", fmt.Sprintf("%.2f%%", report.Strategy.TotalReturn), fmt.Sprintf("%.2f%%", report.Benchmark.TotalReturn)},
+		{"年化收益率", fmt.Sprintf("%.2f%%", report.Strategy.CAGR), fmt.Sprintf("%.2f%%", report.Benchmark.CAGR)},
+		{"最大回撤", fmt.Sprintf("%.2f%%", report.Strategy.MaxDrawdown), fmt.Sprintf("%.2f%%", report.Benchmark.MaxDrawdown)},
+		{"平均每期换手率", fmt.Sprintf("%.2f%%", report.Strategy.AvgTurnover), "--"},
+		{"年化换手率", fmt.Sprintf("%.2f%%", report.Strategy.AnnualTurnover), "--"},
+	}
+}
+
+// backtestHoldingNames 持仓名称及权重
+func backtestHoldingNames(period core.BacktestPeriod) string {
+	if len(period.Holdings) == 0 {
+		return "空仓"
+	}
+	names := []string{}
+	for _, h := range period.Holdings {
+		names = append(names, fmt.Sprintf("%s(%.1f%%)", h.Name, h.Weight*100))
+	}
+	return strings.Join(names, " ")
+}
+
+// showBacktestReport 以表格形式输出回测结果
+func showBacktestReport(report core.BacktestReport) {
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetAlignment(tablewriter.ALIGN_LEFT)
+	table.SetRowLine(true)
+	table.SetHeader([]string{"指标", "策略", "基准"})
+	table.SetCaption(true, fmt.Sprintf("回测区间:%s ~ %s 候选股票:%d 超额年化收益率:%.2f%%",
+		report.StartDate, report.EndDate, report.UniverseSize, report.ExcessCAGR))
+	table.AppendBulk(backtestMetricsRows(report))
+	table.Render()
+
+	table = tablewriter.NewWriter(os.Stdout)
+	table.SetAlignment(tablewriter.ALIGN_LEFT)
+	table.SetRowLine(true)
+	table.SetAutoWrapText(true)
+	table.SetColWidth(80)
+	table.SetHeader([]string{"调仓日", "收益率", "基准收益率", "换手率", "持仓"})
+	for _, p := range report.Periods {
+		table.Append([]string{
+			p.Date,
+			fmt.Sprintf("%.2f%%", p.Return),
+			fmt.Sprintf("%.2f%%", p.BenchmarkReturn),
+			fmt.Sprintf("%.2f%%", p.Turnover),
+			backtestHoldingNames(p),
+		})
+	}
+	table.Render()
+	for _, note := range report.Notes {
+		fmt.Println("* " + note)
+	}
+}
+
+// backtestMarkdown 回测报告 markdown
+func backtestMarkdown(report core.BacktestReport) []byte {
+	buf := bytes.Buffer{}
+	fmt.Fprintf(&buf, "# 回测报告\n\n")
+	fmt.Fprintf(&buf, "回测区间：%s ~ %s，候选股票：%d，权重方式：%s，最多持仓：%d，基准：%s\n\n",
+		report.StartDate, report.EndDate, report.UniverseSize,
+		report.Options.Weighting, report.Options.MaxHoldings, report.Options.Benchmark)
+	fmt.Fprintf(&buf, "| 指标 | 策略 | 基准 |\n| --- | --- | --- |\n")
+	for _, row := range backtestMetricsRows(report) {
+		fmt.Fprintf(&buf, "| %s |\n", strings.Join(row, " | "))
+	}
+	fmt.Fprintf(&buf, "\n超额年化收益率：%.2f%%\n\n", report.ExcessCAGR)
+	fmt.Fprintf(&buf, "## 调仓记录\n\n| 调仓日 | 收益率 | 基准收益率 | 换手率 | 持仓 |\n| --- | --- | --- | --- | --- |\n")
+	for _, p := range report.Periods {
+		fmt.Fprintf(&buf, "| %s | %.2f%% | %.2f%% | %.2f%% | %s |\n",
+			p.Date, p.Return, p.BenchmarkReturn, p.Turnover, backtestHoldingNames(p))
+	}
+	fmt.Fprintf(&buf, "\n## 说明\n\n")
+	for _, note := range report.Notes {
+		fmt.Fprintf(&buf, "- %s\n", note)
+	}
+	return buf.Bytes()
+}
+
+// backtestNAVCSV 每日净值 csv
+func backtestNAVCSV(report core.BacktestReport) ([]byte, error) {
+	buf := bytes.Buffer{}
+	w := csv.NewWriter(&buf)
+	if err := w.Write([]string{"date", "nav", "benchmark"}); err != nil {
+		return nil, err
+	}
+	for _, n := range report.NAVs {
+		if err := w.Write([]string{n.Date, fmt.Sprintf("%.6f", n.NAV), fmt.Sprintf("%.6f", n.Benchmark)}); err != nil {
+			return nil, err
+		}
+	}
+	w.Flush()
+	return buf.Bytes(), w.Error()
+}
+
+// ExportBacktestReport 按文件后缀名导出回测报告：json 为完整报告，csv 为每日净值，md 为 markdown 报告
+func ExportBacktestReport(ctx context.Context, report core.BacktestReport, filename string) (err error) {
+	var result []byte
+	switch strings.ToLower(path.Ext(filename)) {
+	case ".json":
+		result, err = json.MarshalIndent(report, "", "  ")
+	case ".csv":
+		result, err = backtestNAVCSV(report)
+	case ".md", ".markdown":
+		result = backtestMarkdown(report)
+	default:
+		return fmt.Errorf("unsupported backtest report file type: %s", filename)
+	}
+	if err != nil {
+		return
+	}
+	if filedir := path.Dir(filename); filedir != "" {
+		if _, err := os.Stat(filedir); os.IsNotExist(err) {
+			os.MkdirAll(filedir, 0755)
+		}
+	}
+	return ioutil.WriteFile(filename, result, 0666)
+}
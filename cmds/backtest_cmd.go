@@ -0,0 +1,122 @@
+// 回测 cli command
+
+package cmds
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/axiaoxin-com/investool/core"
+	"github.com/axiaoxin-com/logging"
+	"github.com/urfave/cli/v2"
+)
+
+const (
+	// ProcessorBacktest 策略回测
+	ProcessorBacktest = "backtest"
+)
+
+// FlagsBacktest backtest cli flags
+func FlagsBacktest() []cli.Flag {
+	return []cli.Flag{
+		&cli.StringFlag{
+			Name:        "filename",
+			Aliases:     []string{"f"},
+			Value:       "",
+			Usage:       "回测报告导出文件名，根据后缀名导出: json 完整报告，csv 每日净值，md markdown 报告",
+			DefaultText: "",
+		},
+		&cli.StringFlag{
+			Name:        "backtest.start_date",
+			Value:       core.DefaultBacktestOptions.StartDate,
+			Usage:       "回测开始日期",
+			DefaultText: core.DefaultBacktestOptions.StartDate,
+		},
+		&cli.StringFlag{
+			Name:        "backtest.end_date",
+			Value:       core.DefaultBacktestOptions.EndDate,
+			Usage:       "回测结束日期，为空则为当前日期",
+			DefaultText: core.DefaultBacktestOptions.EndDate,
+		},
+		&cli.StringFlag{
+			Name:        "backtest.weighting",
+			Value:       core.DefaultBacktestOptions.Weighting,
+			Usage:       "持仓权重方式 (equal 等权 或 score 按评分加权)",
+			DefaultText: core.DefaultBacktestOptions.Weighting,
+		},
+		&cli.IntFlag{
+			Name:        "backtest.max_holdings",
+			Value:       core.DefaultBacktestOptions.MaxHoldings,
+			Usage:       "每期最多持仓数量，按评分从高到低选取，0 表示不限制",
+			DefaultText: fmt.Sprint(core.DefaultBacktestOptions.MaxHoldings),
+		},
+		&cli.StringFlag{
+			Name:        "backtest.benchmark",
+			Value:       core.DefaultBacktestOptions.Benchmark,
+			Usage:       "基准指数 secid",
+			DefaultText: core.DefaultBacktestOptions.Benchmark,
+		},
+	}
+}
+
+// NewBacktestOptions 从命令行参数解析 BacktestOptions
+func NewBacktestOptions(c *cli.Context) (core.BacktestOptions, error) {
+	opts := core.DefaultBacktestOptions
+	opts.StartDate = c.String("backtest.start_date")
+	opts.EndDate = c.String("backtest.end_date")
+	opts.Weighting = c.String("backtest.weighting")
+	opts.MaxHoldings = c.Int("backtest.max_holdings")
+	opts.Benchmark = c.String("backtest.benchmark")
+	if opts.Weighting != core.BacktestWeightingEqual && opts.Weighting != core.BacktestWeightingScore {
+		return opts, fmt.Errorf("invalid backtest.weighting %s", opts.Weighting)
+	}
+	return opts, nil
+}
+
+// ActionBacktest cli action
+func ActionBacktest() func(c *cli.Context) error {
+	return func(c *cli.Context) error {
+		ctx := context.Background()
+		loglevel := c.String("loglevel")
+		logging.SetLevel(loglevel)
+
+		opts, err := NewBacktestOptions(c)
+		if err != nil {
+			return err
+		}
+		checkerOpts := NewCheckerOptions(c)
+		if err := InitCheckerRuleSet(c, checkerOpts); err != nil {
+			return err
+		}
+		// 回测按各调仓日检测，忽略指定的检测时间点
+		checkerOpts.AsOf = ""
+		selector := core.NewSelector(ctx, NewFilter(c), core.NewChecker(ctx, checkerOpts))
+		report, err := core.NewBacktester(ctx, selector, opts).Run(ctx)
+		if err != nil {
+			return err
+		}
+		showBacktestReport(report)
+		if filename := c.String("filename"); filename != "" {
+			if err := ExportBacktestReport(ctx, report, filename); err != nil {
+				return err
+			}
+			logging.Infof(ctx, "backtest report exported to %s", filename)
+		}
+		return nil
+	}
+}
+
+// CommandBacktest 策略回测 cli command
+func CommandBacktest() *cli.Command {
+	flags := FlagsBacktest()
+	flags = append(flags, FlagsFilter()...)
+	flags = append(flags, FlagsCheckerOptions()...)
+	cmd := &cli.Command{
+		Name:      ProcessorBacktest,
+		Usage:     "选股策略回测",
+		UsageText: "按筛选条件及检测条件在各财报季后调仓，计算策略收益、最大回撤、换手率并与基准指数对比",
+		Flags:     flags,
+		Action:    ActionBacktest(),
+	}
+	return cmd
+}
@@ -0,0 +1,36 @@
+package cmds
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/axiaoxin-com/investool/core"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExportBacktestReport(t *testing.T) {
+	report := core.BacktestReport{
+		Options:   core.DefaultBacktestOptions,
+		StartDate: "2021-05-01",
+		EndDate:   "2021-06-30",
+		Periods: []core.BacktestPeriod{
+			{Date: "2021-05-01", Holdings: []core.BacktestHolding{{Code: "600000.SH", Name: "浦发银行", Weight: 1}}, Return: 10},
+		},
+		NAVs: []core.BacktestNAV{
+			{Date: "2021-05-06", NAV: 1, Benchmark: 1},
+			{Date: "2021-06-30", NAV: 1.1, Benchmark: 1.05},
+		},
+	}
+	dir := t.TempDir()
+	for _, name := range []string{"report.json", "report.csv", "report.md"} {
+		filename := filepath.Join(dir, name)
+		require.Nil(t, ExportBacktestReport(_ctx, report, filename))
+		b, err := ioutil.ReadFile(filename)
+		require.Nil(t, err)
+		require.NotEmpty(t, b)
+	}
+	b, _ := ioutil.ReadFile(filepath.Join(dir, "report.csv"))
+	require.Equal(t, "date,nav,benchmark\n2021-05-06,1.000000,1.000000\n2021-06-30,1.100000,1.050000\n", string(b))
+	require.NotNil(t, ExportBacktestReport(_ctx, report, filepath.Join(dir, "report.xlsx")))
+}
@@ -4,6 +4,7 @@ package cmds
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"os"
 	"strings"
@@ -18,24 +19,29 @@ import (
 func Check(ctx context.Context, keywords []string, opts core.CheckerOptions) (results map[string]core.CheckResult, err error) {
 	results = make(map[string]core.CheckResult)
 	searcher := core.NewSearcher(ctx)
-	stocks, err := searcher.SearchStocks(ctx, keywords)
+	checker := core.NewChecker(ctx, opts)
+	// 巴菲特评分需要利润表、现金流量表及资产负债表
+	stockOpts := checker.StockOptions(models.EnrichGincome | models.EnrichCashflow | models.EnrichBalance)
+	stocks, err := searcher.SearchStocksWithOptions(ctx, keywords, stockOpts)
 	if err != nil {
 		logging.Fatal(ctx, err.Error())
 	}
 
 	for _, stock := range stocks {
-		checker := core.NewChecker(ctx, opts)
 		checkResult, ok := checker.CheckFundamentals(ctx, stock)
 		k := fmt.Sprintf("%s-%s", stock.BaseInfo.SecurityNameAbbr, stock.BaseInfo.Secucode)
 		results[k] = checkResult
 
-		if opts.OutputFormat == "markdown" {
+		switch opts.OutputFormat {
+		case "json":
+			// json 在全部检测完成后统一输出
+		case "markdown":
 			if !ok {
 				renderMarkdown(checkResult, []string{k, "FAILED"}, stock)
 			} else {
 				renderMarkdown(checkResult, []string{k, "OK"}, stock)
 			}
-		} else {
+		default:
 			// 默认使用表格输出
 			table := newTable()
 			if !ok {
@@ -45,6 +51,13 @@ func Check(ctx context.Context, keywords []string, opts core.CheckerOptions) (re
 			}
 		}
 	}
+	if opts.OutputFormat == "json" {
+		b, err := json.MarshalIndent(results, "", "  ")
+		if err != nil {
+			return nil, err
+		}
+		fmt.Println(string(b))
+	}
 	return results, nil
 }
 
@@ -77,15 +90,26 @@ func renderTable(table *tablewriter.Table, checkResult core.CheckResult, footers
 	table.SetColumnAlignment([]int{tablewriter.ALIGN_LEFT, tablewriter.ALIGN_LEFT})
 	table.SetHeaderAlignment(tablewriter.ALIGN_LEFT)
 
-	for k, m := range checkResult {
-		row := []string{k, strings.ReplaceAll(m["desc"], "<br/>", "\n")}
+	for _, item := range checkResult.Items {
+		row := []string{item.Label, item.Desc}
 
-		if m["ok"] == "false" {
+		switch item.Status {
+		case core.CheckStatusFail:
 			table.Rich(
 				row,
 				[]tablewriter.Colors{{tablewriter.Bold, tablewriter.BgRedColor}, {tablewriter.Bold, tablewriter.BgRedColor}},
 			)
-		} else {
+		case core.CheckStatusWarn:
+			table.Rich(
+				row,
+				[]tablewriter.Colors{{tablewriter.Bold, tablewriter.FgYellowColor}, {tablewriter.Bold, tablewriter.FgYellowColor}},
+			)
+		case core.CheckStatusUnknown:
+			table.Rich(
+				row,
+				[]tablewriter.Colors{{tablewriter.Bold, tablewriter.FgMagentaColor}, {tablewriter.Bold, tablewriter.FgMagentaColor}},
+			)
+		default:
 			table.Append(row)
 		}
 	}
@@ -111,13 +135,13 @@ func renderMarkdown(checkResult core.CheckResult, footers []string, stock models
 	fmt.Println("| --- | --- |")
 
 	// 输出表格内容
-	for k, m := range checkResult {
-		desc := strings.ReplaceAll(m["desc"], "<br/>", "<br>")
-		if m["ok"] == "false" {
+	for _, item := range checkResult.Items {
+		desc := strings.Join(item.DescLines(), "<br>")
+		if item.Status == core.CheckStatusFail {
 			// 失败项目使用高亮标记
-			fmt.Printf("| **%s** | **%s** |\n", k, desc)
+			fmt.Printf("| **%s** | **%s** %s |\n", item.Label, desc, item.Status.StatusSymbol())
 		} else {
-			fmt.Printf("| %s | %s |\n", k, desc)
+			fmt.Printf("| %s | %s %s |\n", item.Label, desc, item.Status.StatusSymbol())
 		}
 	}
 
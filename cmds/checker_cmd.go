@@ -171,6 +171,12 @@ func FlagsCheckerOptions() []cli.Flag {
 			Usage:       "是否检测净利润逐年递增",
 			DefaultText: fmt.Sprint(core.DefaultCheckerOptions.IsCheckNetprofitGrow),
 		},
+		&cli.BoolFlag{
+			Name:        "checker.use_ttm_growth",
+			Value:       core.DefaultCheckerOptions.UseTTMGrowth,
+			Usage:       "EPS、营收、净利润增长及 PEG 使用最新一期财报的 TTM 数据检测",
+			DefaultText: fmt.Sprint(core.DefaultCheckerOptions.UseTTMGrowth),
+		},
 		&cli.Float64Flag{
 			Name:        "checker.min_gxl",
 			Value:       core.DefaultCheckerOptions.MinGxl,
@@ -180,9 +186,195 @@ func FlagsCheckerOptions() []cli.Flag {
 		&cli.StringFlag{
 			Name:        "checker.output_format",
 			Value:       core.DefaultCheckerOptions.OutputFormat,
-			Usage:       "输出格式 (table 或 markdown)",
+			Usage:       "输出格式 (table, markdown 或 json)",
 			DefaultText: core.DefaultCheckerOptions.OutputFormat,
 		},
+		&cli.StringFlag{
+			Name:        "checker.rule_set",
+			Value:       core.DefaultCheckerOptions.RuleSet,
+			Usage:       "额外执行的检测规则集名称，规则集定义在 checker.rules_file 中",
+			DefaultText: core.DefaultCheckerOptions.RuleSet,
+		},
+		&cli.StringFlag{
+			Name:        "checker.rules_file",
+			Value:       core.RuleSetsFilename,
+			Usage:       "检测规则集文件 (toml 或 yaml)",
+			DefaultText: core.RuleSetsFilename,
+		},
+		&cli.IntFlag{
+			Name:        "checker.valuation.eps_years",
+			Value:       core.DefaultCheckerOptions.Valuation.EPSYears,
+			Usage:       "合理价基准 EPS 取近 n 年年报 EPS 均值",
+			DefaultText: fmt.Sprint(core.DefaultCheckerOptions.Valuation.EPSYears),
+		},
+		&cli.Float64Flag{
+			Name:        "checker.valuation.max_growth_rate",
+			Value:       core.DefaultCheckerOptions.Valuation.MaxGrowthRate,
+			Usage:       "合理价营收增长率上限(%)",
+			DefaultText: fmt.Sprint(core.DefaultCheckerOptions.Valuation.MaxGrowthRate),
+		},
+		&cli.Float64Flag{
+			Name:        "checker.valuation.explosive_growth_threshold",
+			Value:       core.DefaultCheckerOptions.Valuation.ExplosiveGrowthThreshold,
+			Usage:       "上年 EPS 增长率超过该值(%)时视为爆发增长",
+			DefaultText: fmt.Sprint(core.DefaultCheckerOptions.Valuation.ExplosiveGrowthThreshold),
+		},
+		&cli.Float64Flag{
+			Name:        "checker.valuation.explosive_growth_discount",
+			Value:       core.DefaultCheckerOptions.Valuation.ExplosiveGrowthDiscount,
+			Usage:       "爆发增长时合理价的折扣系数",
+			DefaultText: fmt.Sprint(core.DefaultCheckerOptions.Valuation.ExplosiveGrowthDiscount),
+		},
+		&cli.BoolFlag{
+			Name:        "checker.is_check_dcf",
+			Value:       core.DefaultCheckerOptions.IsCheckDCF,
+			Usage:       "是否检测 DCF 估值安全边际",
+			DefaultText: fmt.Sprint(core.DefaultCheckerOptions.IsCheckDCF),
+		},
+		&cli.BoolFlag{
+			Name:        "checker.is_check_ddm",
+			Value:       core.DefaultCheckerOptions.IsCheckDDM,
+			Usage:       "是否检测 DDM 估值安全边际，只适用于高分红股票",
+			DefaultText: fmt.Sprint(core.DefaultCheckerOptions.IsCheckDDM),
+		},
+		&cli.Float64Flag{
+			Name:        "checker.min_margin_of_safety",
+			Value:       core.DefaultCheckerOptions.MinMarginOfSafety,
+			Usage:       "DCF、DDM 估值的最低安全边际(%)",
+			DefaultText: fmt.Sprint(core.DefaultCheckerOptions.MinMarginOfSafety),
+		},
+		&cli.Float64Flag{
+			Name:        "checker.dcf.discount_rate",
+			Value:       core.DefaultCheckerOptions.DCF.DiscountRate,
+			Usage:       "DCF 折现率(%)，为 0 时使用中债 AAA 公司债收益率+风险溢价",
+			DefaultText: fmt.Sprint(core.DefaultCheckerOptions.DCF.DiscountRate),
+		},
+		&cli.Float64Flag{
+			Name:        "checker.dcf.risk_premium",
+			Value:       core.DefaultCheckerOptions.DCF.RiskPremium,
+			Usage:       "DCF 风险溢价(%)",
+			DefaultText: fmt.Sprint(core.DefaultCheckerOptions.DCF.RiskPremium),
+		},
+		&cli.IntFlag{
+			Name:        "checker.dcf.fcf_years",
+			Value:       core.DefaultCheckerOptions.DCF.FCFYears,
+			Usage:       "DCF 基准自由现金流取近 n 年年报均值",
+			DefaultText: fmt.Sprint(core.DefaultCheckerOptions.DCF.FCFYears),
+		},
+		&cli.IntFlag{
+			Name:        "checker.dcf.high_growth_years",
+			Value:       core.DefaultCheckerOptions.DCF.HighGrowthYears,
+			Usage:       "DCF 高速增长期年数",
+			DefaultText: fmt.Sprint(core.DefaultCheckerOptions.DCF.HighGrowthYears),
+		},
+		&cli.Float64Flag{
+			Name:        "checker.dcf.high_growth_rate",
+			Value:       core.DefaultCheckerOptions.DCF.HighGrowthRate,
+			Usage:       "DCF 高速增长期增长率(%)",
+			DefaultText: fmt.Sprint(core.DefaultCheckerOptions.DCF.HighGrowthRate),
+		},
+		&cli.IntFlag{
+			Name:        "checker.dcf.stable_growth_years",
+			Value:       core.DefaultCheckerOptions.DCF.StableGrowthYears,
+			Usage:       "DCF 稳定增长期年数",
+			DefaultText: fmt.Sprint(core.DefaultCheckerOptions.DCF.StableGrowthYears),
+		},
+		&cli.Float64Flag{
+			Name:        "checker.dcf.stable_growth_rate",
+			Value:       core.DefaultCheckerOptions.DCF.StableGrowthRate,
+			Usage:       "DCF 稳定增长期增长率(%)",
+			DefaultText: fmt.Sprint(core.DefaultCheckerOptions.DCF.StableGrowthRate),
+		},
+		&cli.Float64Flag{
+			Name:        "checker.dcf.terminal_growth_rate",
+			Value:       core.DefaultCheckerOptions.DCF.TerminalGrowthRate,
+			Usage:       "DCF 永续增长率(%)",
+			DefaultText: fmt.Sprint(core.DefaultCheckerOptions.DCF.TerminalGrowthRate),
+		},
+		&cli.Float64Flag{
+			Name:        "checker.ddm.discount_rate",
+			Value:       core.DefaultCheckerOptions.DDM.DiscountRate,
+			Usage:       "DDM 折现率(%)，为 0 时使用中债 AAA 公司债收益率+风险溢价",
+			DefaultText: fmt.Sprint(core.DefaultCheckerOptions.DDM.DiscountRate),
+		},
+		&cli.Float64Flag{
+			Name:        "checker.ddm.risk_premium",
+			Value:       core.DefaultCheckerOptions.DDM.RiskPremium,
+			Usage:       "DDM 风险溢价(%)",
+			DefaultText: fmt.Sprint(core.DefaultCheckerOptions.DDM.RiskPremium),
+		},
+		&cli.IntFlag{
+			Name:        "checker.ddm.growth_years",
+			Value:       core.DefaultCheckerOptions.DDM.GrowthYears,
+			Usage:       "DDM 股利增长期年数",
+			DefaultText: fmt.Sprint(core.DefaultCheckerOptions.DDM.GrowthYears),
+		},
+		&cli.Float64Flag{
+			Name:        "checker.ddm.growth_rate",
+			Value:       core.DefaultCheckerOptions.DDM.GrowthRate,
+			Usage:       "DDM 股利增长期增长率(%)",
+			DefaultText: fmt.Sprint(core.DefaultCheckerOptions.DDM.GrowthRate),
+		},
+		&cli.Float64Flag{
+			Name:        "checker.ddm.terminal_growth_rate",
+			Value:       core.DefaultCheckerOptions.DDM.TerminalGrowthRate,
+			Usage:       "DDM 永续增长率(%)",
+			DefaultText: fmt.Sprint(core.DefaultCheckerOptions.DDM.TerminalGrowthRate),
+		},
+		&cli.Float64Flag{
+			Name:        "checker.ddm.min_payout_ratio",
+			Value:       core.DefaultCheckerOptions.DDM.MinPayoutRatio,
+			Usage:       "DDM 最低分红率(%)，低于该值时不适用股利折现模型",
+			DefaultText: fmt.Sprint(core.DefaultCheckerOptions.DDM.MinPayoutRatio),
+		},
+		&cli.BoolFlag{
+			Name:        "checker.is_check_dupont",
+			Value:       core.DefaultCheckerOptions.IsCheckDuPont,
+			Usage:       "是否检测 ROE 增长是否主要由杠杆(权益乘数)驱动",
+			DefaultText: fmt.Sprint(core.DefaultCheckerOptions.IsCheckDuPont),
+		},
+		&cli.Float64Flag{
+			Name:        "checker.max_leverage_roe_share",
+			Value:       core.DefaultCheckerOptions.MaxLeverageROEShare,
+			Usage:       "杜邦分析中 ROE 增长的权益乘数贡献最大比例(%)",
+			DefaultText: fmt.Sprint(core.DefaultCheckerOptions.MaxLeverageROEShare),
+		},
+		&cli.Float64Flag{
+			Name:        "checker.min_piotroski_f_score",
+			Value:       core.DefaultCheckerOptions.MinPiotroskiFScore,
+			Usage:       "最低 Piotroski F-Score(0-9)，为 0 时不检测",
+			DefaultText: fmt.Sprint(core.DefaultCheckerOptions.MinPiotroskiFScore),
+		},
+		&cli.Float64Flag{
+			Name:        "checker.min_altman_z_score",
+			Value:       core.DefaultCheckerOptions.MinAltmanZScore,
+			Usage:       "最低 Altman Z-Score，为 0 时不检测，非制造业使用非制造业模型",
+			DefaultText: fmt.Sprint(core.DefaultCheckerOptions.MinAltmanZScore),
+		},
+		&cli.BoolFlag{
+			Name:        "checker.is_check_beneish_m",
+			Value:       core.DefaultCheckerOptions.IsCheckBeneishM,
+			Usage:       "是否检测 Beneish M-Score 财务操纵风险",
+			DefaultText: fmt.Sprint(core.DefaultCheckerOptions.IsCheckBeneishM),
+		},
+		&cli.Float64Flag{
+			Name:        "checker.max_beneish_m_score",
+			Value:       core.DefaultCheckerOptions.MaxBeneishMScore,
+			Usage:       "最高 Beneish M-Score，高于该值时可能存在财务操纵",
+			DefaultText: fmt.Sprint(core.DefaultCheckerOptions.MaxBeneishMScore),
+		},
+		&cli.BoolFlag{
+			Name:        "checker.unknown_as_pass",
+			Value:       core.DefaultCheckerOptions.UnknownAsPass,
+			Usage:       "依赖数据缺失无法判断的检测项视为通过，默认视为未通过",
+			DefaultText: fmt.Sprint(core.DefaultCheckerOptions.UnknownAsPass),
+		},
+		&cli.StringFlag{
+			Name:        "checker.as_of",
+			Value:       core.DefaultCheckerOptions.AsOf,
+			Usage:       "按历史时间点检测，忽略该日期时尚未发布的财报，格式: 2021-06-30",
+			DefaultText: core.DefaultCheckerOptions.AsOf,
+		},
 	}
 }
 
@@ -212,11 +404,68 @@ func NewCheckerOptions(c *cli.Context) core.CheckerOptions {
 	checkerOpts.IsCheckEPSGrow = c.Bool("checker.is_check_eps_grow")
 	checkerOpts.IsCheckRevGrow = c.Bool("checker.is_check_rev_grow")
 	checkerOpts.IsCheckNetprofitGrow = c.Bool("checker.is_check_netprofit_grow")
+	checkerOpts.UseTTMGrowth = c.Bool("checker.use_ttm_growth")
 	checkerOpts.MinGxl = c.Float64("checker.min_gxl")
 	checkerOpts.OutputFormat = c.String("checker.output_format")
+	checkerOpts.RuleSet = c.String("checker.rule_set")
+	checkerOpts.AsOf = c.String("checker.as_of")
+	checkerOpts.IsCheckDCF = c.Bool("checker.is_check_dcf")
+	checkerOpts.IsCheckDDM = c.Bool("checker.is_check_ddm")
+	checkerOpts.MinMarginOfSafety = c.Float64("checker.min_margin_of_safety")
+	checkerOpts.DCF.DiscountRate = c.Float64("checker.dcf.discount_rate")
+	checkerOpts.DCF.RiskPremium = c.Float64("checker.dcf.risk_premium")
+	checkerOpts.DCF.FCFYears = c.Int("checker.dcf.fcf_years")
+	checkerOpts.DCF.HighGrowthYears = c.Int("checker.dcf.high_growth_years")
+	checkerOpts.DCF.HighGrowthRate = c.Float64("checker.dcf.high_growth_rate")
+	checkerOpts.DCF.StableGrowthYears = c.Int("checker.dcf.stable_growth_years")
+	checkerOpts.DCF.StableGrowthRate = c.Float64("checker.dcf.stable_growth_rate")
+	checkerOpts.DCF.TerminalGrowthRate = c.Float64("checker.dcf.terminal_growth_rate")
+	checkerOpts.DDM.DiscountRate = c.Float64("checker.ddm.discount_rate")
+	checkerOpts.DDM.RiskPremium = c.Float64("checker.ddm.risk_premium")
+	checkerOpts.DDM.GrowthYears = c.Int("checker.ddm.growth_years")
+	checkerOpts.DDM.GrowthRate = c.Float64("checker.ddm.growth_rate")
+	checkerOpts.DDM.TerminalGrowthRate = c.Float64("checker.ddm.terminal_growth_rate")
+	checkerOpts.DDM.MinPayoutRatio = c.Float64("checker.ddm.min_payout_ratio")
+	checkerOpts.IsCheckDuPont = c.Bool("checker.is_check_dupont")
+	checkerOpts.MaxLeverageROEShare = c.Float64("checker.max_leverage_roe_share")
+	checkerOpts.MinPiotroskiFScore = c.Float64("checker.min_piotroski_f_score")
+	checkerOpts.MinAltmanZScore = c.Float64("checker.min_altman_z_score")
+	checkerOpts.IsCheckBeneishM = c.Bool("checker.is_check_beneish_m")
+	checkerOpts.MaxBeneishMScore = c.Float64("checker.max_beneish_m_score")
+	checkerOpts.UnknownAsPass = c.Bool("checker.unknown_as_pass")
+	checkerOpts.Valuation.EPSYears = c.Int("checker.valuation.eps_years")
+	checkerOpts.Valuation.MaxGrowthRate = c.Float64("checker.valuation.max_growth_rate")
+	checkerOpts.Valuation.ExplosiveGrowthThreshold = c.Float64("checker.valuation.explosive_growth_threshold")
+	checkerOpts.Valuation.ExplosiveGrowthDiscount = c.Float64("checker.valuation.explosive_growth_discount")
 	return checkerOpts
 }
 
+// InitCheckerRuleSet 按命令行参数加载规则集文件并校验指定的规则集是否存在，同时校验检测时间点格式及 DCF、DDM 参数
+func InitCheckerRuleSet(c *cli.Context, opts core.CheckerOptions) error {
+	if _, err := opts.AsOfTime(); err != nil {
+		return fmt.Errorf("invalid checker.as_of %s: %w", opts.AsOf, err)
+	}
+	if err := opts.DCF.Validate(); err != nil {
+		return fmt.Errorf("invalid checker.dcf options: %w", err)
+	}
+	if err := opts.DDM.Validate(); err != nil {
+		return fmt.Errorf("invalid checker.ddm options: %w", err)
+	}
+	if opts.RuleSet == "" {
+		return nil
+	}
+	if rulesFile := c.String("checker.rules_file"); rulesFile != core.RuleSetsFilename {
+		core.RuleSetsFilename = rulesFile
+		if err := core.InitRuleSets(); err != nil {
+			return err
+		}
+	}
+	if _, exists := core.RuleSets[opts.RuleSet]; !exists {
+		return fmt.Errorf("rule set %s not found in %s", opts.RuleSet, core.RuleSetsFilename)
+	}
+	return nil
+}
+
 // ActionChecker cli action
 func ActionChecker() func(c *cli.Context) error {
 	return func(c *cli.Context) error {
@@ -226,6 +475,9 @@ func ActionChecker() func(c *cli.Context) error {
 		ctx := context.Background()
 		keywords := strings.Split(keyword, "/")
 		opts := NewCheckerOptions(c)
+		if err := InitCheckerRuleSet(c, opts); err != nil {
+			return err
+		}
 		Check(ctx, keywords, opts)
 		return nil
 	}
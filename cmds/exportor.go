@@ -23,6 +23,7 @@ type Exportor struct {
 
 // New 创建要导出的数据列表
 func New(ctx context.Context, stocks models.StockList, selector core.Selector) Exportor {
+	stocks.SetIndustryBuffettScore(models.IndustryBuffettScoreTable)
 	dlist := models.ExportorDataList{}
 	for _, s := range stocks {
 		dlist = append(dlist, models.NewExportorData(ctx, s))
@@ -220,6 +220,9 @@ func ActionExportor() func(c *cli.Context) error {
 		logging.SetLevel(loglevel)
 
 		checkerOpts := NewCheckerOptions(c)
+		if err := InitCheckerRuleSet(c, checkerOpts); err != nil {
+			return err
+		}
 		checker := core.NewChecker(ctx, checkerOpts)
 		if c.Bool("disable_check") {
 			checker = nil
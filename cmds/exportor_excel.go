@@ -91,7 +91,7 @@ func (e Exportor) ExportExcel(ctx context.Context, filename string) (result []by
 			colNum := i + 1
 			width := 30.0
 			switch header {
-			case "主营构成", "每股收益预测":
+			case "主营构成", "每股收益预测", "杜邦分析":
 				width = 45.0
 			case "公司信息":
 				width = 65.0
@@ -30,8 +30,10 @@ func ActionJSON() func(c *cli.Context) error {
 
 		if c.Bool("d") {
 			cron.SyncFund()
+			cron.SyncFundFees()
 			cron.SyncFundManagers()
 			cron.SyncIndustryList()
+			cron.SyncIndustryBuffettScores()
 			return nil
 		}
 		return nil
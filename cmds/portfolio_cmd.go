@@ -0,0 +1,592 @@
+// 持仓账本 cli command
+
+package cmds
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/axiaoxin-com/investool/core"
+	"github.com/axiaoxin-com/investool/portfolio"
+	"github.com/olekukonko/tablewriter"
+	"github.com/urfave/cli/v2"
+)
+
+const (
+	// ProcessorPortfolio 持仓账本
+	ProcessorPortfolio = "portfolio"
+)
+
+// FlagsPortfolio portfolio cli flags
+func FlagsPortfolio() []cli.Flag {
+	return []cli.Flag{
+		&cli.StringFlag{
+			Name:        "portfolio.file",
+			Value:       portfolio.LedgerFilename,
+			Usage:       "持仓账本文件",
+			DefaultText: portfolio.LedgerFilename,
+		},
+		&cli.StringFlag{
+			Name:    "account",
+			Aliases: []string{"a"},
+			Value:   "",
+			Usage:   "持仓账户名称",
+		},
+	}
+}
+
+// FlagsPortfolioTransaction 添加交易 cli flags
+func FlagsPortfolioTransaction() []cli.Flag {
+	return []cli.Flag{
+		&cli.StringFlag{
+			Name:     "type",
+			Aliases:  []string{"t"},
+			Usage:    "交易类型 (buy 买入 sell 卖出 dividend 现金分红 split 拆股或送转)",
+			Required: true,
+		},
+		&cli.StringFlag{
+			Name:        "date",
+			Aliases:     []string{"d"},
+			Value:       time.Now().Format(portfolio.DateLayout),
+			Usage:       "交易日期",
+			DefaultText: "今天",
+		},
+		&cli.StringFlag{
+			Name:     "code",
+			Aliases:  []string{"c"},
+			Usage:    "股票代码",
+			Required: true,
+		},
+		&cli.StringFlag{
+			Name:  "name",
+			Usage: "股票名称",
+		},
+		&cli.Float64Flag{
+			Name:  "shares",
+			Usage: "买入或卖出股数",
+		},
+		&cli.Float64Flag{
+			Name:  "price",
+			Usage: "成交价",
+		},
+		&cli.Float64Flag{
+			Name:  "fee",
+			Usage: "交易费用",
+		},
+		&cli.Float64Flag{
+			Name:  "amount",
+			Usage: "分红到账现金总额（税后）",
+		},
+		&cli.Float64Flag{
+			Name:  "ratio",
+			Usage: "拆股或送转后每 1 股变为的股数，如 10 送 5 为 1.5",
+		},
+		&cli.StringFlag{
+			Name:  "note",
+			Usage: "备注",
+		},
+	}
+}
+
+// portfolioStore 按命令行参数创建账本存储
+func portfolioStore(c *cli.Context) portfolio.Store {
+	return portfolio.NewStore(c.String("portfolio.file"))
+}
+
+// portfolioAccount 读取命令行参数指定的账户
+func portfolioAccount(c *cli.Context) (portfolio.Account, error) {
+	name := c.String("account")
+	if name == "" {
+		return portfolio.Account{}, fmt.Errorf("account is required")
+	}
+	return portfolioStore(c).Account(name)
+}
+
+// ActionPortfolioShow 显示账户持仓及盈亏
+func ActionPortfolioShow() func(c *cli.Context) error {
+	return func(c *cli.Context) error {
+		ctx := context.Background()
+		a, err := portfolioAccount(c)
+		if err != nil {
+			return err
+		}
+		summary, err := core.PortfolioSummary(ctx, a)
+		if err != nil {
+			return err
+		}
+		showPortfolioSummary(summary)
+		return nil
+	}
+}
+
+// ActionPortfolioTransactions 显示账户交易记录
+func ActionPortfolioTransactions() func(c *cli.Context) error {
+	return func(c *cli.Context) error {
+		a, err := portfolioAccount(c)
+		if err != nil {
+			return err
+		}
+		table := tablewriter.NewWriter(os.Stdout)
+		table.SetAlignment(tablewriter.ALIGN_LEFT)
+		table.SetHeader([]string{"编号", "日期", "类型", "代码", "名称", "股数", "价格", "费用", "分红金额", "拆股比例", "备注"})
+		for _, tx := range a.SortedTransactions() {
+			table.Append([]string{
+				fmt.Sprint(tx.ID), tx.Date, string(tx.Type), tx.Code, tx.Name,
+				fmt.Sprint(tx.Shares), fmt.Sprint(tx.Price), fmt.Sprint(tx.Fee),
+				fmt.Sprint(tx.Amount), fmt.Sprint(tx.Ratio), tx.Note,
+			})
+		}
+		table.Render()
+		return nil
+	}
+}
+
+// ActionPortfolioAccounts 显示全部账户
+func ActionPortfolioAccounts() func(c *cli.Context) error {
+	return func(c *cli.Context) error {
+		accounts, err := portfolioStore(c).Accounts()
+		if err != nil {
+			return err
+		}
+		table := tablewriter.NewWriter(os.Stdout)
+		table.SetAlignment(tablewriter.ALIGN_LEFT)
+		table.SetHeader([]string{"账户", "描述", "成本方式", "交易数"})
+		for _, a := range accounts {
+			table.Append([]string{a.Name, a.Desc, a.CostMethod, fmt.Sprint(len(a.Transactions))})
+		}
+		table.Render()
+		return nil
+	}
+}
+
+// ActionPortfolioAddAccount 新建账户
+func ActionPortfolioAddAccount() func(c *cli.Context) error {
+	return func(c *cli.Context) error {
+		a := portfolio.Account{
+			Name:       c.String("account"),
+			Desc:       c.String("desc"),
+			CostMethod: c.String("cost_method"),
+		}
+		if err := portfolioStore(c).AddAccount(a); err != nil {
+			return err
+		}
+		fmt.Printf("account %s created\n", a.Name)
+		return nil
+	}
+}
+
+// ActionPortfolioAddTransaction 添加交易
+func ActionPortfolioAddTransaction() func(c *cli.Context) error {
+	return func(c *cli.Context) error {
+		tx := portfolio.Transaction{
+			Date:   c.String("date"),
+			Type:   portfolio.TransactionType(c.String("type")),
+			Code:   c.String("code"),
+			Name:   c.String("name"),
+			Shares: c.Float64("shares"),
+			Price:  c.Float64("price"),
+			Fee:    c.Float64("fee"),
+			Amount: c.Float64("amount"),
+			Ratio:  c.Float64("ratio"),
+			Note:   c.String("note"),
+		}
+		tx, err := portfolioStore(c).AddTransaction(c.String("account"), tx)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("transaction %d added\n", tx.ID)
+		return nil
+	}
+}
+
+// ActionPortfolioDeleteTransaction 删除交易
+func ActionPortfolioDeleteTransaction() func(c *cli.Context) error {
+	return func(c *cli.Context) error {
+		id := c.Int("id")
+		if err := portfolioStore(c).DeleteTransaction(c.String("account"), id); err != nil {
+			return err
+		}
+		fmt.Printf("transaction %d deleted\n", id)
+		return nil
+	}
+}
+
+// FlagsPortfolioRebalance 调仓 cli flags
+func FlagsPortfolioRebalance() []cli.Flag {
+	opts := portfolio.DefaultRebalanceOptions
+	return []cli.Flag{
+		&cli.StringSliceFlag{
+			Name:    "target",
+			Aliases: []string{"t"},
+			Usage:   "目标持仓金额（元），格式 股票代码=金额，可多次指定；未指定的持仓保持不变",
+		},
+		&cli.Float64Flag{
+			Name:  "cash",
+			Usage: "可用现金（元）",
+		},
+		&cli.Float64Flag{
+			Name:        "no_trade_band",
+			Value:       opts.NoTradeBand,
+			Usage:       "不交易区间，持仓权重与目标权重相差不超过该值（百分点）时不调整",
+			DefaultText: fmt.Sprint(opts.NoTradeBand),
+		},
+		&cli.IntFlag{
+			Name:        "lot_size",
+			Value:       opts.LotSize,
+			Usage:       "每手股数",
+			DefaultText: fmt.Sprint(opts.LotSize),
+		},
+		&cli.Float64Flag{
+			Name:        "commission_rate",
+			Value:       opts.Fees.CommissionRate,
+			Usage:       "佣金费率",
+			DefaultText: fmt.Sprint(opts.Fees.CommissionRate),
+		},
+		&cli.Float64Flag{
+			Name:        "min_commission",
+			Value:       opts.Fees.MinCommission,
+			Usage:       "单笔最低佣金（元）",
+			DefaultText: fmt.Sprint(opts.Fees.MinCommission),
+		},
+		&cli.Float64Flag{
+			Name:        "stamp_duty_rate",
+			Value:       opts.Fees.StampDutyRate,
+			Usage:       "印花税率（卖出）",
+			DefaultText: fmt.Sprint(opts.Fees.StampDutyRate),
+		},
+		&cli.Float64Flag{
+			Name:        "transfer_fee_rate",
+			Value:       opts.Fees.TransferFeeRate,
+			Usage:       "过户费率",
+			DefaultText: fmt.Sprint(opts.Fees.TransferFeeRate),
+		},
+	}
+}
+
+// parseRebalanceTargets 解析 股票代码=金额 格式的目标持仓金额
+func parseRebalanceTargets(values []string) (map[string]float64, []string, error) {
+	targets := map[string]float64{}
+	codes := []string{}
+	for _, v := range values {
+		kv := strings.SplitN(v, "=", 2)
+		if len(kv) != 2 {
+			return nil, nil, fmt.Errorf("invalid target %s", v)
+		}
+		amount, err := strconv.ParseFloat(kv[1], 64)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid target %s: %w", v, err)
+		}
+		if _, exists := targets[kv[0]]; !exists {
+			codes = append(codes, kv[0])
+		}
+		targets[kv[0]] = amount
+	}
+	return targets, codes, nil
+}
+
+// ActionPortfolioRebalance 生成调仓交易单
+func ActionPortfolioRebalance() func(c *cli.Context) error {
+	return func(c *cli.Context) error {
+		ctx := context.Background()
+		a, err := portfolioAccount(c)
+		if err != nil {
+			return err
+		}
+		targetAmounts, targetCodes, err := parseRebalanceTargets(c.StringSlice("target"))
+		if err != nil {
+			return err
+		}
+		positions, err := a.Positions()
+		if err != nil {
+			return err
+		}
+		codes := append([]string{}, targetCodes...)
+		for _, p := range positions {
+			if p.IsOpen() {
+				codes = append(codes, p.Code)
+			}
+		}
+		prices, err := core.QueryPrices(ctx, codes)
+		if err != nil {
+			return err
+		}
+		summary, err := a.Summarize(prices)
+		if err != nil {
+			return err
+		}
+		if len(summary.MissingPrices) > 0 {
+			return fmt.Errorf("无法获取现价: %v", summary.MissingPrices)
+		}
+		targets := []portfolio.RebalanceTarget{}
+		held := map[string]bool{}
+		for _, p := range summary.OpenPositions() {
+			held[p.Code] = true
+			target, exists := targetAmounts[p.Code]
+			if !exists {
+				target = p.MarketValue
+			}
+			targets = append(targets, portfolio.RebalanceTarget{Code: p.Code, Name: p.Name, Shares: p.Shares, Price: p.Price, TargetAmount: target})
+		}
+		for _, code := range targetCodes {
+			if !held[code] {
+				targets = append(targets, portfolio.RebalanceTarget{Code: code, Price: prices[code], TargetAmount: targetAmounts[code]})
+			}
+		}
+
+		opts := portfolio.RebalanceOptions{
+			LotSize:     c.Int("lot_size"),
+			NoTradeBand: c.Float64("no_trade_band"),
+			Cash:        c.Float64("cash"),
+			Fees: portfolio.FeeOptions{
+				CommissionRate:  c.Float64("commission_rate"),
+				MinCommission:   c.Float64("min_commission"),
+				StampDutyRate:   c.Float64("stamp_duty_rate"),
+				TransferFeeRate: c.Float64("transfer_fee_rate"),
+			},
+		}
+		plan, err := portfolio.Rebalance(targets, opts)
+		if err != nil {
+			return err
+		}
+		showRebalancePlan(plan)
+		return nil
+	}
+}
+
+// showRebalancePlan 表格显示调仓交易单及调仓后权重
+func showRebalancePlan(plan portfolio.RebalancePlan) {
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetAlignment(tablewriter.ALIGN_LEFT)
+	table.SetHeader([]string{"方向", "代码", "名称", "股数", "参考价", "金额", "佣金", "印花税", "过户费", "说明"})
+	table.SetCaption(true, fmt.Sprintf("总资产:%.2f 费用合计:%.2f 调仓后现金:%.2f(%.2f%%)",
+		plan.TotalValue, plan.TotalFee, plan.CashAfter, plan.CashWeightAfter))
+	for _, t := range plan.Trades {
+		table.Append([]string{
+			t.Side, t.Code, t.Name, fmt.Sprint(t.Shares), fmt.Sprintf("%.3f", t.Price), fmt.Sprintf("%.2f", t.Amount),
+			fmt.Sprintf("%.2f", t.Fee.Commission), fmt.Sprintf("%.2f", t.Fee.StampDuty), fmt.Sprintf("%.2f", t.Fee.TransferFee), t.Note,
+		})
+	}
+	table.Render()
+
+	table = tablewriter.NewWriter(os.Stdout)
+	table.SetAlignment(tablewriter.ALIGN_LEFT)
+	table.SetHeader([]string{"代码", "名称", "调仓前股数", "调仓后股数", "调仓前权重", "目标权重", "调仓后权重", "不交易区间内"})
+	for _, h := range plan.Holdings {
+		table.Append([]string{
+			h.Code, h.Name, fmt.Sprint(h.SharesBefore), fmt.Sprint(h.SharesAfter),
+			fmt.Sprintf("%.2f%%", h.WeightBefore), fmt.Sprintf("%.2f%%", h.TargetWeight), fmt.Sprintf("%.2f%%", h.WeightAfter),
+			fmt.Sprint(h.InBand),
+		})
+	}
+	table.Render()
+	for _, note := range plan.Notes {
+		fmt.Println("* " + note)
+	}
+}
+
+// FlagsPortfolioRisk 组合风险分析 cli flags
+func FlagsPortfolioRisk() []cli.Flag {
+	opts := portfolio.DefaultRiskOptions
+	return []cli.Flag{
+		&cli.IntFlag{
+			Name:        "days",
+			Value:       opts.Days,
+			Usage:       "回看交易日数",
+			DefaultText: fmt.Sprint(opts.Days),
+		},
+		&cli.Float64Flag{
+			Name:        "confidence",
+			Value:       opts.Confidence,
+			Usage:       "VaR 置信度（%）",
+			DefaultText: fmt.Sprint(opts.Confidence),
+		},
+		&cli.Float64Flag{
+			Name:        "correlation_threshold",
+			Value:       opts.CorrelationThreshold,
+			Usage:       "高相关阈值",
+			DefaultText: fmt.Sprint(opts.CorrelationThreshold),
+		},
+		&cli.Float64Flag{
+			Name:        "industry_limit",
+			Value:       opts.IndustryLimit,
+			Usage:       "单一行业权重上限（%）",
+			DefaultText: fmt.Sprint(opts.IndustryLimit),
+		},
+	}
+}
+
+// ActionPortfolioRisk 组合风险分析
+func ActionPortfolioRisk() func(c *cli.Context) error {
+	return func(c *cli.Context) error {
+		ctx := context.Background()
+		a, err := portfolioAccount(c)
+		if err != nil {
+			return err
+		}
+		report, err := core.PortfolioRisk(ctx, a, portfolio.RiskOptions{
+			Days:                 c.Int("days"),
+			Confidence:           c.Float64("confidence"),
+			CorrelationThreshold: c.Float64("correlation_threshold"),
+			IndustryLimit:        c.Float64("industry_limit"),
+		})
+		if err != nil {
+			return err
+		}
+		showRiskReport(report)
+		return nil
+	}
+}
+
+// showRiskReport 表格显示组合风险分析结果
+func showRiskReport(r portfolio.RiskReport) {
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetAlignment(tablewriter.ALIGN_LEFT)
+	table.SetHeader([]string{"指标", "组合", "沪深300"})
+	table.SetCaption(true, fmt.Sprintf("%s ~ %s 共 %d 个交易日，持仓市值 %.2f", r.StartDate, r.EndDate, r.Observations, r.MarketValue))
+	table.AppendBulk([][]string{
+		{"区间收益", fmt.Sprintf("%.2f%%", r.Return), fmt.Sprintf("%.2f%%", r.BenchmarkReturn)},
+		{"年化波动率", fmt.Sprintf("%.2f%%", r.Volatility), fmt.Sprintf("%.2f%%", r.BenchmarkVolatility)},
+		{"最大回撤", fmt.Sprintf("%.2f%%", r.MaxDrawdown), fmt.Sprintf("%.2f%%", r.BenchmarkMaxDrawdown)},
+		{"贝塔", fmt.Sprintf("%.2f", r.Beta), "1.00"},
+		{fmt.Sprintf("单日 VaR(%.1f%%)", r.Options.Confidence), fmt.Sprintf("%.2f%% (%.2f)", r.VaR, r.VaRAmount), "-"},
+		{"单日 CVaR", fmt.Sprintf("%.2f%% (%.2f)", r.CVaR, r.CVaRAmount), "-"},
+		{"平均相关系数", fmt.Sprintf("%.2f", r.AvgCorrelation), "-"},
+		{"分散化比率", fmt.Sprintf("%.2f", r.DiversificationRatio), "-"},
+		{"有效持仓数", fmt.Sprintf("%.1f", r.EffectiveN), "-"},
+		{"行业 HHI", fmt.Sprintf("%.3f", r.IndustryHHI), "-"},
+	})
+	table.Render()
+
+	table = tablewriter.NewWriter(os.Stdout)
+	table.SetAlignment(tablewriter.ALIGN_LEFT)
+	table.SetHeader([]string{"代码", "名称", "行业", "权重", "区间收益", "年化波动率", "贝塔", "最大回撤", "波动贡献"})
+	for _, a := range r.Assets {
+		table.Append([]string{
+			a.Code, a.Name, a.Industry, fmt.Sprintf("%.2f%%", a.Weight), fmt.Sprintf("%.2f%%", a.Return),
+			fmt.Sprintf("%.2f%%", a.Volatility), fmt.Sprintf("%.2f", a.Beta), fmt.Sprintf("%.2f%%", a.MaxDrawdown),
+			fmt.Sprintf("%.2f%%", a.RiskContribution),
+		})
+	}
+	table.Render()
+
+	table = tablewriter.NewWriter(os.Stdout)
+	table.SetAlignment(tablewriter.ALIGN_LEFT)
+	header := []string{""}
+	for _, a := range r.Assets {
+		header = append(header, a.Code)
+	}
+	table.SetHeader(header)
+	for i, row := range r.Correlation {
+		line := []string{r.Assets[i].Code}
+		for _, v := range row {
+			line = append(line, fmt.Sprintf("%.2f", v))
+		}
+		table.Append(line)
+	}
+	table.Render()
+
+	table = tablewriter.NewWriter(os.Stdout)
+	table.SetAlignment(tablewriter.ALIGN_LEFT)
+	table.SetHeader([]string{"行业", "权重", "波动贡献", "持仓"})
+	for _, iw := range r.Industries {
+		table.Append([]string{iw.Industry, fmt.Sprintf("%.2f%%", iw.Weight), fmt.Sprintf("%.2f%%", iw.RiskContribution), strings.Join(iw.Codes, ",")})
+	}
+	table.Render()
+	for _, w := range r.Warnings {
+		fmt.Println("* " + w)
+	}
+}
+
+// showPortfolioSummary 表格显示账户持仓及盈亏
+func showPortfolioSummary(s portfolio.Summary) {
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetAlignment(tablewriter.ALIGN_LEFT)
+	table.SetHeader([]string{"代码", "名称", "持股数", "平均成本", "FIFO成本", "现价", "市值", "浮动盈亏", "已实现盈亏", "分红", "总盈亏"})
+	table.SetCaption(true, fmt.Sprintf("账户:%s 成本方式:%s 市值:%.2f 成本:%.2f 浮动盈亏:%.2f 已实现盈亏:%.2f 分红:%.2f 总盈亏:%.2f",
+		s.Account, s.CostMethod, s.MarketValue, s.CostBasis, s.UnrealizedPL, s.RealizedPL, s.Dividend, s.TotalPL))
+	for _, p := range s.Positions {
+		table.Append([]string{
+			p.Code, p.Name, fmt.Sprint(p.Shares),
+			fmt.Sprintf("%.3f", p.AvgCost), fmt.Sprintf("%.3f", p.FIFOCost), fmt.Sprintf("%.3f", p.Price),
+			fmt.Sprintf("%.2f", p.MarketValue),
+			fmt.Sprintf("%.2f(%.2f%%)", p.UnrealizedPL, p.UnrealizedPLRatio),
+			fmt.Sprintf("%.2f", p.RealizedPL), fmt.Sprintf("%.2f", p.Dividend), fmt.Sprintf("%.2f", p.TotalPL),
+		})
+	}
+	table.Render()
+	if len(s.MissingPrices) > 0 {
+		fmt.Printf("* 无法获取现价，未计算浮动盈亏: %v\n", s.MissingPrices)
+	}
+}
+
+// CommandPortfolio 持仓账本 cli command
+func CommandPortfolio() *cli.Command {
+	cmd := &cli.Command{
+		Name:      ProcessorPortfolio,
+		Usage:     "持仓账本",
+		UsageText: "记录买入、卖出、分红、拆股交易，按平均成本或先进先出计算持仓成本、已实现及浮动盈亏",
+		Flags:     FlagsPortfolio(),
+		Subcommands: []*cli.Command{
+			{
+				Name:   "show",
+				Usage:  "显示账户持仓及盈亏",
+				Action: ActionPortfolioShow(),
+			},
+			{
+				Name:   "transactions",
+				Usage:  "显示账户交易记录",
+				Action: ActionPortfolioTransactions(),
+			},
+			{
+				Name:   "accounts",
+				Usage:  "显示全部账户",
+				Action: ActionPortfolioAccounts(),
+			},
+			{
+				Name:  "add-account",
+				Usage: "新建账户",
+				Flags: []cli.Flag{
+					&cli.StringFlag{Name: "desc", Usage: "账户描述"},
+					&cli.StringFlag{
+						Name:        "cost_method",
+						Value:       portfolio.CostMethodAverage,
+						Usage:       "成本计算方式 (average 平均成本 或 fifo 先进先出)",
+						DefaultText: portfolio.CostMethodAverage,
+					},
+				},
+				Action: ActionPortfolioAddAccount(),
+			},
+			{
+				Name:   "add",
+				Usage:  "添加交易",
+				Flags:  FlagsPortfolioTransaction(),
+				Action: ActionPortfolioAddTransaction(),
+			},
+			{
+				Name:   "risk",
+				Usage:  "基于历史股价分析组合风险",
+				Flags:  FlagsPortfolioRisk(),
+				Action: ActionPortfolioRisk(),
+			},
+			{
+				Name:   "rebalance",
+				Usage:  "按目标持仓金额生成调仓交易单",
+				Flags:  FlagsPortfolioRebalance(),
+				Action: ActionPortfolioRebalance(),
+			},
+			{
+				Name:  "delete",
+				Usage: "删除交易",
+				Flags: []cli.Flag{
+					&cli.IntFlag{Name: "id", Usage: "交易编号", Required: true},
+				},
+				Action: ActionPortfolioDeleteTransaction(),
+			},
+		},
+	}
+	return cmd
+}
@@ -0,0 +1,105 @@
+// 场内基金溢价率监控 cli command
+
+package cmds
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/axiaoxin-com/goutils"
+	"github.com/axiaoxin-com/investool/core"
+	"github.com/axiaoxin-com/investool/models"
+	"github.com/axiaoxin-com/logging"
+	"github.com/olekukonko/tablewriter"
+	"github.com/urfave/cli/v2"
+)
+
+const (
+	// ProcessorPremium 场内基金溢价率监控
+	ProcessorPremium = "premium"
+)
+
+// FlagsPremium premium cli flags
+func FlagsPremium() []cli.Flag {
+	return []cli.Flag{
+		&cli.StringFlag{
+			Name:     "codes",
+			Aliases:  []string{"c"},
+			Usage:    "场内基金代码，多个用英文逗号或空格分隔",
+			Required: true,
+		},
+		&cli.IntFlag{
+			Name:        "days",
+			Value:       90,
+			Usage:       "历史溢价率及跟踪误差的计算天数",
+			DefaultText: "90",
+		},
+		&cli.Float64Flag{
+			Name:        "threshold",
+			Value:       models.DefaultFundPremiumThreshold,
+			Usage:       "溢价率提示阈值（%）",
+			DefaultText: fmt.Sprint(models.DefaultFundPremiumThreshold),
+		},
+	}
+}
+
+// ActionPremium cli action
+func ActionPremium() func(c *cli.Context) error {
+	return func(c *cli.Context) error {
+		ctx := context.Background()
+		loglevel := c.String("loglevel")
+		logging.SetLevel(loglevel)
+
+		codes := goutils.SplitStringFields(c.String("codes"))
+		results := core.FundsPremiumMonitor(ctx, codes, c.Int("days"), c.Float64("threshold"))
+		if len(results) == 0 {
+			return fmt.Errorf("无法获取基金溢价率数据")
+		}
+		showPremiumResults(results, c.Int("days"))
+		return nil
+	}
+}
+
+// showPremiumResults 表格显示溢价率监控结果
+func showPremiumResults(results []*core.FundPremiumResult, days int) {
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetAlignment(tablewriter.ALIGN_LEFT)
+	table.SetHeader([]string{"代码", "名称", "现价", "参考净值", "实时溢价率", "最新收盘溢价率",
+		fmt.Sprintf("近%d天平均溢价率", days), "最高溢价率", "跟踪误差", "提示"})
+	for _, r := range results {
+		price, te, alert := "--", "--", ""
+		if r.Quote != nil {
+			price = fmt.Sprintf("%.3f", r.Quote.Price)
+		}
+		if r.TrackingError != nil {
+			te = fmt.Sprintf("%.2f%%", r.TrackingError.TrackingError)
+		}
+		if r.Alert {
+			alert = "⚠️"
+		}
+		table.Append([]string{
+			r.Code, r.Name, price, fmt.Sprintf("%.4f(%s)", r.IOPV, r.IOPVSource), fmt.Sprintf("%.2f%%", r.Premium),
+			fmt.Sprintf("%.2f%%", r.History.Latest.Premium), fmt.Sprintf("%.2f%%", r.History.AvgPremium),
+			fmt.Sprintf("%.2f%% (%s)", r.History.MaxPremium, r.History.MaxPremiumDate), te, alert,
+		})
+	}
+	table.Render()
+	for _, r := range results {
+		for _, w := range r.Warnings {
+			fmt.Printf("* %s %s: %s\n", r.Code, r.Name, w)
+		}
+	}
+}
+
+// CommandPremium 场内基金溢价率监控 cli command
+func CommandPremium() *cli.Command {
+	cmd := &cli.Command{
+		Name:      ProcessorPremium,
+		Usage:     "场内基金溢价率监控",
+		UsageText: "获取 ETF、LOF 场内价格及参考净值，计算实时及历史溢价率、相对跟踪指数的跟踪误差，溢价率超过阈值时提示",
+		Flags:     FlagsPremium(),
+		Action:    ActionPremium(),
+	}
+	return cmd
+}
@@ -0,0 +1,193 @@
+// 基金定投回测 cli command
+
+package cmds
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/axiaoxin-com/investool/core"
+	"github.com/axiaoxin-com/investool/portfolio"
+	"github.com/axiaoxin-com/logging"
+	"github.com/olekukonko/tablewriter"
+	"github.com/urfave/cli/v2"
+)
+
+const (
+	// ProcessorSIP 基金定投回测
+	ProcessorSIP = "sip"
+)
+
+// FlagsSIP sip cli flags
+func FlagsSIP() []cli.Flag {
+	opts := portfolio.DefaultSIPOptions
+	start := time.Now().AddDate(-3, 0, 0).Format(portfolio.DateLayout)
+	return []cli.Flag{
+		&cli.StringFlag{
+			Name:     "code",
+			Aliases:  []string{"c"},
+			Usage:    "基金代码",
+			Required: true,
+		},
+		&cli.StringFlag{
+			Name:        "start_date",
+			Value:       start,
+			Usage:       "定投开始日期",
+			DefaultText: "3年前",
+		},
+		&cli.StringFlag{
+			Name:        "end_date",
+			Value:       "",
+			Usage:       "定投结束日期，为空则为最新净值日期",
+			DefaultText: "",
+		},
+		&cli.StringFlag{
+			Name:        "frequency",
+			Value:       opts.Frequency,
+			Usage:       "定投频率 (weekly 每周 biweekly 每两周 monthly 每月)",
+			DefaultText: opts.Frequency,
+		},
+		&cli.IntFlag{
+			Name:        "day",
+			Value:       opts.Day,
+			Usage:       "定投日，每月几号（1-28），每周或每两周为星期几（1-5），非交易日顺延",
+			DefaultText: fmt.Sprint(opts.Day),
+		},
+		&cli.Float64Flag{
+			Name:        "amount",
+			Value:       opts.Amount,
+			Usage:       "每期定投金额（元）",
+			DefaultText: fmt.Sprint(opts.Amount),
+		},
+		&cli.Float64Flag{
+			Name:        "purchase_rate",
+			Value:       opts.PurchaseRate,
+			Usage:       "申购费率（%），小于 0 时使用基金当前购买费率",
+			DefaultText: fmt.Sprint(opts.PurchaseRate),
+		},
+		&cli.Float64Flag{
+			Name:        "redemption_rate",
+			Value:       opts.RedemptionRate,
+			Usage:       "期末赎回费率（%），为 0 时按基金赎回费率档位和每笔份额的持有天数收取",
+			DefaultText: fmt.Sprint(opts.RedemptionRate),
+		},
+		&cli.BoolFlag{
+			Name:        "reinvest",
+			Value:       opts.Reinvest,
+			Usage:       "红利再投资，为 false 时现金分红",
+			DefaultText: fmt.Sprint(opts.Reinvest),
+		},
+		&cli.BoolFlag{
+			Name:  "valuation_scaled",
+			Usage: "按估值分位调整定投金额：分位 20% 以下 2 倍，40% 以下 1.5 倍，60% 以下 1 倍，80% 以下 0.5 倍，其余暂停",
+		},
+		&cli.Float64Flag{
+			Name:        "valuation_years",
+			Value:       opts.ValuationYears,
+			Usage:       "估值分位回看年数",
+			DefaultText: fmt.Sprint(opts.ValuationYears),
+		},
+		&cli.StringFlag{
+			Name:  "valuation_file",
+			Usage: "指数估值 CSV 文件，每行为 日期,PE，为空时使用基金复权净值分位代替",
+		},
+	}
+}
+
+// ActionSIP cli action
+func ActionSIP() func(c *cli.Context) error {
+	return func(c *cli.Context) error {
+		ctx := context.Background()
+		loglevel := c.String("loglevel")
+		logging.SetLevel(loglevel)
+
+		opts := portfolio.SIPOptions{
+			StartDate:       c.String("start_date"),
+			EndDate:         c.String("end_date"),
+			Frequency:       c.String("frequency"),
+			Day:             c.Int("day"),
+			Amount:          c.Float64("amount"),
+			PurchaseRate:    c.Float64("purchase_rate"),
+			RedemptionRate:  c.Float64("redemption_rate"),
+			Reinvest:        c.Bool("reinvest"),
+			ValuationScaled: c.Bool("valuation_scaled"),
+			ValuationYears:  c.Float64("valuation_years"),
+		}
+		valuations := []portfolio.ValuationPoint{}
+		if filename := c.String("valuation_file"); filename != "" {
+			f, err := os.Open(filename)
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+			if valuations, err = portfolio.ParseValuations(f); err != nil {
+				return err
+			}
+		}
+		result, err := core.FundSIPBacktest(ctx, c.String("code"), opts, valuations)
+		if err != nil {
+			return err
+		}
+		showSIPResult(result)
+		return nil
+	}
+}
+
+// showSIPResult 表格显示定投回测结果
+func showSIPResult(r core.FundSIPResult) {
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetAlignment(tablewriter.ALIGN_LEFT)
+	table.SetHeader([]string{"指标", "值"})
+	redemption := fmt.Sprintf("%.2f%%", r.Options.RedemptionRate)
+	if len(r.Options.RedemptionTiers) > 0 {
+		redemption = "按持有天数档位"
+	}
+	table.SetCaption(true, fmt.Sprintf("%s %s %s ~ %s 申购费率 %.2f%% 赎回费率 %s",
+		r.Code, r.Name, r.StartDate, r.EndDate, r.Options.PurchaseRate, redemption))
+	table.AppendBulk([][]string{
+		{"定投期数", fmt.Sprintf("%d（暂停 %d）", r.Periods, r.SkippedPeriods)},
+		{"累计投入", fmt.Sprintf("%.2f", r.TotalInvested)},
+		{"申购费", fmt.Sprintf("%.2f", r.PurchaseFee)},
+		{"赎回费", fmt.Sprintf("%.2f", r.RedemptionFee)},
+		{"现金分红", fmt.Sprintf("%.2f", r.CashDividends)},
+		{"持有份额", fmt.Sprintf("%.2f", r.FinalShares)},
+		{"平均成本", fmt.Sprintf("%.4f", r.AvgCost)},
+		{"期末净值", fmt.Sprintf("%.4f", r.FinalNav)},
+		{"期末市值", fmt.Sprintf("%.2f", r.MarketValue)},
+		{"收益", fmt.Sprintf("%.2f", r.Profit)},
+		{"总收益率", fmt.Sprintf("%.2f%%", r.TotalReturn)},
+		{"年化收益率(IRR)", fmt.Sprintf("%.2f%%", r.IRR)},
+		{"净值最大回撤", fmt.Sprintf("%.2f%%", r.MaxDrawdown)},
+		{"最大浮亏", fmt.Sprintf("%.2f%% (%s)", r.MaxLoss, r.MaxLossDate)},
+	})
+	table.Render()
+
+	table = tablewriter.NewWriter(os.Stdout)
+	table.SetAlignment(tablewriter.ALIGN_LEFT)
+	table.SetHeader([]string{"日期", "类型", "净值", "现金流", "费用", "份额变动", "持有份额", "累计投入", "市值", "估值分位", "倍数"})
+	for _, f := range r.CashFlows {
+		table.Append([]string{
+			f.Date, f.Type, fmt.Sprintf("%.4f", f.Nav), fmt.Sprintf("%.2f", f.CashFlow), fmt.Sprintf("%.2f", f.Fee),
+			fmt.Sprintf("%.2f", f.Shares), fmt.Sprintf("%.2f", f.TotalShares), fmt.Sprintf("%.2f", f.Invested),
+			fmt.Sprintf("%.2f", f.MarketValue), fmt.Sprintf("%.2f%%", f.Percentile), fmt.Sprintf("%.1f", f.Multiple),
+		})
+	}
+	table.Render()
+	for _, note := range r.Notes {
+		fmt.Println("* " + note)
+	}
+}
+
+// CommandSIP 基金定投回测 cli command
+func CommandSIP() *cli.Command {
+	cmd := &cli.Command{
+		Name:      ProcessorSIP,
+		Usage:     "基金定投回测",
+		UsageText: "按历史净值模拟每周、每两周或每月定投，支持按估值分位调整金额、红利再投资及申购赎回费，计算 IRR、收益率、回撤及现金流",
+		Flags:     FlagsSIP(),
+		Action:    ActionSIP(),
+	}
+	return cmd
+}
@@ -4,6 +4,7 @@ package cmds
 
 import (
 	"github.com/axiaoxin-com/investool/cron"
+	"github.com/axiaoxin-com/investool/portfolio"
 	"github.com/axiaoxin-com/investool/routes"
 	"github.com/axiaoxin-com/investool/routes/response"
 	"github.com/axiaoxin-com/investool/webserver"
@@ -51,6 +52,9 @@ func ActionWebserver() func(c *cli.Context) error {
 	return func(c *cli.Context) error {
 		configFile := c.String("config")
 		webserver.InitWithConfigFile(configFile)
+		if filename := viper.GetString("app.portfolio_file"); filename != "" {
+			portfolio.LedgerFilename = filename
+		}
 
 		// 启动定时任务
 		cron.RunCronJobs(true)
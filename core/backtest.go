@@ -0,0 +1,526 @@
+// 策略回测：在各财报季后的调仓日按当时已发布的数据执行检测，构建组合并计算收益、回撤、换手率，与基准指数对比
+
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/axiaoxin-com/investool/datacenter"
+	"github.com/axiaoxin-com/investool/datacenter/eastmoney"
+	"github.com/axiaoxin-com/investool/models"
+	"github.com/axiaoxin-com/logging"
+	"github.com/spf13/viper"
+)
+
+const (
+	// BacktestWeightingEqual 等权
+	BacktestWeightingEqual = "equal"
+	// BacktestWeightingScore 按评分加权
+	BacktestWeightingScore = "score"
+)
+
+// BacktestOptions 回测选项
+type BacktestOptions struct {
+	// 回测开始日期，格式: 2018-01-01
+	StartDate string `json:"start_date"`
+	// 回测结束日期，为空则为当前日期
+	EndDate string `json:"end_date"`
+	// 权重方式: equal 等权，score 按评分加权
+	Weighting string `json:"weighting"`
+	// 每期最多持仓数量，按评分从高到低选取，0 表示不限制
+	MaxHoldings int `json:"max_holdings"`
+	// 基准指数 secid，如: 1.000300
+	Benchmark string `json:"benchmark"`
+	// 评分选项，用于选取持仓及按评分加权
+	ScoreOptions ScoreOptions `json:"score_options"`
+}
+
+// DefaultBacktestOptions 默认回测选项
+var DefaultBacktestOptions = BacktestOptions{
+	StartDate:    "2018-01-01",
+	Weighting:    BacktestWeightingEqual,
+	MaxHoldings:  20,
+	Benchmark:    eastmoney.SecidHS300,
+	ScoreOptions: DefaultScoreOptions,
+}
+
+// dateRange 解析回测起止日期
+func (o BacktestOptions) dateRange() (start, end time.Time, err error) {
+	start, err = time.ParseInLocation(AsOfDateLayout, o.StartDate, time.Local)
+	if err != nil {
+		return
+	}
+	end = time.Now()
+	if o.EndDate != "" {
+		end, err = time.ParseInLocation(AsOfDateLayout, o.EndDate, time.Local)
+		if err != nil {
+			return
+		}
+	}
+	if !end.After(start) {
+		err = fmt.Errorf("end date %s is not after start date %s", end.Format(AsOfDateLayout), o.StartDate)
+	}
+	return
+}
+
+// rebalanceMonthDays 调仓日为各定期报告法定披露截止日的次日：年报及一季报 4月30日，中报 8月31日，三季报 10月31日
+var rebalanceMonthDays = [][2]int{{5, 1}, {9, 1}, {11, 1}}
+
+// RebalanceDates 返回 [start, end] 内的调仓日期
+func RebalanceDates(start, end time.Time) []time.Time {
+	dates := []time.Time{}
+	for year := start.Year(); year <= end.Year(); year++ {
+		for _, md := range rebalanceMonthDays {
+			date := time.Date(year, time.Month(md[0]), md[1], 0, 0, 0, 0, time.Local)
+			if date.Before(start) || date.After(end) {
+				continue
+			}
+			dates = append(dates, date)
+		}
+	}
+	return dates
+}
+
+// BacktestHolding 调仓时的持仓
+type BacktestHolding struct {
+	// 股票代码
+	Code string `json:"code"`
+	// 股票名称
+	Name string `json:"name"`
+	// 权重 0-1
+	Weight float64 `json:"weight"`
+	// 检测评分
+	Score float64 `json:"score"`
+	// 调仓日买入价格（前复权）
+	Price float64 `json:"price"`
+}
+
+// BacktestPeriod 单个调仓周期
+type BacktestPeriod struct {
+	// 调仓日期
+	Date string `json:"date"`
+	// 持仓，为空表示空仓
+	Holdings []BacktestHolding `json:"holdings"`
+	// 本期收益率 (%)
+	Return float64 `json:"return"`
+	// 基准本期收益率 (%)
+	BenchmarkReturn float64 `json:"benchmark_return"`
+	// 本期换手率 (%)
+	Turnover float64 `json:"turnover"`
+}
+
+// BacktestNAV 净值点
+type BacktestNAV struct {
+	// 交易日期
+	Date string `json:"date"`
+	// 策略净值，初始为 1
+	NAV float64 `json:"nav"`
+	// 基准净值，初始为 1
+	Benchmark float64 `json:"benchmark"`
+}
+
+// BacktestMetrics 回测指标
+type BacktestMetrics struct {
+	// 累计收益率 (%)
+	TotalReturn float64 `json:"total_return"`
+	// 年化收益率 (%)
+	CAGR float64 `json:"cagr"`
+	// 最大回撤 (%)
+	MaxDrawdown float64 `json:"max_drawdown"`
+	// 平均每期换手率 (%)，不含首次建仓
+	AvgTurnover float64 `json:"avg_turnover"`
+	// 年化换手率 (%)
+	AnnualTurnover float64 `json:"annual_turnover"`
+}
+
+// BacktestReport 回测报告
+type BacktestReport struct {
+	Options BacktestOptions `json:"options"`
+	// 首个调仓日
+	StartDate string `json:"start_date"`
+	// 最后一个交易日
+	EndDate string `json:"end_date"`
+	// 候选股票数量
+	UniverseSize int `json:"universe_size"`
+	// 各调仓周期
+	Periods []BacktestPeriod `json:"periods"`
+	// 每日净值
+	NAVs []BacktestNAV `json:"navs"`
+	// 策略指标
+	Strategy BacktestMetrics `json:"strategy"`
+	// 基准指标
+	Benchmark BacktestMetrics `json:"benchmark"`
+	// 超额年化收益率 (%)
+	ExcessCAGR float64 `json:"excess_cagr"`
+	// 回测说明
+	Notes []string `json:"notes"`
+}
+
+// priceSeries 按日期升序排列的价格序列
+type priceSeries struct {
+	dates  []string
+	prices []float64
+}
+
+// priceOn 返回 date 当天及之前最近一个交易日的价格，无数据时返回 0
+func (p priceSeries) priceOn(date string) float64 {
+	idx := sort.Search(len(p.dates), func(i int) bool {
+		return p.dates[i] > date
+	}) - 1
+	if idx < 0 || idx >= len(p.prices) {
+		return 0
+	}
+	return p.prices[idx]
+}
+
+// Backtester 回测器
+type Backtester struct {
+	Selector Selector
+	Options  BacktestOptions
+}
+
+// NewBacktester 创建回测器，候选股票由 selector 的筛选条件确定，调仓时使用 selector 的检测器
+func NewBacktester(ctx context.Context, selector Selector, opts BacktestOptions) Backtester {
+	return Backtester{
+		Selector: selector,
+		Options:  opts,
+	}
+}
+
+// Run 查询候选股票及基准指数数据后执行回测
+func (b Backtester) Run(ctx context.Context) (BacktestReport, error) {
+	start, end, err := b.Options.dateRange()
+	if err != nil {
+		return BacktestReport{}, err
+	}
+	// 只获取检测需要的数据，价值评估及四率估值只有最新数据，回测时不使用
+	selector := b.Selector
+	checker := selector.Checker
+	if checker == nil {
+		checker = NewChecker(ctx, DefaultCheckerOptions)
+	}
+	selector.StockOptions.Enrichments = checker.RequiredEnrichments() &^ (models.EnrichJZPG | models.EnrichValuation)
+	stocks := models.StockList{}
+	var mu sync.Mutex
+	err = selector.walkStocks(ctx, func(stock models.Stock) {
+		mu.Lock()
+		stocks = append(stocks, stock)
+		mu.Unlock()
+	})
+	if err != nil {
+		return BacktestReport{}, err
+	}
+	// 多取一个月数据，保证首个调仓日为非交易日时有基准价格
+	beg := start.AddDate(0, -1, 0).Format("20060102")
+	benchmark, err := datacenter.EastMoney.QueryIndexKline(ctx, b.Options.Benchmark, beg, end.Format("20060102"))
+	if err != nil {
+		return BacktestReport{}, err
+	}
+	secucodes := []string{}
+	for _, s := range stocks {
+		secucodes = append(secucodes, s.BaseInfo.Secucode)
+	}
+	klines := queryStockKlines(ctx, secucodes, beg, end.Format("20060102"))
+	return b.RunWithData(ctx, stocks, klines, benchmark)
+}
+
+// queryStockKlines 并发获取股票前复权日线，key 为股票代码，获取失败的股票不在结果中
+func queryStockKlines(ctx context.Context, secucodes []string, beg, end string) map[string]eastmoney.IndexKlineList {
+	result := map[string]eastmoney.IndexKlineList{}
+	if len(secucodes) == 0 {
+		return result
+	}
+	workerCount := int(math.Max(1, math.Min(float64(len(secucodes)), viper.GetFloat64("app.chan_size"))))
+	jobChan := make(chan struct{}, workerCount)
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for _, secucode := range secucodes {
+		wg.Add(1)
+		jobChan <- struct{}{}
+		go func(secucode string) {
+			defer func() {
+				wg.Done()
+				<-jobChan
+			}()
+			klines, err := datacenter.EastMoney.QueryStockKline(ctx, secucode, beg, end)
+			if err != nil {
+				logging.Warnf(ctx, "QueryStockKline %s err:%v", secucode, err)
+				return
+			}
+			mu.Lock()
+			result[secucode] = klines
+			mu.Unlock()
+		}(secucode)
+	}
+	wg.Wait()
+	return result
+}
+
+// RunWithData 使用给定的候选股票、股票前复权日线（key 为股票代码）及基准指数日线执行回测，
+// 收益按前复权价格计算，没有日线的股票不参与持仓
+func (b Backtester) RunWithData(
+	ctx context.Context,
+	stocks models.StockList,
+	klines map[string]eastmoney.IndexKlineList,
+	benchmark eastmoney.IndexKlineList,
+) (BacktestReport, error) {
+	report := BacktestReport{
+		Options:      b.Options,
+		UniverseSize: len(stocks),
+		Periods:      []BacktestPeriod{},
+		NAVs:         []BacktestNAV{},
+		Notes: []string{
+			"候选股票为当前筛选条件的结果，存在幸存者偏差",
+			"调仓日按当时已发布的财报及股价检测，买入价为调仓日及之前最近一个交易日的前复权收盘价",
+			"收益按前复权价格计算，包含送转及分红，未计算交易费用",
+		},
+	}
+	start, end, err := b.Options.dateRange()
+	if err != nil {
+		return report, err
+	}
+	dates := RebalanceDates(start, end)
+	if len(dates) == 0 {
+		return report, errors.New("no rebalance date in backtest range")
+	}
+	firstDate := dates[0].Format(AsOfDateLayout)
+	endDate := end.Format(AsOfDateLayout)
+
+	// 以基准指数的交易日作为交易日历
+	bench := priceSeries{}
+	calendar := []string{}
+	for _, k := range benchmark {
+		bench.dates = append(bench.dates, k.Date)
+		bench.prices = append(bench.prices, k.Close)
+		if k.Date >= firstDate && k.Date <= endDate {
+			calendar = append(calendar, k.Date)
+		}
+	}
+	benchBase := bench.priceOn(firstDate)
+	if len(calendar) == 0 || benchBase <= 0 {
+		return report, errors.New("no benchmark data in backtest range")
+	}
+
+	checker := b.Selector.Checker
+	if checker == nil {
+		checker = NewChecker(ctx, DefaultCheckerOptions)
+	}
+	prices := map[string]priceSeries{}
+	for code, kl := range klines {
+		series := priceSeries{}
+		for _, k := range kl {
+			series.dates = append(series.dates, k.Date)
+			series.prices = append(series.prices, k.Close)
+		}
+		prices[code] = series
+	}
+
+	nav := 1.0
+	dayIdx := 0
+	var prevHoldings []BacktestHolding
+	for i, date := range dates {
+		dateStr := date.Format(AsOfDateLayout)
+		holdings := b.selectHoldings(ctx, checker, stocks, prices, date)
+		period := BacktestPeriod{
+			Date:     dateStr,
+			Holdings: holdings,
+			Turnover: turnover(driftWeights(prevHoldings, prices, dateStr), holdingWeights(holdings)) * 100,
+		}
+		logging.Infof(ctx, "Backtest rebalance at %s with %d holdings", dateStr, len(holdings))
+
+		startNAV := nav
+		benchStart := bench.priceOn(dateStr)
+		benchEnd := benchStart
+		for ; dayIdx < len(calendar); dayIdx++ {
+			day := calendar[dayIdx]
+			if i+1 < len(dates) && day >= dates[i+1].Format(AsOfDateLayout) {
+				break
+			}
+			nav = startNAV * holdingsValue(holdings, prices, day)
+			benchEnd = bench.priceOn(day)
+			report.NAVs = append(report.NAVs, BacktestNAV{
+				Date:      day,
+				NAV:       nav,
+				Benchmark: benchEnd / benchBase,
+			})
+		}
+		period.Return = (nav/startNAV - 1) * 100
+		if benchStart > 0 {
+			period.BenchmarkReturn = (benchEnd/benchStart - 1) * 100
+		}
+		report.Periods = append(report.Periods, period)
+		prevHoldings = holdings
+	}
+
+	report.StartDate = firstDate
+	report.EndDate = report.NAVs[len(report.NAVs)-1].Date
+	strategyNAVs := []float64{}
+	benchmarkNAVs := []float64{}
+	for _, n := range report.NAVs {
+		strategyNAVs = append(strategyNAVs, n.NAV)
+		benchmarkNAVs = append(benchmarkNAVs, n.Benchmark)
+	}
+	years := backtestYears(report.StartDate, report.EndDate)
+	report.Strategy = navMetrics(strategyNAVs, years)
+	report.Benchmark = navMetrics(benchmarkNAVs, years)
+	if len(report.Periods) > 1 {
+		sum := 0.0
+		for _, p := range report.Periods[1:] {
+			sum += p.Turnover
+		}
+		report.Strategy.AvgTurnover = sum / float64(len(report.Periods)-1)
+		report.Strategy.AnnualTurnover = report.Strategy.AvgTurnover * float64(len(rebalanceMonthDays))
+	}
+	report.ExcessCAGR = report.Strategy.CAGR - report.Benchmark.CAGR
+	return report, nil
+}
+
+// selectHoldings 在调仓日按当时已发布的数据检测候选股票，通过检测的股票按评分选取持仓并计算权重
+func (b Backtester) selectHoldings(
+	ctx context.Context,
+	checker *Checker,
+	stocks models.StockList,
+	prices map[string]priceSeries,
+	date time.Time,
+) []BacktestHolding {
+	dateStr := date.Format(AsOfDateLayout)
+	holdings := []BacktestHolding{}
+	for _, stock := range stocks {
+		price := prices[stock.BaseInfo.Secucode].priceOn(dateStr)
+		if price <= 0 {
+			continue
+		}
+		result, ok := checker.CheckFundamentalsAsOf(ctx, stock, date)
+		if !ok {
+			continue
+		}
+		score, _ := b.Options.ScoreOptions.Score(result)
+		holdings = append(holdings, BacktestHolding{
+			Code:  stock.BaseInfo.Secucode,
+			Name:  stock.BaseInfo.SecurityNameAbbr,
+			Score: score,
+			Price: price,
+		})
+	}
+	sort.SliceStable(holdings, func(i, j int) bool {
+		return holdings[i].Score > holdings[j].Score
+	})
+	if b.Options.MaxHoldings > 0 && len(holdings) > b.Options.MaxHoldings {
+		holdings = holdings[:b.Options.MaxHoldings]
+	}
+
+	totalScore := 0.0
+	for _, h := range holdings {
+		totalScore += h.Score
+	}
+	for i := range holdings {
+		if b.Options.Weighting == BacktestWeightingScore && totalScore > 0 {
+			holdings[i].Weight = holdings[i].Score / totalScore
+		} else {
+			holdings[i].Weight = 1 / float64(len(holdings))
+		}
+	}
+	return holdings
+}
+
+// holdingsValue 持仓在 date 的价值相对调仓日的倍数，空仓时为 1
+func holdingsValue(holdings []BacktestHolding, prices map[string]priceSeries, date string) float64 {
+	if len(holdings) == 0 {
+		return 1
+	}
+	value := 0.0
+	for _, h := range holdings {
+		price := prices[h.Code].priceOn(date)
+		if price <= 0 {
+			price = h.Price
+		}
+		value += h.Weight * price / h.Price
+	}
+	return value
+}
+
+// holdingWeights 持仓权重，key 为股票代码，空字符串表示现金
+func holdingWeights(holdings []BacktestHolding) map[string]float64 {
+	weights := map[string]float64{}
+	invested := 0.0
+	for _, h := range holdings {
+		weights[h.Code] += h.Weight
+		invested += h.Weight
+	}
+	weights[""] = 1 - invested
+	return weights
+}
+
+// driftWeights 上期持仓随价格变动到 date 时的权重
+func driftWeights(holdings []BacktestHolding, prices map[string]priceSeries, date string) map[string]float64 {
+	if len(holdings) == 0 {
+		return map[string]float64{"": 1}
+	}
+	value := holdingsValue(holdings, prices, date)
+	weights := map[string]float64{}
+	for _, h := range holdings {
+		price := prices[h.Code].priceOn(date)
+		if price <= 0 {
+			price = h.Price
+		}
+		weights[h.Code] += h.Weight * price / h.Price / value
+	}
+	weights[""] = 0
+	return weights
+}
+
+// turnover 单边换手率 0-1
+func turnover(from, to map[string]float64) float64 {
+	sum := 0.0
+	for code, w := range to {
+		sum += math.Abs(w - from[code])
+	}
+	for code, w := range from {
+		if _, exists := to[code]; !exists {
+			sum += math.Abs(w)
+		}
+	}
+	return sum / 2
+}
+
+// backtestYears 回测年数
+func backtestYears(start, end string) float64 {
+	s, err := time.ParseInLocation(AsOfDateLayout, start, time.Local)
+	if err != nil {
+		return 0
+	}
+	e, err := time.ParseInLocation(AsOfDateLayout, end, time.Local)
+	if err != nil {
+		return 0
+	}
+	return e.Sub(s).Hours() / 24 / 365.25
+}
+
+// navMetrics 根据净值序列计算累计收益、年化收益及最大回撤
+func navMetrics(navs []float64, years float64) BacktestMetrics {
+	m := BacktestMetrics{}
+	if len(navs) == 0 {
+		return m
+	}
+	last := navs[len(navs)-1]
+	m.TotalReturn = (last - 1) * 100
+	if years > 0 && last > 0 {
+		m.CAGR = (math.Pow(last, 1/years) - 1) * 100
+	}
+	peak := navs[0]
+	for _, n := range navs {
+		if n > peak {
+			peak = n
+		}
+		if peak > 0 {
+			m.MaxDrawdown = math.Max(m.MaxDrawdown, (peak-n)/peak*100)
+		}
+	}
+	return m
+}
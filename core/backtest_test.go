@@ -0,0 +1,125 @@
+package core
+
+import (
+	"testing"
+	"time"
+
+	"github.com/axiaoxin-com/investool/datacenter/eastmoney"
+	"github.com/axiaoxin-com/investool/datacenter/eniu"
+	"github.com/axiaoxin-com/investool/models"
+	"github.com/axiaoxin-com/logging"
+	"github.com/stretchr/testify/require"
+)
+
+func backtestStock(code, noticeDate string, dates []string, prices []float64) models.Stock {
+	return models.Stock{
+		BaseInfo: eastmoney.StockInfo{
+			Secucode:         code,
+			SecurityNameAbbr: code,
+			TotalMarketCap:   200 * 100000000,
+			NewPrice:         prices[len(prices)-1],
+		},
+		HistoricalFinaMainData: eastmoney.HistoricalFinaMainData{
+			{ReportType: eastmoney.FinaReportTypeYear, ReportYear: "2020", ReportDate: "2020-12-31 00:00:00", NoticeDate: noticeDate, Roejq: 20, Zcfzl: 10, Ld: 2},
+		},
+		HistoricalPrice: eniu.RespHistoricalStockPrice{Date: dates, Price: prices},
+	}
+}
+
+// backtestKlines 按股票的历史股价生成前复权日线
+func backtestKlines(stocks models.StockList) map[string]eastmoney.IndexKlineList {
+	klines := map[string]eastmoney.IndexKlineList{}
+	for _, s := range stocks {
+		kl := eastmoney.IndexKlineList{}
+		for i, d := range s.HistoricalPrice.Date {
+			kl = append(kl, eastmoney.IndexKline{Date: d, Close: s.HistoricalPrice.Price[i]})
+		}
+		klines[s.BaseInfo.Secucode] = kl
+	}
+	return klines
+}
+
+func TestRebalanceDates(t *testing.T) {
+	start := time.Date(2020, 6, 1, 0, 0, 0, 0, time.Local)
+	end := time.Date(2021, 9, 1, 0, 0, 0, 0, time.Local)
+	dates := []string{}
+	for _, d := range RebalanceDates(start, end) {
+		dates = append(dates, d.Format(AsOfDateLayout))
+	}
+	require.Equal(t, []string{"2020-09-01", "2020-11-01", "2021-05-01", "2021-09-01"}, dates)
+}
+
+func TestBacktestRunWithData(t *testing.T) {
+	logging.SetLevel("error")
+	dates := []string{"2021-04-30", "2021-06-30", "2021-08-31", "2021-10-29", "2021-12-31"}
+	stocks := models.StockList{
+		backtestStock("A", "2021-03-01 00:00:00", dates, []float64{10, 12, 12, 15, 15}),
+		// 2021-06-01 才发布年报，首个调仓日不应持有
+		backtestStock("B", "2021-06-01 00:00:00", dates, []float64{20, 20, 20, 10, 10}),
+	}
+	benchmark := eastmoney.IndexKlineList{}
+	for i, v := range []float64{100, 110, 100, 100, 120} {
+		benchmark = append(benchmark, eastmoney.IndexKline{Date: dates[i], Close: v})
+	}
+	opts := DefaultBacktestOptions
+	opts.StartDate = "2021-04-01"
+	opts.EndDate = "2021-12-31"
+	selector := NewSelector(_ctx, eastmoney.DefaultFilter, NewChecker(_ctx, CheckerOptions{}))
+	report, err := NewBacktester(_ctx, selector, opts).RunWithData(_ctx, stocks, backtestKlines(stocks), benchmark)
+	require.Nil(t, err)
+	require.Equal(t, "2021-05-01", report.StartDate)
+	require.Equal(t, "2021-12-31", report.EndDate)
+	require.Len(t, report.Periods, 3)
+	require.Len(t, report.Periods[0].Holdings, 1)
+	require.Equal(t, "A", report.Periods[0].Holdings[0].Code)
+	require.InDelta(t, 20, report.Periods[0].Return, 1e-9)
+	require.InDelta(t, 100, report.Periods[0].Turnover, 1e-9)
+	require.Len(t, report.Periods[1].Holdings, 2)
+	require.InDelta(t, 50, report.Periods[1].Turnover, 1e-9)
+	require.InDelta(t, -12.5, report.Periods[1].Return, 1e-9)
+	require.InDelta(t, 5, report.Strategy.TotalReturn, 1e-9)
+	require.InDelta(t, 12.5, report.Strategy.MaxDrawdown, 1e-9)
+	require.InDelta(t, 20, report.Benchmark.TotalReturn, 1e-9)
+	require.InDelta(t, (50+300.0/14)/2, report.Strategy.AvgTurnover, 1e-9)
+	require.Less(t, report.ExcessCAGR, 0.0)
+
+	opts.Weighting = BacktestWeightingScore
+	report, err = NewBacktester(_ctx, selector, opts).RunWithData(_ctx, stocks, backtestKlines(stocks), benchmark)
+	require.Nil(t, err)
+	sum := 0.0
+	for _, h := range report.Periods[1].Holdings {
+		sum += h.Weight
+	}
+	require.InDelta(t, 1, sum, 1e-9)
+
+	opts.EndDate = "2021-04-02"
+	_, err = NewBacktester(_ctx, selector, opts).RunWithData(_ctx, stocks, backtestKlines(stocks), benchmark)
+	require.NotNil(t, err)
+}
+
+func TestBacktestRunWithDataAdjusted(t *testing.T) {
+	logging.SetLevel("error")
+	dates := []string{"2021-04-30", "2021-06-30", "2021-08-31"}
+	// 2021-06 十送十，不复权股价腰斩，前复权股价不变
+	stock := backtestStock("A", "2021-03-01 00:00:00", dates, []float64{20, 10, 11})
+	klines := map[string]eastmoney.IndexKlineList{
+		"A": {{Date: dates[0], Close: 10}, {Date: dates[1], Close: 10}, {Date: dates[2], Close: 11}},
+	}
+	benchmark := eastmoney.IndexKlineList{}
+	for _, d := range dates {
+		benchmark = append(benchmark, eastmoney.IndexKline{Date: d, Close: 100})
+	}
+	opts := DefaultBacktestOptions
+	opts.StartDate = "2021-04-01"
+	opts.EndDate = "2021-08-31"
+	selector := NewSelector(_ctx, eastmoney.DefaultFilter, NewChecker(_ctx, CheckerOptions{}))
+	report, err := NewBacktester(_ctx, selector, opts).RunWithData(_ctx, models.StockList{stock}, klines, benchmark)
+	require.Nil(t, err)
+	require.InDelta(t, 10, report.Strategy.TotalReturn, 1e-9)
+	require.InDelta(t, 0, report.Strategy.MaxDrawdown, 1e-9)
+
+	// 没有前复权日线的股票不参与持仓
+	report, err = NewBacktester(_ctx, selector, opts).RunWithData(_ctx, models.StockList{stock}, nil, benchmark)
+	require.Nil(t, err)
+	require.Empty(t, report.Periods[0].Holdings)
+}
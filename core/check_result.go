@@ -0,0 +1,208 @@
+// 检测结果结构
+
+package core
+
+import (
+	"math"
+	"strings"
+)
+
+// CheckStatus 检测项状态
+type CheckStatus string
+
+const (
+	// CheckStatusPass 通过
+	CheckStatusPass CheckStatus = "pass"
+	// CheckStatusFail 未通过
+	CheckStatusFail CheckStatus = "fail"
+	// CheckStatusSkip 未检测（选项关闭或不适用）
+	CheckStatusSkip CheckStatus = "skip"
+	// CheckStatusWarn 未满足但不影响整体结果
+	CheckStatusWarn CheckStatus = "warn"
+	// CheckStatusUnknown 依赖的数据未获取成功，无法判断
+	CheckStatusUnknown CheckStatus = "unknown"
+)
+
+// CheckThreshold 检测阈值，Min/Max 为 nil 表示无该边界
+type CheckThreshold struct {
+	Min *float64 `json:"min,omitempty"`
+	Max *float64 `json:"max,omitempty"`
+}
+
+// minThreshold 返回下限阈值
+func minThreshold(min float64) *CheckThreshold {
+	return &CheckThreshold{Min: &min}
+}
+
+// maxThreshold 返回上限阈值
+func maxThreshold(max float64) *CheckThreshold {
+	return &CheckThreshold{Max: &max}
+}
+
+// rangeThreshold 返回区间阈值
+func rangeThreshold(min, max float64) *CheckThreshold {
+	return &CheckThreshold{Min: &min, Max: &max}
+}
+
+// observed 返回观测值指针，NaN 或 ±Inf 无法 JSON 编码，返回 nil
+func observed(v float64) *float64 {
+	if !isFinite(v) {
+		return nil
+	}
+	return &v
+}
+
+// isFinite 是否为有限数值
+func isFinite(v float64) bool {
+	return !math.IsNaN(v) && !math.IsInf(v, 0)
+}
+
+// sanitize 去掉无法 JSON 编码的 NaN、±Inf 观测值，历史数据中存在时去掉整个序列
+func (i *CheckItem) sanitize() {
+	if i.Value != nil {
+		i.Value = observed(*i.Value)
+	}
+	for k, v := range i.Values {
+		if !isFinite(v) {
+			delete(i.Values, k)
+		}
+	}
+	for k, series := range i.Series {
+		for _, v := range series {
+			if !isFinite(v) {
+				delete(i.Series, k)
+				break
+			}
+		}
+	}
+}
+
+// Distance 观测值超出阈值的相对距离，在阈值范围内时为 0，无法计算时返回 -1
+func (i CheckItem) Distance() float64 {
+	if i.Value == nil || i.Threshold == nil {
+		return -1
+	}
+	v := *i.Value
+	if i.Threshold.Min != nil && v < *i.Threshold.Min {
+		return relativeDistance(v, *i.Threshold.Min)
+	}
+	if i.Threshold.Max != nil && v > *i.Threshold.Max {
+		return relativeDistance(v, *i.Threshold.Max)
+	}
+	return 0
+}
+
+// relativeDistance v 与 threshold 的相对距离，threshold 为 0 时返回绝对距离
+func relativeDistance(v, threshold float64) float64 {
+	if threshold == 0 {
+		return math.Abs(v)
+	}
+	return math.Abs(v-threshold) / math.Abs(threshold)
+}
+
+// CheckItem 单个检测项结果
+type CheckItem struct {
+	// 检测项唯一标识，如: roe
+	ID string `json:"id"`
+	// 检测项名称，如: 净资产收益率(ROE)
+	Label string `json:"label"`
+	// 检测状态
+	Status CheckStatus `json:"status"`
+	// 检测时观测到的数值
+	Values map[string]float64 `json:"values,omitempty"`
+	// 检测时观测到的历史数据，最新的在最前面
+	Series map[string][]float64 `json:"series,omitempty"`
+	// 与阈值比较的观测值
+	Value *float64 `json:"value,omitempty"`
+	// 检测阈值
+	Threshold *CheckThreshold `json:"threshold,omitempty"`
+	// 格式化后的描述，多行使用 \n 分隔
+	Desc string `json:"desc"`
+	// 检测项的计算过程说明，如合理价的估值过程
+	Detail interface{} `json:"detail,omitempty"`
+}
+
+// Passed 检测项是否未失败
+func (i CheckItem) Passed() bool {
+	return i.Status != CheckStatusFail
+}
+
+// DescLines 描述按行拆分
+func (i CheckItem) DescLines() []string {
+	return strings.Split(i.Desc, "\n")
+}
+
+// CheckResult 检测结果，检测项按检测顺序排列
+type CheckResult struct {
+	// 全部检测项均未失败时为 true
+	OK bool `json:"ok"`
+	// 检测项列表
+	Items []CheckItem `json:"items"`
+}
+
+// NewCheckResult 创建空的检测结果
+func NewCheckResult() CheckResult {
+	return CheckResult{
+		OK:    true,
+		Items: []CheckItem{},
+	}
+}
+
+// Add 添加检测项，状态为失败时整体结果为失败，NaN、±Inf 观测值不输出
+func (r *CheckResult) Add(item CheckItem) {
+	if item.Status == "" {
+		item.Status = CheckStatusPass
+	}
+	item.sanitize()
+	if item.Status == CheckStatusFail {
+		r.OK = false
+	}
+	r.Items = append(r.Items, item)
+}
+
+// Get 按 ID 获取检测项
+func (r CheckResult) Get(id string) (CheckItem, bool) {
+	for _, i := range r.Items {
+		if i.ID == id {
+			return i, true
+		}
+	}
+	return CheckItem{}, false
+}
+
+// UnknownItems 返回因数据缺失无法判断的检测项
+func (r CheckResult) UnknownItems() []CheckItem {
+	items := []CheckItem{}
+	for _, i := range r.Items {
+		if i.Status == CheckStatusUnknown {
+			items = append(items, i)
+		}
+	}
+	return items
+}
+
+// FailedItems 返回未通过的检测项
+func (r CheckResult) FailedItems() []CheckItem {
+	items := []CheckItem{}
+	for _, i := range r.Items {
+		if i.Status == CheckStatusFail {
+			items = append(items, i)
+		}
+	}
+	return items
+}
+
+// StatusSymbol 检测状态的展示符号
+func (s CheckStatus) StatusSymbol() string {
+	switch s {
+	case CheckStatusPass:
+		return "✅"
+	case CheckStatusFail:
+		return "❌"
+	case CheckStatusWarn:
+		return "⚠️"
+	case CheckStatusUnknown:
+		return "❓"
+	}
+	return "➖"
+}
@@ -0,0 +1,52 @@
+package core
+
+import (
+	"encoding/json"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckResult(t *testing.T) {
+	r := NewCheckResult()
+	require.True(t, r.OK)
+	r.Add(CheckItem{ID: "a", Label: "A"})
+	r.Add(CheckItem{ID: "b", Label: "B", Status: CheckStatusWarn})
+	require.True(t, r.OK)
+	r.Add(CheckItem{ID: "c", Label: "C", Status: CheckStatusFail, Threshold: minThreshold(1), Desc: "x\ny"})
+	require.False(t, r.OK)
+
+	ids := []string{}
+	for _, i := range r.Items {
+		ids = append(ids, i.ID)
+	}
+	require.Equal(t, []string{"a", "b", "c"}, ids)
+	item, ok := r.Get("a")
+	require.True(t, ok)
+	require.Equal(t, CheckStatusPass, item.Status)
+	_, ok = r.Get("x")
+	require.False(t, ok)
+	require.Len(t, r.FailedItems(), 1)
+	require.Equal(t, []string{"x", "y"}, r.FailedItems()[0].DescLines())
+
+	b, err := json.Marshal(r)
+	require.Nil(t, err)
+	require.Contains(t, string(b), `"threshold":{"min":1}`)
+}
+
+func TestCheckResultNonFinite(t *testing.T) {
+	r := NewCheckResult()
+	r.Add(CheckItem{
+		ID:     "nan",
+		Values: map[string]float64{"a": math.NaN(), "b": 1},
+		Series: map[string][]float64{"x": {1, math.Inf(1)}, "y": {1, 2}},
+		Value:  observed(math.Inf(-1)),
+	})
+	item, _ := r.Get("nan")
+	require.Nil(t, item.Value)
+	require.Equal(t, map[string]float64{"b": 1}, item.Values)
+	require.Equal(t, map[string][]float64{"y": {1, 2}}, item.Series)
+	_, err := json.Marshal(r)
+	require.Nil(t, err)
+}
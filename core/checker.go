@@ -5,6 +5,7 @@ package core
 import (
 	"context"
 	"fmt"
+	"math"
 	"sort"
 	"strings"
 	"sync"
@@ -13,6 +14,7 @@ import (
 	"github.com/axiaoxin-com/goutils"
 	"github.com/axiaoxin-com/investool/datacenter/eastmoney"
 	"github.com/axiaoxin-com/investool/models"
+	"github.com/axiaoxin-com/investool/valuation"
 	"github.com/axiaoxin-com/logging"
 	mapset "github.com/deckarep/golang-set"
 )
@@ -67,8 +69,40 @@ type CheckerOptions struct {
 	IsCheckNetprofitGrow bool `json:"is_check_netprofit_grow" form:"checker_is_check_netprofit_grow"`
 	// 最低股息率
 	MinGxl float64 `json:"min_gxl"                 form:"checker_min_gxl"`
-	// 输出格式: table或markdown
+	// 输出格式: table、markdown或json
 	OutputFormat string `json:"output_format"           form:"checker_output_format"`
+	// 额外执行的检测规则集名称，为空则不执行
+	RuleSet string `json:"rule_set"                form:"checker_rule_set"`
+	// 按历史时间点检测，格式: 2021-06-30，为空则使用最新数据
+	AsOf string `json:"as_of"                   form:"checker_as_of"`
+	// EPS、营收、净利润增长及 PEG 使用最新一期财报的 TTM 数据检测，不必等待年报发布
+	UseTTMGrowth bool `json:"use_ttm_growth"          form:"checker_use_ttm_growth"`
+	// 合理价估值参数
+	Valuation valuation.Options `json:"valuation"`
+	// 是否检测 DCF 估值安全边际
+	IsCheckDCF bool `json:"is_check_dcf"            form:"checker_is_check_dcf"`
+	// 是否检测 DDM 估值安全边际
+	IsCheckDDM bool `json:"is_check_ddm"            form:"checker_is_check_ddm"`
+	// DCF、DDM 估值的最低安全边际（%）
+	MinMarginOfSafety float64 `json:"min_margin_of_safety"    form:"checker_min_margin_of_safety"`
+	// DCF 估值参数
+	DCF valuation.DCFOptions `json:"dcf"`
+	// DDM 估值参数
+	DDM valuation.DDMOptions `json:"ddm"`
+	// 是否检测 ROE 增长是否主要由杠杆驱动
+	IsCheckDuPont bool `json:"is_check_dupont"         form:"checker_is_check_dupont"`
+	// ROE 增长中权益乘数贡献的最大比例（%）
+	MaxLeverageROEShare float64 `json:"max_leverage_roe_share"  form:"checker_max_leverage_roe_share"`
+	// 最低 Piotroski F-Score，为 0 时不检测
+	MinPiotroskiFScore float64 `json:"min_piotroski_f_score"   form:"checker_min_piotroski_f_score"`
+	// 最低 Altman Z-Score，为 0 时不检测
+	MinAltmanZScore float64 `json:"min_altman_z_score"      form:"checker_min_altman_z_score"`
+	// 是否检测 Beneish M-Score
+	IsCheckBeneishM bool `json:"is_check_beneish_m"      form:"checker_is_check_beneish_m"`
+	// 最高 Beneish M-Score，高于该值时可能存在财务操纵
+	MaxBeneishMScore float64 `json:"max_beneish_m_score"     form:"checker_max_beneish_m_score"`
+	// 依赖数据缺失无法判断的检测项视为通过，默认视为未通过
+	UnknownAsPass bool `json:"unknown_as_pass"         form:"checker_unknown_as_pass"`
 }
 
 // DefaultCheckerOptions 默认检测值
@@ -98,6 +132,19 @@ var DefaultCheckerOptions = CheckerOptions{
 	IsCheckNetprofitGrow: true,
 	MinGxl:               0.0,
 	OutputFormat:         "table",
+	Valuation:            valuation.DefaultOptions,
+	IsCheckDCF:           false,
+	IsCheckDDM:           false,
+	MinMarginOfSafety:    20.0,
+	DCF:                  valuation.DefaultDCFOptions,
+	DDM:                  valuation.DefaultDDMOptions,
+	IsCheckDuPont:        false,
+	MaxLeverageROEShare:  50.0,
+	MinPiotroskiFScore:   0,
+	MinAltmanZScore:      0,
+	IsCheckBeneishM:      false,
+	MaxBeneishMScore:     models.BeneishMThreshold,
+	UnknownAsPass:        false,
 }
 
 // Checker 检测器实例
@@ -112,360 +159,514 @@ func NewChecker(ctx context.Context, opts CheckerOptions) *Checker {
 	}
 }
 
-// CheckResult 检测结果
-// key 为检测项，value为描述map {"ROE": {"desc": "高于8.0", "ok":"true"}}
-type CheckResult map[string]map[string]string
+// checkStatus 根据是否满足条件返回检测状态
+func checkStatus(ok bool) CheckStatus {
+	if ok {
+		return CheckStatusPass
+	}
+	return CheckStatusFail
+}
+
+// AsOfDateLayout 历史检测时间点格式
+const AsOfDateLayout = "2006-01-02"
+
+// AsOfTime 解析历史检测时间点，AsOf 为空时返回零值
+func (o CheckerOptions) AsOfTime() (time.Time, error) {
+	if o.AsOf == "" {
+		return time.Time{}, nil
+	}
+	return time.ParseInLocation(AsOfDateLayout, o.AsOf, time.Local)
+}
 
-// CheckFundamentals 检测股票基本面
-// [[检测失败项, 原因], ...]
-func (c Checker) CheckFundamentals(ctx context.Context, stock models.Stock) (result CheckResult, ok bool) {
+// CheckFundamentalsAsOf 按历史时间点检测股票基本面，忽略 asOf 时尚未发布的财报及之后的股价
+func (c Checker) CheckFundamentalsAsOf(ctx context.Context, stock models.Stock, asOf time.Time) (CheckResult, bool) {
+	return c.checkFundamentals(ctx, stock.AsOf(ctx, asOf))
+}
+
+// CheckFundamentals 检测股票基本面，选项中指定了 AsOf 时按该时间点检测
+func (c Checker) CheckFundamentals(ctx context.Context, stock models.Stock) (CheckResult, bool) {
+	asOf, err := c.Options.AsOfTime()
+	if err != nil {
+		result := NewCheckResult()
+		result.Add(CheckItem{
+			ID:     "as_of",
+			Label:  "检测时间点",
+			Status: CheckStatusFail,
+			Desc:   "无效的检测时间点:" + c.Options.AsOf,
+		})
+		return result, false
+	}
+	if !asOf.IsZero() {
+		return c.CheckFundamentalsAsOf(ctx, stock, asOf)
+	}
+	return c.checkFundamentals(ctx, stock)
+}
+
+// checkFundamentals 按股票数据的参考时间检测股票基本面
+func (c Checker) checkFundamentals(ctx context.Context, stock models.Stock) (result CheckResult, ok bool) {
+	result = NewCheckResult()
 	if len(stock.HistoricalFinaMainData) == 0 {
+		result.OK = false
 		return
 	}
-	ok = true
-	result = make(CheckResult)
+	isFinance := goutils.IsStrInSlice(stock.GetOrgType(), []string{"银行", "保险"})
+	// 检测使用的估值参数与计算合理价时不同，则按检测的估值参数重新计算
+	if opts := c.Options.Valuation.WithDefaults(); stock.RightPriceExplanation != nil && stock.RightPriceExplanation.Options != opts {
+		stock = stock.Revalue(ctx, opts)
+	}
 
 	// 最近一期的年报ROE 高于 n%
-	checkItemName := "净资产收益率(ROE)"
-	itemOK := true
 	// 最新一期的年报
-	lastYearReport := stock.HistoricalFinaMainData.GetReport(ctx, time.Now().Year()-1, eastmoney.FinaReportTypeYear)
+	refYear := stock.ReferenceDate().Year()
+	lastYearReport := stock.HistoricalFinaMainData.GetReport(ctx, refYear-1, eastmoney.FinaReportTypeYear)
 	// nil fix: 新的一年刚开始，这时上一年的年报还没有披露
 	if lastYearReport == nil {
-		lastYearReport = stock.HistoricalFinaMainData.GetReport(ctx, time.Now().Year()-2, eastmoney.FinaReportTypeYear)
+		lastYearReport = stock.HistoricalFinaMainData.GetReport(ctx, refYear-2, eastmoney.FinaReportTypeYear)
 	}
 	// 最新一期的财报
 	curReport := stock.HistoricalFinaMainData.CurrentReport(ctx)
-	desc := fmt.Sprintf("%sROE:%.2f%%，同比增长:%.2f%%<br/>%sROE:%.2f%%，同比增长:%.2f%%",
-		lastYearReport.ReportDateName, lastYearReport.Roejq, lastYearReport.Roejqtz,
-		curReport.ReportDateName, curReport.Roejq, curReport.Roejqtz)
-	if lastYearReport.Roejq < c.Options.MinROE && curReport.Roejq < c.Options.MinROE {
-		ok = false
-		itemOK = false
-	}
-	result[checkItemName] = map[string]string{
-		"desc": desc,
-		"ok":   fmt.Sprint(itemOK),
+	if lastYearReport == nil {
+		lastYearReport = curReport
 	}
+	result.Add(CheckItem{
+		ID:     "roe",
+		Label:  "净资产收益率(ROE)",
+		Status: checkStatus(lastYearReport.Roejq >= c.Options.MinROE || curReport.Roejq >= c.Options.MinROE),
+		Values: map[string]float64{
+			"last_year_roe":     lastYearReport.Roejq,
+			"last_year_roe_yoy": lastYearReport.Roejqtz,
+			"current_roe":       curReport.Roejq,
+			"current_roe_yoy":   curReport.Roejqtz,
+		},
+		Value:     observed(math.Max(lastYearReport.Roejq, curReport.Roejq)),
+		Threshold: minThreshold(c.Options.MinROE),
+		Desc: fmt.Sprintf("%sROE:%.2f%%，同比增长:%.2f%%\n%sROE:%.2f%%，同比增长:%.2f%%",
+			lastYearReport.ReportDateName, lastYearReport.Roejq, lastYearReport.Roejqtz,
+			curReport.ReportDateName, curReport.Roejq, curReport.Roejqtz),
+	})
 
 	// ROE 均值小于 NoCheckYearsROE 时，至少 n 年内逐年递增
-	checkItemName = fmt.Sprintf("ROE逐年递增（均值>=%f除外）", c.Options.NoCheckYearsROE)
-	itemOK = true
 	roeList := stock.HistoricalFinaMainData.ValueList(
 		ctx,
 		eastmoney.ValueListTypeROE,
 		c.Options.CheckYears,
 		eastmoney.FinaReportTypeYear,
 	)
-	desc = fmt.Sprintf("%d年内ROE(年报):<br/>%+v", c.Options.CheckYears, roeList)
 	roeavg, err := goutils.AvgFloat64(roeList)
 	if err != nil {
 		logging.Warn(ctx, "roe avg error:"+err.Error())
 	}
+	item := CheckItem{
+		ID:        "roe_increasing",
+		Label:     fmt.Sprintf("ROE逐年递增（均值>=%v除外）", c.Options.NoCheckYearsROE),
+		Status:    CheckStatusSkip,
+		Values:    map[string]float64{"roe_avg": roeavg},
+		Series:    map[string][]float64{"roe": roeList},
+		Threshold: maxThreshold(c.Options.NoCheckYearsROE),
+		Desc:      fmt.Sprintf("%d年内ROE(年报):\n%+v", c.Options.CheckYears, roeList),
+	}
 	if roeavg < c.Options.NoCheckYearsROE {
 		// 年报的ROE递增
+		item.Status = CheckStatusPass
 		if !stock.HistoricalFinaMainData.IsIncreasingByYears(
 			ctx,
 			eastmoney.ValueListTypeROE,
 			c.Options.CheckYears,
 			eastmoney.FinaReportTypeYear,
 		) {
-			desc = fmt.Sprintf("ROE%d年内未逐年递增:\n%+v", c.Options.CheckYears, roeList)
-			ok = false
-			itemOK = false
+			item.Status = CheckStatusFail
+			item.Desc = fmt.Sprintf("ROE%d年内未逐年递增:\n%+v", c.Options.CheckYears, roeList)
 		}
 	}
-	result[checkItemName] = map[string]string{
-		"desc": desc,
-		"ok":   fmt.Sprint(itemOK),
+	result.Add(item)
+
+	// 杜邦分析：ROE 增长不能主要由权益乘数（杠杆）提升驱动
+	dupont := stock.DuPont(ctx, c.Options.CheckYears)
+	item = CheckItem{
+		ID:     "roe_dupont",
+		Label:  "ROE杜邦分析",
+		Status: CheckStatusSkip,
+		Values: map[string]float64{
+			"roe_change":                     dupont.ROEChange,
+			"net_margin_contribution":        dupont.NetMarginContribution,
+			"asset_turnover_contribution":    dupont.AssetTurnoverContribution,
+			"equity_multiplier_contribution": dupont.EquityMultiplierContribution,
+			"leverage_share":                 dupont.LeverageShare,
+		},
+		Series: map[string][]float64{
+			"roe":               {},
+			"net_margin":        {},
+			"asset_turnover":    {},
+			"equity_multiplier": {},
+		},
+		Value:     observed(dupont.LeverageShare),
+		Threshold: maxThreshold(c.Options.MaxLeverageROEShare),
+		Detail:    dupont,
+		Desc:      strings.Join(dupont.Lines(), "\n"),
+	}
+	for _, y := range dupont.Years {
+		item.Series["roe"] = append(item.Series["roe"], y.ROE)
+		item.Series["net_margin"] = append(item.Series["net_margin"], y.NetMargin)
+		item.Series["asset_turnover"] = append(item.Series["asset_turnover"], y.AssetTurnover)
+		item.Series["equity_multiplier"] = append(item.Series["equity_multiplier"], y.EquityMultiplier)
+	}
+	if dupont.IsLeverageDriven(c.Options.MaxLeverageROEShare) {
+		item.Desc += fmt.Sprintf("\nROE增长主要由杠杆驱动(权益乘数贡献%.2f%%)", dupont.LeverageShare)
 	}
+	if c.Options.IsCheckDuPont {
+		// 数据不足或 ROE 为负无法归因时无法判断
+		item.Status = CheckStatusUnknown
+		if dupont.Attributed() {
+			item.Status = checkStatus(!dupont.IsLeverageDriven(c.Options.MaxLeverageROEShare))
+		}
+	}
+	result.Add(item)
 
 	// EPS 至少 n 年内逐年递增且 > 0
-	checkItemName = "EPS逐年递增且 > 0"
-	itemOK = true
-	epsList := stock.HistoricalFinaMainData.ValueList(
-		ctx,
-		eastmoney.ValueListTypeEPS,
-		c.Options.CheckYears,
-		eastmoney.FinaReportTypeYear,
-	)
-	desc = fmt.Sprintf(
-		"%sEPS:%f,同比增长:%.2f%%<br/>%d年内EPS:<br/>%+v",
-		curReport.ReportDateName,
-		curReport.Epsjb,
-		curReport.Epsjbtz,
-		c.Options.CheckYears,
-		epsList,
-	)
-	if c.Options.IsCheckEPSGrow && len(epsList) > 0 {
-		if epsList[len(epsList)-1] <= 0 ||
-			!stock.HistoricalFinaMainData.IsIncreasingByYears(
-				ctx,
-				eastmoney.ValueListTypeEPS,
-				c.Options.CheckYears,
-				eastmoney.FinaReportTypeYear,
-			) {
-			ok = false
-			itemOK = false
-		}
+	epsList, epsSource := c.growthList(ctx, stock, eastmoney.ValueListTypeEPS)
+	item = CheckItem{
+		ID:     "eps_increasing",
+		Label:  "EPS逐年递增且 > 0",
+		Status: CheckStatusSkip,
+		Values: map[string]float64{"current_eps": curReport.Epsjb, "current_eps_yoy": curReport.Epsjbtz},
+		Series: map[string][]float64{"eps": epsList},
+		Desc: fmt.Sprintf(
+			"%sEPS:%f,同比增长:%.2f%%\n%d年内EPS%s:\n%+v",
+			curReport.ReportDateName,
+			curReport.Epsjb,
+			curReport.Epsjbtz,
+			c.Options.CheckYears,
+			epsSource,
+			epsList,
+		),
 	}
-	result[checkItemName] = map[string]string{
-		"desc": desc,
-		"ok":   fmt.Sprint(itemOK),
+	if c.Options.IsCheckEPSGrow {
+		item.Status = c.growthStatus(epsList)
 	}
+	result.Add(item)
 
 	// 营业总收入至少 n 年内逐年递增且 > 0
-	revList := stock.HistoricalFinaMainData.ValueList(
-		ctx,
-		eastmoney.ValueListTypeRevenue,
-		c.Options.CheckYears,
-		eastmoney.FinaReportTypeYear,
-	)
-	checkItemName = "营收逐年递增且>0"
-	itemOK = true
+	revList, revSource := c.growthList(ctx, stock, eastmoney.ValueListTypeRevenue)
 	revs := []string{}
 	for _, rev := range revList {
 		revs = append(revs, goutils.YiWanString(rev))
 	}
-	desc = fmt.Sprintf(
-		"%s营收:%s,同比增长:%.2f%%<br/>%d年内营收:<br/>%s",
-		curReport.ReportDateName,
-		goutils.YiWanString(curReport.Totaloperatereve),
-		curReport.Totaloperaterevetz,
-		c.Options.CheckYears,
-		strings.Join(revs, "<br/>"),
-	)
-	if c.Options.IsCheckRevGrow && len(revList) > 0 {
-		if revList[len(revList)-1] <= 0 ||
-			!stock.HistoricalFinaMainData.IsIncreasingByYears(
-				ctx,
-				eastmoney.ValueListTypeRevenue,
-				c.Options.CheckYears,
-				eastmoney.FinaReportTypeYear,
-			) {
-			ok = false
-			itemOK = false
-		}
+	item = CheckItem{
+		ID:     "revenue_increasing",
+		Label:  "营收逐年递增且>0",
+		Status: CheckStatusSkip,
+		Values: map[string]float64{"current_revenue": curReport.Totaloperatereve, "current_revenue_yoy": curReport.Totaloperaterevetz},
+		Series: map[string][]float64{"revenue": revList},
+		Desc: fmt.Sprintf(
+			"%s营收:%s,同比增长:%.2f%%\n%d年内营收%s:\n%s",
+			curReport.ReportDateName,
+			goutils.YiWanString(curReport.Totaloperatereve),
+			curReport.Totaloperaterevetz,
+			c.Options.CheckYears,
+			revSource,
+			strings.Join(revs, "\n"),
+		),
 	}
-	result[checkItemName] = map[string]string{
-		"desc": desc,
-		"ok":   fmt.Sprint(itemOK),
+	if c.Options.IsCheckRevGrow {
+		item.Status = c.growthStatus(revList)
 	}
+	result.Add(item)
 
 	// 净利润至少 n 年内逐年递增
-	netprofitList := stock.HistoricalFinaMainData.ValueList(
-		ctx,
-		eastmoney.ValueListTypeNetProfit,
-		c.Options.CheckYears,
-		eastmoney.FinaReportTypeYear,
-	)
-	checkItemName = "净利润逐年递增且>0"
-	itemOK = true
+	netprofitList, netprofitSource := c.growthList(ctx, stock, eastmoney.ValueListTypeNetProfit)
 	nps := []string{}
 	for _, np := range netprofitList {
 		nps = append(nps, goutils.YiWanString(np))
 	}
-	desc = fmt.Sprintf("%s净利润:%s,同比增长:%.2f%%<br/>%d年内净利润:<br/>%s",
-		curReport.ReportDateName,
-		goutils.YiWanString(curReport.Parentnetprofit),
-		curReport.Parentnetprofittz,
-		c.Options.CheckYears,
-		strings.Join(nps, "<br/>"))
-	if c.Options.IsCheckNetprofitGrow && len(netprofitList) > 0 {
-		if netprofitList[len(netprofitList)-1] <= 0 ||
-			!stock.HistoricalFinaMainData.IsIncreasingByYears(
-				ctx,
-				eastmoney.ValueListTypeNetProfit,
-				c.Options.CheckYears,
-				eastmoney.FinaReportTypeYear,
-			) {
-			ok = false
-			itemOK = false
-		}
+	item = CheckItem{
+		ID:     "netprofit_increasing",
+		Label:  "净利润逐年递增且>0",
+		Status: CheckStatusSkip,
+		Values: map[string]float64{"current_netprofit": curReport.Parentnetprofit, "current_netprofit_yoy": curReport.Parentnetprofittz},
+		Series: map[string][]float64{"netprofit": netprofitList},
+		Desc: fmt.Sprintf("%s净利润:%s,同比增长:%.2f%%\n%d年内净利润%s:\n%s",
+			curReport.ReportDateName,
+			goutils.YiWanString(curReport.Parentnetprofit),
+			curReport.Parentnetprofittz,
+			c.Options.CheckYears,
+			netprofitSource,
+			strings.Join(nps, "\n")),
 	}
-	result[checkItemName] = map[string]string{
-		"desc": desc,
-		"ok":   fmt.Sprint(itemOK),
+	if c.Options.IsCheckNetprofitGrow {
+		item.Status = c.growthStatus(netprofitList)
 	}
+	result.Add(item)
 
-	// 整体质地
-	checkItemName = "整体质地"
-	itemOK = true
-	desc = stock.JZPG.GetValueTotalScore()
-	if !goutils.IsStrInSlice(stock.JZPG.GetValueTotalScore(), []string{"优秀", "良好"}) {
-		ok = false
-		itemOK = false
-	}
-	result[checkItemName] = map[string]string{
-		"desc": desc,
-		"ok":   fmt.Sprint(itemOK),
+	// 价值评估及四率估值只有最新数据，按历史时间点检测时跳过
+	pointInTime := !stock.AsOfDate.IsZero()
+	pointInTimeStatus := func(ok bool) CheckStatus {
+		if pointInTime {
+			return CheckStatusSkip
+		}
+		return checkStatus(ok)
 	}
 
+	// 整体质地
+	result.Add(CheckItem{
+		ID:     "jzpg_total",
+		Label:  "整体质地",
+		Status: pointInTimeStatus(goutils.IsStrInSlice(stock.JZPG.GetValueTotalScore(), []string{"优秀", "良好"})),
+		Desc:   stock.JZPG.GetValueTotalScore(),
+	})
+
 	// 行业均值水平估值
-	checkItemName = "行业均值水平估值"
-	itemOK = true
-	desc = stock.JZPG.GetValuationScore()
-	if stock.JZPG.GetValuationScore() == "高于行业均值水平" {
-		ok = false
-		itemOK = false
-	}
-	result[checkItemName] = map[string]string{
-		"desc": desc,
-		"ok":   fmt.Sprint(itemOK),
-	}
+	result.Add(CheckItem{
+		ID:     "jzpg_valuation",
+		Label:  "行业均值水平估值",
+		Status: pointInTimeStatus(stock.JZPG.GetValuationScore() != "高于行业均值水平"),
+		Desc:   stock.JZPG.GetValuationScore(),
+	})
 
 	// 市盈率、市净率、市现率、市销率全部估值较高
-	checkItemName = "四率估值"
-	itemOK = true
 	allHighValuation := true
 	valuationDesc := []string{}
-	for k, v := range stock.ValuationMap {
-		valuationDesc = append(valuationDesc, k+v)
+	valuationKeys := []string{}
+	for k := range stock.ValuationMap {
+		valuationKeys = append(valuationKeys, k)
 	}
-	for _, v := range stock.ValuationMap {
+	sort.Strings(valuationKeys)
+	for _, k := range valuationKeys {
+		v := stock.ValuationMap[k]
+		valuationDesc = append(valuationDesc, k+v)
 		if v != "估值较高" {
 			allHighValuation = false
-			break
 		}
 	}
-	if allHighValuation {
-		ok = false
-		itemOK = false
-	}
-	result[checkItemName] = map[string]string{
-		"desc": strings.Join(valuationDesc, "<br/>"),
-		"ok":   fmt.Sprint(itemOK),
-	}
+	result.Add(CheckItem{
+		ID:     "valuation_status",
+		Label:  "四率估值",
+		Status: pointInTimeStatus(!allHighValuation),
+		Desc:   strings.Join(valuationDesc, "\n"),
+	})
 
 	// 股价低于合理价格
-	checkItemName = "合理股价"
-	itemOK = true
 	price := stock.GetPrice()
-	desc = fmt.Sprintf(
-		"最新股价:%f<br/>合理价:%.2f(%.2f%%)<br/>去年合理价:%.2f,去年实际价格:%.2f",
-		price,
-		stock.RightPrice,
-		stock.PriceSpace,
-		stock.LastYearRightPrice,
-		stock.HistoricalPrice.LastYearFinalPrice(),
-	)
+	item = CheckItem{
+		ID:     "right_price",
+		Label:  "合理股价",
+		Status: CheckStatusSkip,
+		Values: map[string]float64{
+			"price":                 price,
+			"right_price":           stock.RightPrice,
+			"price_space":           stock.PriceSpace,
+			"last_year_right_price": stock.LastYearRightPrice,
+			"last_year_price":       stock.HistoricalPrice.YearFinalPrice(refYear - 1),
+		},
+		Value:     observed(price),
+		Threshold: maxThreshold(stock.RightPrice),
+		Desc: fmt.Sprintf(
+			"最新股价:%f\n合理价:%.2f(%.2f%%)\n去年合理价:%.2f,去年实际价格:%.2f",
+			price,
+			stock.RightPrice,
+			stock.PriceSpace,
+			stock.LastYearRightPrice,
+			stock.HistoricalPrice.YearFinalPrice(refYear-1),
+		),
+	}
+	if expl := stock.RightPriceExplanation; expl != nil {
+		item.Detail = expl
+		item.Values["pe_median"] = expl.PEMedian
+		item.Values["base_eps"] = expl.BaseEPS
+		item.Values["growth_used"] = expl.GrowthUsed
+		item.Desc += "\n" + strings.Join(expl.Lines(), "\n")
+	}
 	if c.Options.IsCheckPriceByCalc {
-		if price > stock.RightPrice {
-			ok = false
-			itemOK = false
+		item.Status = checkStatus(price <= stock.RightPrice)
+	}
+	result.Add(item)
+
+	// DCF 估值安全边际
+	item = CheckItem{
+		ID:        "dcf",
+		Label:     "DCF估值",
+		Status:    CheckStatusSkip,
+		Threshold: minThreshold(c.Options.MinMarginOfSafety),
+	}
+	if dcf, err := stock.DCFValue(ctx, c.Options.DCF); err != nil {
+		item.Desc = "无法估值:" + err.Error()
+		if c.Options.IsCheckDCF {
+			item.Status = CheckStatusWarn
+		}
+	} else {
+		item.Values = map[string]float64{
+			"value_per_share":  dcf.ValuePerShare,
+			"margin_of_safety": dcf.MarginOfSafety,
+			"discount_rate":    dcf.DiscountRate,
 		}
+		item.Value = observed(dcf.MarginOfSafety)
+		item.Detail = dcf
+		item.Desc = strings.Join(dcf.Lines(), "\n")
+		if c.Options.IsCheckDCF {
+			item.Status = checkStatus(dcf.MarginOfSafety >= c.Options.MinMarginOfSafety)
+		}
+	}
+	result.Add(item)
+
+	// DDM 估值安全边际，只适用于高分红股票
+	item = CheckItem{
+		ID:        "ddm",
+		Label:     "DDM估值",
+		Status:    CheckStatusSkip,
+		Threshold: minThreshold(c.Options.MinMarginOfSafety),
 	}
-	result[checkItemName] = map[string]string{
-		"desc": desc,
-		"ok":   fmt.Sprint(itemOK),
+	if ddm, err := stock.DDMValue(ctx, c.Options.DDM); err != nil {
+		item.Desc = "不适用:" + err.Error()
+	} else {
+		item.Values = map[string]float64{
+			"value_per_share":  ddm.ValuePerShare,
+			"margin_of_safety": ddm.MarginOfSafety,
+			"discount_rate":    ddm.DiscountRate,
+			"payout_ratio":     ddm.PayoutRatio,
+		}
+		item.Value = observed(ddm.MarginOfSafety)
+		item.Detail = ddm
+		item.Desc = strings.Join(ddm.Lines(), "\n")
+		if c.Options.IsCheckDDM {
+			item.Status = checkStatus(ddm.MarginOfSafety >= c.Options.MinMarginOfSafety)
+		}
 	}
+	result.Add(item)
+
+	// 财务质量评分
+	fscore := stock.PiotroskiFScore
+	result.Add(qualityScoreItem("piotroski_f", "Piotroski F-Score", fscore,
+		c.Options.MinPiotroskiFScore > 0, minThreshold(c.Options.MinPiotroskiFScore),
+		fscore != nil && fscore.Score >= c.Options.MinPiotroskiFScore))
+	zscore := stock.AltmanZScore
+	result.Add(qualityScoreItem("altman_z", "Altman Z-Score", zscore,
+		c.Options.MinAltmanZScore > 0, minThreshold(c.Options.MinAltmanZScore),
+		zscore != nil && zscore.Score >= c.Options.MinAltmanZScore))
+	mscore := stock.BeneishMScore
+	result.Add(qualityScoreItem("beneish_m", "Beneish M-Score", mscore,
+		c.Options.IsCheckBeneishM, maxThreshold(c.Options.MaxBeneishMScore),
+		mscore != nil && mscore.Score <= c.Options.MaxBeneishMScore))
 
 	// 负债率低于 MaxDebtRatio （可选条件），金融股不检测该项
-	checkItemName = "负债率"
-	itemOK = true
 	fzl := stock.HistoricalFinaMainData[0].Zcfzl
-	desc = fmt.Sprintf("负债率:%f", fzl)
-	if !goutils.IsStrInSlice(stock.GetOrgType(), []string{"银行", "保险"}) {
-		if c.Options.MaxDebtAssetRatio != 0 && len(stock.HistoricalFinaMainData) > 0 {
-			if fzl > c.Options.MaxDebtAssetRatio {
-				desc = fmt.Sprintf("负债率:%f<br/>高于:%f", fzl, c.Options.MaxDebtAssetRatio)
-				ok = false
-				itemOK = false
-			}
-		}
+	item = CheckItem{
+		ID:        "debt_ratio",
+		Label:     "负债率",
+		Status:    CheckStatusSkip,
+		Values:    map[string]float64{"debt_ratio": fzl},
+		Value:     observed(fzl),
+		Threshold: maxThreshold(c.Options.MaxDebtAssetRatio),
+		Desc:      fmt.Sprintf("负债率:%f", fzl),
 	}
-	result[checkItemName] = map[string]string{
-		"desc": desc,
-		"ok":   fmt.Sprint(itemOK),
+	if !isFinance && c.Options.MaxDebtAssetRatio != 0 {
+		item.Status = CheckStatusPass
+		if fzl > c.Options.MaxDebtAssetRatio {
+			item.Status = CheckStatusFail
+			item.Desc = fmt.Sprintf("负债率:%f\n高于:%f", fzl, c.Options.MaxDebtAssetRatio)
+		}
 	}
+	result.Add(item)
 
 	// 历史波动率 （可选条件）
-	checkItemName = "历史波动率"
-	itemOK = true
-	desc = fmt.Sprintf("历史波动率:%f", stock.HistoricalVolatility)
+	item = CheckItem{
+		ID:        "hv",
+		Label:     "历史波动率",
+		Status:    CheckStatusSkip,
+		Values:    map[string]float64{"hv": stock.HistoricalVolatility},
+		Value:     observed(stock.HistoricalVolatility),
+		Threshold: maxThreshold(c.Options.MaxHV),
+		Desc:      fmt.Sprintf("历史波动率:%f", stock.HistoricalVolatility),
+	}
 	if c.Options.MaxHV != 0 {
+		item.Status = CheckStatusPass
 		if stock.HistoricalVolatility > c.Options.MaxHV {
-			desc = fmt.Sprintf("历史波动率:%f<br/>高于:%f", stock.HistoricalVolatility, c.Options.MaxHV)
-			ok = false
-			itemOK = false
+			item.Status = CheckStatusFail
+			item.Desc = fmt.Sprintf("历史波动率:%f\n高于:%f", stock.HistoricalVolatility, c.Options.MaxHV)
 		}
 	}
-	result[checkItemName] = map[string]string{
-		"desc": desc,
-		"ok":   fmt.Sprint(itemOK),
-	}
+	result.Add(item)
 
 	// 市值
-	checkItemName = "市值"
-	itemOK = true
 	sz := goutils.YiWanString(stock.BaseInfo.TotalMarketCap)
-	desc = fmt.Sprintf("市值:%s", sz)
-	if stock.BaseInfo.TotalMarketCap < c.Options.MinTotalMarketCap*100000000 {
-		desc = fmt.Sprintf("市值:%s<br/>低于:%f亿", sz, c.Options.MinTotalMarketCap)
-		ok = false
-		itemOK = false
+	item = CheckItem{
+		ID:        "market_cap",
+		Label:     "市值",
+		Status:    CheckStatusPass,
+		Values:    map[string]float64{"market_cap": stock.BaseInfo.TotalMarketCap},
+		Value:     observed(stock.BaseInfo.TotalMarketCap),
+		Threshold: minThreshold(c.Options.MinTotalMarketCap * 100000000),
+		Desc:      fmt.Sprintf("市值:%s", sz),
 	}
-	result[checkItemName] = map[string]string{
-		"desc": desc,
-		"ok":   fmt.Sprint(itemOK),
+	if stock.BaseInfo.TotalMarketCap < c.Options.MinTotalMarketCap*100000000 {
+		item.Status = CheckStatusFail
+		item.Desc = fmt.Sprintf("市值:%s\n低于:%f亿", sz, c.Options.MinTotalMarketCap)
 	}
+	result.Add(item)
 
 	// 银行股特殊检测
 	if stock.GetOrgType() == "银行" {
 		fmd := stock.HistoricalFinaMainData[0]
-		checkItemName = "总资产收益率(ROA)"
-		itemOK = true
-		desc = fmt.Sprintf("最新ROA:%f", stock.BaseInfo.ROA)
-		if stock.BaseInfo.ROA < c.Options.BankMinROA {
-			desc = fmt.Sprintf("ROA:%f<br/>低于:%f", stock.BaseInfo.ROA, c.Options.BankMinROA)
-			ok = false
-			itemOK = false
+		item = CheckItem{
+			ID:        "bank_roa",
+			Label:     "总资产收益率(ROA)",
+			Status:    CheckStatusPass,
+			Values:    map[string]float64{"roa": stock.BaseInfo.ROA},
+			Value:     observed(stock.BaseInfo.ROA),
+			Threshold: minThreshold(c.Options.BankMinROA),
+			Desc:      fmt.Sprintf("最新ROA:%f", stock.BaseInfo.ROA),
 		}
-		result[checkItemName] = map[string]string{
-			"desc": desc,
-			"ok":   fmt.Sprint(itemOK),
+		if stock.BaseInfo.ROA < c.Options.BankMinROA {
+			item.Status = CheckStatusFail
+			item.Desc = fmt.Sprintf("ROA:%f\n低于:%f", stock.BaseInfo.ROA, c.Options.BankMinROA)
 		}
+		result.Add(item)
 
-		checkItemName = "资本充足率"
-		itemOK = true
-		desc = fmt.Sprintf("资本充足率:%f", fmd.Newcapitalader)
-		if fmd.Newcapitalader < c.Options.BankMinZBCZL {
-			desc = fmt.Sprintf("资本充足率:%f<br/>低于:%f", fmd.Newcapitalader, c.Options.BankMinZBCZL)
-			ok = false
-			itemOK = false
+		item = CheckItem{
+			ID:        "bank_zbczl",
+			Label:     "资本充足率",
+			Status:    CheckStatusPass,
+			Values:    map[string]float64{"zbczl": fmd.Newcapitalader},
+			Value:     observed(fmd.Newcapitalader),
+			Threshold: minThreshold(c.Options.BankMinZBCZL),
+			Desc:      fmt.Sprintf("资本充足率:%f", fmd.Newcapitalader),
 		}
-		result[checkItemName] = map[string]string{
-			"desc": desc,
-			"ok":   fmt.Sprint(itemOK),
+		if fmd.Newcapitalader < c.Options.BankMinZBCZL {
+			item.Status = CheckStatusFail
+			item.Desc = fmt.Sprintf("资本充足率:%f\n低于:%f", fmd.Newcapitalader, c.Options.BankMinZBCZL)
 		}
+		result.Add(item)
 
-		checkItemName = "不良贷款率"
-		itemOK = true
-		desc = fmt.Sprintf("不良贷款率:%f", fmd.NonPerLoan)
+		item = CheckItem{
+			ID:        "bank_bldkl",
+			Label:     "不良贷款率",
+			Status:    CheckStatusSkip,
+			Values:    map[string]float64{"bldkl": fmd.NonPerLoan},
+			Value:     observed(fmd.NonPerLoan),
+			Threshold: maxThreshold(c.Options.BankMaxBLDKL),
+			Desc:      fmt.Sprintf("不良贷款率:%f", fmd.NonPerLoan),
+		}
 		if c.Options.BankMaxBLDKL != 0 {
+			item.Status = CheckStatusPass
 			if fmd.NonPerLoan > c.Options.BankMaxBLDKL {
-				desc = fmt.Sprintf("不良贷款率:%f<br/>高于:%f", fmd.NonPerLoan, c.Options.BankMaxBLDKL)
-				ok = false
-				itemOK = false
+				item.Status = CheckStatusFail
+				item.Desc = fmt.Sprintf("不良贷款率:%f\n高于:%f", fmd.NonPerLoan, c.Options.BankMaxBLDKL)
 			}
 		}
-		result[checkItemName] = map[string]string{
-			"desc": desc,
-			"ok":   fmt.Sprint(itemOK),
-		}
+		result.Add(item)
 
-		checkItemName = "不良贷款拨备覆盖率"
-		itemOK = true
-		desc = fmt.Sprintf("不良贷款拨备覆盖率:%f", fmd.Bldkbbl)
-		if fmd.Bldkbbl < c.Options.BankMinBLDKBBFGL {
-			desc = fmt.Sprintf("不良贷款拨备覆盖率:%f<br/>低于:%f", fmd.Bldkbbl, c.Options.BankMinBLDKBBFGL)
-			ok = false
-			itemOK = false
+		item = CheckItem{
+			ID:        "bank_bldkbbfgl",
+			Label:     "不良贷款拨备覆盖率",
+			Status:    CheckStatusPass,
+			Values:    map[string]float64{"bldkbbfgl": fmd.Bldkbbl},
+			Value:     observed(fmd.Bldkbbl),
+			Threshold: minThreshold(c.Options.BankMinBLDKBBFGL),
+			Desc:      fmt.Sprintf("不良贷款拨备覆盖率:%f", fmd.Bldkbbl),
 		}
-		result[checkItemName] = map[string]string{
-			"desc": desc,
-			"ok":   fmt.Sprint(itemOK),
+		if fmd.Bldkbbl < c.Options.BankMinBLDKBBFGL {
+			item.Status = CheckStatusFail
+			item.Desc = fmt.Sprintf("不良贷款拨备覆盖率:%f\n低于:%f", fmd.Bldkbbl, c.Options.BankMinBLDKBBFGL)
 		}
+		result.Add(item)
 	}
 
 	// 毛利率稳定性 （只检测非金融股）
@@ -475,45 +676,41 @@ func (c Checker) CheckFundamentals(ctx context.Context, stock models.Stock) (res
 		c.Options.CheckYears,
 		eastmoney.FinaReportTypeYear,
 	)
-	if c.Options.IsCheckMLLStability && !goutils.IsStrInSlice(stock.GetOrgType(), []string{"银行", "保险"}) {
-		checkItemName = "毛利率稳定性"
-		itemOK = true
-		desc = fmt.Sprintf("%d年内毛利率:<br/>%v", c.Options.CheckYears, mllList)
+	if c.Options.IsCheckMLLStability && !isFinance {
+		item = CheckItem{
+			ID:     "mll_stability",
+			Label:  "毛利率稳定性",
+			Status: CheckStatusPass,
+			Series: map[string][]float64{"mll": mllList},
+			Desc:   fmt.Sprintf("%d年内毛利率:\n%v", c.Options.CheckYears, mllList),
+		}
 		if !stock.HistoricalFinaMainData.IsStability(
 			ctx,
 			eastmoney.ValueListTypeMLL,
 			c.Options.CheckYears,
 			eastmoney.FinaReportTypeYear,
 		) {
-			desc = fmt.Sprintf("%d年内稳定性较差:<br/>%v", c.Options.CheckYears, mllList)
-			ok = false
-			itemOK = false
-		}
-		result[checkItemName] = map[string]string{
-			"desc": desc,
-			"ok":   fmt.Sprint(itemOK),
+			item.Status = CheckStatusFail
+			item.Desc = fmt.Sprintf("%d年内稳定性较差:\n%v", c.Options.CheckYears, mllList)
 		}
+		result.Add(item)
 	}
 
 	// 毛利率逐年递增 （只检测非金融股）
-	if c.Options.IsCheckMLLGrow && !goutils.IsStrInSlice(stock.GetOrgType(), []string{"银行", "保险"}) && len(mllList) > 0 {
-		checkItemName = "毛利率逐年递增且>0"
-		itemOK = true
-		desc = fmt.Sprintf("%d年内毛利率:<br/>%v", c.Options.CheckYears, mllList)
-		if revList[len(mllList)-1] <= 0 ||
-			!stock.HistoricalFinaMainData.IsIncreasingByYears(
-				ctx,
-				eastmoney.ValueListTypeMLL,
-				c.Options.CheckYears,
-				eastmoney.FinaReportTypeYear,
-			) {
-			ok = false
-			itemOK = false
-		}
-		result[checkItemName] = map[string]string{
-			"desc": desc,
-			"ok":   fmt.Sprint(itemOK),
-		}
+	if c.Options.IsCheckMLLGrow && !isFinance && len(mllList) > 0 {
+		result.Add(CheckItem{
+			ID:    "mll_increasing",
+			Label: "毛利率逐年递增且>0",
+			Status: checkStatus(mllList[len(mllList)-1] > 0 &&
+				stock.HistoricalFinaMainData.IsIncreasingByYears(
+					ctx,
+					eastmoney.ValueListTypeMLL,
+					c.Options.CheckYears,
+					eastmoney.FinaReportTypeYear,
+				)),
+			Series: map[string][]float64{"mll": mllList},
+			Desc:   fmt.Sprintf("%d年内毛利率:\n%v", c.Options.CheckYears, mllList),
+		})
 	}
 
 	// 净利率稳定性
@@ -523,158 +720,326 @@ func (c Checker) CheckFundamentals(ctx context.Context, stock models.Stock) (res
 		c.Options.CheckYears,
 		eastmoney.FinaReportTypeYear,
 	)
-	checkItemName = "净利率稳定性"
-	itemOK = true
-	desc = fmt.Sprintf("%d年内净利率:<br/>%v", c.Options.CheckYears, jllList)
+	item = CheckItem{
+		ID:     "jll_stability",
+		Label:  "净利率稳定性",
+		Status: CheckStatusSkip,
+		Series: map[string][]float64{"jll": jllList},
+		Desc:   fmt.Sprintf("%d年内净利率:\n%v", c.Options.CheckYears, jllList),
+	}
 	if c.Options.IsCheckJLLStability {
+		item.Status = CheckStatusPass
 		if !stock.HistoricalFinaMainData.IsStability(
 			ctx,
 			eastmoney.ValueListTypeJLL,
 			c.Options.CheckYears,
 			eastmoney.FinaReportTypeYear,
 		) {
-			desc = fmt.Sprintf("%d年内稳定性较差:<br/>%v", c.Options.CheckYears, jllList)
-			ok = false
-			itemOK = false
+			item.Status = CheckStatusFail
+			item.Desc = fmt.Sprintf("%d年内稳定性较差:\n%v", c.Options.CheckYears, jllList)
 		}
 	}
-	result[checkItemName] = map[string]string{
-		"desc": desc,
-		"ok":   fmt.Sprint(itemOK),
-	}
+	result.Add(item)
 
 	// 净利率逐年递增
-	checkItemName = "净利率逐年递增且>0"
-	itemOK = true
-	desc = fmt.Sprintf("%d年内净利率:<br/>%v", c.Options.CheckYears, jllList)
+	item = CheckItem{
+		ID:     "jll_increasing",
+		Label:  "净利率逐年递增且>0",
+		Status: CheckStatusSkip,
+		Series: map[string][]float64{"jll": jllList},
+		Desc:   fmt.Sprintf("%d年内净利率:\n%v", c.Options.CheckYears, jllList),
+	}
 	if c.Options.IsCheckJLLGrow && len(jllList) > 0 {
-		if revList[len(jllList)-1] <= 0 ||
-			!stock.HistoricalFinaMainData.IsIncreasingByYears(
+		item.Status = checkStatus(jllList[len(jllList)-1] > 0 &&
+			stock.HistoricalFinaMainData.IsIncreasingByYears(
 				ctx,
 				eastmoney.ValueListTypeJLL,
 				c.Options.CheckYears,
 				eastmoney.FinaReportTypeYear,
-			) {
-			ok = false
-			itemOK = false
-		}
-	}
-	result[checkItemName] = map[string]string{
-		"desc": desc,
-		"ok":   fmt.Sprint(itemOK),
+			))
 	}
+	result.Add(item)
 
 	// PEG
-	checkItemName = "PEG"
-	itemOK = true
-	desc = fmt.Sprintf("PEG:%v", stock.PEG)
+	peg, pegLabel := stock.PEG, "PEG"
+	if c.Options.UseTTMGrowth {
+		peg, pegLabel = stock.TTMPEG, "TTM PEG"
+	}
+	item = CheckItem{
+		ID:        "peg",
+		Label:     pegLabel,
+		Status:    CheckStatusSkip,
+		Values:    map[string]float64{"peg": stock.PEG, "ttm_pe": stock.TTMPE, "ttm_peg": stock.TTMPEG},
+		Value:     observed(peg),
+		Threshold: rangeThreshold(0, c.Options.MaxPEG),
+		Desc:      fmt.Sprintf("%s:%v", pegLabel, peg),
+	}
 	if c.Options.MaxPEG != 0 {
-		if stock.PEG > c.Options.MaxPEG {
-			desc = fmt.Sprintf("PEG:%v<br/>高于:%v", stock.PEG, c.Options.MaxPEG)
-			ok = false
-			itemOK = false
-		} else if stock.PEG < 0 {
-			desc = fmt.Sprintf("PEG:%v<br/>低于:0", stock.PEG)
-			ok = false
-			itemOK = false
+		item.Status = CheckStatusPass
+		if peg > c.Options.MaxPEG {
+			item.Status = CheckStatusFail
+			item.Desc = fmt.Sprintf("%s:%v\n高于:%v", pegLabel, peg, c.Options.MaxPEG)
+		} else if peg < 0 {
+			item.Status = CheckStatusFail
+			item.Desc = fmt.Sprintf("%s:%v\n低于:0", pegLabel, peg)
 		}
 	}
-	result[checkItemName] = map[string]string{
-		"desc": desc,
-		"ok":   fmt.Sprint(itemOK),
-	}
+	result.Add(item)
 
 	// 本业营收比
-	checkItemName = "本业营收比"
-	itemOK = true
-	desc = fmt.Sprintf("当前本业营收比:%v", stock.BYYSRatio)
+	item = CheckItem{
+		ID:        "byys_ratio",
+		Label:     "本业营收比",
+		Status:    CheckStatusSkip,
+		Values:    map[string]float64{"byys_ratio": stock.BYYSRatio},
+		Value:     observed(stock.BYYSRatio),
+		Threshold: rangeThreshold(c.Options.MinBYYSRatio, c.Options.MaxBYYSRatio),
+		Desc:      fmt.Sprintf("当前本业营收比:%v", stock.BYYSRatio),
+	}
 	if c.Options.MinBYYSRatio != 0 && c.Options.MaxBYYSRatio != 0 {
+		item.Status = CheckStatusPass
 		if stock.BYYSRatio > c.Options.MaxBYYSRatio || stock.BYYSRatio < c.Options.MinBYYSRatio {
-			desc = fmt.Sprintf("当前本业营收比:%v<br/>超出范围:%v-%v", stock.BYYSRatio, c.Options.MinBYYSRatio, c.Options.MaxBYYSRatio)
-			ok = false
-			itemOK = false
+			item.Status = CheckStatusFail
+			item.Desc = fmt.Sprintf("当前本业营收比:%v\n超出范围:%v-%v", stock.BYYSRatio, c.Options.MinBYYSRatio, c.Options.MaxBYYSRatio)
 		}
 	}
-	result[checkItemName] = map[string]string{
-		"desc": desc,
-		"ok":   fmt.Sprint(itemOK),
-	}
+	result.Add(item)
 
 	// 审计意见
-	checkItemName = "财报审计意见"
-	itemOK = true
-	desc = stock.FinaReportOpinion
-	if stock.FinaReportOpinion != "" {
-		if stock.FinaReportOpinion != "标准无保留意见" {
-			ok = false
-			itemOK = false
-		}
+	item = CheckItem{
+		ID:     "audit_opinion",
+		Label:  "财报审计意见",
+		Status: CheckStatusSkip,
+		Desc:   stock.FinaReportOpinion,
 	}
-	result[checkItemName] = map[string]string{
-		"desc": desc,
-		"ok":   fmt.Sprint(itemOK),
+	if stock.FinaReportOpinion != "" {
+		item.Status = checkStatus(stock.FinaReportOpinion == "标准无保留意见")
 	}
+	result.Add(item)
 
 	// 配发股利股息
-	checkItemName = "配发股利股息"
-	itemOK = true
-	desc = fmt.Sprintf("最新股息率: %f", stock.BaseInfo.Zxgxl)
-	if stock.BaseInfo.Zxgxl < c.Options.MinGxl {
-		desc = fmt.Sprintf("最新股息率: %f < %f", stock.BaseInfo.Zxgxl, c.Options.MinGxl)
-		ok = false
-		itemOK = false
+	item = CheckItem{
+		ID:        "dividend",
+		Label:     "配发股利股息",
+		Status:    CheckStatusPass,
+		Values:    map[string]float64{"gxl": stock.BaseInfo.Zxgxl},
+		Value:     observed(stock.BaseInfo.Zxgxl),
+		Threshold: minThreshold(c.Options.MinGxl),
+		Desc:      fmt.Sprintf("最新股息率: %f", stock.BaseInfo.Zxgxl),
 	}
-	result[checkItemName] = map[string]string{
-		"desc": desc,
-		"ok":   fmt.Sprint(itemOK),
+	if !stock.AsOfDate.IsZero() && stock.BaseInfo.Zxgxl < 0 {
+		// 未要求最低股息率时无需历史股息率数据
+		item.Desc = "股息率无历史数据，无法按历史时间点检测"
+		if c.Options.MinGxl > 0 {
+			item.Status = CheckStatusUnknown
+		}
+	} else if stock.BaseInfo.Zxgxl < c.Options.MinGxl {
+		item.Status = CheckStatusFail
+		item.Desc = fmt.Sprintf("最新股息率: %f < %f", stock.BaseInfo.Zxgxl, c.Options.MinGxl)
 	}
+	result.Add(item)
 
 	// 负债流动比检测
-	checkItemName = "负债流动比"
-	itemOK = true
 	fzldb := stock.HistoricalFinaMainData[0].Ld
-	desc = fmt.Sprintf("最新负债流动比: %f", fzldb)
-	if fzldb < c.Options.MinFZLDB {
-		ok = false
-		itemOK = false
-	}
-	result[checkItemName] = map[string]string{
-		"desc": desc,
-		"ok":   fmt.Sprint(itemOK),
-	}
+	result.Add(CheckItem{
+		ID:        "fzldb",
+		Label:     "负债流动比",
+		Status:    checkStatus(fzldb >= c.Options.MinFZLDB),
+		Values:    map[string]float64{"fzldb": fzldb},
+		Value:     observed(fzldb),
+		Threshold: minThreshold(c.Options.MinFZLDB),
+		Desc:      fmt.Sprintf("最新负债流动比: %f", fzldb),
+	})
 
 	// 现金流检测
-	checkItemName = "现金流量"
-	itemOK = true
 	if len(stock.HistoricalCashflowList) > 0 {
-		desc = fmt.Sprintf(
-			`经营活动产生的现金流量净额(>0):%s<br/>投资活动产生的现金流量净额(<0):%s<br/>筹资活动产生的现金流量净额:%s<br/>自由现金流量(>0):%s`,
-			goutils.YiWanString(stock.NetcashOperate),
-			goutils.YiWanString(stock.NetcashInvest),
-			goutils.YiWanString(stock.NetcashFinance),
-			goutils.YiWanString(stock.NetcashFree),
-		)
+		item = CheckItem{
+			ID:     "cashflow",
+			Label:  "现金流量",
+			Status: CheckStatusSkip,
+			Values: map[string]float64{
+				"netcash_operate": stock.NetcashOperate,
+				"netcash_invest":  stock.NetcashInvest,
+				"netcash_finance": stock.NetcashFinance,
+				"netcash_free":    stock.NetcashFree,
+			},
+			Desc: fmt.Sprintf(
+				"经营活动产生的现金流量净额(>0):%s\n投资活动产生的现金流量净额(<0):%s\n筹资活动产生的现金流量净额:%s\n自由现金流量(>0):%s",
+				goutils.YiWanString(stock.NetcashOperate),
+				goutils.YiWanString(stock.NetcashInvest),
+				goutils.YiWanString(stock.NetcashFinance),
+				goutils.YiWanString(stock.NetcashFree),
+			),
+		}
 		if c.Options.IsCheckCashflow {
-			if stock.NetcashOperate < 0 {
-				ok = false
-				itemOK = false
-			}
-			if stock.NetcashInvest > 0 {
-				ok = false
-				itemOK = false
+			item.Status = checkStatus(stock.NetcashOperate >= 0 && stock.NetcashInvest <= 0 && stock.NetcashFree >= 0)
+		}
+		result.Add(item)
+	}
+
+	// 自定义规则集
+	if c.Options.RuleSet != "" {
+		rs, exists := RuleSets[c.Options.RuleSet]
+		if !exists {
+			result.Add(CheckItem{
+				ID:     "rule_set",
+				Label:  "规则集",
+				Status: CheckStatusFail,
+				Desc:   fmt.Sprintf("规则集 %s 不存在", c.Options.RuleSet),
+			})
+		} else {
+			c.CheckRuleSet(ctx, stock, rs, &result)
+		}
+	}
+
+	c.markUnknownItems(stock, &result)
+	ok = result.OK
+	return
+}
+
+// checkItemEnrichments 检测项依赖的股票数据，数据未获取成功时检测项无法判断
+var checkItemEnrichments = map[string]models.Enrichment{
+	"jzpg_total":       models.EnrichJZPG,
+	"jzpg_valuation":   models.EnrichJZPG,
+	"valuation_status": models.EnrichValuation,
+	"right_price":      models.EnrichPE,
+	"hv":               models.EnrichPrice,
+	"byys_ratio":       models.EnrichGincome,
+	"audit_opinion":    models.EnrichGincome,
+	"cashflow":         models.EnrichCashflow,
+	"dcf":              models.EnrichCashflow,
+	"roe_dupont":       models.EnrichBalance | models.EnrichGincome,
+	"piotroski_f":      models.EnrichBalance | models.EnrichGincome | models.EnrichCashflow,
+	"altman_z":         models.EnrichBalance | models.EnrichGincome | models.EnrichCashflow,
+	"beneish_m":        models.EnrichBalance | models.EnrichGincome | models.EnrichCashflow,
+}
+
+// qualityScoreItem 财务质量评分检测项，enabled 时按 pass 判断是否通过，数据不足时为 unknown
+func qualityScoreItem(id, label string, q *models.QualityScore, enabled bool, threshold *CheckThreshold, pass bool) CheckItem {
+	item := CheckItem{
+		ID:        id,
+		Label:     label,
+		Status:    CheckStatusSkip,
+		Threshold: threshold,
+	}
+	if q == nil {
+		item.Desc = "数据不足或不适用"
+		if enabled {
+			item.Status = CheckStatusUnknown
+		}
+		return item
+	}
+	item.Values = map[string]float64{"score": q.Score}
+	for _, comp := range q.Components {
+		item.Values[comp.Name] = comp.Value
+	}
+	item.Value = observed(q.Score)
+	item.Detail = q
+	item.Desc = strings.Join(q.Lines(), "\n")
+	if enabled {
+		item.Status = checkStatus(pass)
+	}
+	return item
+}
+
+// isOptionalItemDisabled 可选检测项未开启时不需要获取其依赖的股票数据
+func (c Checker) isOptionalItemDisabled(id string) bool {
+	switch id {
+	case "jzpg_total", "jzpg_valuation", "valuation_status":
+		// 只有最新数据，按历史时间点检测时跳过
+		return c.Options.AsOf != ""
+	case "right_price":
+		return !c.Options.IsCheckPriceByCalc
+	case "hv":
+		return c.Options.MaxHV == 0
+	case "byys_ratio":
+		return c.Options.MinBYYSRatio == 0 || c.Options.MaxBYYSRatio == 0
+	case "cashflow":
+		return !c.Options.IsCheckCashflow
+	case "dcf":
+		return !c.Options.IsCheckDCF
+	case "roe_dupont":
+		return !c.Options.IsCheckDuPont
+	case "piotroski_f":
+		return c.Options.MinPiotroskiFScore <= 0
+	case "altman_z":
+		return c.Options.MinAltmanZScore <= 0
+	case "beneish_m":
+		return !c.Options.IsCheckBeneishM
+	}
+	return false
+}
+
+// markUnknownItems 将依赖数据未获取成功的检测项标记为 unknown，不再按零值判断是否通过，
+// 存在 unknown 检测项时视为未通过，除非开启 UnknownAsPass
+func (c Checker) markUnknownItems(stock models.Stock, result *CheckResult) {
+	result.OK = true
+	for i, item := range result.Items {
+		if e, exists := checkItemEnrichments[item.ID]; exists && item.Status != CheckStatusSkip {
+			if missing := stock.MissingEnrichments(e); len(missing) > 0 {
+				item.Status = CheckStatusUnknown
+				for _, m := range missing {
+					item.Desc += fmt.Sprintf("\n数据%s:%s", stock.EnrichmentStatus(m), m)
+				}
+				result.Items[i] = item
 			}
-			if stock.NetcashFree < 0 {
-				ok = false
-				itemOK = false
+		}
+		switch result.Items[i].Status {
+		case CheckStatusFail:
+			result.OK = false
+		case CheckStatusUnknown:
+			if !c.Options.UnknownAsPass {
+				result.OK = false
 			}
 		}
-		result[checkItemName] = map[string]string{
-			"desc": desc,
-			"ok":   fmt.Sprint(itemOK),
+	}
+}
+
+// growthList 返回用于逐年递增检测的数据及其来源说明：默认为年报数据，
+// UseTTMGrowth 时为最新一期财报往前各年同一季度的 TTM 数据
+func (c Checker) growthList(ctx context.Context, stock models.Stock, valueType eastmoney.ValueListType) (eastmoney.FinaValueList, string) {
+	if c.Options.UseTTMGrowth {
+		return stock.HistoricalFinaMainData.TTMValueListByYears(ctx, valueType, c.Options.CheckYears).Values(), "(TTM)"
+	}
+	return stock.HistoricalFinaMainData.ValueList(ctx, valueType, c.Options.CheckYears, eastmoney.FinaReportTypeYear), ""
+}
+
+// growthStatus 逐年递增且最早一年 > 0 的检测结果，年报数据为空时跳过，TTM 数据不足 CheckYears 年时无法判断
+func (c Checker) growthStatus(list eastmoney.FinaValueList) CheckStatus {
+	if c.Options.UseTTMGrowth && len(list) < c.Options.CheckYears {
+		return CheckStatusUnknown
+	}
+	if len(list) == 0 {
+		return CheckStatusSkip
+	}
+	return checkStatus(list[len(list)-1] > 0 && list.IsIncreasing())
+}
+
+// RequiredEnrichments 返回检测需要的股票数据
+func (c Checker) RequiredEnrichments() models.Enrichment {
+	required := models.EnrichFinaMain | models.EnrichPrice
+	for id, e := range checkItemEnrichments {
+		if c.isOptionalItemDisabled(id) {
+			continue
 		}
+		required |= e
 	}
+	// 规则集可使用合理价、现金流量及财务质量评分相关变量
+	if c.Options.RuleSet != "" {
+		required |= models.EnrichPE | models.EnrichCashflow | models.EnrichGincome | models.EnrichBalance
+	}
+	return required
+}
 
-	return
+// StockOptions 返回只获取检测需要数据的 Stock 创建选项，extra 为调用方额外需要展示的数据
+func (c Checker) StockOptions(extra models.Enrichment) models.NewStockOptions {
+	opts := models.DefaultNewStockOptions
+	opts.Enrichments = c.RequiredEnrichments() | extra
+	// 合理价及 DCF、DDM 按检测器的估值参数计算
+	opts.Valuation = c.Options.Valuation
+	opts.DCF = c.Options.DCF
+	opts.DDM = c.Options.DDM
+	return opts
 }
 
 // FundStocksCheckResult 股票持仓检测结果
@@ -695,7 +1060,7 @@ func (c Checker) CheckFundStocks(ctx context.Context, fund *models.Fund) (result
 		codes = append(codes, s.Code)
 	}
 	searcher := NewSearcher(ctx)
-	stocks, err := searcher.SearchStocks(ctx, codes)
+	stocks, err := searcher.SearchStocksWithOptions(ctx, codes, c.StockOptions(models.EnrichPublishDate))
 	if err != nil {
 		return
 	}
@@ -732,6 +1097,11 @@ func (c Checker) GetFundStocksSimilarity(ctx context.Context, codes []string) ([
 	if err != nil {
 		return nil, err
 	}
+	return CalcFundStocksSimilarity(funds), nil
+}
+
+// CalcFundStocksSimilarity 计算每只基金与其余基金持仓股票名称的相似度
+func CalcFundStocksSimilarity(funds map[string]*models.Fund) []FundStocksSimilarity {
 	sims := []FundStocksSimilarity{}
 	for codeA, fund := range funds {
 		setA := mapset.NewSet()
@@ -764,5 +1134,5 @@ func (c Checker) GetFundStocksSimilarity(ctx context.Context, codes []string) ([
 	sort.Slice(sims, func(i, j int) bool {
 		return sims[i].SimilarityValue > sims[j].SimilarityValue
 	})
-	return sims, nil
+	return sims
 }
@@ -4,8 +4,12 @@ import (
 	"encoding/json"
 	"fmt"
 	"testing"
+	"time"
 
+	"github.com/axiaoxin-com/investool/datacenter/eastmoney"
+	"github.com/axiaoxin-com/investool/datacenter/eniu"
 	"github.com/axiaoxin-com/investool/models"
+	"github.com/axiaoxin-com/investool/valuation"
 	"github.com/axiaoxin-com/logging"
 	"github.com/spf13/viper"
 	"github.com/stretchr/testify/require"
@@ -16,7 +20,362 @@ func TestCheckFundamentals(t *testing.T) {
 	logging.SetLevel("error")
 	c := NewChecker(_ctx, DefaultCheckerOptions)
 	result, ok := c.CheckFundamentals(_ctx, stock)
-	t.Log(ok, result)
+	require.False(t, ok)
+	require.Empty(t, result.Items)
+
+	stock = models.Stock{
+		BaseInfo: eastmoney.StockInfo{
+			TotalMarketCap: 200 * 100000000,
+			Zxgxl:          2,
+			NewPrice:       10.0,
+		},
+		HistoricalFinaMainData: eastmoney.HistoricalFinaMainData{
+			{ReportType: eastmoney.FinaReportTypeYear, ReportDateName: "2023年报", Roejq: 12, Epsjb: 1.2, Totaloperatereve: 300, Parentnetprofit: 30, Zcfzl: 40, Ld: 1.5},
+			{ReportType: eastmoney.FinaReportTypeYear, ReportDateName: "2022年报", Roejq: 11, Epsjb: 1.1, Totaloperatereve: 200, Parentnetprofit: 20},
+			{ReportType: eastmoney.FinaReportTypeYear, ReportDateName: "2021年报", Roejq: 10, Epsjb: 1.0, Totaloperatereve: 100, Parentnetprofit: 10},
+		},
+		PEG:        2,
+		RightPrice: 12,
+		BYYSRatio:  1,
+	}
+	result, ok = c.CheckFundamentals(_ctx, stock)
+	require.False(t, ok)
+	require.Equal(t, ok, result.OK)
+	require.Equal(t, "roe", result.Items[0].ID)
+	require.Equal(t, CheckStatusPass, result.Items[0].Status)
+	item, exists := result.Get("peg")
+	require.True(t, exists)
+	require.Equal(t, CheckStatusFail, item.Status)
+	require.Equal(t, 2.0, item.Values["peg"])
+	require.Equal(t, DefaultCheckerOptions.MaxPEG, *item.Threshold.Max)
+	item, _ = result.Get("eps_increasing")
+	require.Equal(t, CheckStatusPass, item.Status)
+	require.Equal(t, []float64{1.2, 1.1, 1.0}, item.Series["eps"])
+	item, _ = result.Get("jll_stability")
+	require.Equal(t, CheckStatusSkip, item.Status)
+	for _, i := range result.FailedItems() {
+		require.False(t, i.Passed())
+	}
+}
+
+func TestCheckFundamentalsAsOf(t *testing.T) {
+	logging.SetLevel("error")
+	stock := models.Stock{
+		BaseInfo: eastmoney.StockInfo{
+			TotalMarketCap: 200 * 100000000,
+			NewPrice:       20.0,
+		},
+		HistoricalFinaMainData: eastmoney.HistoricalFinaMainData{
+			{ReportType: eastmoney.FinaReportTypeYear, ReportYear: "2022", ReportDateName: "2022年报", ReportDate: "2022-12-31 00:00:00", NoticeDate: "2023-03-28 00:00:00", Roejq: 5},
+			{ReportType: eastmoney.FinaReportTypeYear, ReportYear: "2021", ReportDateName: "2021年报", ReportDate: "2021-12-31 00:00:00", NoticeDate: "2022-03-28 00:00:00", Roejq: 12},
+		},
+		HistoricalPrice: eniu.RespHistoricalStockPrice{
+			Date:  []string{"2021-12-31", "2023-02-28", "2023-06-30"},
+			Price: []float64{8, 10, 20},
+		},
+	}
+	c := NewChecker(_ctx, DefaultCheckerOptions)
+	result, _ := c.CheckFundamentals(_ctx, stock)
+	item, _ := result.Get("roe")
+	require.Equal(t, 5.0, item.Values["last_year_roe"])
+
+	asOf := time.Date(2023, 3, 1, 0, 0, 0, 0, time.Local)
+	result, _ = c.CheckFundamentalsAsOf(_ctx, stock, asOf)
+	item, _ = result.Get("roe")
+	require.Equal(t, CheckStatusPass, item.Status)
+	require.Equal(t, 12.0, item.Values["last_year_roe"])
+	item, _ = result.Get("right_price")
+	require.Equal(t, 10.0, item.Values["price"])
+	item, _ = result.Get("dividend")
+	require.Equal(t, CheckStatusPass, item.Status)
+	gxlOpts := DefaultCheckerOptions
+	gxlOpts.MinGxl = 1
+	gxlResult, ok := NewChecker(_ctx, gxlOpts).CheckFundamentalsAsOf(_ctx, stock, asOf)
+	item, _ = gxlResult.Get("dividend")
+	require.Equal(t, CheckStatusUnknown, item.Status)
+	require.False(t, ok)
+	snapshot := stock.AsOf(_ctx, asOf)
+	require.Equal(t, 12.0, snapshot.BaseInfo.RoeWeight)
+	require.Equal(t, -1.0, snapshot.BaseInfo.NetprofitGrowthrate3Y)
+	require.Equal(t, -1.0, snapshot.BaseInfo.Zxgxl)
+	require.Equal(t, -1.0, snapshot.PEG)
+
+	opts := DefaultCheckerOptions
+	opts.AsOf = "2023-03-01"
+	result2, _ := NewChecker(_ctx, opts).CheckFundamentals(_ctx, stock)
+	require.Equal(t, result, result2)
+
+	opts.AsOf = "20230301"
+	result, ok = NewChecker(_ctx, opts).CheckFundamentals(_ctx, stock)
+	require.False(t, ok)
+	require.Equal(t, "as_of", result.Items[0].ID)
+}
+
+func TestCheckFundamentalsTTMGrowth(t *testing.T) {
+	logging.SetLevel("error")
+	// 年报营收下滑，但最新一季带动 TTM 营收增长
+	stock := models.Stock{
+		BaseInfo: eastmoney.StockInfo{NewPrice: 10.0},
+		HistoricalFinaMainData: eastmoney.HistoricalFinaMainData{
+			{ReportType: eastmoney.FinaReportTypeQ1, ReportYear: "2021", ReportDate: "2021-03-31 00:00:00", Totaloperatereve: 50},
+			{ReportType: eastmoney.FinaReportTypeYear, ReportYear: "2020", ReportDate: "2020-12-31 00:00:00", Totaloperatereve: 90},
+			{ReportType: eastmoney.FinaReportTypeQ1, ReportYear: "2020", ReportDate: "2020-03-31 00:00:00", Totaloperatereve: 20},
+			{ReportType: eastmoney.FinaReportTypeYear, ReportYear: "2019", ReportDate: "2019-12-31 00:00:00", Totaloperatereve: 100},
+			{ReportType: eastmoney.FinaReportTypeQ1, ReportYear: "2019", ReportDate: "2019-03-31 00:00:00", Totaloperatereve: 30},
+		},
+		TTMPE:  8,
+		TTMPEG: 0.5,
+		PEG:    3,
+	}
+	opts := DefaultCheckerOptions
+	opts.CheckYears = 2
+	result, _ := NewChecker(_ctx, opts).CheckFundamentals(_ctx, stock)
+	item, _ := result.Get("revenue_increasing")
+	require.Equal(t, CheckStatusFail, item.Status)
+	item, _ = result.Get("peg")
+	require.Equal(t, CheckStatusFail, item.Status)
+
+	opts.UseTTMGrowth = true
+	result, _ = NewChecker(_ctx, opts).CheckFundamentals(_ctx, stock)
+	item, _ = result.Get("revenue_increasing")
+	require.Equal(t, CheckStatusPass, item.Status)
+	require.Equal(t, []float64{120, 90}, item.Series["revenue"])
+	item, _ = result.Get("peg")
+	require.Equal(t, CheckStatusPass, item.Status)
+	require.Equal(t, 0.5, *item.Value)
+
+	// TTM 数据不足检测年数时无法判断
+	opts.CheckYears = 3
+	result, _ = NewChecker(_ctx, opts).CheckFundamentals(_ctx, stock)
+	item, _ = result.Get("revenue_increasing")
+	require.Equal(t, CheckStatusUnknown, item.Status)
+}
+
+func TestCheckFundamentalsValuation(t *testing.T) {
+	logging.SetLevel("error")
+	stock := models.Stock{
+		BaseInfo: eastmoney.StockInfo{NewPrice: 20.0},
+		HistoricalFinaMainData: eastmoney.HistoricalFinaMainData{
+			{ReportType: eastmoney.FinaReportTypeYear, ReportYear: "2021", ReportDateName: "2021年报", ReportDate: "2021-12-31 00:00:00", NoticeDate: "2022-03-28 00:00:00", Epsjb: 3},
+			{ReportType: eastmoney.FinaReportTypeYear, ReportYear: "2020", ReportDateName: "2020年报", ReportDate: "2020-12-31 00:00:00", NoticeDate: "2021-03-28 00:00:00", Epsjb: 1},
+			{ReportType: eastmoney.FinaReportTypeYear, ReportYear: "2019", ReportDateName: "2019年报", ReportDate: "2019-12-31 00:00:00", NoticeDate: "2020-03-28 00:00:00", Epsjb: 2},
+		},
+		HistoricalPEList: eastmoney.HistoricalPEList{{Value: 10}},
+		AsOfDate:         time.Date(2022, 5, 1, 0, 0, 0, 0, time.Local),
+	}
+	stock = stock.Revalue(_ctx, valuation.DefaultOptions)
+	require.Equal(t, 14.0, stock.RightPrice)
+
+	opts := DefaultCheckerOptions
+	result, _ := NewChecker(_ctx, opts).CheckFundamentals(_ctx, stock)
+	item, _ := result.Get("right_price")
+	require.Equal(t, CheckStatusFail, item.Status)
+	require.Equal(t, 14.0, item.Values["right_price"])
+	require.Equal(t, 2.0, item.Values["base_eps"])
+	require.Equal(t, stock.RightPriceExplanation, item.Detail)
+
+	opts.Valuation.EPSYears = 1
+	result, _ = NewChecker(_ctx, opts).CheckFundamentals(_ctx, stock)
+	item, _ = result.Get("right_price")
+	require.Equal(t, 3.0, item.Values["base_eps"])
+	require.Equal(t, 30.0, item.Values["right_price"])
+	require.Equal(t, CheckStatusPass, item.Status)
+}
+
+func TestCheckFundamentalsDCF(t *testing.T) {
+	logging.SetLevel("error")
+	stock := models.Stock{
+		BaseInfo: eastmoney.StockInfo{NewPrice: 10.0, TotalMarketCap: 100, Zxgxl: 10},
+		HistoricalFinaMainData: eastmoney.HistoricalFinaMainData{
+			{ReportType: eastmoney.FinaReportTypeYear, ReportYear: "2021", ReportDate: "2021-12-31 00:00:00", Epsjb: 1},
+		},
+		HistoricalCashflowList: eastmoney.CashflowDataList{
+			{ReportType: eastmoney.FinaReportTypeYear, NetcashOperate: 15, ConstructLongAsset: 5},
+		},
+	}
+	opts := DefaultCheckerOptions
+	result, _ := NewChecker(_ctx, opts).CheckFundamentals(_ctx, stock)
+	item, _ := result.Get("dcf")
+	require.Equal(t, CheckStatusSkip, item.Status)
+	require.NotNil(t, item.Detail, item.Desc)
+	item, _ = result.Get("ddm")
+	require.Equal(t, CheckStatusSkip, item.Status)
+	require.Equal(t, 100.0, item.Values["payout_ratio"])
+
+	opts.IsCheckDCF = true
+	opts.IsCheckDDM = true
+	opts.DCF = valuation.DCFOptions{
+		DiscountRate:       20,
+		FCFYears:           1,
+		HighGrowthYears:    1,
+		HighGrowthRate:     10,
+		StableGrowthYears:  1,
+		StableGrowthRate:   10,
+		TerminalGrowthRate: 1,
+	}
+	opts.DDM = valuation.DDMOptions{DiscountRate: 10, TerminalGrowthRate: 5, MinPayoutRatio: 40}
+	result, _ = NewChecker(_ctx, opts).CheckFundamentals(_ctx, stock)
+	item, _ = result.Get("dcf")
+	require.Equal(t, CheckStatusFail, item.Status)
+	require.InDelta(t, (11/1.2+12.1/1.44+12.1*1.01/0.19/1.44)/10, item.Values["value_per_share"], 1e-9)
+	item, _ = result.Get("ddm")
+	require.Equal(t, CheckStatusPass, item.Status)
+
+	stock.HistoricalCashflowList[0].ConstructLongAsset = 50
+	result, _ = NewChecker(_ctx, opts).CheckFundamentals(_ctx, stock)
+	item, _ = result.Get("dcf")
+	require.Equal(t, CheckStatusWarn, item.Status)
+}
+
+func TestCheckFundamentalsDuPont(t *testing.T) {
+	logging.SetLevel("error")
+	year := eastmoney.FinaReportTypeYear
+	stock := models.Stock{
+		BaseInfo: eastmoney.StockInfo{NewPrice: 10.0},
+		HistoricalFinaMainData: eastmoney.HistoricalFinaMainData{
+			{ReportType: year, ReportYear: "2022", Roejq: 30, Xsjll: 10, Toazzl: 1, Qycs: 3},
+			{ReportType: year, ReportYear: "2021", Roejq: 20, Xsjll: 10, Toazzl: 1, Qycs: 2},
+		},
+	}
+	opts := DefaultCheckerOptions
+	result, _ := NewChecker(_ctx, opts).CheckFundamentals(_ctx, stock)
+	item, _ := result.Get("roe_dupont")
+	require.Equal(t, CheckStatusSkip, item.Status)
+	require.Contains(t, item.Desc, "杠杆驱动")
+	require.Equal(t, []float64{3, 2}, item.Series["equity_multiplier"])
+
+	opts.IsCheckDuPont = true
+	result, _ = NewChecker(_ctx, opts).CheckFundamentals(_ctx, stock)
+	item, _ = result.Get("roe_dupont")
+	require.Equal(t, CheckStatusFail, item.Status)
+	require.InDelta(t, 100, *item.Value, 1e-9)
+
+	stock.HistoricalFinaMainData[0].Qycs = 2
+	stock.HistoricalFinaMainData[0].Xsjll = 15
+	result, _ = NewChecker(_ctx, opts).CheckFundamentals(_ctx, stock)
+	item, _ = result.Get("roe_dupont")
+	require.Equal(t, CheckStatusPass, item.Status)
+
+	// ROE 为负无法归因
+	stock.HistoricalFinaMainData[1].Xsjll = -5
+	result, ok := NewChecker(_ctx, opts).CheckFundamentals(_ctx, stock)
+	item, _ = result.Get("roe_dupont")
+	require.Equal(t, CheckStatusUnknown, item.Status)
+	require.False(t, ok)
+	require.True(t, NewChecker(_ctx, opts).RequiredEnrichments().Has(models.EnrichBalance))
+}
+
+func TestCheckFundamentalsQualityScores(t *testing.T) {
+	logging.SetLevel("error")
+	cur, prev := "2022-12-31 00:00:00", "2021-12-31 00:00:00"
+	year := eastmoney.FinaReportTypeYear
+	stock := models.Stock{
+		BaseInfo: eastmoney.StockInfo{NewPrice: 10.0, Industry: "汽车", TotalMarketCap: 2000},
+		HistoricalFinaMainData: eastmoney.HistoricalFinaMainData{
+			{ReportType: year, ReportYear: "2022", ReportDate: cur, Epsjb: 1},
+		},
+		HistoricalBalanceList: eastmoney.BalanceDataList{
+			{
+				ReportDate: cur, ReportType: year, TotalAssets: 1000, TotalCurrentAssets: 500, TotalCurrentLiab: 250,
+				TotalLiabilities: 400, TotalNoncurrentLiab: 150, UnassignRpofit: 250, FixedAsset: 300,
+			},
+			{
+				ReportDate: prev, ReportType: year, TotalAssets: 900, TotalCurrentAssets: 400, TotalCurrentLiab: 250,
+				TotalLiabilities: 400, TotalNoncurrentLiab: 150, FixedAsset: 300,
+			},
+		},
+		HistoricalGincomeList: eastmoney.GincomeDataList{
+			{ReportDate: cur, ReportType: year, TotalOperateIncome: 1000, OperateCost: 600, Netprofit: 100, TotalProfit: 130},
+			{ReportDate: prev, ReportType: year, TotalOperateIncome: 900, OperateCost: 560, Netprofit: 80},
+		},
+		HistoricalCashflowList: eastmoney.CashflowDataList{
+			{ReportDate: cur, ReportType: year, NetcashOperate: 150},
+			{ReportDate: prev, ReportType: year, NetcashOperate: 100},
+		},
+	}
+	stock.PiotroskiFScore = stock.PiotroskiF(_ctx)
+	stock.AltmanZScore = stock.AltmanZ(_ctx)
+	stock.BeneishMScore = stock.BeneishM(_ctx)
+	opts := DefaultCheckerOptions
+	require.False(t, NewChecker(_ctx, opts).RequiredEnrichments().Has(models.EnrichBalance))
+	result, _ := NewChecker(_ctx, opts).CheckFundamentals(_ctx, stock)
+	for _, id := range []string{"piotroski_f", "altman_z", "beneish_m"} {
+		item, _ := result.Get(id)
+		require.Equal(t, CheckStatusSkip, item.Status, id)
+		require.NotNil(t, item.Detail, id)
+	}
+
+	opts.MinPiotroskiFScore = 9
+	opts.MinAltmanZScore = 3
+	opts.IsCheckBeneishM = true
+	require.True(t, NewChecker(_ctx, opts).RequiredEnrichments().Has(models.EnrichBalance))
+	result, _ = NewChecker(_ctx, opts).CheckFundamentals(_ctx, stock)
+	item, _ := result.Get("piotroski_f")
+	require.Equal(t, CheckStatusFail, item.Status)
+	require.Equal(t, 8.0, *item.Value)
+	require.Equal(t, 0.0, item.Values["eq_offer"])
+	item, _ = result.Get("altman_z")
+	require.Equal(t, CheckStatusPass, item.Status)
+	item, _ = result.Get("beneish_m")
+	require.Equal(t, CheckStatusPass, item.Status)
+
+	stock.PiotroskiFScore = nil
+	result, ok := NewChecker(_ctx, opts).CheckFundamentals(_ctx, stock)
+	item, _ = result.Get("piotroski_f")
+	require.Equal(t, CheckStatusUnknown, item.Status)
+	require.False(t, ok)
+}
+
+func TestCheckFundamentalsUnknown(t *testing.T) {
+	logging.SetLevel("error")
+	stock := models.Stock{
+		BaseInfo: eastmoney.StockInfo{
+			TotalMarketCap: 200 * 100000000,
+			NewPrice:       10.0,
+		},
+		HistoricalFinaMainData: eastmoney.HistoricalFinaMainData{
+			{ReportType: eastmoney.FinaReportTypeYear, ReportDateName: "2023年报", Roejq: 12, Zcfzl: 40, Ld: 1.5},
+		},
+		Enrichments: map[string]models.EnrichmentResult{
+			"fina_main":        {Status: models.EnrichmentStatusOK},
+			"historical_pe":    {Status: models.EnrichmentStatusFailed, Error: "timeout"},
+			"historical_price": {Status: models.EnrichmentStatusOK},
+			"gincome":          {Status: models.EnrichmentStatusOK},
+		},
+	}
+	opts := CheckerOptions{IsCheckPriceByCalc: true}
+	c := NewChecker(_ctx, opts)
+	result, ok := c.CheckFundamentals(_ctx, stock)
+	// 合理价为零值时不再判为失败
+	item, _ := result.Get("right_price")
+	require.Equal(t, CheckStatusUnknown, item.Status)
+	item, _ = result.Get("jzpg_total")
+	require.Equal(t, CheckStatusUnknown, item.Status)
+	_, exists := result.Get("cashflow")
+	require.False(t, exists)
+	// 默认存在无法判断的检测项时视为未通过
+	require.False(t, ok)
+	require.NotEmpty(t, result.UnknownItems())
+	require.Empty(t, result.FailedItems())
+
+	opts.UnknownAsPass = true
+	_, ok = NewChecker(_ctx, opts).CheckFundamentals(_ctx, stock)
+	require.True(t, ok)
+
+	required := c.RequiredEnrichments()
+	require.True(t, required.Has(models.EnrichFinaMain|models.EnrichPE|models.EnrichJZPG))
+	require.False(t, required.Has(models.EnrichCashflow))
+	require.False(t, required.Has(models.EnrichFreeHolders))
+
+	stockOpts := c.StockOptions(models.EnrichMainMoney)
+	require.Equal(t, required|models.EnrichMainMoney, stockOpts.Enrichments)
+	require.Equal(t, opts.DCF, stockOpts.DCF)
+
+	// 关闭的可选检测项及按历史时间点跳过的检测项不获取数据
+	required = NewChecker(_ctx, CheckerOptions{AsOf: "2022-06-30"}).RequiredEnrichments()
+	require.False(t, required.Has(models.EnrichPE))
+	require.False(t, required.Has(models.EnrichJZPG))
+	require.False(t, required.Has(models.EnrichValuation))
 }
 
 func _TestGetFundStocksSimilarity(t *testing.T) {
@@ -0,0 +1,49 @@
+// 债券基金回撤修复
+
+package core
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/axiaoxin-com/investool/datacenter"
+	"github.com/axiaoxin-com/investool/models"
+	"github.com/axiaoxin-com/logging"
+)
+
+// FundDrawdownRecovery 获取基金近 years 年历史净值，计算最大回撤及修复时间
+func FundDrawdownRecovery(ctx context.Context, code string, years int) (models.FundDrawdownRecovery, error) {
+	start := time.Now().AddDate(-years, 0, 0).Format("2006-01-02")
+	history, err := datacenter.EastMoney.QueryFundNetHistory(ctx, code, start, "")
+	if err != nil {
+		return models.FundDrawdownRecovery{}, err
+	}
+	return models.NewFundDrawdownRecovery(history), nil
+}
+
+// BondFundsDrawdownRecovery 并发计算债券型基金的最大回撤修复时间，key 为基金代码，获取失败的基金不在结果中
+func BondFundsDrawdownRecovery(ctx context.Context, funds map[string]*models.Fund, years int) map[string]models.FundDrawdownRecovery {
+	results := map[string]models.FundDrawdownRecovery{}
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	for code, fund := range funds {
+		if !fund.IsBond() {
+			continue
+		}
+		wg.Add(1)
+		go func(code string) {
+			defer wg.Done()
+			r, err := FundDrawdownRecovery(ctx, code, years)
+			if err != nil {
+				logging.Errorf(ctx, "FundDrawdownRecovery code:%s err:%v", code, err)
+				return
+			}
+			mu.Lock()
+			results[code] = r
+			mu.Unlock()
+		}(code)
+	}
+	wg.Wait()
+	return results
+}
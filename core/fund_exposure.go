@@ -0,0 +1,331 @@
+// 基金组合穿透分析：按投资金额汇总持仓股票、行业及大类资产暴露
+
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/axiaoxin-com/investool/models"
+)
+
+// FundInvestment 基金投资金额
+type FundInvestment struct {
+	// 基金代码
+	Code string `json:"code"`
+	// 投资金额（元）
+	Amount float64 `json:"amount"`
+}
+
+// FundExposureSource 通过某只基金持有的暴露
+type FundExposureSource struct {
+	// 基金代码
+	FundCode string `json:"fund_code"`
+	// 基金名称
+	FundName string `json:"fund_name"`
+	// 占基金净值比例（%）
+	Ratio float64 `json:"ratio"`
+	// 穿透金额（元）
+	Amount float64 `json:"amount"`
+}
+
+// StockExposure 股票穿透暴露
+type StockExposure struct {
+	// 股票代码
+	Code string `json:"code"`
+	// 股票名称
+	Name string `json:"name"`
+	// 股票行业
+	Industry string `json:"industry"`
+	// 穿透金额（元）
+	Amount float64 `json:"amount"`
+	// 占组合比例（%）
+	Weight float64 `json:"weight"`
+	// 持有该股票的基金，按金额降序
+	Sources []FundExposureSource `json:"sources"`
+}
+
+// Exposure 行业或大类资产穿透暴露
+type Exposure struct {
+	// 名称
+	Name string `json:"name"`
+	// 穿透金额（元）
+	Amount float64 `json:"amount"`
+	// 占组合比例（%）
+	Weight float64 `json:"weight"`
+	// 来源基金，按金额降序
+	Sources []FundExposureSource `json:"sources"`
+}
+
+// FundExposureFund 参与穿透的基金概况
+type FundExposureFund struct {
+	// 基金代码
+	Code string `json:"code"`
+	// 基金名称
+	Name string `json:"name"`
+	// 投资金额（元）
+	Amount float64 `json:"amount"`
+	// 占组合比例（%）
+	Weight float64 `json:"weight"`
+	// 股票占比（%）
+	StockRatio float64 `json:"stock_ratio"`
+	// 已披露重仓股合计占比（%）
+	DisclosedStockRatio float64 `json:"disclosed_stock_ratio"`
+	// 资产占比公布日期
+	AssetsPubDate string `json:"assets_pub_date"`
+	// 行业占比公布日期
+	IndustryPubDate string `json:"industry_pub_date"`
+}
+
+// FundExposure 基金组合穿透分析结果
+type FundExposure struct {
+	// 投资总额（元）
+	TotalAmount float64 `json:"total_amount"`
+	// 参与穿透的基金
+	Funds []FundExposureFund `json:"funds"`
+	// 股票暴露，按金额降序
+	Stocks []StockExposure `json:"stocks"`
+	// 多只基金共同持有的股票，按合计金额降序
+	Overlaps []StockExposure `json:"overlaps"`
+	// 已披露重仓股合计占组合比例（%），其余股票仓位未披露
+	DisclosedStockWeight float64 `json:"disclosed_stock_weight"`
+	// 行业暴露，按金额降序
+	Industries []Exposure `json:"industries"`
+	// 大类资产暴露
+	Assets []Exposure `json:"assets"`
+	// 数据说明
+	Notes []string `json:"notes"`
+}
+
+// 大类资产名称
+const (
+	AssetClassStock = "股票"
+	AssetClassBond  = "债券"
+	AssetClassCash  = "现金"
+	AssetClassOther = "其他"
+)
+
+// parsePercent 解析 85.3% 格式的占比，无法解析时返回 0
+func parsePercent(s string) float64 {
+	v, err := strconv.ParseFloat(strings.TrimSuffix(strings.TrimSpace(s), "%"), 64)
+	if err != nil {
+		return 0
+	}
+	return v
+}
+
+// exposureAccumulator 按名称累加暴露
+type exposureAccumulator struct {
+	order []string
+	items map[string]*Exposure
+}
+
+func newExposureAccumulator() *exposureAccumulator {
+	return &exposureAccumulator{items: map[string]*Exposure{}}
+}
+
+// add 累加 fund 中占比 ratio（%）的暴露
+func (a *exposureAccumulator) add(name string, fund *models.Fund, amount, ratio float64) {
+	if ratio <= 0 {
+		return
+	}
+	e, exists := a.items[name]
+	if !exists {
+		e = &Exposure{Name: name, Sources: []FundExposureSource{}}
+		a.items[name] = e
+		a.order = append(a.order, name)
+	}
+	value := amount * ratio / 100
+	e.Amount += value
+	e.Sources = append(e.Sources, FundExposureSource{FundCode: fund.Code, FundName: fund.Name, Ratio: ratio, Amount: value})
+}
+
+// list 计算占比后返回暴露列表，sorted 为 true 时按金额降序
+func (a *exposureAccumulator) list(total float64, sorted bool) []Exposure {
+	result := []Exposure{}
+	for _, name := range a.order {
+		e := *a.items[name]
+		e.Weight = e.Amount / total * 100
+		sortSources(e.Sources)
+		result = append(result, e)
+	}
+	if sorted {
+		sort.SliceStable(result, func(i, j int) bool {
+			return result[i].Amount > result[j].Amount
+		})
+	}
+	return result
+}
+
+// sortSources 来源基金按金额降序
+func sortSources(sources []FundExposureSource) {
+	sort.SliceStable(sources, func(i, j int) bool {
+		return sources[i].Amount > sources[j].Amount
+	})
+}
+
+// latestIndustryProportions 返回最新公布日期的行业占比
+func latestIndustryProportions(fund *models.Fund) (string, map[string]float64) {
+	latest := ""
+	for _, ip := range fund.IndustryProportions {
+		if ip.PubDate > latest {
+			latest = ip.PubDate
+		}
+	}
+	props := map[string]float64{}
+	for _, ip := range fund.IndustryProportions {
+		if ip.PubDate == latest {
+			props[ip.Industry] += parsePercent(ip.Prop)
+		}
+	}
+	return latest, props
+}
+
+// LookThroughFunds 按投资金额穿透基金持仓，汇总股票、行业及大类资产暴露，overlapLimit 为返回的重复持仓数量，<=0 时全部返回
+func LookThroughFunds(funds map[string]*models.Fund, investments []FundInvestment, overlapLimit int) (FundExposure, error) {
+	result := FundExposure{
+		Funds:      []FundExposureFund{},
+		Stocks:     []StockExposure{},
+		Overlaps:   []StockExposure{},
+		Industries: []Exposure{},
+		Assets:     []Exposure{},
+		Notes: []string{
+			"股票暴露仅包含基金季报披露的前十大重仓股，其余股票仓位计入未披露",
+			"行业及资产占比使用最新一期定期报告数据，与当前实际持仓可能存在差异",
+		},
+	}
+	// 合并同一基金的多笔投资
+	amounts := map[string]float64{}
+	codes := []string{}
+	for _, inv := range investments {
+		if inv.Amount <= 0 {
+			return result, fmt.Errorf("%s 投资金额必须大于 0", inv.Code)
+		}
+		if _, exists := amounts[inv.Code]; !exists {
+			codes = append(codes, inv.Code)
+		}
+		amounts[inv.Code] += inv.Amount
+	}
+	for _, code := range codes {
+		if funds[code] == nil {
+			result.Notes = append(result.Notes, fmt.Sprintf("%s 无法获取基金数据，未参与穿透", code))
+			continue
+		}
+		result.TotalAmount += amounts[code]
+	}
+	if result.TotalAmount <= 0 {
+		return result, errors.New("no fund to look through")
+	}
+
+	stockOrder := []string{}
+	stocks := map[string]*StockExposure{}
+	industries := newExposureAccumulator()
+	assets := newExposureAccumulator()
+	for _, name := range []string{AssetClassStock, AssetClassBond, AssetClassCash, AssetClassOther} {
+		assets.items[name] = &Exposure{Name: name, Sources: []FundExposureSource{}}
+		assets.order = append(assets.order, name)
+	}
+	undisclosed := 0.0
+	for _, code := range codes {
+		fund := funds[code]
+		if fund == nil {
+			continue
+		}
+		amount := amounts[code]
+		ap := fund.AssetsProportion
+		f := FundExposureFund{
+			Code:          fund.Code,
+			Name:          fund.Name,
+			Amount:        amount,
+			Weight:        amount / result.TotalAmount * 100,
+			StockRatio:    parsePercent(ap.Stock),
+			AssetsPubDate: ap.PubDate,
+		}
+
+		// 股票
+		for _, s := range fund.Stocks {
+			key := s.Code
+			if key == "" {
+				key = s.Name
+			}
+			e, exists := stocks[key]
+			if !exists {
+				e = &StockExposure{Code: s.Code, Name: s.Name, Industry: s.Industry, Sources: []FundExposureSource{}}
+				stocks[key] = e
+				stockOrder = append(stockOrder, key)
+			}
+			value := amount * s.HoldRatio / 100
+			e.Amount += value
+			e.Sources = append(e.Sources, FundExposureSource{FundCode: fund.Code, FundName: fund.Name, Ratio: s.HoldRatio, Amount: value})
+			f.DisclosedStockRatio += s.HoldRatio
+		}
+		if f.StockRatio > f.DisclosedStockRatio {
+			undisclosed += amount * (f.StockRatio - f.DisclosedStockRatio) / 100
+		}
+
+		// 行业
+		pubDate, props := latestIndustryProportions(fund)
+		f.IndustryPubDate = pubDate
+		industryNames := make([]string, 0, len(props))
+		for name := range props {
+			industryNames = append(industryNames, name)
+		}
+		sort.Strings(industryNames)
+		for _, name := range industryNames {
+			industries.add(name, fund, amount, props[name])
+		}
+
+		// 大类资产
+		assets.add(AssetClassStock, fund, amount, f.StockRatio)
+		assets.add(AssetClassBond, fund, amount, parsePercent(ap.Bond))
+		assets.add(AssetClassCash, fund, amount, parsePercent(ap.Cash))
+		assets.add(AssetClassOther, fund, amount, parsePercent(ap.Other))
+		if ap.PubDate == "" {
+			result.Notes = append(result.Notes, fmt.Sprintf("%s 无资产占比数据", fund.Code))
+		}
+		result.Funds = append(result.Funds, f)
+	}
+
+	for _, key := range stockOrder {
+		e := *stocks[key]
+		e.Weight = e.Amount / result.TotalAmount * 100
+		sortSources(e.Sources)
+		result.Stocks = append(result.Stocks, e)
+		result.DisclosedStockWeight += e.Weight
+	}
+	sort.SliceStable(result.Stocks, func(i, j int) bool {
+		return result.Stocks[i].Amount > result.Stocks[j].Amount
+	})
+	for _, e := range result.Stocks {
+		if len(e.Sources) > 1 {
+			result.Overlaps = append(result.Overlaps, e)
+		}
+	}
+	if overlapLimit > 0 && len(result.Overlaps) > overlapLimit {
+		result.Overlaps = result.Overlaps[:overlapLimit]
+	}
+	if undisclosed > 0 {
+		result.Notes = append(result.Notes, fmt.Sprintf("未披露股票仓位约 %.2f 元，占组合 %.2f%%", undisclosed, undisclosed/result.TotalAmount*100))
+	}
+
+	result.Industries = industries.list(result.TotalAmount, true)
+	result.Assets = assets.list(result.TotalAmount, false)
+	return result, nil
+}
+
+// GetFundExposure 查询基金数据后进行穿透分析
+func GetFundExposure(ctx context.Context, investments []FundInvestment, overlapLimit int) (FundExposure, error) {
+	codes := []string{}
+	for _, inv := range investments {
+		codes = append(codes, inv.Code)
+	}
+	funds, err := NewSearcher(ctx).SearchFunds(ctx, codes)
+	if err != nil {
+		return FundExposure{}, err
+	}
+	return LookThroughFunds(funds, investments, overlapLimit)
+}
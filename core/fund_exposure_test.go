@@ -0,0 +1,66 @@
+package core
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/axiaoxin-com/investool/models"
+	"github.com/stretchr/testify/require"
+)
+
+func testExposureFund(t *testing.T, data string) *models.Fund {
+	fund := &models.Fund{}
+	require.Nil(t, json.Unmarshal([]byte(data), fund))
+	return fund
+}
+
+func TestLookThroughFunds(t *testing.T) {
+	funds := map[string]*models.Fund{
+		"A": testExposureFund(t, `{
+			"code": "A", "name": "基金A",
+			"stocks": [{"code": "600519", "name": "贵州茅台", "industry": "酿酒行业", "hold_ratio": 10}, {"code": "000858", "name": "五粮液", "hold_ratio": 5}],
+			"assets_proportion": {"pub_date": "2022-06-30", "stock": "90%", "bond": "5%", "cash": "5%", "other": "--%"},
+			"industry_proportions": [
+				{"pub_date": "2022-03-31", "industry": "制造业", "prop": "50"},
+				{"pub_date": "2022-06-30", "industry": "制造业", "prop": "80"},
+				{"pub_date": "2022-06-30", "industry": "金融业", "prop": "10"}
+			]
+		}`),
+		"B": testExposureFund(t, `{
+			"code": "B", "name": "基金B",
+			"stocks": [{"code": "600519", "name": "贵州茅台", "hold_ratio": 8}, {"code": "600036", "name": "招商银行", "hold_ratio": 6}],
+			"assets_proportion": {"pub_date": "2022-06-30", "stock": "60%", "bond": "30%", "cash": "10%", "other": "0%"},
+			"industry_proportions": [{"pub_date": "2022-06-30", "industry": "制造业", "prop": "40"}]
+		}`),
+	}
+	investments := []FundInvestment{{Code: "A", Amount: 6000}, {Code: "B", Amount: 3000}, {Code: "A", Amount: 1000}, {Code: "C", Amount: 1000}}
+	r, err := LookThroughFunds(funds, investments, 0)
+	require.Nil(t, err)
+	require.Equal(t, 10000.0, r.TotalAmount)
+	require.Len(t, r.Funds, 2)
+	require.Equal(t, 7000.0, r.Funds[0].Amount)
+	require.Equal(t, 15.0, r.Funds[0].DisclosedStockRatio)
+
+	require.Equal(t, "600519", r.Stocks[0].Code)
+	require.InDelta(t, 700+240, r.Stocks[0].Amount, 1e-9)
+	require.InDelta(t, 9.4, r.Stocks[0].Weight, 1e-9)
+	require.Equal(t, "A", r.Stocks[0].Sources[0].FundCode)
+	require.Len(t, r.Overlaps, 1)
+	require.InDelta(t, 9.4+3.5+1.8, r.DisclosedStockWeight, 1e-9)
+
+	require.Equal(t, "制造业", r.Industries[0].Name)
+	require.InDelta(t, 5600+1200, r.Industries[0].Amount, 1e-9)
+	require.Len(t, r.Industries, 2)
+
+	require.Equal(t, AssetClassStock, r.Assets[0].Name)
+	require.InDelta(t, 6300+1800, r.Assets[0].Amount, 1e-9)
+	require.InDelta(t, 350+900, r.Assets[1].Amount, 1e-9)
+	require.Equal(t, 0.0, r.Assets[3].Amount)
+	// 基金 C 无数据
+	require.Contains(t, r.Notes, "C 无法获取基金数据，未参与穿透")
+
+	_, err = LookThroughFunds(funds, []FundInvestment{{Code: "A", Amount: 0}}, 0)
+	require.Error(t, err)
+	_, err = LookThroughFunds(funds, []FundInvestment{{Code: "C", Amount: 1}}, 0)
+	require.Error(t, err)
+}
@@ -0,0 +1,122 @@
+// 基金持有成本计算及 A/C 份额对比
+
+package core
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/axiaoxin-com/investool/datacenter"
+	"github.com/axiaoxin-com/investool/models"
+)
+
+// FundFeeSchedule 获取基金费率表
+func FundFeeSchedule(ctx context.Context, code string) (models.FundFeeSchedule, error) {
+	info, err := datacenter.EastMoney.QueryFundFee(ctx, code)
+	if err != nil {
+		return models.FundFeeSchedule{}, err
+	}
+	return models.NewFundFeeSchedule(info), nil
+}
+
+// FundCostResult 基金持有成本计算结果
+type FundCostResult struct {
+	// 基金代码
+	Code string `json:"code"`
+	// 费率表
+	Fee models.FundFeeSchedule `json:"fee"`
+	// 持有成本
+	Cost models.FundCost `json:"cost"`
+}
+
+// FundTotalCost 计算基金申购 amount 元、持有 days 天的总成本
+func FundTotalCost(ctx context.Context, code string, amount float64, days int) (*FundCostResult, error) {
+	if amount <= 0 || days < 0 {
+		return nil, fmt.Errorf("申购金额需大于 0，持有天数不能为负数")
+	}
+	fee, err := FundFeeSchedule(ctx, code)
+	if err != nil {
+		return nil, err
+	}
+	return &FundCostResult{Code: code, Fee: fee, Cost: fee.Cost(amount, days)}, nil
+}
+
+// FundShareClass 基金份额
+type FundShareClass struct {
+	// 基金代码
+	Code string `json:"code"`
+	// 基金名称
+	Name string `json:"name"`
+	// 费率表
+	Fee models.FundFeeSchedule `json:"fee"`
+}
+
+// FundShareClassCompareResult A/C 份额对比结果
+type FundShareClassCompareResult struct {
+	// A 类份额
+	A FundShareClass `json:"a"`
+	// C 类份额
+	C FundShareClass `json:"c"`
+	models.FundShareClassComparison
+}
+
+// FindFundShareClasses 按基金名称搜索同一基金的 A/C 份额代码
+func FindFundShareClasses(ctx context.Context, code string) (a, c FundShareClass, err error) {
+	infos, err := datacenter.EastMoney.SearchFund(ctx, code)
+	if err != nil {
+		return
+	}
+	name := ""
+	for _, i := range infos {
+		if i.Code == code {
+			name = i.Name
+			break
+		}
+	}
+	base, class := models.ShareClassBaseName(name)
+	if class == "" {
+		err = fmt.Errorf("%s(%s) 不是 A/C 份额基金", name, code)
+		return
+	}
+	infos, err = datacenter.EastMoney.SearchFund(ctx, base)
+	if err != nil {
+		return
+	}
+	for _, i := range infos {
+		switch i.Name {
+		case base + "A":
+			a = FundShareClass{Code: i.Code, Name: i.Name}
+		case base + "C":
+			c = FundShareClass{Code: i.Code, Name: i.Name}
+		}
+	}
+	if a.Code == "" || c.Code == "" {
+		err = fmt.Errorf("未找到 %s 的 A/C 份额", base)
+	}
+	return
+}
+
+// CompareFundShareClasses 对比 A/C 份额申购 amount 元的持有成本，codeC 为空时按 codeA 的基金名称搜索对应的 A/C 份额
+func CompareFundShareClasses(ctx context.Context, codeA, codeC string, amount float64) (*FundShareClassCompareResult, error) {
+	if amount <= 0 {
+		return nil, fmt.Errorf("申购金额需大于 0")
+	}
+	var err error
+	a, c := FundShareClass{Code: codeA}, FundShareClass{Code: codeC}
+	if codeC == "" {
+		if a, c, err = FindFundShareClasses(ctx, codeA); err != nil {
+			return nil, err
+		}
+	}
+	if a.Fee, err = FundFeeSchedule(ctx, a.Code); err != nil {
+		return nil, err
+	}
+	if c.Fee, err = FundFeeSchedule(ctx, c.Code); err != nil {
+		return nil, err
+	}
+	return &FundShareClassCompareResult{
+		A:                        a,
+		C:                        c,
+		FundShareClassComparison: models.CompareShareClasses(a.Fee, c.Fee, amount),
+	}, nil
+}
@@ -0,0 +1,68 @@
+// 基金经理对比
+
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/axiaoxin-com/investool/datacenter/eastmoney"
+	"github.com/axiaoxin-com/investool/models"
+)
+
+// MaxCompareFundManagers 最多同时对比的基金经理数
+const MaxCompareFundManagers = 6
+
+// FindFundManagers 按 ID 或姓名在基金经理列表中查找，同名时返回第一个
+func FindFundManagers(keys []string) ([]eastmoney.FundManagerInfo, error) {
+	infos := []eastmoney.FundManagerInfo{}
+	seen := map[string]bool{}
+	for _, key := range keys {
+		var found *eastmoney.FundManagerInfo
+		for _, m := range models.FundManagers {
+			if m != nil && (m.ID == key || m.Name == key) {
+				found = m
+				break
+			}
+		}
+		if found == nil {
+			return nil, fmt.Errorf("未找到基金经理: %s", key)
+		}
+		if !seen[found.ID] {
+			seen[found.ID] = true
+			infos = append(infos, *found)
+		}
+	}
+	return infos, nil
+}
+
+// CompareFundManagers 按 ID 或姓名对比基金经理，获取现任基金详情汇总规模加权业绩、综合评分及共同管理的基金
+func CompareFundManagers(ctx context.Context, keys []string) (*models.FundManagerComparison, error) {
+	if len(keys) == 0 {
+		return nil, errors.New("请选择要对比的基金经理")
+	}
+	if len(keys) > MaxCompareFundManagers {
+		return nil, fmt.Errorf("最多同时对比 %d 位基金经理", MaxCompareFundManagers)
+	}
+	infos, err := FindFundManagers(keys)
+	if err != nil {
+		return nil, err
+	}
+	codes := []string{}
+	seen := map[string]bool{}
+	for _, info := range infos {
+		for _, code := range info.FundCodes {
+			if !seen[code] {
+				seen[code] = true
+				codes = append(codes, code)
+			}
+		}
+	}
+	funds, err := NewSearcher(ctx).SearchFunds(ctx, codes)
+	if err != nil {
+		return nil, err
+	}
+	result := models.CompareFundManagers(infos, funds, models.FundManagers)
+	return &result, nil
+}
@@ -0,0 +1,214 @@
+// 基金两两持仓重合度矩阵及相近基金聚类
+
+package core
+
+import (
+	"context"
+	"math"
+	"sort"
+
+	"github.com/axiaoxin-com/investool/models"
+)
+
+// DefaultFundOverlapThreshold 默认聚类阈值：两只基金持仓权重重合度（%）不低于该值时视为相近
+const DefaultFundOverlapThreshold = 20.0
+
+// FundOverlapFund 参与比较的基金
+type FundOverlapFund struct {
+	// 基金代码
+	Code string `json:"code"`
+	// 基金名称
+	Name string `json:"name"`
+}
+
+// FundOverlapStock 两只基金共同持有的股票
+type FundOverlapStock struct {
+	// 股票代码
+	Code string `json:"code"`
+	// 股票名称
+	Name string `json:"name"`
+	// 占基金 A 净值比例（%）
+	WeightA float64 `json:"weight_a"`
+	// 占基金 B 净值比例（%）
+	WeightB float64 `json:"weight_b"`
+}
+
+// FundOverlapPair 两只基金的持仓重合度
+type FundOverlapPair struct {
+	// 基金 A 代码
+	A string `json:"a"`
+	// 基金 A 名称
+	AName string `json:"a_name"`
+	// 基金 B 代码
+	B string `json:"b"`
+	// 基金 B 名称
+	BName string `json:"b_name"`
+	// 按股票代码计算的 Jaccard 相似系数，1:完全相同 0:完全不同
+	Jaccard float64 `json:"jaccard"`
+	// 权重重合度（%）：共同持有股票的较小持仓占比之和
+	WeightOverlap float64 `json:"weight_overlap"`
+	// 共同持有的股票，按较小持仓占比降序
+	SameStocks []FundOverlapStock `json:"same_stocks"`
+}
+
+// FundOverlapMatrix 基金两两持仓重合度矩阵
+type FundOverlapMatrix struct {
+	// 参与比较的基金，顺序与矩阵行列一致
+	Funds []FundOverlapFund `json:"funds"`
+	// Jaccard 相似系数矩阵
+	Jaccard [][]float64 `json:"jaccard"`
+	// 权重重合度矩阵（%），对角线为基金重仓股占比合计
+	WeightOverlap [][]float64 `json:"weight_overlap"`
+	// 两两重合度，按权重重合度降序
+	Pairs []FundOverlapPair `json:"pairs"`
+	// 聚类阈值（%）
+	Threshold float64 `json:"threshold"`
+	// 相近基金分组，权重重合度不低于阈值的基金单链接聚为一组，只返回多于 1 只基金的分组
+	Clusters [][]FundOverlapFund `json:"clusters"`
+}
+
+// fundStockWeights 返回基金按股票代码的持仓占比，无代码时使用名称
+func fundStockWeights(fund *models.Fund) (map[string]float64, map[string]string) {
+	weights := map[string]float64{}
+	names := map[string]string{}
+	for _, s := range fund.Stocks {
+		key := s.Code
+		if key == "" {
+			key = s.Name
+		}
+		weights[key] += s.HoldRatio
+		names[key] = s.Name
+	}
+	return weights, names
+}
+
+// CalcFundOverlap 计算基金两两持仓重合度矩阵，并将权重重合度不低于 threshold（%）的基金聚类，threshold<=0 时使用默认值
+func CalcFundOverlap(funds []*models.Fund, threshold float64) FundOverlapMatrix {
+	if threshold <= 0 {
+		threshold = DefaultFundOverlapThreshold
+	}
+	n := len(funds)
+	m := FundOverlapMatrix{
+		Funds:         []FundOverlapFund{},
+		Jaccard:       make([][]float64, n),
+		WeightOverlap: make([][]float64, n),
+		Pairs:         []FundOverlapPair{},
+		Threshold:     threshold,
+		Clusters:      [][]FundOverlapFund{},
+	}
+	weights := make([]map[string]float64, n)
+	names := make([]map[string]string, n)
+	for i, fund := range funds {
+		m.Funds = append(m.Funds, FundOverlapFund{Code: fund.Code, Name: fund.Name})
+		weights[i], names[i] = fundStockWeights(fund)
+		m.Jaccard[i] = make([]float64, n)
+		m.WeightOverlap[i] = make([]float64, n)
+	}
+
+	// 并查集聚类
+	parent := make([]int, n)
+	for i := range parent {
+		parent[i] = i
+	}
+	var find func(int) int
+	find = func(i int) int {
+		if parent[i] != i {
+			parent[i] = find(parent[i])
+		}
+		return parent[i]
+	}
+
+	for i := 0; i < n; i++ {
+		for j := i; j < n; j++ {
+			pair := FundOverlapPair{
+				A:          funds[i].Code,
+				AName:      funds[i].Name,
+				B:          funds[j].Code,
+				BName:      funds[j].Name,
+				SameStocks: []FundOverlapStock{},
+			}
+			union := len(weights[i])
+			for code, wa := range weights[i] {
+				wb, exists := weights[j][code]
+				if !exists {
+					continue
+				}
+				pair.SameStocks = append(pair.SameStocks, FundOverlapStock{Code: code, Name: names[i][code], WeightA: wa, WeightB: wb})
+				pair.WeightOverlap += math.Min(wa, wb)
+			}
+			union += len(weights[j]) - len(pair.SameStocks)
+			if union > 0 {
+				pair.Jaccard = float64(len(pair.SameStocks)) / float64(union)
+			}
+			m.Jaccard[i][j], m.Jaccard[j][i] = pair.Jaccard, pair.Jaccard
+			m.WeightOverlap[i][j], m.WeightOverlap[j][i] = pair.WeightOverlap, pair.WeightOverlap
+			if i == j {
+				continue
+			}
+			sort.Slice(pair.SameStocks, func(a, b int) bool {
+				sa, sb := pair.SameStocks[a], pair.SameStocks[b]
+				ma, mb := math.Min(sa.WeightA, sa.WeightB), math.Min(sb.WeightA, sb.WeightB)
+				if ma == mb {
+					return sa.Code < sb.Code
+				}
+				return ma > mb
+			})
+			m.Pairs = append(m.Pairs, pair)
+			if pair.WeightOverlap >= threshold {
+				parent[find(i)] = find(j)
+			}
+		}
+	}
+	sort.SliceStable(m.Pairs, func(a, b int) bool {
+		return m.Pairs[a].WeightOverlap > m.Pairs[b].WeightOverlap
+	})
+
+	groups := map[int][]FundOverlapFund{}
+	roots := []int{}
+	for i := range funds {
+		root := find(i)
+		if _, exists := groups[root]; !exists {
+			roots = append(roots, root)
+		}
+		groups[root] = append(groups[root], m.Funds[i])
+	}
+	for _, root := range roots {
+		if len(groups[root]) > 1 {
+			m.Clusters = append(m.Clusters, groups[root])
+		}
+	}
+	return m
+}
+
+// fundsInOrder 按 codes 顺序返回基金，无法获取数据及重复的基金不返回
+func fundsInOrder(fundMap map[string]*models.Fund, codes []string) []*models.Fund {
+	funds := []*models.Fund{}
+	seen := map[string]bool{}
+	for _, code := range codes {
+		if fund, exists := fundMap[code]; exists && fund != nil && !seen[code] {
+			funds = append(funds, fund)
+			seen[code] = true
+		}
+	}
+	return funds
+}
+
+// GetFundOverlapMatrix 查询基金数据后计算两两持仓重合度矩阵，基金顺序与 codes 一致，无法获取数据的基金不参与计算
+func (c Checker) GetFundOverlapMatrix(ctx context.Context, codes []string, threshold float64) (FundOverlapMatrix, error) {
+	s := NewSearcher(ctx)
+	fundMap, err := s.SearchFunds(ctx, codes)
+	if err != nil {
+		return FundOverlapMatrix{}, err
+	}
+	return CalcFundOverlap(fundsInOrder(fundMap, codes), threshold), nil
+}
+
+// GetFundSimilarity 查询基金数据后同时返回持仓相似度及两两持仓重合度矩阵
+func (c Checker) GetFundSimilarity(ctx context.Context, codes []string, threshold float64) ([]FundStocksSimilarity, FundOverlapMatrix, error) {
+	s := NewSearcher(ctx)
+	fundMap, err := s.SearchFunds(ctx, codes)
+	if err != nil {
+		return nil, FundOverlapMatrix{}, err
+	}
+	return CalcFundStocksSimilarity(fundMap), CalcFundOverlap(fundsInOrder(fundMap, codes), threshold), nil
+}
@@ -0,0 +1,53 @@
+package core
+
+import (
+	"testing"
+
+	"github.com/axiaoxin-com/investool/models"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCalcFundOverlap(t *testing.T) {
+	funds := []*models.Fund{
+		testExposureFund(t, `{"code": "A", "name": "基金A", "stocks": [
+			{"code": "600519", "name": "贵州茅台", "hold_ratio": 10},
+			{"code": "000858", "name": "五粮液", "hold_ratio": 8},
+			{"code": "600036", "name": "招商银行", "hold_ratio": 5}]}`),
+		testExposureFund(t, `{"code": "B", "name": "基金B", "stocks": [
+			{"code": "600519", "name": "茅台", "hold_ratio": 9},
+			{"code": "000858", "name": "五粮液", "hold_ratio": 12},
+			{"code": "000568", "name": "泸州老窖", "hold_ratio": 7}]}`),
+		testExposureFund(t, `{"code": "C", "name": "基金C", "stocks": [
+			{"code": "300750", "name": "宁德时代", "hold_ratio": 10},
+			{"code": "600036", "name": "招商银行", "hold_ratio": 2}]}`),
+		testExposureFund(t, `{"code": "D", "name": "基金D", "stocks": [
+			{"code": "300750", "name": "宁德时代", "hold_ratio": 9}]}`),
+	}
+	m := CalcFundOverlap(funds, 0)
+	require.Equal(t, DefaultFundOverlapThreshold, m.Threshold)
+	require.Len(t, m.Funds, 4)
+	require.Len(t, m.Pairs, 6)
+
+	// A、B 按代码匹配，名称不同也视为同一股票
+	require.InDelta(t, 17, m.WeightOverlap[0][1], 1e-9)
+	require.Equal(t, m.WeightOverlap[0][1], m.WeightOverlap[1][0])
+	require.InDelta(t, 0.5, m.Jaccard[0][1], 1e-9)
+	require.InDelta(t, 23, m.WeightOverlap[0][0], 1e-9)
+	require.Equal(t, 1.0, m.Jaccard[0][0])
+
+	top := m.Pairs[0]
+	require.Equal(t, "A", top.A)
+	require.Equal(t, "B", top.B)
+	require.Equal(t, "600519", top.SameStocks[0].Code)
+	require.Equal(t, "贵州茅台", top.SameStocks[0].Name)
+	require.Equal(t, 10.0, top.SameStocks[0].WeightA)
+	require.Equal(t, 9.0, top.SameStocks[0].WeightB)
+
+	require.InDelta(t, 2, m.WeightOverlap[0][2], 1e-9)
+	require.Len(t, m.Clusters, 0)
+
+	m = CalcFundOverlap(funds, 9)
+	require.Len(t, m.Clusters, 2)
+	require.Equal(t, []FundOverlapFund{{Code: "A", Name: "基金A"}, {Code: "B", Name: "基金B"}}, m.Clusters[0])
+	require.Equal(t, "C", m.Clusters[1][0].Code)
+}
@@ -0,0 +1,155 @@
+// 场内基金（ETF、LOF）溢价率监控
+
+package core
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/axiaoxin-com/investool/datacenter"
+	"github.com/axiaoxin-com/investool/datacenter/eastmoney"
+	"github.com/axiaoxin-com/investool/models"
+	"github.com/axiaoxin-com/logging"
+)
+
+// FundPremiumResult 场内基金溢价率监控结果
+type FundPremiumResult struct {
+	// 基金代码
+	Code string `json:"code"`
+	// 基金名称
+	Name string `json:"name"`
+	// 基金类型
+	Type string `json:"type"`
+	// 跟踪标的代码
+	IndexCode string `json:"index_code"`
+	// 跟踪标的名称
+	IndexName string `json:"index_name"`
+	// 实时行情，获取失败时为空
+	Quote *eastmoney.FundQuote `json:"quote"`
+	// 参考净值：盘中估算净值，无估值时为最新单位净值
+	IOPV float64 `json:"iopv"`
+	// 参考净值来源: 估算净值 最新净值
+	IOPVSource string `json:"iopv_source"`
+	// 参考净值时间或日期
+	IOPVTime string `json:"iopv_time"`
+	// 实时溢价率（%），负数为折价
+	Premium float64 `json:"premium"`
+	// 溢价率提示阈值（%）
+	Threshold float64 `json:"threshold"`
+	// 是否触发溢价提示
+	Alert bool `json:"alert"`
+	// 历史溢价率
+	History models.FundPremiumHistory `json:"history"`
+	// 相对跟踪标的的跟踪误差，无跟踪标的或获取失败时为空
+	TrackingError *models.FundTrackingError `json:"tracking_error"`
+	// 提示
+	Warnings []string `json:"warnings"`
+}
+
+// FundPremiumMonitor 获取场内基金实时价格、参考净值及近 days 天的收盘价与净值，计算溢价率及跟踪误差，溢价率超过 threshold（%）时提示
+func FundPremiumMonitor(ctx context.Context, code string, days int, threshold float64) (*FundPremiumResult, error) {
+	if eastmoney.FundSecid(code) == "" {
+		return nil, fmt.Errorf("%s 不是场内基金代码，仅支持上交所 50、51、52、56、58 开头和深交所 15、16、18 开头的 ETF、LOF", code)
+	}
+	info, err := datacenter.EastMoney.QueryFundInfo(ctx, code)
+	if err != nil {
+		return nil, err
+	}
+	result := &FundPremiumResult{
+		Code:      code,
+		Name:      info.Jjxq.Datas.Shortname,
+		Type:      info.Jjxq.Datas.Ftype,
+		IndexCode: info.Jjxq.Datas.Indexcode,
+		IndexName: info.Jjxq.Datas.Indexname,
+		Threshold: threshold,
+		Warnings:  []string{},
+	}
+
+	now := time.Now()
+	start := now.AddDate(0, 0, -days)
+	navs, err := datacenter.EastMoney.QueryFundNetHistory(ctx, code, start.Format("2006-01-02"), "")
+	if err != nil {
+		return nil, err
+	}
+	prices, err := datacenter.EastMoney.QueryFundPriceKline(ctx, code, start.Format("20060102"), now.Format("20060102"))
+	if err != nil {
+		return nil, err
+	}
+	result.History = models.NewFundPremiumHistory(prices, navs, threshold)
+
+	// 实时溢价率：优先使用盘中估算净值，QDII 等无估值的基金使用最新净值
+	if quote, err := datacenter.EastMoney.QueryFundQuote(ctx, code); err != nil {
+		logging.Warnf(ctx, "FundPremiumMonitor QueryFundQuote code:%s err:%v", code, err)
+		result.Warnings = append(result.Warnings, "无法获取实时行情")
+	} else {
+		result.Quote = quote
+	}
+	if estimate, err := datacenter.EastMoney.QueryFundEstimate(ctx, code); err == nil {
+		if v, err := strconv.ParseFloat(estimate.EstimateNav, 64); err == nil && v > 0 {
+			result.IOPV, result.IOPVSource, result.IOPVTime = v, "估算净值", estimate.EstimateTime
+		}
+	}
+	if result.IOPV == 0 && len(navs) > 0 {
+		latest := navs[len(navs)-1]
+		result.IOPV, result.IOPVSource, result.IOPVTime = latest.Nav, "最新净值", latest.Date
+		if strings.Contains(result.Type, "QDII") {
+			result.Warnings = append(result.Warnings, "QDII 基金净值滞后，实时溢价率按最新净值计算，仅供参考")
+		}
+	}
+	if result.Quote != nil && result.Quote.Price > 0 && result.IOPV > 0 {
+		result.Premium = (result.Quote.Price/result.IOPV - 1) * 100
+		if result.Premium > threshold {
+			result.Alert = true
+			result.Warnings = append(result.Warnings, fmt.Sprintf("实时溢价率%.2f%%超过%.2f%%，场内买入需支付溢价", result.Premium, threshold))
+		}
+	}
+	if latest := result.History.Latest; latest.Date != "" && latest.Premium > threshold {
+		result.Alert = true
+		result.Warnings = append(result.Warnings, fmt.Sprintf("%s 收盘溢价率%.2f%%超过%.2f%%", latest.Date, latest.Premium, threshold))
+	}
+	if result.History.DaysAboveThreshold > 0 {
+		result.Warnings = append(result.Warnings, fmt.Sprintf("近%d天有%d个交易日收盘溢价率超过%.2f%%，最高%.2f%%（%s）",
+			days, result.History.DaysAboveThreshold, threshold, result.History.MaxPremium, result.History.MaxPremiumDate))
+	}
+
+	if secid := eastmoney.IndexSecid(result.IndexCode); secid != "" {
+		index, err := datacenter.EastMoney.QueryIndexKline(ctx, secid, start.Format("20060102"), now.Format("20060102"))
+		if err != nil {
+			logging.Warnf(ctx, "FundPremiumMonitor QueryIndexKline code:%s index:%s err:%v", code, result.IndexCode, err)
+		} else {
+			te := models.NewFundTrackingError(navs, index)
+			result.TrackingError = &te
+		}
+	}
+	return result, nil
+}
+
+// FundsPremiumMonitor 并发监控多只场内基金的溢价率，获取失败的基金不在结果中，结果按 codes 顺序
+func FundsPremiumMonitor(ctx context.Context, codes []string, days int, threshold float64) []*FundPremiumResult {
+	results := make([]*FundPremiumResult, len(codes))
+	var wg sync.WaitGroup
+	for i, code := range codes {
+		wg.Add(1)
+		go func(i int, code string) {
+			defer wg.Done()
+			r, err := FundPremiumMonitor(ctx, code, days, threshold)
+			if err != nil {
+				logging.Errorf(ctx, "FundPremiumMonitor code:%s err:%v", code, err)
+				return
+			}
+			results[i] = r
+		}(i, code)
+	}
+	wg.Wait()
+	filtered := []*FundPremiumResult{}
+	for _, r := range results {
+		if r != nil {
+			filtered = append(filtered, r)
+		}
+	}
+	return filtered
+}
@@ -0,0 +1,89 @@
+// 基金定投回测
+
+package core
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/axiaoxin-com/investool/datacenter"
+	"github.com/axiaoxin-com/investool/portfolio"
+	"github.com/axiaoxin-com/logging"
+)
+
+// FundSIPResult 基金定投回测结果
+type FundSIPResult struct {
+	// 基金代码
+	Code string `json:"code"`
+	// 基金名称
+	Name string `json:"name"`
+	// 定投状态
+	FixedInvestmentStatus string `json:"fixed_investment_status"`
+	portfolio.SIPResult
+}
+
+// FundSIPBacktest 获取基金历史净值回测定投计划，申购费率小于 0 时使用基金当前购买费率，
+// 赎回费率为 0 时按基金赎回费率档位和每笔份额的持有天数收取，valuations 为指数估值序列，可以为空
+func FundSIPBacktest(ctx context.Context, code string, opts portfolio.SIPOptions, valuations []portfolio.ValuationPoint) (FundSIPResult, error) {
+	result := FundSIPResult{Code: code}
+	funds, err := NewSearcher(ctx).SearchFunds(ctx, []string{code})
+	if err != nil {
+		return result, err
+	}
+	fund, exists := funds[code]
+	if !exists {
+		return result, fmt.Errorf("无法获取基金信息(%v)", code)
+	}
+	result.Name = fund.Name
+	result.FixedInvestmentStatus = fund.FixedInvestmentStatus
+	if opts.PurchaseRate < 0 {
+		opts.PurchaseRate = parsePercent(fund.Rate)
+	}
+	if opts.RedemptionRate == 0 && len(opts.RedemptionTiers) == 0 {
+		if !fund.Fee.Loaded {
+			// 费率表获取失败时不收取赎回费
+			if fee, err := FundFeeSchedule(ctx, code); err != nil {
+				logging.Warnf(ctx, "FundSIPBacktest FundFeeSchedule code:%v err:%v", code, err)
+			} else {
+				fund.Fee = fee
+			}
+		}
+		for _, t := range fund.Fee.Redemption {
+			opts.RedemptionTiers = append(opts.RedemptionTiers, portfolio.SIPRedemptionTier{MinDays: t.MinDays, MaxDays: t.MaxDays, Rate: t.Rate})
+		}
+	}
+
+	now := time.Now()
+	if opts.StartDate == "" {
+		opts.StartDate = now.AddDate(-3, 0, 0).Format(portfolio.DateLayout)
+	}
+	start, err := time.Parse(portfolio.DateLayout, opts.StartDate)
+	if err != nil {
+		return result, fmt.Errorf("invalid start date %q", opts.StartDate)
+	}
+	// 按估值调整且没有估值数据时，需要开始日期前的净值计算分位
+	if opts.ValuationScaled && len(valuations) == 0 {
+		years := opts.ValuationYears
+		if years <= 0 {
+			years = portfolio.DefaultSIPOptions.ValuationYears
+		}
+		start = start.AddDate(0, 0, -int(years*365))
+	}
+	history, err := datacenter.EastMoney.QueryFundNetHistory(ctx, code, start.Format(portfolio.DateLayout), opts.EndDate)
+	if err != nil {
+		return result, err
+	}
+	navs := make([]portfolio.NavPoint, len(history))
+	for i, h := range history {
+		navs[i] = portfolio.NavPoint{Date: h.Date, Nav: h.Nav, Dividend: h.Dividend, SplitRatio: h.SplitRatio}
+	}
+	result.SIPResult, err = portfolio.BacktestSIP(navs, valuations, opts)
+	if err != nil {
+		return result, err
+	}
+	if fund.FixedInvestmentStatus != "可定投" {
+		result.Notes = append(result.Notes, "该基金当前不可定投")
+	}
+	return result, nil
+}
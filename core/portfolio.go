@@ -0,0 +1,167 @@
+// 持仓账本汇总
+
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/axiaoxin-com/investool/datacenter"
+	"github.com/axiaoxin-com/investool/datacenter/eastmoney"
+	"github.com/axiaoxin-com/investool/portfolio"
+)
+
+// QueryPrices 查询股票代码的最新股价，key 为股票代码，未开盘等无法获取股价的股票不在结果中
+func QueryPrices(ctx context.Context, codes []string) (map[string]float64, error) {
+	prices := map[string]float64{}
+	if len(codes) == 0 {
+		return prices, nil
+	}
+	stocks, err := datacenter.EastMoney.QuerySelectedStocksWithFilter(ctx, eastmoney.Filter{SpecialSecurityCodeList: codes})
+	if err != nil {
+		return nil, err
+	}
+	for _, stock := range stocks {
+		if price, ok := stock.NewPrice.(float64); ok && price > 0 {
+			prices[stock.SecurityCode] = price
+		}
+	}
+	return prices, nil
+}
+
+// PortfolioSummary 按最新股价汇总账户持仓及盈亏
+func PortfolioSummary(ctx context.Context, account portfolio.Account) (portfolio.Summary, error) {
+	positions, err := account.Positions()
+	if err != nil {
+		return portfolio.Summary{}, err
+	}
+	codes := []string{}
+	for _, p := range positions {
+		if p.IsOpen() {
+			codes = append(codes, p.Code)
+		}
+	}
+	prices, err := QueryPrices(ctx, codes)
+	if err != nil {
+		return portfolio.Summary{}, err
+	}
+	return account.Summarize(prices)
+}
+
+// PortfolioRisk 查询持仓前复权日线及沪深300日线，按最新市值权重分析账户组合风险
+func PortfolioRisk(ctx context.Context, account portfolio.Account, opts portfolio.RiskOptions) (portfolio.RiskReport, error) {
+	positions, err := account.Positions()
+	if err != nil {
+		return portfolio.RiskReport{}, err
+	}
+	codes := []string{}
+	for _, p := range positions {
+		if p.IsOpen() {
+			codes = append(codes, p.Code)
+		}
+	}
+	if len(codes) == 0 {
+		return portfolio.RiskReport{}, errors.New("no open positions")
+	}
+	stocks, err := datacenter.EastMoney.QuerySelectedStocksWithFilter(ctx, eastmoney.Filter{SpecialSecurityCodeList: codes})
+	if err != nil {
+		return portfolio.RiskReport{}, err
+	}
+	prices := map[string]float64{}
+	items := map[string]eastmoney.StockInfo{}
+	for _, stock := range stocks {
+		items[stock.SecurityCode] = stock
+		if price, ok := stock.NewPrice.(float64); ok && price > 0 {
+			prices[stock.SecurityCode] = price
+		}
+	}
+	summary, err := account.Summarize(prices)
+	if err != nil {
+		return portfolio.RiskReport{}, err
+	}
+	if len(summary.MissingPrices) > 0 {
+		return portfolio.RiskReport{}, fmt.Errorf("无法获取现价: %v", summary.MissingPrices)
+	}
+
+	// 交易日约占自然日的 2/3，多取一个月保证数据足够
+	days := opts.Days
+	if days <= 0 {
+		days = portfolio.DefaultRiskOptions.Days
+	}
+	now := time.Now()
+	beg := now.AddDate(0, 0, -days*3/2-30).Format("20060102")
+	end := now.Format("20060102")
+
+	// 并发获取前复权日线，避免除权除息造成的价格跳空被计入收益和波动
+	open := summary.OpenPositions()
+	klines := make([]eastmoney.IndexKlineList, len(open))
+	errs := make([]error, len(open))
+	var wg sync.WaitGroup
+	for i, p := range open {
+		wg.Add(1)
+		go func(i int, p portfolio.Position) {
+			defer wg.Done()
+			kl, err := datacenter.EastMoney.QueryStockKline(ctx, items[p.Code].Secucode, beg, end)
+			if err != nil {
+				errs[i] = fmt.Errorf("%s 历史股价获取失败: %w", p.Code, err)
+				return
+			}
+			klines[i] = kl
+		}(i, p)
+	}
+	wg.Wait()
+	for _, err := range errs {
+		if err != nil {
+			return portfolio.RiskReport{}, err
+		}
+	}
+	stockKlines := map[string]eastmoney.IndexKlineList{}
+	for i, p := range open {
+		stockKlines[p.Code] = klines[i]
+	}
+
+	benchmark, err := datacenter.EastMoney.QueryIndexKline(ctx, eastmoney.SecidHS300, beg, end)
+	if err != nil {
+		return portfolio.RiskReport{}, err
+	}
+	return portfolioRiskWithData(summary, items, stockKlines, benchmark, opts)
+}
+
+// portfolioRiskWithData 使用给定的前复权日线分析持仓风险，klines 的 key 为股票代码
+func portfolioRiskWithData(
+	summary portfolio.Summary,
+	items map[string]eastmoney.StockInfo,
+	klines map[string]eastmoney.IndexKlineList,
+	benchmark eastmoney.IndexKlineList,
+	opts portfolio.RiskOptions,
+) (portfolio.RiskReport, error) {
+	assets := []portfolio.RiskAsset{}
+	for _, p := range summary.OpenPositions() {
+		item := items[p.Code]
+		name := item.SecurityNameAbbr
+		if name == "" {
+			name = p.Name
+		}
+		assets = append(assets, portfolio.RiskAsset{
+			Code:        p.Code,
+			Name:        name,
+			Industry:    item.Industry,
+			MarketValue: p.MarketValue,
+			Prices:      klinePriceSeries(klines[p.Code]),
+		})
+	}
+	return portfolio.AnalyzeRisk(assets, klinePriceSeries(benchmark), opts)
+}
+
+// klinePriceSeries 日线收盘价序列
+func klinePriceSeries(klines eastmoney.IndexKlineList) portfolio.PriceSeries {
+	series := portfolio.PriceSeries{}
+	for _, k := range klines {
+		series.Dates = append(series.Dates, k.Date)
+		series.Prices = append(series.Prices, k.Close)
+	}
+	return series
+}
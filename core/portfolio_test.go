@@ -0,0 +1,52 @@
+package core
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/axiaoxin-com/investool/datacenter/eastmoney"
+	"github.com/axiaoxin-com/investool/portfolio"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPortfolioRiskWithDataSplit(t *testing.T) {
+	day := time.Date(2022, 1, 1, 0, 0, 0, 0, time.Local)
+	benchmark := eastmoney.IndexKlineList{}
+	adjusted := eastmoney.IndexKlineList{}
+	unadjusted := eastmoney.IndexKlineList{}
+	price, bench := 10.0, 3000.0
+	for i := 0; i < 40; i++ {
+		date := day.AddDate(0, 0, i).Format(portfolio.DateLayout)
+		r := 0.01 * math.Sin(float64(i))
+		bench *= 1 + r
+		price *= 1 + r/2
+		benchmark = append(benchmark, eastmoney.IndexKline{Date: date, Close: bench})
+		adjusted = append(adjusted, eastmoney.IndexKline{Date: date, Close: price})
+		// 第 20 天 10 送 10，未复权股价减半
+		raw := price
+		if i < 20 {
+			raw *= 2
+		}
+		unadjusted = append(unadjusted, eastmoney.IndexKline{Date: date, Close: raw})
+	}
+	summary := portfolio.Summary{
+		Positions: []portfolio.Position{{Code: "600000", Name: "浦发银行", Shares: 100, MarketValue: 1000}},
+	}
+	items := map[string]eastmoney.StockInfo{"600000": {SecurityCode: "600000", Industry: "银行"}}
+	opts := portfolio.DefaultRiskOptions
+	opts.Days = 30
+
+	report, err := portfolioRiskWithData(summary, items, map[string]eastmoney.IndexKlineList{"600000": adjusted}, benchmark, opts)
+	require.Nil(t, err)
+	require.Len(t, report.Assets, 1)
+	require.Equal(t, "浦发银行", report.Assets[0].Name)
+	require.Equal(t, "银行", report.Assets[0].Industry)
+	// 前复权股价的收益与基准同向且幅度减半，送转不产生回撤跳空
+	require.InDelta(t, 0.5, report.Beta, 1e-9)
+	require.Less(t, report.Assets[0].MaxDrawdown, 5.0)
+
+	report, err = portfolioRiskWithData(summary, items, map[string]eastmoney.IndexKlineList{"600000": unadjusted}, benchmark, opts)
+	require.Nil(t, err)
+	require.Greater(t, report.Assets[0].MaxDrawdown, 45.0)
+}
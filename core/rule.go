@@ -0,0 +1,299 @@
+// 声明式检测规则：从 toml/yaml 文件加载规则集，对股票执行规则表达式检测
+
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/axiaoxin-com/investool/datacenter/eastmoney"
+	"github.com/axiaoxin-com/investool/models"
+	"github.com/axiaoxin-com/logging"
+	"github.com/spf13/viper"
+)
+
+// RuleSeverity 规则严重程度
+type RuleSeverity string
+
+const (
+	// RuleSeverityError 不满足时检测失败
+	RuleSeverityError RuleSeverity = "error"
+	// RuleSeverityWarn 不满足时仅提示，不影响检测结果
+	RuleSeverityWarn RuleSeverity = "warn"
+)
+
+// Rule 检测规则
+type Rule struct {
+	// 规则名称
+	Name string `json:"name"     mapstructure:"name"`
+	// 规则描述
+	Desc string `json:"desc"     mapstructure:"desc"`
+	// 严重程度: error 或 warn，默认 error
+	Severity RuleSeverity `json:"severity" mapstructure:"severity"`
+	// 规则表达式，如: min(roe, 5y) >= 10
+	Expr string `json:"expr"     mapstructure:"expr"`
+
+	program exprNode
+}
+
+// Compile 解析规则表达式
+func (r *Rule) Compile() error {
+	if r.Severity == "" {
+		r.Severity = RuleSeverityError
+	}
+	if r.Severity != RuleSeverityError && r.Severity != RuleSeverityWarn {
+		return fmt.Errorf("rule %s: invalid severity %q", r.Name, r.Severity)
+	}
+	program, err := parseExpr(r.Expr)
+	if err != nil {
+		return fmt.Errorf("rule %s: %w", r.Name, err)
+	}
+	r.program = program
+	return nil
+}
+
+// Eval 对给定变量环境执行规则，返回是否满足
+func (r Rule) Eval(env RuleEnv) (bool, error) {
+	if r.program == nil {
+		if err := r.Compile(); err != nil {
+			return false, err
+		}
+	}
+	v, err := r.program.eval(env)
+	if err != nil {
+		return false, err
+	}
+	return toBool(v)
+}
+
+// RuleSet 命名规则集
+type RuleSet struct {
+	// 规则集名称
+	Name string `json:"name"  mapstructure:"name"`
+	// 规则集描述
+	Desc string `json:"desc"  mapstructure:"desc"`
+	// 规则列表
+	Rules []Rule `json:"rules" mapstructure:"rules"`
+}
+
+// Compile 解析规则集中的全部规则
+func (rs *RuleSet) Compile() error {
+	for i := range rs.Rules {
+		if err := rs.Rules[i].Compile(); err != nil {
+			return fmt.Errorf("rule set %s: %w", rs.Name, err)
+		}
+	}
+	return nil
+}
+
+var (
+	// RuleSets 已加载的检测规则集，key 为规则集名称
+	RuleSets = map[string]RuleSet{}
+	// RuleSetsFilename 检测规则集文件
+	RuleSetsFilename = "./checker_rules.toml"
+)
+
+// LoadRuleSets 从 toml/yaml 文件加载规则集
+func LoadRuleSets(filename string) (map[string]RuleSet, error) {
+	v := viper.New()
+	v.SetConfigFile(filename)
+	if err := v.ReadInConfig(); err != nil {
+		return nil, err
+	}
+	sets := []RuleSet{}
+	if err := v.UnmarshalKey("rule_sets", &sets); err != nil {
+		return nil, err
+	}
+	result := map[string]RuleSet{}
+	for _, rs := range sets {
+		if rs.Name == "" {
+			return nil, fmt.Errorf("rule set without name in %s", filename)
+		}
+		if _, exists := result[rs.Name]; exists {
+			return nil, fmt.Errorf("duplicate rule set %s in %s", rs.Name, filename)
+		}
+		if err := rs.Compile(); err != nil {
+			return nil, err
+		}
+		result[rs.Name] = rs
+	}
+	return result, nil
+}
+
+// InitRuleSets 加载检测规则集文件
+func InitRuleSets() error {
+	sets, err := LoadRuleSets(RuleSetsFilename)
+	if err != nil {
+		return err
+	}
+	RuleSets = sets
+	return nil
+}
+
+// RuleSetNames 返回已加载的规则集名称
+func RuleSetNames() []string {
+	names := []string{}
+	for name := range RuleSets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// StockRuleEnv 基于股票数据的规则变量环境
+type StockRuleEnv struct {
+	ctx   context.Context
+	stock models.Stock
+}
+
+// NewStockRuleEnv 创建股票规则变量环境
+func NewStockRuleEnv(ctx context.Context, stock models.Stock) StockRuleEnv {
+	return StockRuleEnv{
+		ctx:   ctx,
+		stock: stock,
+	}
+}
+
+// stockSeriesVars 可用的年报历史数据变量
+var stockSeriesVars = map[string]eastmoney.ValueListType{
+	"roe":         eastmoney.ValueListTypeROE,
+	"eps":         eastmoney.ValueListTypeEPS,
+	"roa":         eastmoney.ValueListTypeROA,
+	"revenue":     eastmoney.ValueListTypeRevenue,
+	"netprofit":   eastmoney.ValueListTypeNetProfit,
+	"grossprofit": eastmoney.ValueListTypeGrossProfit,
+	"mll":         eastmoney.ValueListTypeMLL,
+	"jll":         eastmoney.ValueListTypeJLL,
+}
+
+// Lookup 返回变量值
+func (e StockRuleEnv) Lookup(name string) (interface{}, bool) {
+	if vt, ok := stockSeriesVars[name]; ok {
+		return Series(e.stock.HistoricalFinaMainData.ValueList(e.ctx, vt, 0, eastmoney.FinaReportTypeYear)), true
+	}
+	s := e.stock
+	var latest eastmoney.FinaMainData
+	if len(s.HistoricalFinaMainData) > 0 {
+		latest = s.HistoricalFinaMainData[0]
+	}
+	switch name {
+	case "name":
+		return s.BaseInfo.SecurityNameAbbr, true
+	case "code":
+		return s.BaseInfo.Secucode, true
+	case "industry":
+		return s.BaseInfo.Industry, true
+	case "org_type":
+		return s.GetOrgType(), true
+	case "price":
+		return s.GetPrice(), true
+	case "right_price":
+		return s.RightPrice, true
+	case "price_space":
+		return s.PriceSpace, true
+	case "pe":
+		return s.BaseInfo.PE, true
+	case "pb":
+		return s.BaseInfo.PBNewMRQ, true
+	case "peg":
+		return s.PEG, true
+	case "ttm_pe":
+		return s.TTMPE, true
+	case "ttm_peg":
+		return s.TTMPEG, true
+	case "hv":
+		return s.HistoricalVolatility, true
+	case "market_cap":
+		// 亿
+		return s.BaseInfo.TotalMarketCap / 100000000, true
+	case "gxl":
+		return s.BaseInfo.Zxgxl, true
+	case "debt_ratio":
+		return latest.Zcfzl, true
+	case "ld":
+		return latest.Ld, true
+	case "byys_ratio":
+		return s.BYYSRatio, true
+	case "opinion":
+		return s.FinaReportOpinion, true
+	case "netcash_operate":
+		return s.NetcashOperate, true
+	case "netcash_invest":
+		return s.NetcashInvest, true
+	case "netcash_free":
+		return s.NetcashFree, true
+	case "bank_roa":
+		return s.BaseInfo.ROA, true
+	case "bank_zbczl":
+		return latest.Newcapitalader, true
+	case "bank_bldkl":
+		return latest.NonPerLoan, true
+	case "bank_bldkbbfgl":
+		return latest.Bldkbbl, true
+	case "buffett_score":
+		return s.BuffettScore.TotalScore, true
+	case "f_score":
+		return qualityScoreValue(s.PiotroskiFScore), true
+	case "z_score":
+		return qualityScoreValue(s.AltmanZScore), true
+	case "m_score":
+		return qualityScoreValue(s.BeneishMScore), true
+	}
+	return nil, false
+}
+
+// qualityScoreValue 财务质量评分值，数据不足时返回 ErrInsufficientData，检测结果为 unknown
+func qualityScoreValue(q *models.QualityScore) interface{} {
+	if q == nil {
+		return ErrInsufficientData
+	}
+	return q.Score
+}
+
+// CheckRuleSet 使用规则集检测股票，检测项追加到 result，返回是否全部 error 级规则都满足，
+// 数据不足无法判断的 error 级规则按 UnknownAsPass 判断
+func (c Checker) CheckRuleSet(ctx context.Context, stock models.Stock, rs RuleSet, result *CheckResult) bool {
+	ok := true
+	env := NewStockRuleEnv(ctx, stock)
+	for _, rule := range rs.Rules {
+		desc := []string{}
+		if rule.Desc != "" {
+			desc = append(desc, rule.Desc)
+		}
+		desc = append(desc, "规则:"+rule.Expr)
+		passed, err := rule.Eval(env)
+		if err != nil {
+			logging.Warnf(ctx, "rule %s eval error:%v", rule.Name, err)
+			desc = append(desc, "无法执行:"+err.Error())
+		}
+		status := CheckStatusPass
+		if errors.Is(err, ErrInsufficientData) {
+			// warn 级规则不影响检测结果，数据不足时仅提示
+			if rule.Severity == RuleSeverityWarn {
+				status = CheckStatusWarn
+			} else {
+				status = CheckStatusUnknown
+				if !c.Options.UnknownAsPass {
+					ok = false
+				}
+			}
+		} else if !passed {
+			if rule.Severity == RuleSeverityWarn {
+				status = CheckStatusWarn
+			} else {
+				status = CheckStatusFail
+				ok = false
+			}
+		}
+		result.Add(CheckItem{
+			ID:     "rule." + rule.Name,
+			Label:  rule.Name,
+			Status: status,
+			Desc:   strings.Join(desc, "\n"),
+		})
+	}
+	return ok
+}
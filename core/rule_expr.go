@@ -0,0 +1,695 @@
+// 检测规则表达式的词法/语法解析与求值
+// 支持的语法示例：
+// min(roe, 5y) >= 10
+// increasing(eps, 3y)
+// peg < 1.5 unless industry == "银行"
+// not (org_type == "银行" or org_type == "保险") and debt_ratio < 60
+
+package core
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// token 类型
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenNumber
+	tokenYears
+	tokenString
+	tokenIdent
+	tokenOp
+	tokenLParen
+	tokenRParen
+	tokenComma
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	pos  int
+}
+
+// lex 将表达式拆分为 token 列表
+func lex(expr string) ([]token, error) {
+	tokens := []token{}
+	runes := []rune(expr)
+	i := 0
+	for i < len(runes) {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case unicode.IsDigit(r) || (r == '.' && i+1 < len(runes) && unicode.IsDigit(runes[i+1])):
+			start := i
+			for i < len(runes) && (unicode.IsDigit(runes[i]) || runes[i] == '.') {
+				i++
+			}
+			// 5y 表示年数
+			if i < len(runes) && runes[i] == 'y' && (i+1 == len(runes) || !isIdentRune(runes[i+1])) {
+				tokens = append(tokens, token{kind: tokenYears, text: string(runes[start:i]), pos: start})
+				i++
+				continue
+			}
+			tokens = append(tokens, token{kind: tokenNumber, text: string(runes[start:i]), pos: start})
+		case r == '"' || r == '\'':
+			start := i
+			i++
+			for i < len(runes) && runes[i] != r {
+				i++
+			}
+			if i >= len(runes) {
+				return nil, fmt.Errorf("unterminated string at %d", start)
+			}
+			tokens = append(tokens, token{kind: tokenString, text: string(runes[start+1 : i]), pos: start})
+			i++
+		case isIdentRune(r):
+			start := i
+			for i < len(runes) && (isIdentRune(runes[i]) || unicode.IsDigit(runes[i])) {
+				i++
+			}
+			tokens = append(tokens, token{kind: tokenIdent, text: string(runes[start:i]), pos: start})
+		case r == '(':
+			tokens = append(tokens, token{kind: tokenLParen, text: "(", pos: i})
+			i++
+		case r == ')':
+			tokens = append(tokens, token{kind: tokenRParen, text: ")", pos: i})
+			i++
+		case r == ',':
+			tokens = append(tokens, token{kind: tokenComma, text: ",", pos: i})
+			i++
+		default:
+			start := i
+			if i+1 < len(runes) {
+				two := string(runes[i : i+2])
+				switch two {
+				case "<=", ">=", "==", "!=", "&&", "||":
+					tokens = append(tokens, token{kind: tokenOp, text: two, pos: start})
+					i += 2
+					continue
+				}
+			}
+			switch r {
+			case '<', '>', '+', '-', '*', '/', '!':
+				tokens = append(tokens, token{kind: tokenOp, text: string(r), pos: start})
+				i++
+			default:
+				return nil, fmt.Errorf("unexpected character %q at %d", r, start)
+			}
+		}
+	}
+	tokens = append(tokens, token{kind: tokenEOF, pos: len(runes)})
+	return tokens, nil
+}
+
+func isIdentRune(r rune) bool {
+	return r == '_' || r == '.' || unicode.IsLetter(r)
+}
+
+// exprNode 表达式语法树节点
+type exprNode interface {
+	eval(env RuleEnv) (interface{}, error)
+}
+
+type numberNode struct{ value float64 }
+
+type yearsNode struct{ value int }
+
+type stringNode struct{ value string }
+
+type boolNode struct{ value bool }
+
+type identNode struct{ name string }
+
+type unaryNode struct {
+	op      string
+	operand exprNode
+}
+
+type binaryNode struct {
+	op          string
+	left, right exprNode
+}
+
+type callNode struct {
+	name string
+	args []exprNode
+}
+
+// parser 递归下降解析器
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+// parseExpr 将规则表达式解析为语法树
+func parseExpr(expr string) (exprNode, error) {
+	tokens, err := lex(expr)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{tokens: tokens}
+	node, err := p.parseUnless()
+	if err != nil {
+		return nil, err
+	}
+	if t := p.peek(); t.kind != tokenEOF {
+		return nil, fmt.Errorf("unexpected %q at %d", t.text, t.pos)
+	}
+	return node, nil
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	t := p.tokens[p.pos]
+	if t.kind != tokenEOF {
+		p.pos++
+	}
+	return t
+}
+
+// isKeyword 当前 token 是否为指定关键字或操作符
+func (p *parser) isKeyword(words ...string) bool {
+	t := p.peek()
+	if t.kind != tokenIdent && t.kind != tokenOp {
+		return false
+	}
+	for _, w := range words {
+		if t.text == w {
+			return true
+		}
+	}
+	return false
+}
+
+// a unless b : b 成立时跳过 a 的检测
+func (p *parser) parseUnless() (exprNode, error) {
+	left, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if p.isKeyword("unless") {
+		p.next()
+		right, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		return binaryNode{op: "unless", left: left, right: right}, nil
+	}
+	return left, nil
+}
+
+func (p *parser) parseOr() (exprNode, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.isKeyword("or", "||") {
+		p.next()
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = binaryNode{op: "or", left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseAnd() (exprNode, error) {
+	left, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	for p.isKeyword("and", "&&") {
+		p.next()
+		right, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		left = binaryNode{op: "and", left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseNot() (exprNode, error) {
+	if p.isKeyword("not", "!") {
+		p.next()
+		operand, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		return unaryNode{op: "not", operand: operand}, nil
+	}
+	return p.parseCompare()
+}
+
+func (p *parser) parseCompare() (exprNode, error) {
+	left, err := p.parseAdd()
+	if err != nil {
+		return nil, err
+	}
+	if p.isKeyword("<", "<=", ">", ">=", "==", "!=") {
+		op := p.next().text
+		right, err := p.parseAdd()
+		if err != nil {
+			return nil, err
+		}
+		return binaryNode{op: op, left: left, right: right}, nil
+	}
+	return left, nil
+}
+
+func (p *parser) parseAdd() (exprNode, error) {
+	left, err := p.parseMul()
+	if err != nil {
+		return nil, err
+	}
+	for p.isKeyword("+", "-") {
+		op := p.next().text
+		right, err := p.parseMul()
+		if err != nil {
+			return nil, err
+		}
+		left = binaryNode{op: op, left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseMul() (exprNode, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for p.isKeyword("*", "/") {
+		op := p.next().text
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = binaryNode{op: op, left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseUnary() (exprNode, error) {
+	if p.isKeyword("-") {
+		p.next()
+		operand, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return unaryNode{op: "-", operand: operand}, nil
+	}
+	return p.parsePrimary()
+}
+
+func (p *parser) parsePrimary() (exprNode, error) {
+	t := p.next()
+	switch t.kind {
+	case tokenNumber:
+		v, err := strconv.ParseFloat(t.text, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid number %q at %d", t.text, t.pos)
+		}
+		return numberNode{value: v}, nil
+	case tokenYears:
+		v, err := strconv.Atoi(t.text)
+		if err != nil {
+			return nil, fmt.Errorf("invalid years %q at %d", t.text+"y", t.pos)
+		}
+		return yearsNode{value: v}, nil
+	case tokenString:
+		return stringNode{value: t.text}, nil
+	case tokenLParen:
+		node, err := p.parseUnless()
+		if err != nil {
+			return nil, err
+		}
+		if r := p.next(); r.kind != tokenRParen {
+			return nil, fmt.Errorf("expected ) at %d", r.pos)
+		}
+		return node, nil
+	case tokenIdent:
+		switch t.text {
+		case "true":
+			return boolNode{value: true}, nil
+		case "false":
+			return boolNode{value: false}, nil
+		}
+		if p.peek().kind != tokenLParen {
+			return identNode{name: t.text}, nil
+		}
+		p.next()
+		args := []exprNode{}
+		if p.peek().kind != tokenRParen {
+			for {
+				arg, err := p.parseUnless()
+				if err != nil {
+					return nil, err
+				}
+				args = append(args, arg)
+				if p.peek().kind != tokenComma {
+					break
+				}
+				p.next()
+			}
+		}
+		if r := p.next(); r.kind != tokenRParen {
+			return nil, fmt.Errorf("expected ) at %d", r.pos)
+		}
+		if _, ok := ruleFuncs[t.text]; !ok {
+			return nil, fmt.Errorf("unknown function %q at %d", t.text, t.pos)
+		}
+		return callNode{name: t.text, args: args}, nil
+	case tokenEOF:
+		return nil, errors.New("unexpected end of expression")
+	}
+	return nil, fmt.Errorf("unexpected %q at %d", t.text, t.pos)
+}
+
+// Series 按年排列的历史数据，最新的在最前面
+type Series []float64
+
+// RuleEnv 规则求值时的变量环境
+type RuleEnv interface {
+	// Lookup 返回变量值：float64, string, bool 或 Series，数据缺失时返回 error
+	Lookup(name string) (interface{}, bool)
+}
+
+func (n numberNode) eval(env RuleEnv) (interface{}, error) { return n.value, nil }
+func (n yearsNode) eval(env RuleEnv) (interface{}, error)  { return n.value, nil }
+func (n stringNode) eval(env RuleEnv) (interface{}, error) { return n.value, nil }
+func (n boolNode) eval(env RuleEnv) (interface{}, error)   { return n.value, nil }
+
+func (n identNode) eval(env RuleEnv) (interface{}, error) {
+	v, ok := env.Lookup(n.name)
+	if !ok {
+		return nil, fmt.Errorf("unknown variable %q", n.name)
+	}
+	if err, isErr := v.(error); isErr {
+		return nil, fmt.Errorf("%s: %w", n.name, err)
+	}
+	return v, nil
+}
+
+func (n unaryNode) eval(env RuleEnv) (interface{}, error) {
+	v, err := n.operand.eval(env)
+	if err != nil {
+		return nil, err
+	}
+	switch n.op {
+	case "not":
+		b, err := toBool(v)
+		if err != nil {
+			return nil, err
+		}
+		return !b, nil
+	case "-":
+		f, err := toNumber(v)
+		if err != nil {
+			return nil, err
+		}
+		return -f, nil
+	}
+	return nil, fmt.Errorf("unknown operator %q", n.op)
+}
+
+func (n binaryNode) eval(env RuleEnv) (interface{}, error) {
+	left, err := n.left.eval(env)
+	if err != nil {
+		return nil, err
+	}
+	// 逻辑运算短路求值
+	switch n.op {
+	case "and", "or":
+		lb, err := toBool(left)
+		if err != nil {
+			return nil, err
+		}
+		if n.op == "and" && !lb {
+			return false, nil
+		}
+		if n.op == "or" && lb {
+			return true, nil
+		}
+		right, err := n.right.eval(env)
+		if err != nil {
+			return nil, err
+		}
+		return toBool(right)
+	case "unless":
+		right, err := n.right.eval(env)
+		if err != nil {
+			return nil, err
+		}
+		skip, err := toBool(right)
+		if err != nil {
+			return nil, err
+		}
+		if skip {
+			return true, nil
+		}
+		return toBool(left)
+	}
+
+	right, err := n.right.eval(env)
+	if err != nil {
+		return nil, err
+	}
+	// 字符串只支持相等比较
+	ls, lIsStr := left.(string)
+	rs, rIsStr := right.(string)
+	if lIsStr || rIsStr {
+		if !lIsStr || !rIsStr {
+			return nil, fmt.Errorf("cannot compare %v with %v", left, right)
+		}
+		switch n.op {
+		case "==":
+			return ls == rs, nil
+		case "!=":
+			return ls != rs, nil
+		}
+		return nil, fmt.Errorf("operator %q not supported for strings", n.op)
+	}
+	lb, lIsBool := left.(bool)
+	rb, rIsBool := right.(bool)
+	if lIsBool && rIsBool {
+		switch n.op {
+		case "==":
+			return lb == rb, nil
+		case "!=":
+			return lb != rb, nil
+		}
+	}
+
+	lf, err := toNumber(left)
+	if err != nil {
+		return nil, err
+	}
+	rf, err := toNumber(right)
+	if err != nil {
+		return nil, err
+	}
+	switch n.op {
+	case "+":
+		return lf + rf, nil
+	case "-":
+		return lf - rf, nil
+	case "*":
+		return lf * rf, nil
+	case "/":
+		return lf / rf, nil
+	case "<":
+		return lf < rf, nil
+	case "<=":
+		return lf <= rf, nil
+	case ">":
+		return lf > rf, nil
+	case ">=":
+		return lf >= rf, nil
+	case "==":
+		return lf == rf, nil
+	case "!=":
+		return lf != rf, nil
+	}
+	return nil, fmt.Errorf("unknown operator %q", n.op)
+}
+
+func (n callNode) eval(env RuleEnv) (interface{}, error) {
+	args := []interface{}{}
+	for _, a := range n.args {
+		v, err := a.eval(env)
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, v)
+	}
+	return ruleFuncs[n.name](args)
+}
+
+// toNumber 转换为数值，Series 取最新值
+func toNumber(v interface{}) (float64, error) {
+	switch t := v.(type) {
+	case float64:
+		return t, nil
+	case int:
+		return float64(t), nil
+	case Series:
+		if len(t) == 0 {
+			return 0, errors.New("empty series")
+		}
+		return t[0], nil
+	}
+	return 0, fmt.Errorf("%v is not a number", v)
+}
+
+func toBool(v interface{}) (bool, error) {
+	if b, ok := v.(bool); ok {
+		return b, nil
+	}
+	return false, fmt.Errorf("%v is not a boolean", v)
+}
+
+// ErrInsufficientData 历史数据年数少于表达式要求的年数，检测结果为 unknown
+var ErrInsufficientData = errors.New("insufficient data")
+
+// seriesArgs 解析 (series[, Ny]) 形式的参数，数据不足 N 年时返回 ErrInsufficientData
+func seriesArgs(name string, args []interface{}) (Series, error) {
+	if len(args) == 0 || len(args) > 2 {
+		return nil, fmt.Errorf("%s expects (series[, years])", name)
+	}
+	s, ok := args[0].(Series)
+	if !ok {
+		return nil, fmt.Errorf("%s: %v is not a series", name, args[0])
+	}
+	if len(args) == 2 {
+		years, ok := args[1].(int)
+		if !ok {
+			f, isNum := args[1].(float64)
+			if !isNum {
+				return nil, fmt.Errorf("%s: %v is not a years count", name, args[1])
+			}
+			years = int(f)
+		}
+		if years > len(s) {
+			return nil, fmt.Errorf("%s: need %d years but only %d: %w", name, years, len(s), ErrInsufficientData)
+		}
+		if years > 0 {
+			s = s[:years]
+		}
+	}
+	if len(s) == 0 {
+		return nil, fmt.Errorf("%s: empty series", name)
+	}
+	return s, nil
+}
+
+// ruleFuncs 表达式中可用的函数
+var ruleFuncs = map[string]func(args []interface{}) (interface{}, error){
+	// min(roe, 5y) 近 n 年最小值
+	"min": func(args []interface{}) (interface{}, error) {
+		s, err := seriesArgs("min", args)
+		if err != nil {
+			return nil, err
+		}
+		m := s[0]
+		for _, v := range s {
+			m = math.Min(m, v)
+		}
+		return m, nil
+	},
+	// max(roe, 5y) 近 n 年最大值
+	"max": func(args []interface{}) (interface{}, error) {
+		s, err := seriesArgs("max", args)
+		if err != nil {
+			return nil, err
+		}
+		m := s[0]
+		for _, v := range s {
+			m = math.Max(m, v)
+		}
+		return m, nil
+	},
+	// avg(roe, 5y) 近 n 年平均值
+	"avg": func(args []interface{}) (interface{}, error) {
+		s, err := seriesArgs("avg", args)
+		if err != nil {
+			return nil, err
+		}
+		sum := 0.0
+		for _, v := range s {
+			sum += v
+		}
+		return sum / float64(len(s)), nil
+	},
+	// increasing(eps, 3y) 近 n 年逐年递增
+	"increasing": func(args []interface{}) (interface{}, error) {
+		s, err := seriesArgs("increasing", args)
+		if err != nil {
+			return nil, err
+		}
+		for i := 0; i < len(s)-1; i++ {
+			if s[i] <= s[i+1] {
+				return false, nil
+			}
+		}
+		return true, nil
+	},
+	// stable(mll, 5y) 近 n 年标准差不超过 2.51，与 IsStability 一致
+	"stable": func(args []interface{}) (interface{}, error) {
+		s, err := seriesArgs("stable", args)
+		if err != nil {
+			return nil, err
+		}
+		avg := 0.0
+		for _, v := range s {
+			avg += v
+		}
+		avg /= float64(len(s))
+		variance := 0.0
+		for _, v := range s {
+			variance += math.Pow(v-avg, 2)
+		}
+		return math.Sqrt(variance/float64(len(s))) <= 2.51, nil
+	},
+	// abs(x) 绝对值
+	"abs": func(args []interface{}) (interface{}, error) {
+		if len(args) != 1 {
+			return nil, errors.New("abs expects 1 argument")
+		}
+		f, err := toNumber(args[0])
+		if err != nil {
+			return nil, err
+		}
+		return math.Abs(f), nil
+	},
+	// in(industry, "银行", "保险") 是否为给定值之一
+	"in": func(args []interface{}) (interface{}, error) {
+		if len(args) < 2 {
+			return nil, errors.New("in expects at least 2 arguments")
+		}
+		for _, a := range args[1:] {
+			if fmt.Sprint(a) == fmt.Sprint(args[0]) {
+				return true, nil
+			}
+		}
+		return false, nil
+	},
+	// contains(industry, "银行") 字符串包含
+	"contains": func(args []interface{}) (interface{}, error) {
+		if len(args) != 2 {
+			return nil, errors.New("contains expects 2 arguments")
+		}
+		return strings.Contains(fmt.Sprint(args[0]), fmt.Sprint(args[1])), nil
+	},
+}
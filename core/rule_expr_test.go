@@ -0,0 +1,87 @@
+package core
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type mapRuleEnv map[string]interface{}
+
+func (m mapRuleEnv) Lookup(name string) (interface{}, bool) {
+	v, ok := m[name]
+	return v, ok
+}
+
+func TestParseExpr(t *testing.T) {
+	env := mapRuleEnv{
+		"roe":      Series{15, 12, 11, 10, 9},
+		"eps":      Series{1.5, 1.2, 1.0},
+		"peg":      2.0,
+		"industry": "银行",
+		"org_type": "银行",
+	}
+	cases := map[string]bool{
+		"min(roe, 4y) >= 10":                      true,
+		"min(roe, 5y) >= 10":                      false,
+		"min(roe) >= 9":                           true,
+		"max(roe, 5y) == 15":                      true,
+		"avg(roe, 2y) > 13":                       true,
+		"increasing(eps, 3y)":                     true,
+		"increasing(roe, 5y) and roe > 14":        true,
+		`peg < 1.5 unless industry == "银行"`:       true,
+		`peg < 1.5 unless industry == "食品饮料"`:     false,
+		`not in(org_type, "银行", "保险")`:            false,
+		"roe * 2 - 10 >= 20":                      true,
+		"-peg < 0 && (peg > 1 || false)":          true,
+		`contains(industry, "银") and stable(eps)`: true,
+	}
+	for expr, expected := range cases {
+		node, err := parseExpr(expr)
+		require.Nil(t, err, expr)
+		v, err := node.eval(env)
+		require.Nil(t, err, expr)
+		require.Equal(t, expected, v, expr)
+	}
+}
+
+func TestParseExprError(t *testing.T) {
+	exprs := []string{
+		"min(roe, 5y >= 10",
+		"roe >= ",
+		"unknown_func(roe)",
+		`industry == "银行`,
+		"roe # 1",
+	}
+	for _, expr := range exprs {
+		_, err := parseExpr(expr)
+		require.NotNil(t, err, expr)
+	}
+}
+
+func TestEvalExprError(t *testing.T) {
+	env := mapRuleEnv{"roe": Series{}, "industry": "银行"}
+	exprs := []string{
+		"missing > 1",
+		"roe > 1",
+		`industry > 1`,
+		"min(industry, 3y) > 1",
+	}
+	for _, expr := range exprs {
+		node, err := parseExpr(expr)
+		require.Nil(t, err, expr)
+		_, err = node.eval(env)
+		require.NotNil(t, err, expr)
+	}
+}
+
+func TestEvalExprInsufficientData(t *testing.T) {
+	env := mapRuleEnv{"roe": Series{15, 12}}
+	for _, expr := range []string{"min(roe, 5y) >= 10", "increasing(roe, 3y)", "avg(roe, 3y) > 1"} {
+		node, err := parseExpr(expr)
+		require.Nil(t, err, expr)
+		_, err = node.eval(env)
+		require.True(t, errors.Is(err, ErrInsufficientData), expr)
+	}
+}
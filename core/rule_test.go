@@ -0,0 +1,94 @@
+package core
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/axiaoxin-com/investool/datacenter/eastmoney"
+	"github.com/axiaoxin-com/investool/models"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadRuleSets(t *testing.T) {
+	sets, err := LoadRuleSets("../checker_rules.toml")
+	require.Nil(t, err)
+	require.NotEmpty(t, sets)
+	for name, rs := range sets {
+		require.Equal(t, name, rs.Name)
+		for _, r := range rs.Rules {
+			require.NotNil(t, r.program)
+		}
+	}
+
+	filename := filepath.Join(t.TempDir(), "rules.yaml")
+	content := `
+rule_sets:
+  - name: bad
+    rules:
+      - name: r1
+        expr: "min(roe, 5y >= 10"
+`
+	require.Nil(t, os.WriteFile(filename, []byte(content), 0644))
+	_, err = LoadRuleSets(filename)
+	require.NotNil(t, err)
+}
+
+func TestCheckRuleSet(t *testing.T) {
+	stock := models.Stock{
+		HistoricalFinaMainData: eastmoney.HistoricalFinaMainData{
+			{ReportType: eastmoney.FinaReportTypeYear, Roejq: 15, Epsjb: 1.2},
+			{ReportType: eastmoney.FinaReportTypeYear, Roejq: 12, Epsjb: 1.0},
+		},
+		PEG: 2,
+	}
+	rs := RuleSet{
+		Name: "test",
+		Rules: []Rule{
+			{Name: "roe", Expr: "min(roe, 2y) >= 10"},
+			{Name: "peg", Expr: "peg < 1.5", Severity: RuleSeverityWarn},
+		},
+	}
+	require.Nil(t, rs.Compile())
+	c := NewChecker(_ctx, DefaultCheckerOptions)
+	result := NewCheckResult()
+	require.True(t, c.CheckRuleSet(_ctx, stock, rs, &result))
+	require.True(t, result.OK)
+	item, ok := result.Get("rule.roe")
+	require.True(t, ok)
+	require.Equal(t, CheckStatusPass, item.Status)
+	item, _ = result.Get("rule.peg")
+	require.Equal(t, CheckStatusWarn, item.Status)
+
+	rs.Rules = append(rs.Rules, Rule{Name: "eps", Expr: "eps > 2"})
+	require.Nil(t, rs.Compile())
+	require.False(t, c.CheckRuleSet(_ctx, stock, rs, &result))
+	require.False(t, result.OK)
+	require.Len(t, result.FailedItems(), 1)
+
+	// 只有 2 年数据时 5 年条件无法判断
+	rs.Rules = []Rule{{Name: "roe5", Expr: "min(roe, 5y) >= 10"}}
+	require.Nil(t, rs.Compile())
+	result = NewCheckResult()
+	require.False(t, c.CheckRuleSet(_ctx, stock, rs, &result))
+	item, _ = result.Get("rule.roe5")
+	require.Equal(t, CheckStatusUnknown, item.Status)
+	opts := DefaultCheckerOptions
+	opts.UnknownAsPass = true
+	require.True(t, NewChecker(_ctx, opts).CheckRuleSet(_ctx, stock, rs, &result))
+
+	// warn 级规则数据不足时只提示
+	rs.Rules = []Rule{{Name: "roe5", Expr: "min(roe, 5y) >= 10", Severity: RuleSeverityWarn}}
+	require.Nil(t, rs.Compile())
+	result = NewCheckResult()
+	require.True(t, c.CheckRuleSet(_ctx, stock, rs, &result))
+	item, _ = result.Get("rule.roe5")
+	require.Equal(t, CheckStatusWarn, item.Status)
+
+	// 没有财务质量评分时无法判断，!= 及 not 也不通过
+	rs.Rules = []Rule{{Name: "f", Expr: "f_score != 0"}, {Name: "z", Expr: "not (z_score < 1.8)"}}
+	require.Nil(t, rs.Compile())
+	result = NewCheckResult()
+	require.False(t, c.CheckRuleSet(_ctx, stock, rs, &result))
+	require.Len(t, result.UnknownItems(), 2)
+}
@@ -0,0 +1,143 @@
+// 检测结果加权评分：按检测项权重及距离阈值的远近给出部分得分
+
+package core
+
+import (
+	"math"
+	"sort"
+
+	"github.com/axiaoxin-com/investool/models"
+)
+
+// ScoreOptions 评分选项
+type ScoreOptions struct {
+	// 检测项权重，key 为检测项 ID，未配置的检测项使用 DefaultWeight
+	Weights map[string]float64 `json:"weights"`
+	// 未配置权重的检测项的默认权重
+	DefaultWeight float64 `json:"default_weight" form:"score_default_weight"`
+	// 部分得分的容忍度：观测值超出阈值的相对距离达到该值时该项得 0 分
+	Tolerance float64 `json:"tolerance"      form:"score_tolerance"`
+	// warn 状态检测项的得分比例
+	WarnCredit float64 `json:"warn_credit"    form:"score_warn_credit"`
+}
+
+// DefaultScoreOptions 默认评分选项
+var DefaultScoreOptions = ScoreOptions{
+	Weights: map[string]float64{
+		"roe":                  3,
+		"roe_increasing":       2,
+		"eps_increasing":       2,
+		"revenue_increasing":   2,
+		"netprofit_increasing": 2,
+		"right_price":          2,
+		"peg":                  2,
+		"debt_ratio":           1.5,
+		"cashflow":             1.5,
+		"audit_opinion":        2,
+	},
+	DefaultWeight: 1,
+	Tolerance:     0.5,
+	WarnCredit:    0.5,
+}
+
+// ItemScore 单个检测项得分
+type ItemScore struct {
+	// 检测项 ID
+	ID string `json:"id"`
+	// 检测项名称
+	Label string `json:"label"`
+	// 检测状态
+	Status CheckStatus `json:"status"`
+	// 权重
+	Weight float64 `json:"weight"`
+	// 得分比例 0-1
+	Credit float64 `json:"credit"`
+	// 超出阈值的相对距离，无法计算时为 -1
+	Distance float64 `json:"distance"`
+}
+
+// StockScore 股票综合评分
+type StockScore struct {
+	Stock models.Stock `json:"stock"`
+	// 综合得分 0-100
+	Score float64 `json:"score"`
+	// 是否通过全部检测
+	Passed bool `json:"passed"`
+	// 各检测项得分明细
+	Items []ItemScore `json:"items"`
+}
+
+// StockScoreList 股票评分列表
+type StockScoreList []StockScore
+
+// SortByScore 按综合得分从高到低排序
+func (s StockScoreList) SortByScore() {
+	sort.SliceStable(s, func(i, j int) bool {
+		return s[i].Score > s[j].Score
+	})
+}
+
+// Stocks 返回股票列表
+func (s StockScoreList) Stocks() models.StockList {
+	stocks := models.StockList{}
+	for _, i := range s {
+		stocks = append(stocks, i.Stock)
+	}
+	return stocks
+}
+
+// weight 返回检测项权重
+func (o ScoreOptions) weight(id string) float64 {
+	if w, ok := o.Weights[id]; ok {
+		return w
+	}
+	return o.DefaultWeight
+}
+
+// itemCredit 计算检测项得分比例
+func (o ScoreOptions) itemCredit(item CheckItem) float64 {
+	switch item.Status {
+	case CheckStatusPass:
+		return 1
+	case CheckStatusWarn:
+		return o.WarnCredit
+	case CheckStatusFail:
+		distance := item.Distance()
+		if distance < 0 || o.Tolerance <= 0 {
+			return 0
+		}
+		return math.Max(0, 1-distance/o.Tolerance)
+	}
+	return 0
+}
+
+// Score 根据检测结果计算综合得分（0-100）及各项得分明细，skip 及 unknown 状态的检测项不参与评分
+func (o ScoreOptions) Score(result CheckResult) (float64, []ItemScore) {
+	items := []ItemScore{}
+	totalWeight := 0.0
+	total := 0.0
+	for _, item := range result.Items {
+		if item.Status == CheckStatusSkip || item.Status == CheckStatusUnknown {
+			continue
+		}
+		weight := o.weight(item.ID)
+		if weight <= 0 {
+			continue
+		}
+		credit := o.itemCredit(item)
+		items = append(items, ItemScore{
+			ID:       item.ID,
+			Label:    item.Label,
+			Status:   item.Status,
+			Weight:   weight,
+			Credit:   credit,
+			Distance: item.Distance(),
+		})
+		totalWeight += weight
+		total += weight * credit
+	}
+	if totalWeight == 0 {
+		return 0, items
+	}
+	return total / totalWeight * 100, items
+}
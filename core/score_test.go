@@ -0,0 +1,52 @@
+package core
+
+import (
+	"testing"
+
+	"github.com/axiaoxin-com/investool/models"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScore(t *testing.T) {
+	opts := ScoreOptions{
+		Weights:       map[string]float64{"a": 2, "ignored": 0},
+		DefaultWeight: 1,
+		Tolerance:     0.5,
+		WarnCredit:    0.5,
+	}
+	result := NewCheckResult()
+	result.Add(CheckItem{ID: "a", Status: CheckStatusPass})
+	// 超出阈值 25%，得一半分
+	result.Add(CheckItem{ID: "b", Status: CheckStatusFail, Value: observed(7.5), Threshold: minThreshold(10)})
+	result.Add(CheckItem{ID: "c", Status: CheckStatusWarn})
+	result.Add(CheckItem{ID: "d", Status: CheckStatusSkip})
+	result.Add(CheckItem{ID: "u", Status: CheckStatusUnknown})
+	result.Add(CheckItem{ID: "e", Status: CheckStatusFail})
+	result.Add(CheckItem{ID: "ignored", Status: CheckStatusFail})
+
+	score, items := opts.Score(result)
+	require.Len(t, items, 4)
+	require.Equal(t, 0.5, items[1].Credit)
+	require.InDelta(t, 0.25, items[1].Distance, 1e-9)
+	require.Equal(t, -1.0, items[3].Distance)
+	// (2*1 + 1*0.5 + 1*0.5 + 1*0) / 5
+	require.InDelta(t, 60.0, score, 1e-9)
+
+	// 超出容忍度不得分
+	result = NewCheckResult()
+	result.Add(CheckItem{ID: "b", Status: CheckStatusFail, Value: observed(3), Threshold: maxThreshold(1.5)})
+	score, _ = opts.Score(result)
+	require.Equal(t, 0.0, score)
+}
+
+func TestStockScoreListSortByScore(t *testing.T) {
+	list := StockScoreList{
+		{Score: 10, Stock: models.Stock{PEG: 1}},
+		{Score: 90, Stock: models.Stock{PEG: 2}},
+		{Score: 50, Stock: models.Stock{PEG: 3}},
+	}
+	list.SortByScore()
+	require.Equal(t, 90.0, list[0].Score)
+	require.Equal(t, 10.0, list[2].Score)
+	require.Equal(t, 2.0, list.Stocks()[0].PEG)
+}
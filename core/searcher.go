@@ -31,6 +31,11 @@ func NewSearcher(ctx context.Context) Searcher {
 
 // SearchStocks 按股票名或代码搜索股票
 func (s Searcher) SearchStocks(ctx context.Context, keywords []string) (map[string]models.Stock, error) {
+	return s.SearchStocksWithOptions(ctx, keywords, models.DefaultNewStockOptions)
+}
+
+// SearchStocksWithOptions 按股票名或代码搜索股票，只获取 opts 中指定的数据
+func (s Searcher) SearchStocksWithOptions(ctx context.Context, keywords []string, opts models.NewStockOptions) (map[string]models.Stock, error) {
 	var wg sync.WaitGroup
 	var mu sync.Mutex
 	kLen := len(keywords)
@@ -80,7 +85,7 @@ func (s Searcher) SearchStocks(ctx context.Context, keywords []string) (map[stri
 			defer func() {
 				wg.Done()
 			}()
-			mstock, err := models.NewStock(ctx, stock)
+			mstock, err := models.NewStockWithOptions(ctx, stock, opts)
 			if err != nil {
 				logging.Errorf(ctx, "%s new models stock error:%v", stock.SecurityCode, err.Error())
 				return
@@ -30,32 +30,43 @@ import (
 type Selector struct {
 	Filter  eastmoney.Filter
 	Checker *Checker
+	// 创建 Stock 对象的选项，未指定数据项时获取全部数据
+	StockOptions models.NewStockOptions
 }
 
 // NewSelector 创建选股器
 func NewSelector(ctx context.Context, filter eastmoney.Filter, checker *Checker) Selector {
+	stockOpts := models.DefaultNewStockOptions
+	if checker != nil {
+		// 筛选时只获取检测需要的数据，通过检测的股票再补全其余数据
+		stockOpts = checker.StockOptions(0)
+	}
 	return Selector{
-		Filter:  filter,
-		Checker: checker,
+		Filter:       filter,
+		Checker:      checker,
+		StockOptions: stockOpts,
 	}
 }
 
-// AutoFilterStocks 按默认设置自动筛选股票
-func (s Selector) AutoFilterStocks(ctx context.Context) (result models.StockList, err error) {
+// walkStocks 按筛选条件查询股票，并发创建 Stock 对象后逐个回调 fn，fn 需自行保证并发安全
+func (s Selector) walkStocks(ctx context.Context, fn func(stock models.Stock)) error {
 	stocks, err := datacenter.EastMoney.QuerySelectedStocksWithFilter(ctx, s.Filter)
 	if err != nil {
-		return
+		return err
 	}
-	logging.Infof(ctx, "AutoFilterStocks will filter from %d stocks by %s", len(stocks), s.Filter.String())
+	logging.Infof(ctx, "Selector will filter from %d stocks by %s", len(stocks), s.Filter.String())
 	if len(stocks) == 0 {
-		return
+		return nil
+	}
+	stockOpts := s.StockOptions
+	if stockOpts.Enrichments == 0 {
+		stockOpts = models.DefaultNewStockOptions
 	}
 
 	// 并发执行筛选任务
 	workerCount := int(math.Min(float64(len(stocks)), float64(viper.GetFloat64("app.chan_size"))))
 	jobChan := make(chan struct{}, workerCount)
 	wg := sync.WaitGroup{}
-	var mu sync.Mutex
 
 	for _, baseInfo := range stocks {
 		wg.Add(1)
@@ -70,29 +81,86 @@ func (s Selector) AutoFilterStocks(ctx context.Context) (result models.StockList
 				}
 			}()
 
-			stock, err := models.NewStock(ctx, baseInfo)
+			stock, err := models.NewStockWithOptions(ctx, baseInfo, stockOpts)
 			if err != nil {
 				logging.Error(ctx, "NewStock error:"+err.Error())
 				return
 			}
-			if s.Checker == nil {
-				mu.Lock()
-				result = append(result, stock)
-				mu.Unlock()
-			} else {
-				// 检测是否为优质股票
-				if details, ok := s.Checker.CheckFundamentals(ctx, stock); ok {
-					mu.Lock()
-					result = append(result, stock)
-					mu.Unlock()
-				} else {
-					logging.Debug(ctx, fmt.Sprintf("%s %s has some defects", stock.BaseInfo.SecurityNameAbbr, stock.BaseInfo.Secucode), zap.Any("details", details))
-				}
-			}
+			fn(stock)
 		}(ctx, baseInfo)
 	}
 	wg.Wait()
+	return nil
+}
+
+// AutoFilterStocks 按默认设置自动筛选股票
+func (s Selector) AutoFilterStocks(ctx context.Context) (result models.StockList, err error) {
+	var mu sync.Mutex
+	err = s.walkStocks(ctx, func(stock models.Stock) {
+		if s.Checker == nil {
+			mu.Lock()
+			result = append(result, stock)
+			mu.Unlock()
+			return
+		}
+		// 检测是否为优质股票
+		if details, ok := s.Checker.CheckFundamentals(ctx, stock); ok {
+			stock = s.fullStock(ctx, stock)
+			mu.Lock()
+			result = append(result, stock)
+			mu.Unlock()
+		} else {
+			logging.Debug(ctx, fmt.Sprintf("%s %s has some defects", stock.BaseInfo.SecurityNameAbbr, stock.BaseInfo.Secucode), zap.Any("details", details))
+		}
+	})
+	if err != nil {
+		return
+	}
 	logging.Infof(ctx, "AutoFilterStocks selected %d stocks", len(result))
 	result.SortByROE()
 	return
 }
+
+// fullStock 补全只获取了检测所需数据的股票，获取失败时返回原股票
+func (s Selector) fullStock(ctx context.Context, stock models.Stock) models.Stock {
+	if s.StockOptions.Enrichments == 0 || s.StockOptions.Enrichments.Has(models.EnrichAll) {
+		return stock
+	}
+	opts := s.StockOptions
+	opts.Enrichments = models.EnrichAll
+	full, err := models.NewStockWithOptions(ctx, stock.BaseInfo, opts)
+	if err != nil {
+		logging.Error(ctx, "NewStock full data error:"+err.Error())
+		return stock
+	}
+	return full
+}
+
+// ScoreStocks 评分模式：不过滤检测失败的股票，按检测结果加权评分后返回全部候选股票，得分从高到低排列
+func (s Selector) ScoreStocks(ctx context.Context, opts ScoreOptions) (result StockScoreList, err error) {
+	checker := s.Checker
+	if checker == nil {
+		checker = NewChecker(ctx, DefaultCheckerOptions)
+	}
+	// 全部候选股票都会返回，直接获取全部数据
+	s.StockOptions.Enrichments = models.EnrichAll
+	var mu sync.Mutex
+	err = s.walkStocks(ctx, func(stock models.Stock) {
+		details, ok := checker.CheckFundamentals(ctx, stock)
+		score, items := opts.Score(details)
+		mu.Lock()
+		result = append(result, StockScore{
+			Stock:  stock,
+			Score:  score,
+			Passed: ok,
+			Items:  items,
+		})
+		mu.Unlock()
+	})
+	if err != nil {
+		return
+	}
+	logging.Infof(ctx, "ScoreStocks scored %d stocks", len(result))
+	result.SortByScore()
+	return
+}
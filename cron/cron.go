@@ -37,10 +37,14 @@ func RunCronJobs(async bool) {
 
 	// 同步基金净值列表和4433列表
 	// sched.Cron(viper.GetString("app.cronexp.sync_fund")).Do(SyncFund)
+	// 同步基金费率表
+	// sched.Cron(viper.GetString("app.cronexp.sync_fund_fees")).Do(SyncFundFees)
 	// 同步东方财富行业列表
 	// sched.Cron(viper.GetString("app.cronexp.sync_industry_list")).Do(SyncIndustryList)
 	// 同步基金经理列表
 	// sched.Cron(viper.GetString("app.cronexp.sync_fund_managers")).Do(SyncFundManagers)
+	// 同步行业巴菲特评分均值
+	// sched.Cron(viper.GetString("app.cronexp.sync_industry_buffett_scores")).Do(SyncIndustryBuffettScores)
 
 	// ----------------------
 	// 以上的定时任务注释掉不再执行是因为部署的机器内存不够，执行时会oom
@@ -5,6 +5,8 @@ import (
 	"context"
 	"encoding/json"
 	"io/ioutil"
+	"math"
+	"sync"
 	"time"
 
 	"github.com/axiaoxin-com/goutils"
@@ -13,6 +15,7 @@ import (
 	"github.com/axiaoxin-com/investool/datacenter/eastmoney"
 	"github.com/axiaoxin-com/investool/models"
 	"github.com/axiaoxin-com/logging"
+	"github.com/spf13/viper"
 )
 
 // SyncFund 同步基金数据
@@ -44,6 +47,11 @@ func SyncFund() {
 		typeMap[fund.Type] = struct{}{}
 	}
 
+	// 费率表由 SyncFundFees 单独同步
+	fundlist.SetFees(models.FundFeeTable)
+	// 按同类型计算综合评分
+	fundlist.CalcScores()
+
 	// 更新 services 变量
 	models.FundAllList = fundlist
 	fundtypes := []string{}
@@ -55,8 +63,8 @@ func SyncFund() {
 	// 更新同步时间
 	models.SyncFundTime = time.Now()
 
-	// 更新4433列表
-	Update4433()
+	// 更新各规则集基金列表
+	UpdateFundRuleSetLists()
 
 	// 更新文件
 	b, err := json.Marshal(efundlist)
@@ -85,28 +93,83 @@ func SyncFund() {
 	}
 }
 
-// Update4433 更新4433检测结果
-func Update4433() {
+// SyncFundFees 同步全量基金的费率表
+func SyncFundFees() {
+	if !goutils.IsTradingDay() {
+		return
+	}
 	ctx := context.Background()
-	fundlist := models.FundList{}
+	if len(models.FundAllList) == 0 {
+		logging.Error(ctx, "SyncFundFees fund list is empty")
+		promSyncError.WithLabelValues("SyncFundFees").Inc()
+		return
+	}
+	logging.Info(ctx, "SyncFundFees request start...")
+	workerCount := int(math.Min(float64(len(models.FundAllList)), viper.GetFloat64("app.chan_size")))
+	if workerCount <= 0 {
+		workerCount = 1
+	}
+	reqChan := make(chan struct{}, workerCount)
+	fees := models.FundFeeSchedules{}
+	var mu sync.Mutex
+	var wg sync.WaitGroup
 	for _, fund := range models.FundAllList {
-		if fund.Is4433(ctx) {
-			fundlist = append(fundlist, fund)
-		}
+		wg.Add(1)
+		reqChan <- struct{}{}
+		go func(code string) {
+			defer func() {
+				<-reqChan
+				wg.Done()
+			}()
+			fee, err := core.FundFeeSchedule(ctx, code)
+			if err != nil {
+				logging.Warnf(ctx, "SyncFundFees FundFeeSchedule code:%v err:%v", code, err)
+				return
+			}
+			mu.Lock()
+			fees[code] = fee
+			mu.Unlock()
+		}(fund.Code)
 	}
+	wg.Wait()
+
 	// 更新 models 变量
-	fundlist.Sort(models.FundSortTypeWeek)
-	models.Fund4433List = fundlist
+	models.FundFeeTable = fees
+	models.FundAllList.SetFees(fees)
+	// 费率变化后更新各规则集基金列表
+	UpdateFundRuleSetLists()
 
 	// 更新文件
-	b, err := json.Marshal(fundlist)
+	b, err := json.Marshal(fees)
 	if err != nil {
-		logging.Errorf(ctx, "Update4433 json marshal error:", err)
-		promSyncError.WithLabelValues("Update4433").Inc()
+		logging.Errorf(ctx, "SyncFundFees json marshal error:%v", err)
+		promSyncError.WithLabelValues("SyncFundFees").Inc()
 		return
-	} else if err := ioutil.WriteFile(models.Fund4433ListFilename, b, 0666); err != nil {
-		logging.Errorf(ctx, "Update4433 WriteFile error:", err)
-		promSyncError.WithLabelValues("Update4433").Inc()
+	}
+	if err := ioutil.WriteFile(models.FundFeesFilename, b, 0666); err != nil {
+		logging.Errorf(ctx, "SyncFundFees WriteFile error:%v", err)
+		promSyncError.WithLabelValues("SyncFundFees").Inc()
 		return
 	}
 }
+
+// UpdateFundRuleSetLists 按规则集更新基金列表，4433 列表同时更新
+func UpdateFundRuleSetLists() {
+	ctx := context.Background()
+	for _, rs := range models.FundRuleSetsWith4433() {
+		// 更新 models 变量
+		fundlist := models.UpdateFundRuleSetList(ctx, rs)
+
+		// 更新文件
+		b, err := json.Marshal(fundlist)
+		if err != nil {
+			logging.Errorf(ctx, "UpdateFundRuleSetLists %s json marshal error:%v", rs.Name, err)
+			promSyncError.WithLabelValues("UpdateFundRuleSetLists").Inc()
+			continue
+		} else if err := ioutil.WriteFile(rs.ListFilename(), b, 0666); err != nil {
+			logging.Errorf(ctx, "UpdateFundRuleSetLists %s WriteFile error:%v", rs.Name, err)
+			promSyncError.WithLabelValues("UpdateFundRuleSetLists").Inc()
+			continue
+		}
+	}
+}
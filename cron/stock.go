@@ -7,7 +7,9 @@ import (
 	"io/ioutil"
 
 	"github.com/axiaoxin-com/goutils"
+	"github.com/axiaoxin-com/investool/core"
 	"github.com/axiaoxin-com/investool/datacenter"
+	"github.com/axiaoxin-com/investool/datacenter/eastmoney"
 	"github.com/axiaoxin-com/investool/models"
 	"github.com/axiaoxin-com/logging"
 )
@@ -41,3 +43,43 @@ func SyncIndustryList() {
 		return
 	}
 }
+
+// SyncIndustryBuffettScores 按各行业全部成分股同步行业巴菲特评分均值
+func SyncIndustryBuffettScores() {
+	if !goutils.IsTradingDay() {
+		return
+	}
+	ctx := context.Background()
+	if len(models.StockIndustryList) == 0 {
+		logging.Error(ctx, "SyncIndustryBuffettScores industry list is empty")
+		promSyncError.WithLabelValues("SyncIndustryBuffettScores").Inc()
+		return
+	}
+	filter := eastmoney.Filter{SpecialIndustryList: models.StockIndustryList}
+	selector := core.NewSelector(ctx, filter, nil)
+	// 巴菲特评分只依赖财报数据
+	selector.StockOptions.Enrichments = models.EnrichFinaMain | models.EnrichGincome | models.EnrichCashflow | models.EnrichBalance
+	stocks, err := selector.AutoFilterStocks(ctx)
+	if err != nil {
+		logging.Errorf(ctx, "SyncIndustryBuffettScores AutoFilterStocks error:%v", err)
+		promSyncError.WithLabelValues("SyncIndustryBuffettScores").Inc()
+		return
+	}
+	scores := stocks.IndustryBuffettScores()
+	if len(scores) != 0 {
+		models.IndustryBuffettScoreTable = scores
+	}
+
+	// 更新文件
+	b, err := json.Marshal(scores)
+	if err != nil {
+		logging.Errorf(ctx, "SyncIndustryBuffettScores json marshal error:%v", err)
+		promSyncError.WithLabelValues("SyncIndustryBuffettScores").Inc()
+		return
+	}
+	if err := ioutil.WriteFile(models.IndustryBuffettScoresFilename, b, 0666); err != nil {
+		logging.Errorf(ctx, "SyncIndustryBuffettScores WriteFile error:%v", err)
+		promSyncError.WithLabelValues("SyncIndustryBuffettScores").Inc()
+		return
+	}
+}
@@ -0,0 +1,143 @@
+// 获取财务分析资产负债表数据
+
+package eastmoney
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/axiaoxin-com/goutils"
+	"github.com/axiaoxin-com/logging"
+
+	"go.uber.org/zap"
+)
+
+// BalanceData 资产负债表数据
+type BalanceData struct {
+	Secucode         string         `json:"SECUCODE"`
+	SecurityCode     string         `json:"SECURITY_CODE"`
+	SecurityNameAbbr string         `json:"SECURITY_NAME_ABBR"`
+	OrgCode          string         `json:"ORG_CODE"`
+	OrgType          string         `json:"ORG_TYPE"`
+	ReportDate       string         `json:"REPORT_DATE"`
+	ReportType       FinaReportType `json:"REPORT_TYPE"`
+	ReportDateName   string         `json:"REPORT_DATE_NAME"`
+	SecurityTypeCode string         `json:"SECURITY_TYPE_CODE"`
+	NoticeDate       string         `json:"NOTICE_DATE"`
+	UpdateDate       string         `json:"UPDATE_DATE"`
+	Currency         string         `json:"CURRENCY"`
+	// 货币资金
+	Monetaryfunds float64 `json:"MONETARYFUNDS"`
+	// 应收票据及应收账款
+	NoteAccountsRece float64 `json:"NOTE_ACCOUNTS_RECE"`
+	// 应收账款
+	AccountsRece float64 `json:"ACCOUNTS_RECE"`
+	// 存货
+	Inventory float64 `json:"INVENTORY"`
+	// 流动资产合计
+	TotalCurrentAssets float64 `json:"TOTAL_CURRENT_ASSETS"`
+	// 固定资产
+	FixedAsset float64 `json:"FIXED_ASSET"`
+	// 非流动资产合计
+	TotalNoncurrentAssets float64 `json:"TOTAL_NONCURRENT_ASSETS"`
+	// 资产总计
+	TotalAssets float64 `json:"TOTAL_ASSETS"`
+	// 短期借款
+	ShortLoan float64 `json:"SHORT_LOAN"`
+	// 流动负债合计
+	TotalCurrentLiab float64 `json:"TOTAL_CURRENT_LIAB"`
+	// 长期借款
+	LongLoan float64 `json:"LONG_LOAN"`
+	// 应付债券
+	BondPayable float64 `json:"BOND_PAYABLE"`
+	// 非流动负债合计
+	TotalNoncurrentLiab float64 `json:"TOTAL_NONCURRENT_LIAB"`
+	// 负债合计
+	TotalLiabilities float64 `json:"TOTAL_LIABILITIES"`
+	// 实收资本（或股本）
+	ShareCapital float64 `json:"SHARE_CAPITAL"`
+	// 盈余公积
+	SurplusReserve float64 `json:"SURPLUS_RESERVE"`
+	// 未分配利润
+	UnassignRpofit float64 `json:"UNASSIGN_RPOFIT"`
+	// 归属于母公司股东权益总计
+	TotalParentEquity float64 `json:"TOTAL_PARENT_EQUITY"`
+	// 股东权益合计
+	TotalEquity float64 `json:"TOTAL_EQUITY"`
+}
+
+// BalanceDataList 资产负债表列表
+type BalanceDataList []BalanceData
+
+// AsOf 返回截至 date 当天已发布的资产负债表数据
+func (b BalanceDataList) AsOf(ctx context.Context, date time.Time) BalanceDataList {
+	result := BalanceDataList{}
+	for _, i := range b {
+		if isPublishedAsOf(ReportPublishDate(i.NoticeDate, i.ReportDate, i.ReportType), date) {
+			result = append(result, i)
+		}
+	}
+	return result
+}
+
+// GetReport 获取指定报告期的资产负债表，不存在时返回 nil
+func (b BalanceDataList) GetReport(ctx context.Context, reportDate string) *BalanceData {
+	for _, i := range b {
+		if i.ReportDate == reportDate {
+			return &i
+		}
+	}
+	return nil
+}
+
+// RespFinaBalanceData 资产负债表接口返回数据
+type RespFinaBalanceData struct {
+	Version string `json:"version"`
+	Result  struct {
+		Pages int             `json:"pages"`
+		Data  BalanceDataList `json:"data"`
+		Count int             `json:"count"`
+	} `json:"result"`
+	Success bool   `json:"success"`
+	Message string `json:"message"`
+	Code    int    `json:"code"`
+}
+
+// QueryFinaBalanceData 获取财务分析资产负债表数据，最新数据在最前面
+func (e EastMoney) QueryFinaBalanceData(ctx context.Context, secuCode string) (BalanceDataList, error) {
+	apiurl := "https://datacenter.eastmoney.com/securities/api/data/get"
+	params := map[string]string{
+		"source": "HSF10",
+		"client": "APP",
+		"type":   "RPT_F10_FINANCE_GBALANCE",
+		"sty":    "APP_F10_GBALANCE",
+		"filter": fmt.Sprintf(`(SECUCODE="%s")`, strings.ToUpper(secuCode)),
+		"ps":     "40", // 按季度返回，40 期覆盖近 10 年年报，杜邦分析需要各年年报
+		"sr":     "-1",
+		"st":     "REPORT_DATE",
+	}
+	logging.Debug(ctx, "EastMoney QueryFinaBalanceData "+apiurl+" begin", zap.Any("params", params))
+	beginTime := time.Now()
+	apiurl, err := goutils.NewHTTPGetURLWithQueryString(ctx, apiurl, params)
+	if err != nil {
+		return nil, err
+	}
+	resp := RespFinaBalanceData{}
+	err = goutils.HTTPGET(ctx, e.HTTPClient, apiurl, nil, &resp)
+	latency := time.Now().Sub(beginTime).Milliseconds()
+	logging.Debug(
+		ctx,
+		"EastMoney QueryFinaBalanceData "+apiurl+" end",
+		zap.Int64("latency(ms)", latency),
+		// zap.Any("resp", resp),
+	)
+	if err != nil {
+		return nil, err
+	}
+	if resp.Code != 0 {
+		return nil, fmt.Errorf("%s %#v", secuCode, resp)
+	}
+	return resp.Result.Data, nil
+}
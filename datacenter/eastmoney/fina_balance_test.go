@@ -0,0 +1,14 @@
+package eastmoney
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestQueryFinaBalanceData(t *testing.T) {
+	data, err := _em.QueryFinaBalanceData(_ctx, "000958.SZ")
+	require.Nil(t, err)
+	require.NotEmpty(t, data)
+	t.Log(data[0].ReportType, data[0].TotalAssets)
+}
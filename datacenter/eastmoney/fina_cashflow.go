@@ -276,6 +276,36 @@ type CashflowData struct {
 // CashflowDataList cashflow 列表
 type CashflowDataList []CashflowData
 
+// AsOf 返回截至 date 当天已发布的现金流量表数据
+func (c CashflowDataList) AsOf(ctx context.Context, date time.Time) CashflowDataList {
+	result := CashflowDataList{}
+	for _, i := range c {
+		if isPublishedAsOf(ReportPublishDate(i.NoticeDate, i.ReportDate, i.ReportType), date) {
+			result = append(result, i)
+		}
+	}
+	return result
+}
+
+// FreeCashflow 自由现金流=经营活动产生的现金流量净额-购建固定资产、无形资产和其他长期资产支付的现金
+func (c CashflowData) FreeCashflow() float64 {
+	return c.NetcashOperate - c.ConstructLongAsset
+}
+
+// YearFreeCashflowList 最近 count 个年报的自由现金流，最新的在最前面
+func (c CashflowDataList) YearFreeCashflowList(ctx context.Context, count int) []float64 {
+	result := []float64{}
+	for _, i := range c {
+		if len(result) >= count {
+			break
+		}
+		if i.ReportType == FinaReportTypeYear {
+			result = append(result, i.FreeCashflow())
+		}
+	}
+	return result
+}
+
 // RespFinaCashflowData 现金流量接口返回数据
 type RespFinaCashflowData struct {
 	Version string `json:"version"`
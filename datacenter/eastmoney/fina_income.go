@@ -223,6 +223,17 @@ type GincomeData struct {
 // GincomeDataList 利润表历史数据
 type GincomeDataList []GincomeData
 
+// AsOf 返回截至 date 当天已发布的利润表数据
+func (g GincomeDataList) AsOf(ctx context.Context, date time.Time) GincomeDataList {
+	result := GincomeDataList{}
+	for _, i := range g {
+		if isPublishedAsOf(ReportPublishDate(i.NoticeDate, i.ReportDate, i.ReportType), date) {
+			result = append(result, i)
+		}
+	}
+	return result
+}
+
 // RespFinaGincomeData 财务分析利润表接口返回结构
 type RespFinaGincomeData struct {
 	Version string `json:"version"`
@@ -245,7 +256,7 @@ func (e EastMoney) QueryFinaGincomeData(ctx context.Context, secuCode string) (G
 		"type":   "RPT_F10_FINANCE_GINCOME",
 		"sty":    "APP_F10_GINCOME",
 		"filter": fmt.Sprintf(`(SECUCODE="%s")`, strings.ToUpper(secuCode)),
-		"ps":     "10",
+		"ps":     "40", // 按季度返回，40 期覆盖近 10 年年报，杜邦分析需要各年年报
 		"sr":     "-1",
 		"st":     "REPORT_DATE",
 	}
@@ -5,6 +5,8 @@ package eastmoney
 import (
 	"context"
 	"fmt"
+	"math"
+	"strconv"
 	"strings"
 	"time"
 
@@ -236,6 +238,76 @@ func (h HistoricalFinaMainData) GetReport(ctx context.Context, reportYear int, r
 	return nil
 }
 
+// parseFinaDate 解析财报接口返回的日期: 2021-04-28 00:00:00
+func parseFinaDate(date string) (time.Time, bool) {
+	if len(date) < 10 {
+		return time.Time{}, false
+	}
+	t, err := time.ParseInLocation("2006-01-02", date[:10], time.Local)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return t, true
+}
+
+// ReportPublishDate 返回财报的发布日期，优先使用实际公告日期，
+// 没有公告日期时按法定披露截止日估算：一季报 4月30日，中报 8月31日，三季报 10月31日，年报次年 4月30日。
+// 日期均无法解析时返回零值
+func ReportPublishDate(noticeDate, reportDate string, reportType FinaReportType) time.Time {
+	if t, ok := parseFinaDate(noticeDate); ok {
+		return t
+	}
+	t, ok := parseFinaDate(reportDate)
+	if !ok {
+		return time.Time{}
+	}
+	year := t.Year()
+	switch reportType {
+	case FinaReportTypeQ1:
+		return time.Date(year, 4, 30, 0, 0, 0, 0, time.Local)
+	case FinaReportTypeMid:
+		return time.Date(year, 8, 31, 0, 0, 0, 0, time.Local)
+	case FinaReportTypeQ3:
+		return time.Date(year, 10, 31, 0, 0, 0, 0, time.Local)
+	case FinaReportTypeYear:
+		return time.Date(year+1, 4, 30, 0, 0, 0, 0, time.Local)
+	}
+	return t
+}
+
+// isPublishedAsOf 发布日期是否不晚于 date 当天
+func isPublishedAsOf(publishDate, date time.Time) bool {
+	y, m, d := date.Date()
+	endOfDay := time.Date(y, m, d, 0, 0, 0, 0, date.Location()).AddDate(0, 0, 1)
+	return publishDate.Before(endOfDay)
+}
+
+// PublishDate 财报发布日期
+func (d FinaMainData) PublishDate() time.Time {
+	return ReportPublishDate(d.NoticeDate, d.ReportDate, d.ReportType)
+}
+
+// AsOf 返回截至 date 当天已发布的财报，用于按历史时间点回看数据，避免使用未来数据
+func (h HistoricalFinaMainData) AsOf(ctx context.Context, date time.Time) HistoricalFinaMainData {
+	result := HistoricalFinaMainData{}
+	for _, i := range h {
+		if isPublishedAsOf(i.PublishDate(), date) {
+			result = append(result, i)
+		}
+	}
+	return result
+}
+
+// LatestYearReport 最近一期已发布的年报
+func (h HistoricalFinaMainData) LatestYearReport(ctx context.Context) *FinaMainData {
+	for _, i := range h {
+		if i.ReportType == FinaReportTypeYear {
+			return &i
+		}
+	}
+	return nil
+}
+
 // CurrentReport 当前最新一期财报
 func (h HistoricalFinaMainData) CurrentReport(ctx context.Context) *FinaMainData {
 	if len(h) > 0 {
@@ -272,6 +344,8 @@ const (
 	ValueListTypeMLL ValueListType = "MLL"
 	// ValueListTypeJLL 净利率
 	ValueListTypeJLL ValueListType = "JLL"
+	// ValueListTypeOCFPS 每股经营现金流
+	ValueListTypeOCFPS ValueListType = "OCFPS"
 )
 
 // FinaValueList 历史数据值列表
@@ -305,30 +379,46 @@ func (h HistoricalFinaMainData) ValueList(
 		data = data[:count]
 	}
 	for _, i := range data {
-		value := float64(-1)
-		switch valueType {
-		case ValueListTypeNetProfit:
-			value = i.Parentnetprofit
-		case ValueListTypeGrossProfit:
-			value = i.Mlr
-		case ValueListTypeRevenue:
-			value = i.Totaloperatereve
-		case ValueListTypeEPS:
-			value = i.Epsjb
-		case ValueListTypeROA:
-			value = i.Zzcjll
-		case ValueListTypeROE:
-			value = i.Roejq
-		case ValueListTypeMLL:
-			value = i.Xsmll
-		case ValueListTypeJLL:
-			value = i.Xsjll
-		}
-		r = append(r, value)
+		r = append(r, i.Value(valueType))
 	}
 	return r
 }
 
+// Value 返回指定类型的数据值，不支持的类型返回 -1
+func (d FinaMainData) Value(valueType ValueListType) float64 {
+	switch valueType {
+	case ValueListTypeNetProfit:
+		return d.Parentnetprofit
+	case ValueListTypeGrossProfit:
+		return d.Mlr
+	case ValueListTypeRevenue:
+		return d.Totaloperatereve
+	case ValueListTypeEPS:
+		return d.Epsjb
+	case ValueListTypeROA:
+		return d.Zzcjll
+	case ValueListTypeROE:
+		return d.Roejq
+	case ValueListTypeMLL:
+		return d.Xsmll
+	case ValueListTypeJLL:
+		return d.Xsjll
+	case ValueListTypeOCFPS:
+		return d.Mgjyxjje
+	}
+	return -1
+}
+
+// IsIncreasing 数据（最新的在最前面）是否逐期递增
+func (fvl FinaValueList) IsIncreasing() bool {
+	for i := 0; i < len(fvl)-1; i++ {
+		if fvl[i] <= fvl[i+1] {
+			return false
+		}
+	}
+	return true
+}
+
 // IsIncreasingByYears roe/eps/revenue/profit 是否逐年递增
 func (h HistoricalFinaMainData) IsIncreasingByYears(
 	ctx context.Context,
@@ -336,15 +426,7 @@ func (h HistoricalFinaMainData) IsIncreasingByYears(
 	yearsCount int,
 	reportType FinaReportType,
 ) bool {
-	data := h.ValueList(ctx, valueType, yearsCount, reportType)
-	increasing := true
-	for i := 0; i < len(data)-1; i++ {
-		if data[i] <= data[i+1] {
-			increasing = false
-			break
-		}
-	}
-	return increasing
+	return h.ValueList(ctx, valueType, yearsCount, reportType).IsIncreasing()
 }
 
 // IsStability 数据是否稳定（标准差在 1 以内）
@@ -421,6 +503,24 @@ func (h HistoricalFinaMainData) GetAvgParentNetprofitIncreasingRatioByYear(ctx c
 	return sum / float64(dlen)
 }
 
+// ParentNetprofitCAGR 最近一期年报相对 years 年前年报的归属净利润复合增长率 (%)，
+// 缺少对应年报或净利润不为正时无法计算
+func (h HistoricalFinaMainData) ParentNetprofitCAGR(ctx context.Context, years int) (float64, bool) {
+	latest := h.LatestYearReport(ctx)
+	if latest == nil || years <= 0 {
+		return 0, false
+	}
+	year, err := strconv.Atoi(latest.ReportYear)
+	if err != nil {
+		return 0, false
+	}
+	base := h.GetReport(ctx, year-years, FinaReportTypeYear)
+	if base == nil || base.Parentnetprofit <= 0 || latest.Parentnetprofit <= 0 {
+		return 0, false
+	}
+	return (math.Pow(latest.Parentnetprofit/base.Parentnetprofit, 1/float64(years)) - 1) * 100, true
+}
+
 // RespFinaMainData 接口返回 json 结构
 type RespFinaMainData struct {
 	Version string `json:"version"`
@@ -30,3 +30,43 @@ func TestQueryFinaPublishDateList(t *testing.T) {
 	require.Nil(t, err)
 	t.Log("pubdate:", date)
 }
+
+func TestHistoricalFinaMainDataAsOf(t *testing.T) {
+	data := HistoricalFinaMainData{
+		{ReportType: FinaReportTypeQ1, ReportYear: "2022", ReportDate: "2022-03-31 00:00:00", NoticeDate: "2022-04-26 00:00:00"},
+		{ReportType: FinaReportTypeYear, ReportYear: "2021", ReportDate: "2021-12-31 00:00:00", NoticeDate: "2022-03-30 00:00:00"},
+		{ReportType: FinaReportTypeQ3, ReportYear: "2021", ReportDate: "2021-09-30 00:00:00"},
+		{ReportType: FinaReportTypeYear, ReportYear: "2020", ReportDate: "2020-12-31 00:00:00", NoticeDate: "2021-03-29 00:00:00"},
+	}
+	date := time.Date(2022, 3, 30, 0, 0, 0, 0, time.Local)
+	require.Equal(t, date, data[1].PublishDate())
+	// 没有公告日期时按披露截止日估算
+	require.Equal(t, time.Date(2021, 10, 31, 0, 0, 0, 0, time.Local), data[2].PublishDate())
+	require.Equal(t, time.Date(2022, 4, 30, 0, 0, 0, 0, time.Local), ReportPublishDate("", "2021-12-31 00:00:00", FinaReportTypeYear))
+
+	asOf := data.AsOf(_ctx, date)
+	require.Len(t, asOf, 3)
+	require.Equal(t, "2021", asOf.LatestYearReport(_ctx).ReportYear)
+	asOf = data.AsOf(_ctx, date.AddDate(0, 0, -1))
+	require.Len(t, asOf, 2)
+	require.Equal(t, "2020", asOf.LatestYearReport(_ctx).ReportYear)
+	require.Empty(t, data.AsOf(_ctx, time.Date(2021, 1, 1, 0, 0, 0, 0, time.Local)))
+}
+
+func TestParentNetprofitCAGR(t *testing.T) {
+	data := HistoricalFinaMainData{
+		{ReportType: FinaReportTypeQ1, ReportYear: "2022", Parentnetprofit: 1},
+		{ReportType: FinaReportTypeYear, ReportYear: "2021", Parentnetprofit: 800},
+		{ReportType: FinaReportTypeYear, ReportYear: "2020", Parentnetprofit: 400},
+		{ReportType: FinaReportTypeYear, ReportYear: "2019", Parentnetprofit: 200},
+		{ReportType: FinaReportTypeYear, ReportYear: "2018", Parentnetprofit: 100},
+	}
+	v, ok := data.ParentNetprofitCAGR(_ctx, 3)
+	require.True(t, ok)
+	require.InDelta(t, 100, v, 1e-9)
+	_, ok = data.ParentNetprofitCAGR(_ctx, 4)
+	require.False(t, ok)
+	data[4].Parentnetprofit = -1
+	_, ok = data.ParentNetprofitCAGR(_ctx, 3)
+	require.False(t, ok)
+}
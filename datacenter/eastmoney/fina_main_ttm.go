@@ -0,0 +1,215 @@
+// 单季度及滚动四季度（TTM）数据
+
+package eastmoney
+
+import (
+	"context"
+	"strconv"
+)
+
+// Quarter 财报类型对应的季度：一季报 1，中报 2，三季报 3，年报 4，未知类型返回 0
+func (t FinaReportType) Quarter() int {
+	switch t {
+	case FinaReportTypeQ1:
+		return 1
+	case FinaReportTypeMid:
+		return 2
+	case FinaReportTypeQ3:
+		return 3
+	case FinaReportTypeYear:
+		return 4
+	}
+	return 0
+}
+
+// quarterReportTypes 季度对应的财报类型
+var quarterReportTypes = map[int]FinaReportType{
+	1: FinaReportTypeQ1,
+	2: FinaReportTypeMid,
+	3: FinaReportTypeQ3,
+	4: FinaReportTypeYear,
+}
+
+// FinaPeriodValue 某一报告期的派生数据
+type FinaPeriodValue struct {
+	// 报告期
+	ReportDate string `json:"report_date"`
+	// 财报年份
+	ReportYear int `json:"report_year"`
+	// 季度 1-4
+	Quarter int `json:"quarter"`
+	// 数据值
+	Value float64 `json:"value"`
+}
+
+// FinaPeriodValueList 派生数据列表，最新的在最前面
+type FinaPeriodValueList []FinaPeriodValue
+
+// Values 返回数据值列表
+func (l FinaPeriodValueList) Values() FinaValueList {
+	r := FinaValueList{}
+	for _, i := range l {
+		r = append(r, i.Value)
+	}
+	return r
+}
+
+// isAdditiveValueType 可按季度累加的数据类型
+func isAdditiveValueType(valueType ValueListType) bool {
+	switch valueType {
+	case ValueListTypeNetProfit, ValueListTypeGrossProfit, ValueListTypeRevenue, ValueListTypeEPS, ValueListTypeOCFPS:
+		return true
+	}
+	return false
+}
+
+// cumulative 返回指定年份季度的累计数据（年初至今），不存在时 ok 为 false
+func (h HistoricalFinaMainData) cumulative(year, quarter int, valueType ValueListType) (float64, bool) {
+	if quarter == 0 {
+		return 0, true
+	}
+	reportType, exists := quarterReportTypes[quarter]
+	if !exists {
+		return 0, false
+	}
+	r := h.GetReport(context.Background(), year, reportType)
+	if r == nil {
+		return 0, false
+	}
+	return r.Value(valueType), true
+}
+
+// rolling 按累计数据计算 [year, quarter] 往前 n 个季度的累计值，n 为 1 时是单季度值，n 为 4 时是 TTM 值
+func (h HistoricalFinaMainData) rolling(year, quarter int, valueType ValueListType, n int) (float64, bool) {
+	cur, ok := h.cumulative(year, quarter, valueType)
+	if !ok {
+		return 0, false
+	}
+	if quarter >= n {
+		prev, ok := h.cumulative(year, quarter-n, valueType)
+		if !ok {
+			return 0, false
+		}
+		return cur - prev, true
+	}
+	// 跨年：本年累计 + 上年年报 - 上年同期累计
+	lastYear, ok := h.cumulative(year-1, 4, valueType)
+	if !ok {
+		return 0, false
+	}
+	lastYearSame, ok := h.cumulative(year-1, quarter+4-n, valueType)
+	if !ok {
+		return 0, false
+	}
+	return cur + lastYear - lastYearSame, true
+}
+
+// periodValue 计算 [year, quarter] 往前 n 个季度的数据，毛利率和净利率按对应期间的毛利润、净利润与营收计算
+func (h HistoricalFinaMainData) periodValue(year, quarter int, valueType ValueListType, n int) (float64, bool) {
+	switch valueType {
+	case ValueListTypeMLL, ValueListTypeJLL:
+		numType := ValueListTypeGrossProfit
+		if valueType == ValueListTypeJLL {
+			numType = ValueListTypeNetProfit
+		}
+		num, ok := h.rolling(year, quarter, numType, n)
+		if !ok {
+			return 0, false
+		}
+		revenue, ok := h.rolling(year, quarter, ValueListTypeRevenue, n)
+		if !ok || revenue == 0 {
+			return 0, false
+		}
+		return num / revenue * 100, true
+	}
+	if !isAdditiveValueType(valueType) {
+		return 0, false
+	}
+	return h.rolling(year, quarter, valueType, n)
+}
+
+// periodValueList 按报告期从新到旧计算最多 count 个派生数据，数据缺失的报告期会被跳过
+func (h HistoricalFinaMainData) periodValueList(ctx context.Context, valueType ValueListType, count int, n int) FinaPeriodValueList {
+	r := FinaPeriodValueList{}
+	for _, i := range h {
+		if len(r) >= count {
+			break
+		}
+		year, err := strconv.Atoi(i.ReportYear)
+		if err != nil {
+			continue
+		}
+		quarter := i.ReportType.Quarter()
+		if quarter == 0 {
+			continue
+		}
+		value, ok := h.periodValue(year, quarter, valueType, n)
+		if !ok {
+			continue
+		}
+		r = append(r, FinaPeriodValue{
+			ReportDate: i.ReportDate,
+			ReportYear: year,
+			Quarter:    quarter,
+			Value:      value,
+		})
+	}
+	return r
+}
+
+// QuarterValueList 获取最近 count 个报告期的单季度数据，支持营收、毛利润、净利润、EPS、每股经营现金流、毛利率、净利率
+func (h HistoricalFinaMainData) QuarterValueList(ctx context.Context, valueType ValueListType, count int) FinaPeriodValueList {
+	return h.periodValueList(ctx, valueType, count, 1)
+}
+
+// TTMValueList 获取最近 count 个报告期的滚动四季度（TTM）数据，支持的数据类型同 QuarterValueList
+func (h HistoricalFinaMainData) TTMValueList(ctx context.Context, valueType ValueListType, count int) FinaPeriodValueList {
+	return h.periodValueList(ctx, valueType, count, 4)
+}
+
+// TTMValue 最新一期的 TTM 数据，无法计算时 ok 为 false
+func (h HistoricalFinaMainData) TTMValue(ctx context.Context, valueType ValueListType) (float64, bool) {
+	l := h.TTMValueList(ctx, valueType, 1)
+	if len(l) == 0 {
+		return 0, false
+	}
+	return l[0].Value, true
+}
+
+// TTMValueListByYears 获取最新一期财报往前 yearsCount 年同一季度的 TTM 数据，用于不等年报发布即可检测增长
+func (h HistoricalFinaMainData) TTMValueListByYears(ctx context.Context, valueType ValueListType, yearsCount int) FinaPeriodValueList {
+	r := FinaPeriodValueList{}
+	if len(h) == 0 {
+		return r
+	}
+	year, err := strconv.Atoi(h[0].ReportYear)
+	if err != nil {
+		return r
+	}
+	quarter := h[0].ReportType.Quarter()
+	if quarter == 0 {
+		return r
+	}
+	for y := year; y > year-yearsCount; y-- {
+		value, ok := h.periodValue(y, quarter, valueType, 4)
+		if !ok {
+			break
+		}
+		r = append(r, FinaPeriodValue{
+			ReportDate: h.GetReport(ctx, y, quarterReportTypes[quarter]).ReportDate,
+			ReportYear: y,
+			Quarter:    quarter,
+			Value:      value,
+		})
+	}
+	return r
+}
+
+// TTMYoY 最新一期 TTM 数据同比增长率（%），无法计算时 ok 为 false
+func (h HistoricalFinaMainData) TTMYoY(ctx context.Context, valueType ValueListType) (float64, bool) {
+	l := h.TTMValueListByYears(ctx, valueType, 2)
+	if len(l) < 2 || l[1].Value <= 0 {
+		return 0, false
+	}
+	return (l[0].Value - l[1].Value) / l[1].Value * 100, true
+}
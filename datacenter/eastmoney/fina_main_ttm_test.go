@@ -0,0 +1,47 @@
+package eastmoney
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestHistoricalFinaMainDataTTM(t *testing.T) {
+	// 累计数据：2020 每季度营收 10，2021 每季度营收 20
+	data := HistoricalFinaMainData{
+		{ReportType: FinaReportTypeMid, ReportYear: "2021", ReportDate: "2021-06-30 00:00:00", Totaloperatereve: 40, Parentnetprofit: 8, Mlr: 20, Epsjb: 0.4},
+		{ReportType: FinaReportTypeQ1, ReportYear: "2021", ReportDate: "2021-03-31 00:00:00", Totaloperatereve: 20, Parentnetprofit: 4, Mlr: 10, Epsjb: 0.2},
+		{ReportType: FinaReportTypeYear, ReportYear: "2020", ReportDate: "2020-12-31 00:00:00", Totaloperatereve: 40, Parentnetprofit: 4, Mlr: 12, Epsjb: 0.4},
+		{ReportType: FinaReportTypeQ3, ReportYear: "2020", ReportDate: "2020-09-30 00:00:00", Totaloperatereve: 30, Parentnetprofit: 3, Mlr: 9, Epsjb: 0.3},
+		{ReportType: FinaReportTypeMid, ReportYear: "2020", ReportDate: "2020-06-30 00:00:00", Totaloperatereve: 20, Parentnetprofit: 2, Mlr: 6, Epsjb: 0.2},
+		{ReportType: FinaReportTypeQ1, ReportYear: "2020", ReportDate: "2020-03-31 00:00:00", Totaloperatereve: 10, Parentnetprofit: 1, Mlr: 3, Epsjb: 0.1},
+	}
+
+	q := data.QuarterValueList(_ctx, ValueListTypeRevenue, 10)
+	require.Equal(t, FinaValueList{20, 20, 10, 10, 10, 10}, q.Values())
+	require.Equal(t, 2, q[0].Quarter)
+
+	ttm := data.TTMValueList(_ctx, ValueListTypeRevenue, 10)
+	// 2021 中报: 40 + 40 - 20, 2021 一季报: 20 + 40 - 10, 2020 年报: 40，更早的缺少上年数据
+	require.Equal(t, FinaValueList{60, 50, 40}, ttm.Values())
+
+	v, ok := data.TTMValue(_ctx, ValueListTypeEPS)
+	require.True(t, ok)
+	require.InDelta(t, 0.6, v, 1e-9)
+
+	jll, ok := data.TTMValue(_ctx, ValueListTypeJLL)
+	require.True(t, ok)
+	require.InDelta(t, 10.0/60*100, jll, 1e-9)
+
+	qmll := data.QuarterValueList(_ctx, ValueListTypeMLL, 1)
+	require.InDelta(t, 50, qmll[0].Value, 1e-9)
+
+	// 2020 中报的 TTM 缺少 2019 数据
+	byYears := data.TTMValueListByYears(_ctx, ValueListTypeRevenue, 3)
+	require.Len(t, byYears, 1)
+	_, ok = data.TTMYoY(_ctx, ValueListTypeRevenue)
+	require.False(t, ok)
+
+	_, ok = data.TTMValue(_ctx, ValueListTypeROE)
+	require.False(t, ok)
+}
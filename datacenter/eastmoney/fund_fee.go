@@ -0,0 +1,128 @@
+// 天天基金获取基金费率：申购费率、赎回费率及管理费、托管费、销售服务费
+
+package eastmoney
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/axiaoxin-com/goutils"
+	"github.com/axiaoxin-com/logging"
+	"github.com/corpix/uarand"
+	"go.uber.org/zap"
+)
+
+// FundFeeRow 费率表中的一行，均为页面原始文本
+type FundFeeRow struct {
+	// 适用金额，如: 小于100万元、大于等于500万元、---
+	Amount string `json:"amount"`
+	// 适用期限，如: 小于7天、大于等于7天，小于1年、---
+	Period string `json:"period"`
+	// 费率，如: 1.50%、1.50% | 0.15% | 0.15%、每笔1000元
+	Rate string `json:"rate"`
+}
+
+// FundFeeInfo 基金费率页面原始数据
+type FundFeeInfo struct {
+	// 管理费率，如: 1.50%（每年）
+	ManagementRate string `json:"management_rate"`
+	// 托管费率，如: 0.25%（每年）
+	CustodyRate string `json:"custody_rate"`
+	// 销售服务费率，如: 0.40%（每年）、---（每年）
+	SalesServiceRate string `json:"sales_service_rate"`
+	// 申购费率（前端），费率列为 原费率|天天基金优惠费率
+	Subscription []FundFeeRow `json:"subscription"`
+	// 赎回费率
+	Redemption []FundFeeRow `json:"redemption"`
+}
+
+var (
+	fundFeeTableRegexp = regexp.MustCompile(`(?s)<table.*?</table>`)
+	fundFeeRowRegexp   = regexp.MustCompile(`(?s)<tr.*?</tr>`)
+	fundFeeCellRegexp  = regexp.MustCompile(`(?s)<td.*?>(.*?)</td>`)
+	fundFeeTagRegexp   = regexp.MustCompile(`(?s)<.*?>`)
+	fundFeeSpaceRegexp = regexp.MustCompile(`\s+`)
+)
+
+// fundFeeCellText 去除单元格中的标签及多余空白
+func fundFeeCellText(cell string) string {
+	text := html.UnescapeString(fundFeeTagRegexp.ReplaceAllString(cell, ""))
+	text = strings.ReplaceAll(text, "\u00a0", " ")
+	return strings.TrimSpace(fundFeeSpaceRegexp.ReplaceAllString(text, " "))
+}
+
+// fundFeeTableRows 返回标题 title 之后第一个表格的各行单元格文本，跳过表头
+func fundFeeTableRows(page, title string) [][]string {
+	idx := strings.Index(page, title)
+	if idx < 0 {
+		return nil
+	}
+	table := fundFeeTableRegexp.FindString(page[idx:])
+	rows := [][]string{}
+	for _, tr := range fundFeeRowRegexp.FindAllString(table, -1) {
+		cells := []string{}
+		for _, m := range fundFeeCellRegexp.FindAllStringSubmatch(tr, -1) {
+			cells = append(cells, fundFeeCellText(m[1]))
+		}
+		if len(cells) > 0 {
+			rows = append(rows, cells)
+		}
+	}
+	return rows
+}
+
+// parseFundFeePage 解析基金费率页面
+func parseFundFeePage(page string) FundFeeInfo {
+	info := FundFeeInfo{Subscription: []FundFeeRow{}, Redemption: []FundFeeRow{}}
+	// 运作费用为一行: 管理费率 x 托管费率 x 销售服务费率 x
+	for _, cells := range fundFeeTableRows(page, "运作费用") {
+		for i := 0; i+1 < len(cells); i += 2 {
+			switch cells[i] {
+			case "管理费率":
+				info.ManagementRate = cells[i+1]
+			case "托管费率":
+				info.CustodyRate = cells[i+1]
+			case "销售服务费率":
+				info.SalesServiceRate = cells[i+1]
+			}
+		}
+	}
+	for _, cells := range fundFeeTableRows(page, "申购费率（前端）") {
+		if len(cells) == 3 {
+			info.Subscription = append(info.Subscription, FundFeeRow{Amount: cells[0], Period: cells[1], Rate: cells[2]})
+		}
+	}
+	for _, cells := range fundFeeTableRows(page, "赎回费率") {
+		if len(cells) == 3 {
+			info.Redemption = append(info.Redemption, FundFeeRow{Amount: cells[0], Period: cells[1], Rate: cells[2]})
+		}
+	}
+	return info
+}
+
+// QueryFundFee 查询基金费率
+func (e EastMoney) QueryFundFee(ctx context.Context, fundCode string) (*FundFeeInfo, error) {
+	apiurl := fmt.Sprintf("https://fundf10.eastmoney.com/jjfl_%s.html", fundCode)
+	logging.Debug(ctx, "EastMoney QueryFundFee "+apiurl+" begin")
+	beginTime := time.Now()
+	header := map[string]string{
+		"user-agent": uarand.GetRandom(),
+	}
+	resp, err := goutils.HTTPGETRaw(ctx, e.HTTPClient, apiurl, header)
+	latency := time.Now().Sub(beginTime).Milliseconds()
+	logging.Debug(ctx, "EastMoney QueryFundFee "+apiurl+" end",
+		zap.Int64("latency(ms)", latency),
+	)
+	if err != nil {
+		return nil, err
+	}
+	info := parseFundFeePage(string(resp))
+	if info.ManagementRate == "" && len(info.Subscription) == 0 && len(info.Redemption) == 0 {
+		return nil, fmt.Errorf("无法获取基金费率(%v)", fundCode)
+	}
+	return &info, nil
+}
@@ -0,0 +1,42 @@
+package eastmoney
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const fundFeePageSample = `
+<h4 class="t"><label class="left">运作费用</label></h4>
+<table class="w770 comm jjfl"><tbody><tr><td class="th w110">管理费率</td><td class="w135">1.50%（每年）</td><td class="th w110">托管费率</td><td class="w135">0.25%（每年）</td><td class="th w110">销售服务费率</td><td class="w135">---（每年）</td></tr></tbody></table>
+<h4 class="t"><label class="left">申购费率（前端）</label></h4>
+<table class="w650 comm jjfl"><thead><tr><th class="first">适用金额</th><th>适用期限</th><th class="w300">原费率|天天基金优惠费率</th></tr></thead>
+<tbody><tr><td class="th">小于100万元</td><td>---</td><td class="w300"><strike class='gray'>1.50%</strike>&nbsp;&nbsp;|&nbsp;&nbsp;0.15%&nbsp;&nbsp;|&nbsp;&nbsp;0.15%</td></tr>
+<tr><td class="th">大于等于500万元</td><td>---</td><td class="w300">每笔1000元</td></tr></tbody></table>
+<h4 class="t"><label class="left">赎回费率</label></h4>
+<table class="w650 comm jjfl"><thead><tr><th class="first">适用金额</th><th>适用期限</th><th>赎回费率</th></tr></thead>
+<tbody><tr><td class="th">---</td><td>小于7天</td><td>1.50%</td></tr>
+<tr><td class="th">---</td><td>大于等于7天，小于1年</td><td>0.50%</td></tr></tbody></table>
+`
+
+func TestParseFundFeePage(t *testing.T) {
+	info := parseFundFeePage(fundFeePageSample)
+	require.Equal(t, "1.50%（每年）", info.ManagementRate)
+	require.Equal(t, "0.25%（每年）", info.CustodyRate)
+	require.Equal(t, "---（每年）", info.SalesServiceRate)
+	require.Equal(t, []FundFeeRow{
+		{Amount: "小于100万元", Period: "---", Rate: "1.50% | 0.15% | 0.15%"},
+		{Amount: "大于等于500万元", Period: "---", Rate: "每笔1000元"},
+	}, info.Subscription)
+	require.Equal(t, []FundFeeRow{
+		{Amount: "---", Period: "小于7天", Rate: "1.50%"},
+		{Amount: "---", Period: "大于等于7天，小于1年", Rate: "0.50%"},
+	}, info.Redemption)
+}
+
+func TestQueryFundFee(t *testing.T) {
+	info, err := _em.QueryFundFee(_ctx, "161725")
+	require.Nil(t, err)
+	require.NotEmpty(t, info.ManagementRate)
+	t.Log(info)
+}
@@ -0,0 +1,137 @@
+// 天天基金获取基金历史净值
+
+package eastmoney
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/axiaoxin-com/goutils"
+	"github.com/axiaoxin-com/logging"
+	"github.com/corpix/uarand"
+	"go.uber.org/zap"
+)
+
+// FundNet 基金单日净值
+type FundNet struct {
+	// 净值日期: 2021-06-30
+	Date string `json:"date"`
+	// 单位净值
+	Nav float64 `json:"nav"`
+	// 累计净值
+	AccNav float64 `json:"acc_nav"`
+	// 日增长率（%）
+	ChangeRatio float64 `json:"change_ratio"`
+	// 每份派现金（元），当日为除息日
+	Dividend float64 `json:"dividend"`
+	// 份额折算/拆分比例，每份折算为多少份，无折算时为 0
+	SplitRatio float64 `json:"split_ratio"`
+	// 分红送配描述
+	DividendDesc string `json:"dividend_desc"`
+}
+
+// FundNetHistory 基金历史净值，最早的在最前面
+type FundNetHistory []FundNet
+
+// RespFundNetHistory 基金历史净值接口返回结构
+type RespFundNetHistory struct {
+	Data struct {
+		LSJZList []struct {
+			// 净值日期
+			Fsrq string `json:"FSRQ"`
+			// 单位净值
+			Dwjz string `json:"DWJZ"`
+			// 累计净值
+			Ljjz string `json:"LJJZ"`
+			// 日增长率
+			Jzzzl string `json:"JZZZL"`
+			// 分红送配描述，如: 每份派现金0.0100元
+			Fhsp string `json:"FHSP"`
+		} `json:"LSJZList"`
+	} `json:"Data"`
+	ErrCode    int    `json:"ErrCode"`
+	ErrMsg     string `json:"ErrMsg"`
+	TotalCount int    `json:"TotalCount"`
+	PageSize   int    `json:"PageSize"`
+	PageIndex  int    `json:"PageIndex"`
+}
+
+var (
+	fundDividendRegexp = regexp.MustCompile(`派现金([\d.]+)元`)
+	fundSplitRegexp    = regexp.MustCompile(`(?:折算|分拆)([\d.]+)份`)
+)
+
+// parseFundDividendDesc 解析分红送配描述，返回每份派现金及份额折算比例
+func parseFundDividendDesc(desc string) (dividend, splitRatio float64) {
+	if m := fundDividendRegexp.FindStringSubmatch(desc); len(m) == 2 {
+		dividend, _ = strconv.ParseFloat(m[1], 64)
+	}
+	if m := fundSplitRegexp.FindStringSubmatch(desc); len(m) == 2 {
+		splitRatio, _ = strconv.ParseFloat(m[1], 64)
+	}
+	return
+}
+
+// fundNetHistoryPageSize 基金历史净值接口每页条数
+const fundNetHistoryPageSize = 20
+
+// QueryFundNetHistory 获取基金历史净值，startDate/endDate 格式: 2021-06-30，为空时不限制
+func (e EastMoney) QueryFundNetHistory(ctx context.Context, fundCode, startDate, endDate string) (FundNetHistory, error) {
+	apiurl := "https://api.fund.eastmoney.com/f10/lsjz"
+	header := map[string]string{
+		"user-agent": uarand.GetRandom(),
+		"referer":    "https://fundf10.eastmoney.com/",
+	}
+	result := FundNetHistory{}
+	beginTime := time.Now()
+	for pageIndex := 1; ; pageIndex++ {
+		params := map[string]string{
+			"fundCode":  fundCode,
+			"pageIndex": fmt.Sprint(pageIndex),
+			"pageSize":  fmt.Sprint(fundNetHistoryPageSize),
+			"startDate": startDate,
+			"endDate":   endDate,
+		}
+		logging.Debug(ctx, "EastMoney QueryFundNetHistory "+apiurl+" begin", zap.Any("params", params))
+		reqURL, err := goutils.NewHTTPGetURLWithQueryString(ctx, apiurl, params)
+		if err != nil {
+			return nil, err
+		}
+		resp := RespFundNetHistory{}
+		if err := goutils.HTTPGET(ctx, e.HTTPClient, reqURL, header, &resp); err != nil {
+			return nil, err
+		}
+		if resp.ErrCode != 0 {
+			return nil, fmt.Errorf("QueryFundNetHistory rsp code error, rsp:%+v", resp)
+		}
+		for _, i := range resp.Data.LSJZList {
+			nav, err := strconv.ParseFloat(i.Dwjz, 64)
+			if err != nil {
+				logging.Error(ctx, "QueryFundNetHistory ParseFloat error:"+err.Error())
+				continue
+			}
+			net := FundNet{Date: i.Fsrq, Nav: nav, DividendDesc: i.Fhsp}
+			net.AccNav, _ = strconv.ParseFloat(i.Ljjz, 64)
+			net.ChangeRatio, _ = strconv.ParseFloat(strings.TrimSuffix(i.Jzzzl, "%"), 64)
+			net.Dividend, net.SplitRatio = parseFundDividendDesc(i.Fhsp)
+			result = append(result, net)
+		}
+		if len(resp.Data.LSJZList) == 0 || pageIndex*fundNetHistoryPageSize >= resp.TotalCount {
+			break
+		}
+	}
+	latency := time.Now().Sub(beginTime).Milliseconds()
+	logging.Debug(ctx, "EastMoney QueryFundNetHistory "+apiurl+" end", zap.Int64("latency(ms)", latency), zap.Int("count", len(result)))
+	if len(result) == 0 {
+		return nil, fmt.Errorf("无法获取基金历史净值(%v)", fundCode)
+	}
+	// 接口返回最新的在最前面
+	for i, j := 0, len(result)-1; i < j; i, j = i+1, j-1 {
+		result[i], result[j] = result[j], result[i]
+	}
+	return result, nil
+}
@@ -0,0 +1,27 @@
+package eastmoney
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseFundDividendDesc(t *testing.T) {
+	dividend, split := parseFundDividendDesc("每份派现金0.0100元")
+	require.Equal(t, 0.01, dividend)
+	require.Equal(t, 0.0, split)
+	dividend, split = parseFundDividendDesc("每份基金份额折算1.0263份")
+	require.Equal(t, 0.0, dividend)
+	require.Equal(t, 1.0263, split)
+	dividend, split = parseFundDividendDesc("")
+	require.Equal(t, 0.0, dividend)
+	require.Equal(t, 0.0, split)
+}
+
+func TestQueryFundNetHistory(t *testing.T) {
+	data, err := _em.QueryFundNetHistory(_ctx, "161725", "2021-01-01", "2021-03-01")
+	require.Nil(t, err)
+	require.NotEmpty(t, data)
+	require.True(t, data[0].Date < data[len(data)-1].Date)
+	t.Log(data[0], data[len(data)-1])
+}
@@ -0,0 +1,201 @@
+// 场内基金实时行情、历史收盘价及盘中估算净值
+
+package eastmoney
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/axiaoxin-com/goutils"
+	"github.com/axiaoxin-com/logging"
+	"github.com/corpix/uarand"
+	"go.uber.org/zap"
+)
+
+// FundSecid 返回场内基金的 secid，深交所 15、16、18 开头为 0.代码，上交所 50、51、52、56、58 开头为 1.代码，非场内基金代码返回空字符串
+func FundSecid(code string) string {
+	if len(code) != 6 {
+		return ""
+	}
+	switch code[:2] {
+	case "15", "16", "18":
+		return "0." + code
+	case "50", "51", "52", "56", "58":
+		return "1." + code
+	}
+	return ""
+}
+
+// IndexSecid 返回指数的 secid，399 开头为深证指数 0.代码，000 开头为上证及中证指数 1.代码，其余中证指数为 2.代码
+func IndexSecid(code string) string {
+	code = strings.TrimSpace(code)
+	switch {
+	case code == "" || code == "--":
+		return ""
+	case strings.HasPrefix(code, "399"):
+		return "0." + code
+	case strings.HasPrefix(code, "000"):
+		return "1." + code
+	}
+	return "2." + code
+}
+
+// QueryFundPriceKline 获取场内基金不复权历史收盘价，beg/end 格式: 20210630
+func (e EastMoney) QueryFundPriceKline(ctx context.Context, code, beg, end string) (IndexKlineList, error) {
+	secid := FundSecid(code)
+	if secid == "" {
+		return nil, fmt.Errorf("%s 不是场内基金代码", code)
+	}
+	return e.queryKline(ctx, secid, beg, end, "0")
+}
+
+// FundQuote 场内基金实时行情
+type FundQuote struct {
+	// 基金代码
+	Code string `json:"code"`
+	// 基金名称
+	Name string `json:"name"`
+	// 最新价，停牌或无成交时为 0
+	Price float64 `json:"price"`
+	// 昨收价
+	PreClose float64 `json:"pre_close"`
+	// 涨跌幅（%）
+	ChangeRatio float64 `json:"change_ratio"`
+	// 行情时间: 2021-06-30 15:00:00
+	Time string `json:"time"`
+}
+
+// RespFundQuote 实时行情接口返回结构，fltt=2 时价格为小数，无数据时为 -
+type RespFundQuote struct {
+	Rc   int `json:"rc"`
+	Data *struct {
+		// 最新价
+		F43 interface{} `json:"f43"`
+		// 代码
+		F57 string `json:"f57"`
+		// 名称
+		F58 string `json:"f58"`
+		// 昨收
+		F60 interface{} `json:"f60"`
+		// 行情时间戳
+		F86 interface{} `json:"f86"`
+		// 涨跌幅
+		F170 interface{} `json:"f170"`
+	} `json:"data"`
+}
+
+// quoteFloat 行情字段转 float64，无数据时为 0
+func quoteFloat(v interface{}) float64 {
+	switch i := v.(type) {
+	case float64:
+		return i
+	case string:
+		f, _ := strconv.ParseFloat(i, 64)
+		return f
+	}
+	return 0
+}
+
+// QueryFundQuote 获取场内基金实时行情
+func (e EastMoney) QueryFundQuote(ctx context.Context, code string) (*FundQuote, error) {
+	secid := FundSecid(code)
+	if secid == "" {
+		return nil, fmt.Errorf("%s 不是场内基金代码", code)
+	}
+	apiurl := "https://push2.eastmoney.com/api/qt/stock/get"
+	params := map[string]string{
+		"secid":  secid,
+		"fields": "f43,f57,f58,f60,f86,f170",
+		"fltt":   "2",
+	}
+	logging.Debug(ctx, "EastMoney QueryFundQuote "+apiurl+" begin", zap.Any("params", params))
+	beginTime := time.Now()
+	apiurl, err := goutils.NewHTTPGetURLWithQueryString(ctx, apiurl, params)
+	if err != nil {
+		return nil, err
+	}
+	header := map[string]string{
+		"user-agent": uarand.GetRandom(),
+	}
+	resp := RespFundQuote{}
+	err = goutils.HTTPGET(ctx, e.HTTPClient, apiurl, header, &resp)
+	latency := time.Now().Sub(beginTime).Milliseconds()
+	logging.Debug(ctx, "EastMoney QueryFundQuote "+apiurl+" end", zap.Int64("latency(ms)", latency))
+	if err != nil {
+		return nil, err
+	}
+	if resp.Rc != 0 || resp.Data == nil {
+		return nil, fmt.Errorf("无法获取场内行情(%v)", code)
+	}
+	quote := &FundQuote{
+		Code:        resp.Data.F57,
+		Name:        resp.Data.F58,
+		Price:       quoteFloat(resp.Data.F43),
+		PreClose:    quoteFloat(resp.Data.F60),
+		ChangeRatio: quoteFloat(resp.Data.F170),
+	}
+	if ts := int64(quoteFloat(resp.Data.F86)); ts > 0 {
+		quote.Time = time.Unix(ts, 0).Format("2006-01-02 15:04:05")
+	}
+	return quote, nil
+}
+
+// FundEstimate 基金盘中估算净值
+type FundEstimate struct {
+	// 基金代码
+	Code string `json:"fundcode"`
+	// 基金名称
+	Name string `json:"name"`
+	// 最新净值日期
+	NavDate string `json:"jzrq"`
+	// 最新单位净值
+	Nav string `json:"dwjz"`
+	// 估算净值
+	EstimateNav string `json:"gsz"`
+	// 估算涨跌幅（%）
+	EstimateRatio string `json:"gszzl"`
+	// 估算时间: 2021-06-30 15:00
+	EstimateTime string `json:"gztime"`
+}
+
+var fundEstimateRegexp = regexp.MustCompile(`(?s)jsonpgz\((.*)\)`)
+
+// parseFundEstimate 解析 jsonpgz({...}); 格式的估值数据
+func parseFundEstimate(resp string) (*FundEstimate, error) {
+	m := fundEstimateRegexp.FindStringSubmatch(resp)
+	if len(m) != 2 || strings.TrimSpace(m[1]) == "" {
+		return nil, fmt.Errorf("无估算净值数据")
+	}
+	estimate := &FundEstimate{}
+	if err := json.Unmarshal([]byte(m[1]), estimate); err != nil {
+		return nil, err
+	}
+	return estimate, nil
+}
+
+// QueryFundEstimate 获取基金盘中估算净值，场内基金可作为 IOPV 参考
+func (e EastMoney) QueryFundEstimate(ctx context.Context, code string) (*FundEstimate, error) {
+	apiurl := fmt.Sprintf("https://fundgz.1234567.com.cn/js/%s.js", code)
+	logging.Debug(ctx, "EastMoney QueryFundEstimate "+apiurl+" begin")
+	beginTime := time.Now()
+	header := map[string]string{
+		"user-agent": uarand.GetRandom(),
+		"referer":    "https://fund.eastmoney.com/",
+	}
+	resp, err := goutils.HTTPGETRaw(ctx, e.HTTPClient, apiurl, header)
+	latency := time.Now().Sub(beginTime).Milliseconds()
+	logging.Debug(ctx, "EastMoney QueryFundEstimate "+apiurl+" end", zap.Int64("latency(ms)", latency))
+	if err != nil {
+		return nil, err
+	}
+	estimate, err := parseFundEstimate(string(resp))
+	if err != nil {
+		return nil, fmt.Errorf("无法获取基金估算净值(%v): %w", code, err)
+	}
+	return estimate, nil
+}
@@ -0,0 +1,64 @@
+package eastmoney
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFundSecid(t *testing.T) {
+	cases := map[string]string{
+		"510300": "1.510300",
+		"501018": "1.501018",
+		"520830": "1.520830",
+		"560010": "1.560010",
+		"588000": "1.588000",
+		"159915": "0.159915",
+		"161725": "0.161725",
+		"184801": "0.184801",
+		// 场外基金
+		"110011": "",
+		"000001": "",
+		"005827": "",
+		"5103":   "",
+	}
+	for code, secid := range cases {
+		require.Equal(t, secid, FundSecid(code), code)
+	}
+}
+
+func TestIndexSecid(t *testing.T) {
+	require.Equal(t, "1.000300", IndexSecid("000300"))
+	require.Equal(t, "0.399006", IndexSecid("399006"))
+	require.Equal(t, "2.930050", IndexSecid("930050"))
+	require.Equal(t, "", IndexSecid("--"))
+}
+
+func TestParseFundEstimate(t *testing.T) {
+	e, err := parseFundEstimate(`jsonpgz({"fundcode":"510300","name":"华泰柏瑞沪深300ETF","jzrq":"2021-06-29","dwjz":"5.1234","gsz":"5.2000","gszzl":"1.50","gztime":"2021-06-30 15:00"});`)
+	require.Nil(t, err)
+	require.Equal(t, "510300", e.Code)
+	require.Equal(t, "5.2000", e.EstimateNav)
+	require.Equal(t, "2021-06-30 15:00", e.EstimateTime)
+	_, err = parseFundEstimate("jsonpgz();")
+	require.Error(t, err)
+}
+
+func TestQueryFundQuote(t *testing.T) {
+	quote, err := _em.QueryFundQuote(_ctx, "510300")
+	require.Nil(t, err)
+	require.Equal(t, "510300", quote.Code)
+	t.Log(quote)
+}
+
+func TestQueryFundPriceKline(t *testing.T) {
+	data, err := _em.QueryFundPriceKline(_ctx, "510300", "20210601", "20210630")
+	require.Nil(t, err)
+	require.NotEmpty(t, data)
+}
+
+func TestQueryFundEstimate(t *testing.T) {
+	e, err := _em.QueryFundEstimate(_ctx, "161725")
+	require.Nil(t, err)
+	t.Log(e)
+}
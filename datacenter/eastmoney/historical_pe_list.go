@@ -6,6 +6,7 @@ import (
 	"context"
 	"errors"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/axiaoxin-com/goutils"
@@ -43,6 +44,33 @@ type HistoricalPE struct {
 // HistoricalPEList 历史 pe 列表
 type HistoricalPEList []HistoricalPE
 
+// parsePEDate 解析历史 pe 日期，支持 2021-06-25 及 2021/6/25 格式
+func parsePEDate(date string) (time.Time, bool) {
+	if t, ok := parseFinaDate(date); ok {
+		return t, true
+	}
+	if i := strings.Index(date, " "); i > 0 {
+		date = date[:i]
+	}
+	t, err := time.ParseInLocation("2006/1/2", date, time.Local)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return t, true
+}
+
+// AsOf 返回 date 当天及之前的历史 pe，日期无法解析的数据无法判断时间，直接丢弃
+func (h HistoricalPEList) AsOf(ctx context.Context, date time.Time) HistoricalPEList {
+	result := HistoricalPEList{}
+	for _, i := range h {
+		t, ok := parsePEDate(i.Date)
+		if ok && isPublishedAsOf(t, date) {
+			result = append(result, i)
+		}
+	}
+	return result
+}
+
 // GetMidValue 获取历史 pe 中位数
 func (h HistoricalPEList) GetMidValue(ctx context.Context) (float64, error) {
 	values := []float64{}
@@ -2,6 +2,7 @@ package eastmoney
 
 import (
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 )
@@ -25,3 +26,14 @@ func TestQueryHistoricalPEList(t *testing.T) {
 	require.Nil(t, err)
 	t.Log(d)
 }
+
+func TestHistoricalPEListAsOf(t *testing.T) {
+	d := HistoricalPEList{
+		HistoricalPE{Date: "2021-01-04", Value: 1.0},
+		HistoricalPE{Date: "2021-06-01", Value: 2.0},
+		HistoricalPE{Date: "2021/2/26 0:00:00", Value: 3.0},
+		HistoricalPE{Date: "bad", Value: 4.0},
+	}
+	asOf := d.AsOf(_ctx, time.Date(2021, 3, 1, 0, 0, 0, 0, time.Local))
+	require.Equal(t, HistoricalPEList{d[0], d[2]}, asOf)
+}
@@ -0,0 +1,122 @@
+// 指数及股票历史日线
+
+package eastmoney
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/axiaoxin-com/goutils"
+	"github.com/axiaoxin-com/logging"
+	"github.com/corpix/uarand"
+	"go.uber.org/zap"
+)
+
+const (
+	// SecidHS300 沪深300指数 secid
+	SecidHS300 = "1.000300"
+)
+
+// IndexKline 指数日线
+type IndexKline struct {
+	// 日期: 2021-06-30
+	Date string `json:"date"`
+	// 收盘点数
+	Close float64 `json:"close"`
+}
+
+// IndexKlineList 指数日线列表，最早的在最前面
+type IndexKlineList []IndexKline
+
+// RespIndexKline 指数日线接口返回结构
+type RespIndexKline struct {
+	Rc   int `json:"rc"`
+	Data struct {
+		Code   string   `json:"code"`
+		Name   string   `json:"name"`
+		Klines []string `json:"klines"`
+	} `json:"data"`
+}
+
+// QueryIndexKline 获取指数历史日线，secid 格式: 1.000300，beg/end 格式: 20210630
+func (e EastMoney) QueryIndexKline(ctx context.Context, secid, beg, end string) (IndexKlineList, error) {
+	return e.queryKline(ctx, secid, beg, end, "1")
+}
+
+// StockSecid 股票代码转换为行情接口 secid，如: 600519.SH -> 1.600519，无法识别时返回空字符串
+func StockSecid(secuCode string) string {
+	parts := strings.Split(strings.ToUpper(secuCode), ".")
+	if len(parts) != 2 {
+		return ""
+	}
+	switch parts[1] {
+	case "SH":
+		return "1." + parts[0]
+	case "SZ", "BJ":
+		return "0." + parts[0]
+	}
+	return ""
+}
+
+// QueryStockKline 获取股票前复权历史收盘价，用于计算包含送转及分红的收益率，secuCode 格式: 600519.SH，beg/end 格式: 20210630
+func (e EastMoney) QueryStockKline(ctx context.Context, secuCode, beg, end string) (IndexKlineList, error) {
+	secid := StockSecid(secuCode)
+	if secid == "" {
+		return nil, fmt.Errorf("无法识别的股票代码: %s", secuCode)
+	}
+	return e.queryKline(ctx, secid, beg, end, "1")
+}
+
+// queryKline 获取日线收盘价，fqt 复权方式: 0 不复权 1 前复权
+func (e EastMoney) queryKline(ctx context.Context, secid, beg, end, fqt string) (IndexKlineList, error) {
+	apiurl := "https://push2his.eastmoney.com/api/qt/stock/kline/get"
+	params := map[string]string{
+		"secid":   secid,
+		"fields1": "f1,f2,f3",
+		"fields2": "f51,f52,f53",
+		"klt":     "101", // 日线
+		"fqt":     fqt,
+		"beg":     beg,
+		"end":     end,
+	}
+	logging.Debug(ctx, "EastMoney queryKline "+apiurl+" begin", zap.Any("params", params))
+	beginTime := time.Now()
+	apiurl, err := goutils.NewHTTPGetURLWithQueryString(ctx, apiurl, params)
+	if err != nil {
+		return nil, err
+	}
+	header := map[string]string{
+		"user-agent": uarand.GetRandom(),
+	}
+	resp := RespIndexKline{}
+	err = goutils.HTTPGET(ctx, e.HTTPClient, apiurl, header, &resp)
+	latency := time.Now().Sub(beginTime).Milliseconds()
+	logging.Debug(ctx, "EastMoney queryKline "+apiurl+" end", zap.Int64("latency(ms)", latency))
+	if err != nil {
+		return nil, err
+	}
+	if resp.Rc != 0 {
+		return nil, fmt.Errorf("queryKline rsp code error, rsp:%+v", resp)
+	}
+	result := IndexKlineList{}
+	for _, line := range resp.Data.Klines {
+		// 日期,开盘,收盘
+		fields := strings.Split(line, ",")
+		if len(fields) < 3 {
+			continue
+		}
+		close, err := strconv.ParseFloat(fields[2], 64)
+		if err != nil {
+			logging.Error(ctx, "queryKline ParseFloat error:"+err.Error())
+			continue
+		}
+		result = append(result, IndexKline{
+			Date:  fields[0],
+			Close: close,
+		})
+	}
+	return result, nil
+}
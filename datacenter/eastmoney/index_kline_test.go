@@ -0,0 +1,27 @@
+package eastmoney
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestQueryIndexKline(t *testing.T) {
+	data, err := _em.QueryIndexKline(_ctx, SecidHS300, "20210101", "20210701")
+	require.Nil(t, err)
+	require.NotEmpty(t, data)
+	t.Log(data[0], data[len(data)-1])
+}
+
+func TestStockSecid(t *testing.T) {
+	require.Equal(t, "1.600519", StockSecid("600519.SH"))
+	require.Equal(t, "0.000001", StockSecid("000001.sz"))
+	require.Equal(t, "0.830799", StockSecid("830799.BJ"))
+	require.Equal(t, "", StockSecid("600519"))
+}
+
+func TestQueryStockKline(t *testing.T) {
+	data, err := _em.QueryStockKline(_ctx, "600519.SH", "20210101", "20210701")
+	require.Nil(t, err)
+	require.NotEmpty(t, data)
+}
@@ -64,6 +64,8 @@ type Filter struct {
 	SpecialSecurityNameAbbrList []string `json:"special_security_name_abbr_list" form:"selector_special_security_name_abbr_list"`
 	// 查询指定代码
 	SpecialSecurityCodeList []string `json:"special_security_code_list"      form:"selector_special_security_code_list"`
+	// 查询指定行业的全部股票
+	SpecialIndustryList []string `json:"special_industry_list"           form:"selector_special_industry_list"`
 	// 最小总资产收益率 ROA
 	MinROA float64 `json:"min_roa"                         form:"selector_min_roa"`
 }
@@ -88,6 +90,14 @@ func (f Filter) String() string {
 		filter += fmt.Sprintf(`(SECURITY_CODE in (%s))`, strings.Join(codes, ","))
 		return filter
 	}
+	if len(f.SpecialIndustryList) > 0 {
+		industries := []string{}
+		for _, i := range f.SpecialIndustryList {
+			industries = append(industries, fmt.Sprintf(`"%s"`, i))
+		}
+		filter += fmt.Sprintf(`(INDUSTRY in (%s))`, strings.Join(industries, ","))
+		return filter
+	}
 	// 必要参数
 	filter += fmt.Sprintf(`(ROE_WEIGHT>=%f)`, f.MinROE)
 	filter += fmt.Sprintf(`(NETPROFIT_YOY_RATIO>=%f)`, f.MinNetprofitYoyRatio)
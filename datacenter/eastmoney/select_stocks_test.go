@@ -21,3 +21,9 @@ func TestQuerySelectedStocksWithFilter(t *testing.T) {
 	b, _ := json.Marshal(data)
 	t.Log(string(b))
 }
+
+func TestFilterStringSpecialIndustry(t *testing.T) {
+	filter := DefaultFilter
+	filter.SpecialIndustryList = []string{"银行", "白酒"}
+	require.Equal(t, `(INDUSTRY in ("银行","白酒"))`, filter.String())
+}
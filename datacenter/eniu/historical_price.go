@@ -23,11 +23,16 @@ type RespHistoricalStockPrice struct {
 
 // LastYearFinalPrice 获取去年12月份最后一个交易日的股价
 func (p RespHistoricalStockPrice) LastYearFinalPrice() float64 {
+	return p.YearFinalPrice(time.Now().Year() - 1)
+}
+
+// YearFinalPrice 获取指定年份12月份最后一个交易日的股价
+func (p RespHistoricalStockPrice) YearFinalPrice(year int) float64 {
 	if len(p.Date) == 0 {
 		return 0
 	}
+	prefix := fmt.Sprintf("%d-12-", year)
 	for i := len(p.Date) - 1; i > 0; i-- {
-		prefix := fmt.Sprintf("%d-12-", time.Now().Year()-1)
 		date := p.Date[i]
 		if strings.Contains(date, prefix) {
 			price := p.Price[i]
@@ -38,6 +43,34 @@ func (p RespHistoricalStockPrice) LastYearFinalPrice() float64 {
 	return 0
 }
 
+// AsOf 返回 date 当天及之前的历史股价
+func (p RespHistoricalStockPrice) AsOf(date time.Time) RespHistoricalStockPrice {
+	end := date.Format("2006-01-02")
+	result := RespHistoricalStockPrice{
+		Date:  []string{},
+		Price: []float64{},
+	}
+	for i, d := range p.Date {
+		if i >= len(p.Price) {
+			break
+		}
+		if d > end {
+			break
+		}
+		result.Date = append(result.Date, d)
+		result.Price = append(result.Price, p.Price[i])
+	}
+	return result
+}
+
+// LatestPrice 获取最近一个交易日的股价，无数据时返回 -1
+func (p RespHistoricalStockPrice) LatestPrice() float64 {
+	if len(p.Price) == 0 {
+		return -1
+	}
+	return p.Price[len(p.Price)-1]
+}
+
 // HistoricalVolatility 计算历史波动率
 // 历史波动率计算方法：https://goodcalculators.com/historical-volatility-calculator/
 // 1、从市场上获得标的股票在固定时间间隔(如每天DAY、每周WEEK或每月MONTH等)上的价格。
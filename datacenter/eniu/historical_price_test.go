@@ -2,6 +2,7 @@ package eniu
 
 import (
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 )
@@ -54,3 +55,15 @@ func TestHistoricalVolatility(t *testing.T) {
 	require.Nil(t, err)
 	t.Log("day volatility:", d, " week volatility:", w, " month volatility:", m, " year volatility:", y)
 }
+
+func TestHistoricalStockPriceAsOf(t *testing.T) {
+	data := RespHistoricalStockPrice{
+		Date:  []string{"2020-12-30", "2020-12-31", "2021-06-29", "2021-06-30", "2021-07-01"},
+		Price: []float64{9, 10, 11, 12, 13},
+	}
+	p := data.AsOf(time.Date(2021, 6, 30, 15, 0, 0, 0, time.Local))
+	require.Len(t, p.Price, 4)
+	require.Equal(t, 12.0, p.LatestPrice())
+	require.Equal(t, 10.0, p.YearFinalPrice(2020))
+	require.Equal(t, -1.0, data.AsOf(time.Date(2020, 1, 1, 0, 0, 0, 0, time.Local)).LatestPrice())
+}
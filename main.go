@@ -9,8 +9,10 @@ import (
 	"time"
 
 	"github.com/axiaoxin-com/investool/cmds"
+	"github.com/axiaoxin-com/investool/core"
 	"github.com/axiaoxin-com/investool/models"
 	"github.com/axiaoxin-com/investool/version"
+	"github.com/axiaoxin-com/logging"
 	"github.com/spf13/viper"
 	"github.com/urfave/cli/v2"
 )
@@ -19,12 +21,18 @@ var (
 	// DefaultLoglevel 日志级别默认值
 	DefaultLoglevel = "info"
 	// ProcessorOptions 要启动运行的进程可选项
-	ProcessorOptions = []string{cmds.ProcessorChecker, cmds.ProcessorExportor, cmds.ProcessorWebserver, cmds.ProcessorIndex, cmds.ProcessorJSON}
+	ProcessorOptions = []string{cmds.ProcessorChecker, cmds.ProcessorExportor, cmds.ProcessorWebserver, cmds.ProcessorIndex, cmds.ProcessorJSON, cmds.ProcessorBacktest, cmds.ProcessorPortfolio, cmds.ProcessorSIP, cmds.ProcessorPremium}
 )
 
 func init() {
 	viper.SetDefault("app.chan_size", 1)
 	models.InitGlobalVars()
+	if err := core.InitRuleSets(); err != nil {
+		logging.Error(nil, "init checker rule sets error:"+err.Error())
+	}
+	if err := models.InitBuffettScoreModel(); err != nil {
+		logging.Error(nil, "init buffett score model error:"+err.Error())
+	}
 }
 
 func main() {
@@ -75,6 +83,10 @@ func main() {
 	app.Commands = append(app.Commands, cmds.CommandWebserver())
 	app.Commands = append(app.Commands, cmds.CommandIndex())
 	app.Commands = append(app.Commands, cmds.CommandJSON())
+	app.Commands = append(app.Commands, cmds.CommandBacktest())
+	app.Commands = append(app.Commands, cmds.CommandPortfolio())
+	app.Commands = append(app.Commands, cmds.CommandSIP())
+	app.Commands = append(app.Commands, cmds.CommandPremium())
 
 	if err := app.Run(os.Args); err != nil {
 		fmt.Println(err.Error())
@@ -0,0 +1,352 @@
+// 财务质量评分：Piotroski F-Score、Altman Z-Score、Beneish M-Score
+
+package models
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/axiaoxin-com/investool/datacenter/eastmoney"
+	"github.com/axiaoxin-com/logging"
+)
+
+// 财务质量评分模型名称
+const (
+	// QualityModelPiotroskiF Piotroski F-Score
+	QualityModelPiotroskiF = "piotroski_f"
+	// QualityModelAltmanZ Altman Z-Score（制造业）
+	QualityModelAltmanZ = "altman_z"
+	// QualityModelAltmanZ2 Altman Z-Score（非制造业）
+	QualityModelAltmanZ2 = "altman_z2"
+	// QualityModelBeneishM Beneish M-Score
+	QualityModelBeneishM = "beneish_m"
+)
+
+// BeneishMThreshold M-Score 高于该值时可能存在财务操纵
+var BeneishMThreshold = -1.78
+
+// altmanNonManufacturingKeywords 行业名称包含这些关键词时使用非制造业模型
+var altmanNonManufacturingKeywords = []string{
+	"房地产", "建筑", "商业", "零售", "贸易", "传媒", "计算机", "软件", "互联网", "通信服务",
+	"旅游", "酒店", "餐饮", "交通运输", "物流", "航空", "机场", "港口", "高速公路", "航运",
+	"公用事业", "电力", "燃气", "水务", "环保", "教育", "服务",
+}
+
+// altmanExcludedKeywords 行业名称包含这些关键词时不适用 Z-Score
+var altmanExcludedKeywords = []string{"银行", "保险", "证券", "多元金融"}
+
+// ScoreComponent 评分组成项
+type ScoreComponent struct {
+	// 组成项标识
+	Name string `json:"name"`
+	// 组成项说明
+	Label string `json:"label"`
+	// 指标值
+	Value float64 `json:"value"`
+	// 得分：F-Score 为 0 或 1，Z-Score 及 M-Score 为加权后的贡献值
+	Score float64 `json:"score"`
+}
+
+// QualityScore 财务质量评分
+type QualityScore struct {
+	// 评分模型
+	Model string `json:"model"`
+	// 评分
+	Score float64 `json:"score"`
+	// 评分区间说明
+	Zone string `json:"zone"`
+	// 基准年报
+	ReportName string `json:"report_name"`
+	// 各组成项
+	Components []ScoreComponent `json:"components"`
+}
+
+// Lines 评分各组成项的文字说明
+func (q QualityScore) Lines() []string {
+	lines := []string{fmt.Sprintf("%s:%.2f(%s,%s)", q.Model, q.Score, q.Zone, q.ReportName)}
+	for _, c := range q.Components {
+		lines = append(lines, fmt.Sprintf("%s:%.4f,得分:%.4f", c.Label, c.Value, c.Score))
+	}
+	return lines
+}
+
+// annualStatement 同一年报期的资产负债表、利润表及现金流量表
+type annualStatement struct {
+	name     string
+	balance  eastmoney.BalanceData
+	gincome  eastmoney.GincomeData
+	cashflow eastmoney.CashflowData
+}
+
+// annualStatements 返回三张报表均存在的最近两期连续年报，最新的在最前面，数据不足时返回 nil
+func (s Stock) annualStatements(ctx context.Context) []annualStatement {
+	result := []annualStatement{}
+	for _, b := range s.HistoricalBalanceList {
+		if b.ReportType != eastmoney.FinaReportTypeYear {
+			continue
+		}
+		st := annualStatement{name: b.ReportDateName, balance: b}
+		found := 0
+		for _, g := range s.HistoricalGincomeList {
+			if g.ReportDate == b.ReportDate {
+				st.gincome = g
+				found++
+				break
+			}
+		}
+		for _, c := range s.HistoricalCashflowList {
+			if c.ReportDate == b.ReportDate {
+				st.cashflow = c
+				found++
+				break
+			}
+		}
+		if found != 2 {
+			continue
+		}
+		if st.name == "" && len(b.ReportDate) >= 4 {
+			st.name = b.ReportDate[:4] + "年报"
+		}
+		result = append(result, st)
+		if len(result) == 2 {
+			break
+		}
+	}
+	if len(result) < 2 || len(result[0].balance.ReportDate) < 4 || len(result[1].balance.ReportDate) < 4 {
+		logging.Debugf(ctx, "annualStatements %s not enough year reports", s.BaseInfo.Secucode)
+		return nil
+	}
+	var cur, prev int
+	fmt.Sscanf(result[0].balance.ReportDate[:4], "%d", &cur)
+	fmt.Sscanf(result[1].balance.ReportDate[:4], "%d", &prev)
+	if cur-prev != 1 {
+		return nil
+	}
+	return result
+}
+
+// div 安全除法，除数为 0 时返回 0
+func div(a, b float64) float64 {
+	if b == 0 {
+		return 0
+	}
+	return a / b
+}
+
+// index 两期比值指数 cur/prev，无法计算时按中性值 1 处理
+func index(cur, prev float64) float64 {
+	if prev == 0 || cur == 0 {
+		return 1
+	}
+	return cur / prev
+}
+
+// boolScore 条件成立得 1 分
+func boolScore(ok bool) float64 {
+	if ok {
+		return 1
+	}
+	return 0
+}
+
+// grossMargin 毛利率=(营业总收入-营业成本)/营业总收入
+func grossMargin(g eastmoney.GincomeData) float64 {
+	return div(g.TotalOperateIncome-g.OperateCost, g.TotalOperateIncome)
+}
+
+// PiotroskiF 按最近两期年报计算 Piotroski F-Score，9 项信号各 1 分，数据不足时返回 nil
+func (s Stock) PiotroskiF(ctx context.Context) *QualityScore {
+	st := s.annualStatements(ctx)
+	if st == nil || st[0].balance.TotalAssets <= 0 || st[1].balance.TotalAssets <= 0 {
+		return nil
+	}
+	cur, prev := st[0], st[1]
+	ta, prevTA := cur.balance.TotalAssets, prev.balance.TotalAssets
+	roa := cur.gincome.Netprofit / ta
+	prevROA := prev.gincome.Netprofit / prevTA
+	cfo := cur.cashflow.NetcashOperate
+	deltaLever := cur.balance.TotalNoncurrentLiab/ta - prev.balance.TotalNoncurrentLiab/prevTA
+	deltaLiquid := div(cur.balance.TotalCurrentAssets, cur.balance.TotalCurrentLiab) -
+		div(prev.balance.TotalCurrentAssets, prev.balance.TotalCurrentLiab)
+	deltaShares := cur.balance.ShareCapital - prev.balance.ShareCapital
+	deltaMargin := grossMargin(cur.gincome) - grossMargin(prev.gincome)
+	deltaTurn := cur.gincome.TotalOperateIncome/ta - prev.gincome.TotalOperateIncome/prevTA
+
+	components := []ScoreComponent{
+		{Name: "roa", Label: "ROA>0", Value: roa, Score: boolScore(roa > 0)},
+		{Name: "cfo", Label: "经营现金流>0", Value: cfo, Score: boolScore(cfo > 0)},
+		{Name: "delta_roa", Label: "ROA同比提升", Value: roa - prevROA, Score: boolScore(roa > prevROA)},
+		{Name: "accrual", Label: "经营现金流/总资产>ROA", Value: cfo/ta - roa, Score: boolScore(cfo/ta > roa)},
+		{Name: "delta_leverage", Label: "长期负债率未上升", Value: deltaLever, Score: boolScore(deltaLever <= 0)},
+		{Name: "delta_liquidity", Label: "流动比率同比提升", Value: deltaLiquid, Score: boolScore(deltaLiquid > 0)},
+		{Name: "eq_offer", Label: "股本未增加", Value: deltaShares, Score: boolScore(deltaShares <= 0)},
+		{Name: "delta_margin", Label: "毛利率同比提升", Value: deltaMargin, Score: boolScore(deltaMargin > 0)},
+		{Name: "delta_turnover", Label: "总资产周转率同比提升", Value: deltaTurn, Score: boolScore(deltaTurn > 0)},
+	}
+	q := &QualityScore{
+		Model:      QualityModelPiotroskiF,
+		ReportName: cur.name,
+		Components: components,
+	}
+	for _, c := range components {
+		q.Score += c.Score
+	}
+	switch {
+	case q.Score >= 8:
+		q.Zone = "财务健康"
+	case q.Score <= 2:
+		q.Zone = "财务较差"
+	default:
+		q.Zone = "一般"
+	}
+	return q
+}
+
+// altmanModel 按行业返回适用的 Z-Score 模型，金融行业不适用时返回空字符串
+func (s Stock) altmanModel() string {
+	industry := s.BaseInfo.Industry
+	for _, k := range altmanExcludedKeywords {
+		if strings.Contains(industry, k) {
+			return ""
+		}
+	}
+	for _, k := range altmanNonManufacturingKeywords {
+		if strings.Contains(industry, k) {
+			return QualityModelAltmanZ2
+		}
+	}
+	return QualityModelAltmanZ
+}
+
+// AltmanZ 按最新年报计算 Altman Z-Score，非制造业使用非制造业模型系数，金融行业或数据不足时返回 nil
+func (s Stock) AltmanZ(ctx context.Context) *QualityScore {
+	model := s.altmanModel()
+	st := s.annualStatements(ctx)
+	if model == "" || st == nil {
+		return nil
+	}
+	cur := st[0]
+	ta, tl := cur.balance.TotalAssets, cur.balance.TotalLiabilities
+	if ta <= 0 || tl <= 0 {
+		return nil
+	}
+	wc := (cur.balance.TotalCurrentAssets - cur.balance.TotalCurrentLiab) / ta
+	re := (cur.balance.UnassignRpofit + cur.balance.SurplusReserve) / ta
+	ebit := (cur.gincome.TotalProfit + cur.gincome.FeInterestExpense) / ta
+
+	q := &QualityScore{Model: model, ReportName: cur.name}
+	if model == QualityModelAltmanZ {
+		if s.BaseInfo.TotalMarketCap <= 0 {
+			return nil
+		}
+		mve := s.BaseInfo.TotalMarketCap / tl
+		sales := cur.gincome.TotalOperateIncome / ta
+		q.Components = []ScoreComponent{
+			{Name: "x1", Label: "营运资本/总资产×1.2", Value: wc, Score: 1.2 * wc},
+			{Name: "x2", Label: "留存收益/总资产×1.4", Value: re, Score: 1.4 * re},
+			{Name: "x3", Label: "息税前利润/总资产×3.3", Value: ebit, Score: 3.3 * ebit},
+			{Name: "x4", Label: "总市值/总负债×0.6", Value: mve, Score: 0.6 * mve},
+			{Name: "x5", Label: "营业总收入/总资产×1.0", Value: sales, Score: sales},
+		}
+	} else {
+		bve := cur.balance.TotalEquity / tl
+		q.Components = []ScoreComponent{
+			{Name: "x1", Label: "营运资本/总资产×6.56", Value: wc, Score: 6.56 * wc},
+			{Name: "x2", Label: "留存收益/总资产×3.26", Value: re, Score: 3.26 * re},
+			{Name: "x3", Label: "息税前利润/总资产×6.72", Value: ebit, Score: 6.72 * ebit},
+			{Name: "x4", Label: "股东权益/总负债×1.05", Value: bve, Score: 1.05 * bve},
+		}
+	}
+	for _, c := range q.Components {
+		q.Score += c.Score
+	}
+	safe, distress := 2.99, 1.81
+	if model == QualityModelAltmanZ2 {
+		safe, distress = 2.6, 1.1
+	}
+	switch {
+	case q.Score > safe:
+		q.Zone = "安全区"
+	case q.Score < distress:
+		q.Zone = "危险区"
+	default:
+		q.Zone = "灰色区"
+	}
+	return q
+}
+
+// accountsRece 应收账款，未披露时使用应收票据及应收账款
+func accountsRece(b eastmoney.BalanceData) float64 {
+	if b.AccountsRece != 0 {
+		return b.AccountsRece
+	}
+	return b.NoteAccountsRece
+}
+
+// BeneishM 按最近两期年报计算 Beneish M-Score（8 变量模型），数据不足时返回 nil
+func (s Stock) BeneishM(ctx context.Context) *QualityScore {
+	st := s.annualStatements(ctx)
+	if st == nil {
+		return nil
+	}
+	cur, prev := st[0], st[1]
+	ta, prevTA := cur.balance.TotalAssets, prev.balance.TotalAssets
+	sales, prevSales := cur.gincome.TotalOperateIncome, prev.gincome.TotalOperateIncome
+	if ta <= 0 || prevTA <= 0 || sales <= 0 || prevSales <= 0 {
+		return nil
+	}
+	dsri := index(accountsRece(cur.balance)/sales, accountsRece(prev.balance)/prevSales)
+	gmi := index(grossMargin(prev.gincome), grossMargin(cur.gincome))
+	aqi := index(
+		1-(cur.balance.TotalCurrentAssets+cur.balance.FixedAsset)/ta,
+		1-(prev.balance.TotalCurrentAssets+prev.balance.FixedAsset)/prevTA,
+	)
+	sgi := sales / prevSales
+	depi := index(
+		div(prev.cashflow.FaIrDepr, prev.cashflow.FaIrDepr+prev.balance.FixedAsset),
+		div(cur.cashflow.FaIrDepr, cur.cashflow.FaIrDepr+cur.balance.FixedAsset),
+	)
+	sgai := index(
+		(cur.gincome.SaleExpense+cur.gincome.ManageExpense)/sales,
+		(prev.gincome.SaleExpense+prev.gincome.ManageExpense)/prevSales,
+	)
+	lvgi := index(cur.balance.TotalLiabilities/ta, prev.balance.TotalLiabilities/prevTA)
+	tata := (cur.gincome.Netprofit - cur.cashflow.NetcashOperate) / ta
+
+	q := &QualityScore{
+		Model:      QualityModelBeneishM,
+		ReportName: cur.name,
+		Components: []ScoreComponent{
+			{Name: "dsri", Label: "应收账款周转指数×0.92", Value: dsri, Score: 0.92 * dsri},
+			{Name: "gmi", Label: "毛利率指数×0.528", Value: gmi, Score: 0.528 * gmi},
+			{Name: "aqi", Label: "资产质量指数×0.404", Value: aqi, Score: 0.404 * aqi},
+			{Name: "sgi", Label: "营收增长指数×0.892", Value: sgi, Score: 0.892 * sgi},
+			{Name: "depi", Label: "折旧率指数×0.115", Value: depi, Score: 0.115 * depi},
+			{Name: "sgai", Label: "销售管理费用指数×-0.172", Value: sgai, Score: -0.172 * sgai},
+			{Name: "tata", Label: "应计项/总资产×4.679", Value: tata, Score: 4.679 * tata},
+			{Name: "lvgi", Label: "杠杆指数×-0.327", Value: lvgi, Score: -0.327 * lvgi},
+		},
+	}
+	q.Score = -4.84
+	for _, c := range q.Components {
+		q.Score += c.Score
+	}
+	if math.IsNaN(q.Score) || math.IsInf(q.Score, 0) {
+		return nil
+	}
+	if q.Score > BeneishMThreshold {
+		q.Zone = "可能存在财务操纵"
+	} else {
+		q.Zone = "操纵可能性低"
+	}
+	return q
+}
+
+// calcQualityScores 计算财务质量评分，数据不足时对应字段为 nil
+func (s *Stock) calcQualityScores(ctx context.Context) {
+	s.PiotroskiFScore = s.PiotroskiF(ctx)
+	s.AltmanZScore = s.AltmanZ(ctx)
+	s.BeneishMScore = s.BeneishM(ctx)
+}
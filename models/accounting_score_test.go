@@ -0,0 +1,77 @@
+package models
+
+import (
+	"context"
+	"testing"
+
+	"github.com/axiaoxin-com/investool/datacenter/eastmoney"
+	"github.com/stretchr/testify/require"
+)
+
+func qualityScoreTestStock() Stock {
+	cur, prev := "2022-12-31 00:00:00", "2021-12-31 00:00:00"
+	year := eastmoney.FinaReportTypeYear
+	return Stock{
+		BaseInfo: eastmoney.StockInfo{Industry: "汽车", TotalMarketCap: 2000},
+		HistoricalBalanceList: eastmoney.BalanceDataList{
+			{ReportDate: "2023-03-31 00:00:00", ReportType: eastmoney.FinaReportTypeQ1, TotalAssets: 1100},
+			{
+				ReportDate: cur, ReportType: year, TotalAssets: 1000, TotalCurrentAssets: 500, TotalCurrentLiab: 250,
+				TotalLiabilities: 400, TotalNoncurrentLiab: 150, TotalEquity: 600, AccountsRece: 100, FixedAsset: 300,
+				UnassignRpofit: 200, SurplusReserve: 50, ShareCapital: 100,
+			},
+			{
+				ReportDate: prev, ReportType: year, TotalAssets: 900, TotalCurrentAssets: 400, TotalCurrentLiab: 250,
+				TotalLiabilities: 400, TotalNoncurrentLiab: 150, TotalEquity: 500, AccountsRece: 90, FixedAsset: 300,
+				ShareCapital: 100,
+			},
+		},
+		HistoricalGincomeList: eastmoney.GincomeDataList{
+			{
+				ReportDate: cur, ReportType: year, TotalOperateIncome: 1000, OperateCost: 600, Netprofit: 100,
+				TotalProfit: 120, FeInterestExpense: 10, SaleExpense: 50, ManageExpense: 50,
+			},
+			{ReportDate: prev, ReportType: year, TotalOperateIncome: 900, OperateCost: 560, Netprofit: 80, SaleExpense: 45, ManageExpense: 45},
+		},
+		HistoricalCashflowList: eastmoney.CashflowDataList{
+			{ReportDate: cur, ReportType: year, NetcashOperate: 150, FaIrDepr: 30},
+			{ReportDate: prev, ReportType: year, NetcashOperate: 100, FaIrDepr: 30},
+		},
+	}
+}
+
+func TestQualityScores(t *testing.T) {
+	ctx := context.TODO()
+	s := qualityScoreTestStock()
+
+	f := s.PiotroskiF(ctx)
+	require.NotNil(t, f)
+	require.Len(t, f.Components, 9)
+	require.Equal(t, 8.0, f.Score)
+	require.Equal(t, "2022年报", f.ReportName)
+	require.Equal(t, 0.0, f.Components[8].Score)
+
+	z := s.AltmanZ(ctx)
+	require.NotNil(t, z)
+	require.Equal(t, QualityModelAltmanZ, z.Model)
+	require.InDelta(t, 5.079, z.Score, 1e-9)
+	require.Equal(t, "安全区", z.Zone)
+
+	s.BaseInfo.Industry = "房地产开发"
+	z = s.AltmanZ(ctx)
+	require.Equal(t, QualityModelAltmanZ2, z.Model)
+	require.InDelta(t, 4.9036, z.Score, 1e-9)
+	s.BaseInfo.Industry = "银行"
+	require.Nil(t, s.AltmanZ(ctx))
+
+	m := s.BeneishM(ctx)
+	require.NotNil(t, m)
+	require.Len(t, m.Components, 8)
+	require.InDelta(t, -2.6519, m.Score, 1e-3)
+	require.Equal(t, "操纵可能性低", m.Zone)
+
+	// 年报不连续时无法计算
+	s.HistoricalBalanceList[2].ReportDate = "2020-12-31 00:00:00"
+	require.Nil(t, s.PiotroskiF(ctx))
+	require.Nil(t, s.BeneishM(ctx))
+}
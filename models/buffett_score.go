@@ -0,0 +1,660 @@
+// 巴菲特评分：评分模型（权重、行业护城河分级、得分曲线）可通过配置文件定义
+
+package models
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/axiaoxin-com/investool/datacenter/eastmoney"
+	"github.com/spf13/viper"
+)
+
+// BuffettScore 巴菲特评分结构体
+type BuffettScore struct {
+	ROEScore          float64 `json:"roe_score"`           // ROE评分
+	CashFlowScore     float64 `json:"cash_flow_score"`     // 自由现金流评分
+	ProfitGrowthScore float64 `json:"profit_growth_score"` // 利润增长评分
+	DebtRatioScore    float64 `json:"debt_ratio_score"`    // 负债率评分
+	MoatScore         float64 `json:"moat_score"`          // 护城河评分
+	ManagementScore   float64 `json:"management_score"`    // 管理层评分
+	ValuationScore    float64 `json:"valuation_score"`     // 估值评分
+	TotalScore        float64 `json:"total_score"`         // 总分（100分）
+	ScoreDescription  string  `json:"score_description"`   // 评分说明
+	RDScore           float64 `json:"rd_score"`            // 研发投入评分
+	DividendScore     float64 `json:"dividend_score"`      // 分红评分
+	RepurchaseScore   float64 `json:"repurchase_score"`    // 回购评分
+
+	// 各组成项得分及依据
+	Components []BuffettComponent `json:"components"`
+	// 所属行业的平均总分，未与同行业比较时为 0
+	IndustryAvgScore float64 `json:"industry_avg_score"`
+	// 参与计算行业平均分的股票数
+	IndustryStockCount int `json:"industry_stock_count"`
+}
+
+// VsIndustry 总分与行业平均分的差值，未与同行业比较时返回 0
+func (b BuffettScore) VsIndustry() float64 {
+	if b.IndustryStockCount == 0 {
+		return 0
+	}
+	return b.TotalScore - b.IndustryAvgScore
+}
+
+// BuffettComponent 巴菲特评分组成项得分
+type BuffettComponent struct {
+	// 组成项标识
+	Name string `json:"name"`
+	// 组成项名称
+	Label string `json:"label"`
+	// 满分
+	Weight float64 `json:"weight"`
+	// 得分
+	Score float64 `json:"score"`
+	// 使用的指标值，key 为指标名称，缺失的指标不在其中
+	Inputs map[string]float64 `json:"inputs"`
+	// 评分依据
+	Rationale string `json:"rationale"`
+}
+
+// ScorePoint 得分曲线上的点
+type ScorePoint struct {
+	// 指标值
+	Value float64 `json:"value"  mapstructure:"value"`
+	// 得分比例 0-1
+	Credit float64 `json:"credit" mapstructure:"credit"`
+}
+
+// ScoreCurve 分段得分曲线，Points 按 Value 从小到大排列。
+// 指标值低于第一个点时得 0 分，否则取不超过指标值的最后一个点的得分，Linear 时在相邻两点间线性插值
+type ScoreCurve struct {
+	Points []ScorePoint `json:"points" mapstructure:"points"`
+	Linear bool         `json:"linear" mapstructure:"linear"`
+}
+
+// Credit 返回指标值 v 的得分比例
+func (c ScoreCurve) Credit(v float64) float64 {
+	i := -1
+	for j, p := range c.Points {
+		if p.Value <= v {
+			i = j
+		}
+	}
+	if i < 0 {
+		return 0
+	}
+	cur := c.Points[i]
+	if !c.Linear || i == len(c.Points)-1 || c.Points[i+1].Value == cur.Value {
+		return cur.Credit
+	}
+	next := c.Points[i+1]
+	return cur.Credit + (next.Credit-cur.Credit)*(v-cur.Value)/(next.Value-cur.Value)
+}
+
+// BuffettFactor 组成项中的评分因子
+type BuffettFactor struct {
+	// 指标名称，见 buffettMetrics
+	Metric string `json:"metric"  mapstructure:"metric"`
+	// 指标说明
+	Label string `json:"label"   mapstructure:"label"`
+	// 占组成项满分的比例
+	Share float64 `json:"share"   mapstructure:"share"`
+	// 得分曲线
+	Curve ScoreCurve `json:"curve"   mapstructure:"curve"`
+	// 指标缺失时的得分比例
+	Missing float64 `json:"missing" mapstructure:"missing"`
+}
+
+// BuffettPenalty 组成项扣分规则：指标值高于 Above 时组成项得分乘以 Factor
+type BuffettPenalty struct {
+	Metric string  `json:"metric" mapstructure:"metric"`
+	Label  string  `json:"label"  mapstructure:"label"`
+	Above  float64 `json:"above"  mapstructure:"above"`
+	Factor float64 `json:"factor" mapstructure:"factor"`
+}
+
+// BuffettComponentModel 评分组成项定义
+type BuffettComponentModel struct {
+	// 组成项标识
+	Name string `json:"name"      mapstructure:"name"`
+	// 组成项名称
+	Label string `json:"label"     mapstructure:"label"`
+	// 满分
+	Weight float64 `json:"weight"    mapstructure:"weight"`
+	// 因子得分合并方式: sum 按比例求和（默认），max 取最高得分比例
+	Combine string `json:"combine"   mapstructure:"combine"`
+	// 评分因子
+	Factors []BuffettFactor `json:"factors"   mapstructure:"factors"`
+	// 扣分规则
+	Penalties []BuffettPenalty `json:"penalties" mapstructure:"penalties"`
+}
+
+// MoatTier 行业护城河分级
+type MoatTier struct {
+	// 分级名称
+	Name string `json:"name"       mapstructure:"name"`
+	// 护城河得分比例 0-1
+	Credit float64 `json:"credit"     mapstructure:"credit"`
+	// 行业名称关键词，股票所属行业包含其中任意一个即属于该分级
+	Industries []string `json:"industries" mapstructure:"industries"`
+}
+
+// BuffettModel 巴菲特评分模型，总分按各组成项满分之和换算为 100 分制
+type BuffettModel struct {
+	// 评分组成项
+	Components []BuffettComponentModel `json:"components"          mapstructure:"components"`
+	// 行业护城河分级，按顺序匹配
+	MoatTiers []MoatTier `json:"moat_tiers"          mapstructure:"moat_tiers"`
+	// 未匹配任何分级的行业护城河得分比例
+	DefaultMoatCredit float64 `json:"default_moat_credit" mapstructure:"default_moat_credit"`
+}
+
+// curve 按 (指标值, 得分比例) 成对的参数创建得分曲线
+func curve(linear bool, points ...float64) ScoreCurve {
+	c := ScoreCurve{Linear: linear, Points: []ScorePoint{}}
+	for i := 0; i+1 < len(points); i += 2 {
+		c.Points = append(c.Points, ScorePoint{Value: points[i], Credit: points[i+1]})
+	}
+	return c
+}
+
+// DefaultBuffettModel 默认巴菲特评分模型，buffett_score.toml 不存在时使用
+var DefaultBuffettModel = BuffettModel{
+	Components: []BuffettComponentModel{
+		{
+			Name: "roe", Label: "ROE", Weight: 20,
+			Factors: []BuffettFactor{
+				{Metric: "roe_avg_5y", Label: "近5年平均ROE(%)", Share: 1, Curve: curve(true, 0, 0, 15, 0.75, 20, 0.75, 20, 1)},
+			},
+			Penalties: []BuffettPenalty{{Metric: "roe_volatility_5y", Label: "ROE波动率", Above: 0.3, Factor: 0.8}},
+		},
+		{
+			Name: "cashflow", Label: "现金流", Weight: 15,
+			Factors: []BuffettFactor{
+				{Metric: "operate_cashflow_positive_3", Label: "近3期经营现金流为正次数", Share: 0.5, Curve: curve(false, 1, 1.0/3, 2, 2.0/3, 3, 1)},
+				{Metric: "free_cashflow_positive_3", Label: "近3期自由现金流为正次数", Share: 0.5, Curve: curve(false, 1, 1.0/3, 2, 2.0/3, 3, 1)},
+			},
+		},
+		{
+			Name: "profit_growth", Label: "利润增长", Weight: 15,
+			Factors: []BuffettFactor{
+				{Metric: "netprofit_growth_years_5y", Label: "近5年净利润增长次数", Share: 1, Curve: curve(true, 0, 0, 5, 1)},
+			},
+			Penalties: []BuffettPenalty{{Metric: "netprofit_growth_volatility_5y", Label: "净利润增速波动", Above: 0.5, Factor: 0.8}},
+		},
+		{
+			Name: "debt_ratio", Label: "负债率", Weight: 10,
+			Factors: []BuffettFactor{
+				{Metric: "debt_ratio", Label: "资产负债率(%)", Share: 1, Curve: curve(false, 0, 1, 30, 0.8, 50, 0.5, 70, 0)},
+			},
+		},
+		{
+			Name: "moat", Label: "护城河", Weight: 10,
+			Factors: []BuffettFactor{
+				{Metric: "industry_moat", Label: "行业护城河", Share: 0.5, Curve: curve(true, 0, 0, 1, 1)},
+				{Metric: "mll_avg_5y", Label: "近5年平均毛利率(%)", Share: 0.5, Curve: curve(true, 0, 0, 20, 0.5, 40, 1), Missing: 0.5},
+			},
+		},
+		{
+			Name: "management", Label: "管理层", Weight: 10,
+			Factors: []BuffettFactor{
+				{Metric: "roic_avg_3y", Label: "近3年平均ROIC(%)", Share: 0.5, Curve: curve(true, 0, 0, 10, 0.75, 15, 1), Missing: 0.5},
+				{Metric: "audit_opinion_standard", Label: "标准无保留审计意见", Share: 0.5, Curve: curve(false, 0, 0, 1, 1), Missing: 0.5},
+			},
+		},
+		{
+			Name: "valuation", Label: "估值", Weight: 15, Combine: "max",
+			Factors: []BuffettFactor{
+				{Metric: "pe", Label: "PE", Share: 1, Curve: curve(false, 0, 1, 10, 0.8, 15, 8.0/15, 20, 1.0/3, 30, 0)},
+				{Metric: "peg", Label: "PEG", Share: 1, Curve: curve(false, 0, 1, 1, 0)},
+			},
+		},
+		{
+			Name: "rd", Label: "研发投入", Weight: 5,
+			Factors: []BuffettFactor{
+				{Metric: "rd_ratio", Label: "研发投入占营收(%)", Share: 0.6, Curve: curve(false, 1, 1.0/3, 3, 2.0/3, 5, 1)},
+				{Metric: "rd_growth", Label: "研发投入同比增长(%)", Share: 0.4, Curve: curve(false, 10, 0.5, 30, 1)},
+			},
+		},
+		{
+			Name: "dividend", Label: "分红", Weight: 5,
+			Factors: []BuffettFactor{
+				{Metric: "dividend_ratio", Label: "分红率(%)", Share: 0.6, Curve: curve(false, 10, 1.0/3, 30, 2.0/3, 50, 1)},
+				{Metric: "dividend_years_3", Label: "近3年连续分红年数", Share: 0.4, Curve: curve(false, 2, 0.5, 3, 1)},
+			},
+		},
+		{
+			Name: "repurchase", Label: "回购", Weight: 5,
+			Factors: []BuffettFactor{
+				{Metric: "share_capital_change", Label: "股本同比变化(%)", Share: 1, Curve: curve(false, -100, 1, -0.5, 0.6, 5, 0), Missing: 0.6},
+			},
+		},
+	},
+	MoatTiers: []MoatTier{
+		{Name: "强", Credit: 0.8, Industries: []string{"食品饮料", "酿酒", "医药生物", "中药", "家用电器", "家电", "银行", "保险"}},
+		{Name: "弱", Credit: 0.3, Industries: []string{"建筑", "采掘", "煤炭", "农林牧渔"}},
+	},
+	DefaultMoatCredit: 0.5,
+}
+
+var (
+	// BuffettScoreModel 当前使用的巴菲特评分模型
+	BuffettScoreModel = DefaultBuffettModel
+	// BuffettScoreModelFilename 巴菲特评分模型配置文件
+	BuffettScoreModelFilename = "./buffett_score.toml"
+)
+
+// Validate 校验评分模型
+func (m BuffettModel) Validate() error {
+	if len(m.Components) == 0 {
+		return errors.New("buffett score model without components")
+	}
+	for _, c := range m.Components {
+		if c.Name == "" || c.Weight < 0 {
+			return fmt.Errorf("buffett score component %q: invalid name or weight", c.Name)
+		}
+		if c.Combine != "" && c.Combine != "sum" && c.Combine != "max" {
+			return fmt.Errorf("buffett score component %s: invalid combine %q", c.Name, c.Combine)
+		}
+		for _, f := range c.Factors {
+			if _, exists := buffettMetrics[f.Metric]; !exists {
+				return fmt.Errorf("buffett score component %s: unknown metric %q", c.Name, f.Metric)
+			}
+			for i := 1; i < len(f.Curve.Points); i++ {
+				if f.Curve.Points[i].Value < f.Curve.Points[i-1].Value {
+					return fmt.Errorf("buffett score component %s: curve of %s is not sorted", c.Name, f.Metric)
+				}
+			}
+		}
+		for _, p := range c.Penalties {
+			if _, exists := buffettMetrics[p.Metric]; !exists {
+				return fmt.Errorf("buffett score component %s: unknown penalty metric %q", c.Name, p.Metric)
+			}
+		}
+	}
+	return nil
+}
+
+// LoadBuffettModel 从 toml/yaml 文件的 buffett_score 配置加载评分模型
+func LoadBuffettModel(filename string) (BuffettModel, error) {
+	v := viper.New()
+	v.SetConfigFile(filename)
+	if err := v.ReadInConfig(); err != nil {
+		return BuffettModel{}, err
+	}
+	m := BuffettModel{}
+	if err := v.UnmarshalKey("buffett_score", &m); err != nil {
+		return m, err
+	}
+	return m, m.Validate()
+}
+
+// InitBuffettScoreModel 加载巴菲特评分模型配置文件，失败时保留当前模型
+func InitBuffettScoreModel() error {
+	m, err := LoadBuffettModel(BuffettScoreModelFilename)
+	if err != nil {
+		return err
+	}
+	BuffettScoreModel = m
+	return nil
+}
+
+// moatTier 返回行业所属的护城河分级，未匹配时返回 nil
+func (m BuffettModel) moatTier(industry string) *MoatTier {
+	for i, tier := range m.MoatTiers {
+		for _, k := range tier.Industries {
+			if k != "" && strings.Contains(industry, k) {
+				return &m.MoatTiers[i]
+			}
+		}
+	}
+	return nil
+}
+
+// buffettMetric 计算评分指标，返回指标值、取值说明及数据是否充足
+type buffettMetric func(ctx context.Context, s *Stock, m BuffettModel) (float64, string, bool)
+
+// yearValues 最近 n 个年报的指标值，不足 n 个时返回 false
+func (s *Stock) yearValues(ctx context.Context, vt eastmoney.ValueListType, n int) ([]float64, bool) {
+	values := s.HistoricalFinaMainData.ValueList(ctx, vt, n, eastmoney.FinaReportTypeYear)
+	return values, len(values) >= n
+}
+
+// avg 均值
+func avg(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	sum := 0.0
+	for _, v := range values {
+		sum += v
+	}
+	return sum / float64(len(values))
+}
+
+// yearGincomeList 最近 n 个年报的利润表，最新的在最前面
+func (s *Stock) yearGincomeList(n int) []eastmoney.GincomeData {
+	result := []eastmoney.GincomeData{}
+	for _, g := range s.HistoricalGincomeList {
+		if len(result) >= n {
+			break
+		}
+		if g.ReportType == eastmoney.FinaReportTypeYear {
+			result = append(result, g)
+		}
+	}
+	return result
+}
+
+// buffettMetrics 可在评分模型中使用的指标
+var buffettMetrics = map[string]buffettMetric{
+	"roe_avg_5y": func(ctx context.Context, s *Stock, m BuffettModel) (float64, string, bool) {
+		roes, ok := s.yearValues(ctx, eastmoney.ValueListTypeROE, 5)
+		return avg(roes), fmt.Sprintf("%v", roes), ok
+	},
+	"roe_volatility_5y": func(ctx context.Context, s *Stock, m BuffettModel) (float64, string, bool) {
+		roes, ok := s.yearValues(ctx, eastmoney.ValueListTypeROE, 5)
+		a := avg(roes)
+		if !ok || a == 0 {
+			return 0, "", false
+		}
+		variance := 0.0
+		for _, roe := range roes {
+			variance += math.Pow(roe-a, 2)
+		}
+		return math.Sqrt(variance/float64(len(roes))) / a, "标准差/均值", true
+	},
+	"operate_cashflow_positive_3": func(ctx context.Context, s *Stock, m BuffettModel) (float64, string, bool) {
+		if len(s.HistoricalCashflowList) < 3 {
+			return 0, "", false
+		}
+		count := 0
+		for _, cf := range s.HistoricalCashflowList[:3] {
+			if cf.NetcashOperate > 0 {
+				count++
+			}
+		}
+		return float64(count), "", true
+	},
+	"free_cashflow_positive_3": func(ctx context.Context, s *Stock, m BuffettModel) (float64, string, bool) {
+		if len(s.HistoricalCashflowList) < 3 {
+			return 0, "", false
+		}
+		count := 0
+		for _, cf := range s.HistoricalCashflowList[:3] {
+			// 自由现金流=经营现金流-投资现金流（绝对值）
+			if cf.NetcashOperate-math.Abs(cf.NetcashInvest) > 0 {
+				count++
+			}
+		}
+		return float64(count), "", true
+	},
+	"netprofit_growth_years_5y": func(ctx context.Context, s *Stock, m BuffettModel) (float64, string, bool) {
+		profits, ok := s.yearValues(ctx, eastmoney.ValueListTypeNetProfit, 5)
+		if !ok {
+			return 0, "", false
+		}
+		count := 0
+		for i := 0; i < len(profits)-1; i++ {
+			if profits[i] > profits[i+1] {
+				count++
+			}
+		}
+		return float64(count), "", true
+	},
+	"netprofit_growth_volatility_5y": func(ctx context.Context, s *Stock, m BuffettModel) (float64, string, bool) {
+		profits, ok := s.yearValues(ctx, eastmoney.ValueListTypeNetProfit, 5)
+		if !ok {
+			return 0, "", false
+		}
+		sum := 0.0
+		for i := 1; i < len(profits)-1; i++ {
+			g1 := (profits[i] - profits[i+1]) / math.Abs(profits[i+1])
+			g2 := (profits[i-1] - profits[i]) / math.Abs(profits[i])
+			sum += math.Abs(g1 - g2)
+		}
+		return sum, "相邻年度增速差之和", true
+	},
+	"debt_ratio": func(ctx context.Context, s *Stock, m BuffettModel) (float64, string, bool) {
+		if len(s.HistoricalFinaMainData) == 0 {
+			return 0, "", false
+		}
+		return s.HistoricalFinaMainData[0].Zcfzl, s.HistoricalFinaMainData[0].ReportDateName, true
+	},
+	"industry_moat": func(ctx context.Context, s *Stock, m BuffettModel) (float64, string, bool) {
+		if tier := m.moatTier(s.BaseInfo.Industry); tier != nil {
+			return tier.Credit, fmt.Sprintf("%s属于%s护城河行业", s.BaseInfo.Industry, tier.Name), true
+		}
+		return m.DefaultMoatCredit, fmt.Sprintf("%s未配置护城河分级", s.BaseInfo.Industry), true
+	},
+	"mll_avg_5y": func(ctx context.Context, s *Stock, m BuffettModel) (float64, string, bool) {
+		mlls, ok := s.yearValues(ctx, eastmoney.ValueListTypeMLL, 5)
+		return avg(mlls), fmt.Sprintf("%v", mlls), ok
+	},
+	"roic_avg_3y": func(ctx context.Context, s *Stock, m BuffettModel) (float64, string, bool) {
+		roics := []float64{}
+		for _, r := range s.HistoricalFinaMainData {
+			if len(roics) >= 3 {
+				break
+			}
+			if r.ReportType == eastmoney.FinaReportTypeYear && r.Roic != 0 {
+				roics = append(roics, r.Roic)
+			}
+		}
+		return avg(roics), fmt.Sprintf("%v", roics), len(roics) > 0
+	},
+	"audit_opinion_standard": func(ctx context.Context, s *Stock, m BuffettModel) (float64, string, bool) {
+		if s.FinaReportOpinion == "" {
+			return 0, "", false
+		}
+		if s.FinaReportOpinion == "标准无保留意见" {
+			return 1, s.FinaReportOpinion, true
+		}
+		return 0, s.FinaReportOpinion, true
+	},
+	"pe": func(ctx context.Context, s *Stock, m BuffettModel) (float64, string, bool) {
+		return s.BaseInfo.PE, "", true
+	},
+	"peg": func(ctx context.Context, s *Stock, m BuffettModel) (float64, string, bool) {
+		return s.PEG, "", s.PEG != 0
+	},
+	"rd_ratio": func(ctx context.Context, s *Stock, m BuffettModel) (float64, string, bool) {
+		gincomes := s.yearGincomeList(1)
+		if len(gincomes) == 0 || gincomes[0].TotalOperateIncome == 0 {
+			return 0, "", false
+		}
+		return gincomes[0].ResearchExpense / gincomes[0].TotalOperateIncome * 100, gincomes[0].ReportDateName, true
+	},
+	"rd_growth": func(ctx context.Context, s *Stock, m BuffettModel) (float64, string, bool) {
+		gincomes := s.yearGincomeList(2)
+		if len(gincomes) < 2 || gincomes[1].ResearchExpense == 0 {
+			return 0, "", false
+		}
+		return (gincomes[0].ResearchExpense - gincomes[1].ResearchExpense) / math.Abs(gincomes[1].ResearchExpense) * 100, "", true
+	},
+	"dividend_ratio": func(ctx context.Context, s *Stock, m BuffettModel) (float64, string, bool) {
+		for _, cf := range s.HistoricalCashflowList {
+			if cf.ReportType != eastmoney.FinaReportTypeYear {
+				continue
+			}
+			for _, g := range s.HistoricalGincomeList {
+				if g.ReportDate == cf.ReportDate && g.ParentNetprofit != 0 {
+					return cf.AssignDividendPorfit / g.ParentNetprofit * 100, cf.ReportDateName, true
+				}
+			}
+			return 0, "", false
+		}
+		return 0, "", false
+	},
+	"dividend_years_3": func(ctx context.Context, s *Stock, m BuffettModel) (float64, string, bool) {
+		years, count := 0, 0
+		for _, cf := range s.HistoricalCashflowList {
+			if cf.ReportType != eastmoney.FinaReportTypeYear {
+				continue
+			}
+			count++
+			if cf.AssignDividendPorfit <= 0 || count > 3 {
+				break
+			}
+			years++
+		}
+		return float64(years), "", count > 0
+	},
+	"share_capital_change": func(ctx context.Context, s *Stock, m BuffettModel) (float64, string, bool) {
+		caps := []float64{}
+		for _, b := range s.HistoricalBalanceList {
+			if len(caps) >= 2 {
+				break
+			}
+			if b.ReportType == eastmoney.FinaReportTypeYear && b.ShareCapital > 0 {
+				caps = append(caps, b.ShareCapital)
+			}
+		}
+		if len(caps) < 2 {
+			return 0, "", false
+		}
+		return (caps[0] - caps[1]) / caps[1] * 100, "负值为回购注销", true
+	},
+}
+
+// scoreComponent 按组成项定义计算得分及评分依据
+func (s *Stock) scoreComponent(ctx context.Context, m BuffettModel, cm BuffettComponentModel) BuffettComponent {
+	c := BuffettComponent{
+		Name:   cm.Name,
+		Label:  cm.Label,
+		Weight: cm.Weight,
+		Inputs: map[string]float64{},
+	}
+	reasons := []string{}
+	credit := 0.0
+	for _, f := range cm.Factors {
+		fc := f.Missing
+		v, note, ok := buffettMetrics[f.Metric](ctx, s, m)
+		if ok {
+			c.Inputs[f.Metric] = v
+			fc = f.Curve.Credit(v)
+			if note != "" {
+				note = "(" + note + ")"
+			}
+			reasons = append(reasons, fmt.Sprintf("%s:%.2f%s→%.2f", f.Label, v, note, fc))
+		} else {
+			reasons = append(reasons, fmt.Sprintf("%s:数据缺失→%.2f", f.Label, fc))
+		}
+		if cm.Combine == "max" {
+			credit = math.Max(credit, fc*f.Share)
+		} else {
+			credit += fc * f.Share
+		}
+	}
+	for _, p := range cm.Penalties {
+		v, _, ok := buffettMetrics[p.Metric](ctx, s, m)
+		if !ok {
+			continue
+		}
+		c.Inputs[p.Metric] = v
+		if v > p.Above {
+			credit *= p.Factor
+			reasons = append(reasons, fmt.Sprintf("%s:%.2f>%.2f,×%.2f", p.Label, v, p.Above, p.Factor))
+		}
+	}
+	c.Score = cm.Weight * math.Max(0, math.Min(1, credit))
+	c.Rationale = strings.Join(reasons, "; ")
+	return c
+}
+
+// calculateBuffettScore 按当前评分模型计算巴菲特评分
+func (s *Stock) calculateBuffettScore(ctx context.Context) BuffettScore {
+	return s.BuffettScoreWithModel(ctx, BuffettScoreModel)
+}
+
+// BuffettScoreWithModel 按评分模型 m 计算巴菲特评分，各组成项满分之和换算为 100 分
+func (s Stock) BuffettScoreWithModel(ctx context.Context, m BuffettModel) BuffettScore {
+	score := BuffettScore{Components: []BuffettComponent{}}
+	raw, full := 0.0, 0.0
+	var desc strings.Builder
+	for _, cm := range m.Components {
+		c := s.scoreComponent(ctx, m, cm)
+		score.Components = append(score.Components, c)
+		raw += c.Score
+		full += c.Weight
+		switch c.Name {
+		case "roe":
+			score.ROEScore = c.Score
+		case "cashflow":
+			score.CashFlowScore = c.Score
+		case "profit_growth":
+			score.ProfitGrowthScore = c.Score
+		case "debt_ratio":
+			score.DebtRatioScore = c.Score
+		case "moat":
+			score.MoatScore = c.Score
+		case "management":
+			score.ManagementScore = c.Score
+		case "valuation":
+			score.ValuationScore = c.Score
+		case "rd":
+			score.RDScore = c.Score
+		case "dividend":
+			score.DividendScore = c.Score
+		case "repurchase":
+			score.RepurchaseScore = c.Score
+		}
+	}
+	if full > 0 {
+		score.TotalScore = raw * 100 / full
+	}
+	desc.WriteString(fmt.Sprintf("总分(100分): %.1f (原始得分: %.1f/%.0f)\n", score.TotalScore, raw, full))
+	for _, c := range score.Components {
+		desc.WriteString(fmt.Sprintf("\n%s(%.0f分): %.1f\n  %s", c.Label, c.Weight, c.Score, c.Rationale))
+	}
+	score.ScoreDescription = desc.String()
+	return score
+}
+
+// IndustryBuffettScore 行业巴菲特评分均值
+type IndustryBuffettScore struct {
+	// 平均总分
+	AvgScore float64 `json:"avg_score"`
+	// 参与计算的股票数
+	StockCount int `json:"stock_count"`
+}
+
+// IndustryBuffettScores 各行业巴菲特评分均值，key 为行业名
+type IndustryBuffettScores map[string]IndustryBuffettScore
+
+// IndustryBuffettScores 按列表中的股票计算各行业巴菲特评分均值，列表应为行业全部成分股
+func (s StockList) IndustryBuffettScores() IndustryBuffettScores {
+	sums, counts := map[string]float64{}, map[string]int{}
+	for _, stock := range s {
+		sums[stock.BaseInfo.Industry] += stock.BuffettScore.TotalScore
+		counts[stock.BaseInfo.Industry]++
+	}
+	scores := IndustryBuffettScores{}
+	for industry, sum := range sums {
+		scores[industry] = IndustryBuffettScore{
+			AvgScore:   sum / float64(counts[industry]),
+			StockCount: counts[industry],
+		}
+	}
+	return scores
+}
+
+// SetIndustryBuffettScore 按行业均值设置股票所属行业的巴菲特评分均值，用于与同行业比较，行业无数据时不比较
+func (s *Stock) SetIndustryBuffettScore(scores IndustryBuffettScores) {
+	industry, exists := scores[s.BaseInfo.Industry]
+	if !exists {
+		return
+	}
+	s.BuffettScore.IndustryAvgScore = industry.AvgScore
+	s.BuffettScore.IndustryStockCount = industry.StockCount
+}
+
+// SetIndustryBuffettScore 为列表中每只股票设置所属行业的巴菲特评分均值
+func (s StockList) SetIndustryBuffettScore(scores IndustryBuffettScores) {
+	for i := range s {
+		s[i].SetIndustryBuffettScore(scores)
+	}
+}
@@ -0,0 +1,135 @@
+package models
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/axiaoxin-com/investool/datacenter/eastmoney"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScoreCurveCredit(t *testing.T) {
+	step := curve(false, 0, 1, 10, 0.8, 30, 0)
+	require.Equal(t, 0.0, step.Credit(-1))
+	require.Equal(t, 1.0, step.Credit(5))
+	require.Equal(t, 0.8, step.Credit(10))
+	require.Equal(t, 0.0, step.Credit(50))
+
+	linear := curve(true, 0, 0, 15, 0.75, 20, 0.75, 20, 1)
+	require.InDelta(t, 0.5, linear.Credit(10), 1e-9)
+	require.InDelta(t, 0.75, linear.Credit(18), 1e-9)
+	require.InDelta(t, 1, linear.Credit(20), 1e-9)
+	require.InDelta(t, 1, linear.Credit(35), 1e-9)
+}
+
+func buffettTestStock() Stock {
+	year := eastmoney.FinaReportTypeYear
+	s := Stock{
+		BaseInfo: eastmoney.StockInfo{Industry: "白酒", PE: 12},
+		HistoricalFinaMainData: eastmoney.HistoricalFinaMainData{
+			{ReportType: year, Roejq: 25, Parentnetprofit: 150, Zcfzl: 20, Xsmll: 45, Roic: 20},
+			{ReportType: year, Roejq: 24, Parentnetprofit: 140, Xsmll: 45},
+			{ReportType: year, Roejq: 26, Parentnetprofit: 130, Xsmll: 45},
+			{ReportType: year, Roejq: 25, Parentnetprofit: 120, Xsmll: 45},
+			{ReportType: year, Roejq: 25, Parentnetprofit: 110, Xsmll: 45},
+		},
+		HistoricalCashflowList: eastmoney.CashflowDataList{
+			{ReportType: year, ReportDate: "2022", NetcashOperate: 10, NetcashInvest: -2, AssignDividendPorfit: 60},
+			{ReportType: year, ReportDate: "2021", NetcashOperate: 10, NetcashInvest: -2, AssignDividendPorfit: 60},
+			{ReportType: year, ReportDate: "2020", NetcashOperate: 10, NetcashInvest: -2, AssignDividendPorfit: 60},
+		},
+		HistoricalGincomeList: eastmoney.GincomeDataList{
+			{ReportType: year, ReportDate: "2022", TotalOperateIncome: 1000, ResearchExpense: 10, ParentNetprofit: 100},
+			{ReportType: year, ReportDate: "2021", TotalOperateIncome: 900, ResearchExpense: 10, ParentNetprofit: 90},
+		},
+		HistoricalBalanceList: eastmoney.BalanceDataList{
+			{ReportType: year, ShareCapital: 99},
+			{ReportType: year, ShareCapital: 100},
+		},
+		FinaReportOpinion: "标准无保留意见",
+	}
+	return s
+}
+
+func TestBuffettScoreWithModel(t *testing.T) {
+	ctx := context.TODO()
+	s := buffettTestStock()
+	score := s.BuffettScoreWithModel(ctx, DefaultBuffettModel)
+	require.Len(t, score.Components, len(DefaultBuffettModel.Components))
+	require.InDelta(t, 20, score.ROEScore, 1e-9)
+	require.InDelta(t, 15, score.CashFlowScore, 1e-9)
+	require.InDelta(t, 12, score.ProfitGrowthScore, 1e-9)
+	require.InDelta(t, 10, score.DebtRatioScore, 1e-9)
+	require.InDelta(t, 12, score.ValuationScore, 1e-9)
+	require.InDelta(t, 10, score.ManagementScore, 1e-9)
+	// 白酒未配置分级得 0.5，毛利率 45% 得 1
+	require.InDelta(t, 7.5, score.MoatScore, 1e-9)
+	require.InDelta(t, 5, score.RepurchaseScore, 1e-9)
+	require.InDelta(t, 5, score.DividendScore, 1e-9)
+	// 研发占比 1% 得 1/3，增长 0 不得分
+	require.InDelta(t, 1, score.RDScore, 1e-9)
+	require.Contains(t, score.ScoreDescription, "白酒未配置护城河分级")
+	require.Equal(t, 45.0, score.Components[4].Inputs["mll_avg_5y"])
+
+	// 行业护城河分级及权重可配置
+	m := DefaultBuffettModel
+	m.MoatTiers = []MoatTier{{Name: "强", Credit: 1, Industries: []string{"酒"}}}
+	m.Components = []BuffettComponentModel{m.Components[4]}
+	score = s.BuffettScoreWithModel(ctx, m)
+	require.InDelta(t, 10, score.MoatScore, 1e-9)
+	require.InDelta(t, 100, score.TotalScore, 1e-9)
+	require.Contains(t, score.Components[0].Rationale, "白酒属于强护城河行业")
+
+	// 数据缺失时使用缺失得分
+	score = Stock{}.BuffettScoreWithModel(ctx, DefaultBuffettModel)
+	require.InDelta(t, 3, score.RepurchaseScore, 1e-9)
+	require.Contains(t, score.Components[9].Rationale, "数据缺失")
+}
+
+func TestStockListSetIndustryBuffettScore(t *testing.T) {
+	list := StockList{
+		{BaseInfo: eastmoney.StockInfo{Industry: "银行"}, BuffettScore: BuffettScore{TotalScore: 60}},
+		{BaseInfo: eastmoney.StockInfo{Industry: "银行"}, BuffettScore: BuffettScore{TotalScore: 80}},
+		{BaseInfo: eastmoney.StockInfo{Industry: "保险"}, BuffettScore: BuffettScore{TotalScore: 50}},
+	}
+	scores := list.IndustryBuffettScores()
+	require.Equal(t, IndustryBuffettScore{AvgScore: 70, StockCount: 2}, scores["银行"])
+
+	// 筛选后的列表按全部成分股的行业均值比较
+	selected := StockList{list[1], list[2]}
+	selected.SetIndustryBuffettScore(scores)
+	require.Equal(t, 70.0, selected[0].BuffettScore.IndustryAvgScore)
+	require.Equal(t, 2, selected[0].BuffettScore.IndustryStockCount)
+	require.Equal(t, 10.0, selected[0].BuffettScore.VsIndustry())
+
+	// 行业无数据时不比较
+	stock := Stock{BaseInfo: eastmoney.StockInfo{Industry: "白酒"}, BuffettScore: BuffettScore{TotalScore: 90}}
+	stock.SetIndustryBuffettScore(scores)
+	require.Equal(t, 0, stock.BuffettScore.IndustryStockCount)
+	require.Equal(t, 0.0, stock.BuffettScore.VsIndustry())
+	require.Equal(t, 0.0, list[2].BuffettScore.VsIndustry())
+}
+
+func TestLoadBuffettModel(t *testing.T) {
+	ctx := context.TODO()
+	m, err := LoadBuffettModel("../buffett_score.toml")
+	require.Nil(t, err)
+	require.Len(t, m.Components, len(DefaultBuffettModel.Components))
+	require.Equal(t, DefaultBuffettModel.DefaultMoatCredit, m.DefaultMoatCredit)
+	s := buffettTestStock()
+	require.InDelta(t, s.BuffettScoreWithModel(ctx, DefaultBuffettModel).TotalScore, s.BuffettScoreWithModel(ctx, m).TotalScore, 0.01)
+
+	filename := filepath.Join(t.TempDir(), "buffett_score.toml")
+	content := `
+[[buffett_score.components]]
+    name = "roe"
+    weight = 10
+    [[buffett_score.components.factors]]
+        metric = "unknown"
+`
+	require.Nil(t, os.WriteFile(filename, []byte(content), 0644))
+	_, err = LoadBuffettModel(filename)
+	require.Error(t, err)
+}
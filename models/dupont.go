@@ -0,0 +1,172 @@
+// 杜邦分析：ROE=净利率×总资产周转率×权益乘数
+
+package models
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/axiaoxin-com/investool/datacenter/eastmoney"
+)
+
+// DuPont 数据来源
+const (
+	// DuPontSourceStatement 按资产负债表及利润表计算
+	DuPontSourceStatement = "statement"
+	// DuPontSourceFinaMain 使用主要指标中的净利率、总资产周转率、权益乘数
+	DuPontSourceFinaMain = "fina_main"
+)
+
+// DuPontYear 单个年报的杜邦分解
+type DuPontYear struct {
+	// 年报年份
+	ReportYear string `json:"report_year"`
+	// 分解后的 ROE（%）=净利率×总资产周转率×权益乘数
+	ROE float64 `json:"roe"`
+	// 财报披露的 ROE（加权）（%）
+	ReportedROE float64 `json:"reported_roe"`
+	// 净利率（%）
+	NetMargin float64 `json:"net_margin"`
+	// 总资产周转率（次）
+	AssetTurnover float64 `json:"asset_turnover"`
+	// 权益乘数
+	EquityMultiplier float64 `json:"equity_multiplier"`
+	// 数据来源
+	Source string `json:"source"`
+}
+
+// DuPontAnalysis 杜邦分析及 ROE 变化归因
+type DuPontAnalysis struct {
+	// 各年分解，最新的在最前面
+	Years []DuPontYear `json:"years"`
+	// 最早一年到最新一年的 ROE 变化（百分点）
+	ROEChange float64 `json:"roe_change"`
+	// 净利率变化对 ROE 变化的贡献（百分点）
+	NetMarginContribution float64 `json:"net_margin_contribution"`
+	// 总资产周转率变化对 ROE 变化的贡献（百分点）
+	AssetTurnoverContribution float64 `json:"asset_turnover_contribution"`
+	// 权益乘数变化对 ROE 变化的贡献（百分点）
+	EquityMultiplierContribution float64 `json:"equity_multiplier_contribution"`
+	// 权益乘数贡献占 ROE 增长的比例（%），ROE 未增长或无法归因时为 0
+	LeverageShare float64 `json:"leverage_share"`
+	// 无法归因的原因
+	Note string `json:"note"`
+}
+
+// Lines 杜邦分析的文字说明
+func (d DuPontAnalysis) Lines() []string {
+	lines := []string{}
+	for _, y := range d.Years {
+		lines = append(lines, fmt.Sprintf("%s:ROE %.2f%%=净利率%.2f%%×周转率%.2f×权益乘数%.2f",
+			y.ReportYear, y.ROE, y.NetMargin, y.AssetTurnover, y.EquityMultiplier))
+	}
+	if len(d.Years) >= 2 {
+		lines = append(lines, fmt.Sprintf("ROE变化:%.2f,净利率贡献:%.2f,周转率贡献:%.2f,权益乘数贡献:%.2f",
+			d.ROEChange, d.NetMarginContribution, d.AssetTurnoverContribution, d.EquityMultiplierContribution))
+	}
+	if d.Note != "" {
+		lines = append(lines, d.Note)
+	}
+	return lines
+}
+
+// String 杜邦分析的单行说明
+func (d DuPontAnalysis) String() string {
+	return strings.Join(d.Lines(), "; ")
+}
+
+// Attributed ROE 变化是否可以归因到三个因素
+func (d DuPontAnalysis) Attributed() bool {
+	return len(d.Years) >= 2 && d.Note == ""
+}
+
+// IsLeverageDriven ROE 增长中权益乘数贡献的比例是否超过 maxShare（%）
+func (d DuPontAnalysis) IsLeverageDriven(maxShare float64) bool {
+	return d.ROEChange > 0 && d.LeverageShare > maxShare
+}
+
+// statementDuPontYear 按同期资产负债表及利润表计算杜邦分解，数据不足时返回 false
+func (s Stock) statementDuPontYear(ctx context.Context, report eastmoney.FinaMainData) (DuPontYear, bool) {
+	y := DuPontYear{ReportYear: report.ReportYear, ReportedROE: report.Roejq, Source: DuPontSourceStatement}
+	b := s.HistoricalBalanceList.GetReport(ctx, report.ReportDate)
+	if b == nil || b.TotalAssets <= 0 || b.TotalParentEquity <= 0 {
+		return y, false
+	}
+	for _, g := range s.HistoricalGincomeList {
+		if g.ReportDate == report.ReportDate && g.TotalOperateIncome > 0 {
+			y.NetMargin = g.ParentNetprofit / g.TotalOperateIncome * 100
+			y.AssetTurnover = g.TotalOperateIncome / b.TotalAssets
+			y.EquityMultiplier = b.TotalAssets / b.TotalParentEquity
+			y.ROE = y.NetMargin * y.AssetTurnover * y.EquityMultiplier
+			return y, true
+		}
+	}
+	return y, false
+}
+
+// finaMainDuPontYear 使用主要指标计算杜邦分解，数据不足时返回 false
+func finaMainDuPontYear(report eastmoney.FinaMainData) (DuPontYear, bool) {
+	y := DuPontYear{ReportYear: report.ReportYear, ReportedROE: report.Roejq, Source: DuPontSourceFinaMain}
+	if report.Toazzl <= 0 || report.Qycs <= 0 {
+		return y, false
+	}
+	y.NetMargin = report.Xsjll
+	y.AssetTurnover = report.Toazzl
+	y.EquityMultiplier = report.Qycs
+	y.ROE = y.NetMargin * y.AssetTurnover * y.EquityMultiplier
+	return y, true
+}
+
+// DuPont 对最近 years 个年报做杜邦分解，并将最早一年到最新一年的 ROE 变化按对数法归因到三个因素。
+// 各年均有资产负债表及利润表时按报表计算，否则统一使用主要指标，避免口径不一致
+func (s Stock) DuPont(ctx context.Context, years int) DuPontAnalysis {
+	d := DuPontAnalysis{Years: []DuPontYear{}}
+	reports := eastmoney.HistoricalFinaMainData{}
+	for _, report := range s.HistoricalFinaMainData {
+		if years > 0 && len(reports) >= years {
+			break
+		}
+		if report.ReportType == eastmoney.FinaReportTypeYear {
+			reports = append(reports, report)
+		}
+	}
+	for _, report := range reports {
+		y, ok := s.statementDuPontYear(ctx, report)
+		if !ok {
+			d.Years = []DuPontYear{}
+			break
+		}
+		d.Years = append(d.Years, y)
+	}
+	if len(d.Years) == 0 {
+		for _, report := range reports {
+			if y, ok := finaMainDuPontYear(report); ok {
+				d.Years = append(d.Years, y)
+			}
+		}
+	}
+	if len(d.Years) < 2 {
+		d.Note = "年报数据不足，无法归因"
+		return d
+	}
+	cur, base := d.Years[0], d.Years[len(d.Years)-1]
+	d.ROEChange = cur.ROE - base.ROE
+	if cur.ROE == base.ROE {
+		return d
+	}
+	if cur.ROE <= 0 || base.ROE <= 0 || cur.NetMargin <= 0 || base.NetMargin <= 0 {
+		d.Note = "ROE或净利率为负，无法归因"
+		return d
+	}
+	// 对数法：各因素贡献=ROE变化×ln(因素比值)/ln(ROE比值)，三者之和等于 ROE 变化
+	weight := d.ROEChange / math.Log(cur.ROE/base.ROE)
+	d.NetMarginContribution = weight * math.Log(cur.NetMargin/base.NetMargin)
+	d.AssetTurnoverContribution = weight * math.Log(cur.AssetTurnover/base.AssetTurnover)
+	d.EquityMultiplierContribution = weight * math.Log(cur.EquityMultiplier/base.EquityMultiplier)
+	if d.ROEChange > 0 {
+		d.LeverageShare = d.EquityMultiplierContribution / d.ROEChange * 100
+	}
+	return d
+}
@@ -0,0 +1,59 @@
+package models
+
+import (
+	"context"
+	"testing"
+
+	"github.com/axiaoxin-com/investool/datacenter/eastmoney"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDuPont(t *testing.T) {
+	ctx := context.TODO()
+	year := eastmoney.FinaReportTypeYear
+	s := Stock{
+		HistoricalFinaMainData: eastmoney.HistoricalFinaMainData{
+			{ReportYear: "2022", ReportType: year, ReportDate: "2022-12-31 00:00:00", Xsjll: 10, Toazzl: 1, Qycs: 3},
+			{ReportYear: "2022", ReportType: eastmoney.FinaReportTypeQ3, Xsjll: 9, Toazzl: 0.7, Qycs: 3},
+			{ReportYear: "2021", ReportType: year, ReportDate: "2021-12-31 00:00:00", Xsjll: 10, Toazzl: 1, Qycs: 2.5},
+			{ReportYear: "2020", ReportType: year, ReportDate: "2020-12-31 00:00:00", Xsjll: 10, Toazzl: 1, Qycs: 2},
+		},
+	}
+	d := s.DuPont(ctx, 3)
+	require.Len(t, d.Years, 3)
+	require.Equal(t, DuPontSourceFinaMain, d.Years[0].Source)
+	require.InDelta(t, 30, d.Years[0].ROE, 1e-9)
+	require.InDelta(t, 10, d.ROEChange, 1e-9)
+	require.InDelta(t, 10, d.EquityMultiplierContribution, 1e-9)
+	require.InDelta(t, 100, d.LeverageShare, 1e-9)
+	require.True(t, d.IsLeverageDriven(50))
+
+	// 净利率驱动
+	s.HistoricalFinaMainData[0].Qycs = 2
+	s.HistoricalFinaMainData[0].Xsjll = 15
+	s.HistoricalFinaMainData[2].Qycs = 2
+	d = s.DuPont(ctx, 3)
+	require.InDelta(t, 10, d.NetMarginContribution, 1e-9)
+	require.InDelta(t, 0, d.LeverageShare, 1e-9)
+	require.False(t, d.IsLeverageDriven(50))
+	require.Len(t, d.Lines(), 4)
+
+	// 各年均有报表时按报表计算，否则统一使用主要指标
+	s.HistoricalBalanceList = eastmoney.BalanceDataList{
+		{ReportDate: "2022-12-31 00:00:00", TotalAssets: 100, TotalParentEquity: 25},
+	}
+	s.HistoricalGincomeList = eastmoney.GincomeDataList{
+		{ReportDate: "2022-12-31 00:00:00", TotalOperateIncome: 50, ParentNetprofit: 5},
+		{ReportDate: "2021-12-31 00:00:00", TotalOperateIncome: 50, ParentNetprofit: 5},
+	}
+	require.Equal(t, DuPontSourceFinaMain, s.DuPont(ctx, 2).Years[0].Source)
+	s.HistoricalBalanceList = append(s.HistoricalBalanceList, eastmoney.BalanceData{
+		ReportDate: "2021-12-31 00:00:00", TotalAssets: 100, TotalParentEquity: 25,
+	})
+	d = s.DuPont(ctx, 2)
+	require.Equal(t, DuPontSourceStatement, d.Years[1].Source)
+	require.InDelta(t, 20, d.Years[0].ROE, 1e-9)
+	require.Equal(t, 0.0, d.ROEChange)
+
+	require.Equal(t, "年报数据不足，无法归因", Stock{}.DuPont(ctx, 5).Note)
+}
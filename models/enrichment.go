@@ -0,0 +1,166 @@
+// 股票数据补充项：创建 Stock 时可按需获取的各项数据及获取结果
+
+package models
+
+import (
+	"strings"
+
+	"github.com/axiaoxin-com/investool/valuation"
+)
+
+// Enrichment 股票数据补充项，可按位组合
+type Enrichment uint
+
+const (
+	// EnrichFinaMain 历史财报主要指标
+	EnrichFinaMain Enrichment = 1 << iota
+	// EnrichPE 历史市盈率及合理价，依赖历史财报
+	EnrichPE
+	// EnrichValuation 四率综合估值
+	EnrichValuation
+	// EnrichPrice 历史股价及历史波动率
+	EnrichPrice
+	// EnrichCompanyProfile 公司资料
+	EnrichCompanyProfile
+	// EnrichPublishDate 财报披露日期
+	EnrichPublishDate
+	// EnrichOrgRating 机构评级
+	EnrichOrgRating
+	// EnrichProfitPredict 盈利预测
+	EnrichProfitPredict
+	// EnrichJZPG 价值评估
+	EnrichJZPG
+	// EnrichGincome 利润表
+	EnrichGincome
+	// EnrichCashflow 现金流量表
+	EnrichCashflow
+	// EnrichFreeHolders 十大流通股东
+	EnrichFreeHolders
+	// EnrichMainMoney 主力资金净流入
+	EnrichMainMoney
+	// EnrichBalance 资产负债表
+	EnrichBalance
+
+	// EnrichAll 全部数据
+	EnrichAll = EnrichFinaMain | EnrichPE | EnrichValuation | EnrichPrice | EnrichCompanyProfile |
+		EnrichPublishDate | EnrichOrgRating | EnrichProfitPredict | EnrichJZPG | EnrichGincome |
+		EnrichCashflow | EnrichFreeHolders | EnrichMainMoney | EnrichBalance
+)
+
+// enrichmentNames 数据补充项名称
+var enrichmentNames = []struct {
+	e    Enrichment
+	name string
+}{
+	{EnrichFinaMain, "fina_main"},
+	{EnrichPE, "historical_pe"},
+	{EnrichValuation, "valuation"},
+	{EnrichPrice, "historical_price"},
+	{EnrichCompanyProfile, "company_profile"},
+	{EnrichPublishDate, "publish_date"},
+	{EnrichOrgRating, "org_rating"},
+	{EnrichProfitPredict, "profit_predict"},
+	{EnrichJZPG, "jzpg"},
+	{EnrichGincome, "gincome"},
+	{EnrichCashflow, "cashflow"},
+	{EnrichFreeHolders, "free_holders"},
+	{EnrichMainMoney, "main_money"},
+	{EnrichBalance, "balance"},
+}
+
+// Has 是否包含 e 中的全部数据项
+func (m Enrichment) Has(e Enrichment) bool {
+	return m&e == e
+}
+
+// withDependencies 补充依赖的数据项
+func (m Enrichment) withDependencies() Enrichment {
+	if m.Has(EnrichPE) {
+		m |= EnrichFinaMain
+	}
+	return m
+}
+
+// List 拆分为单个数据项
+func (m Enrichment) List() []Enrichment {
+	list := []Enrichment{}
+	for _, i := range enrichmentNames {
+		if m.Has(i.e) {
+			list = append(list, i.e)
+		}
+	}
+	return list
+}
+
+// String 数据项名称，多个数据项使用 | 连接
+func (m Enrichment) String() string {
+	names := []string{}
+	for _, i := range enrichmentNames {
+		if m.Has(i.e) {
+			names = append(names, i.name)
+		}
+	}
+	return strings.Join(names, "|")
+}
+
+// EnrichmentStatus 数据获取状态
+type EnrichmentStatus string
+
+const (
+	// EnrichmentStatusOK 获取成功
+	EnrichmentStatusOK EnrichmentStatus = "ok"
+	// EnrichmentStatusFailed 获取失败
+	EnrichmentStatusFailed EnrichmentStatus = "failed"
+	// EnrichmentStatusSkipped 未获取
+	EnrichmentStatusSkipped EnrichmentStatus = "skipped"
+)
+
+// EnrichmentResult 数据获取结果
+type EnrichmentResult struct {
+	Status EnrichmentStatus `json:"status"`
+	// 失败原因
+	Error string `json:"error,omitempty"`
+}
+
+// NewStockOptions 创建 Stock 对象的选项
+type NewStockOptions struct {
+	// 需要获取的数据项
+	Enrichments Enrichment
+	// 合理价估值参数
+	Valuation valuation.Options
+	// DCF 估值参数
+	DCF valuation.DCFOptions
+	// DDM 估值参数
+	DDM valuation.DDMOptions
+}
+
+// DefaultNewStockOptions 默认获取全部数据
+var DefaultNewStockOptions = NewStockOptions{
+	Enrichments: EnrichAll,
+	Valuation:   valuation.DefaultOptions,
+	DCF:         valuation.DefaultDCFOptions,
+	DDM:         valuation.DefaultDDMOptions,
+}
+
+// EnrichmentStatus 返回数据项 e 的获取状态，未记录获取结果时（如直接构造的 Stock）视为获取成功
+func (s Stock) EnrichmentStatus(e Enrichment) EnrichmentStatus {
+	if s.Enrichments == nil {
+		return EnrichmentStatusOK
+	}
+	r, exists := s.Enrichments[e.String()]
+	if !exists {
+		return EnrichmentStatusSkipped
+	}
+	return r.Status
+}
+
+// MissingEnrichments 返回 e 中未成功获取的数据项
+func (s Stock) MissingEnrichments(e Enrichment) []Enrichment {
+	missing := []Enrichment{}
+	for _, i := range e.List() {
+		if s.EnrichmentStatus(i) != EnrichmentStatusOK {
+			missing = append(missing, i)
+		}
+	}
+	return missing
+}
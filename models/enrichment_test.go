@@ -0,0 +1,29 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestEnrichment(t *testing.T) {
+	e := EnrichPE | EnrichCashflow
+	require.True(t, e.Has(EnrichPE))
+	require.False(t, e.Has(EnrichFinaMain))
+	require.True(t, e.withDependencies().Has(EnrichFinaMain))
+	require.Equal(t, "historical_pe|cashflow", e.String())
+	require.Equal(t, []Enrichment{EnrichPE, EnrichCashflow}, e.List())
+	require.Len(t, EnrichAll.List(), 14)
+
+	s := Stock{}
+	require.Equal(t, EnrichmentStatusOK, s.EnrichmentStatus(EnrichPE))
+	require.Empty(t, s.MissingEnrichments(EnrichAll))
+
+	s.Enrichments = map[string]EnrichmentResult{
+		"historical_pe": {Status: EnrichmentStatusFailed, Error: "timeout"},
+		"cashflow":      {Status: EnrichmentStatusOK},
+	}
+	require.Equal(t, EnrichmentStatusFailed, s.EnrichmentStatus(EnrichPE))
+	require.Equal(t, EnrichmentStatusSkipped, s.EnrichmentStatus(EnrichJZPG))
+	require.Equal(t, []Enrichment{EnrichPE, EnrichJZPG}, s.MissingEnrichments(EnrichPE|EnrichCashflow|EnrichJZPG))
+}
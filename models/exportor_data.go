@@ -94,6 +94,10 @@ type ExportorData struct {
 	PE float64 `json:"pe"                        csv:"市盈率"`
 	// PEG
 	PEG float64 `json:"peg"                       csv:"PEG"`
+	// TTM PE
+	TTMPE float64 `json:"ttm_pe"                    csv:"TTM PE"`
+	// TTM PEG
+	TTMPEG float64 `json:"ttm_peg"                   csv:"TTM PEG"`
 	// 机构评级
 	OrgRating string `json:"org_rating"                csv:"机构评级"`
 	// 盈利预测
@@ -132,6 +136,18 @@ type ExportorData struct {
 	BuffettScore float64 `json:"buffett_score" csv:"巴菲特评分"`
 	// 巴菲特评分描述
 	BuffettScoreDesc string `json:"buffett_score_desc" csv:"巴菲特评分描述"`
+	// 同行业巴菲特评分均值
+	BuffettIndustryAvg float64 `json:"buffett_industry_avg" csv:"行业平均巴菲特评分"`
+	// 近五年杜邦分析
+	DuPont string `json:"dupont" csv:"杜邦分析"`
+	// 近五年 ROE 增长中权益乘数贡献的比例
+	ROELeverageShare float64 `json:"roe_leverage_share" csv:"ROE增长杠杆贡献 (%)"`
+	// Piotroski F-Score
+	PiotroskiFScore string `json:"piotroski_f_score" csv:"Piotroski F-Score"`
+	// Altman Z-Score
+	AltmanZScore string `json:"altman_z_score" csv:"Altman Z-Score"`
+	// Beneish M-Score
+	BeneishMScore string `json:"beneish_m_score" csv:"Beneish M-Score"`
 }
 
 // GetHeaderValueMap 获取以 csv tag 为 key 的 Data map
@@ -177,6 +193,7 @@ func NewExportorData(ctx context.Context, stock Stock) ExportorData {
 	}
 
 	fina := stock.HistoricalFinaMainData[0]
+	dupont := stock.DuPont(ctx, 5)
 	return ExportorData{
 		Name:            stock.BaseInfo.SecurityNameAbbr,
 		Code:            stock.BaseInfo.Secucode,
@@ -229,6 +246,8 @@ func NewExportorData(ctx context.Context, stock Stock) ExportorData {
 		ListingYieldYear:       stock.BaseInfo.ListingYieldYear,
 		PE:                     stock.BaseInfo.PE,
 		PEG:                    stock.PEG,
+		TTMPE:                  stock.TTMPE,
+		TTMPEG:                 stock.TTMPEG,
 		OrgRating:              stock.OrgRatingList.String(),
 		ProfitPredict:          stock.ProfitPredictList.String(),
 		ValuationSYL:           stock.ValuationMap["市盈率"],
@@ -254,9 +273,23 @@ func NewExportorData(ctx context.Context, stock Stock) ExportorData {
 		MainMoneyNetInflows: stock.MainMoneyNetInflows.String(),
 		BuffettScore:        stock.BuffettScore.TotalScore,
 		BuffettScoreDesc:    stock.BuffettScore.ScoreDescription,
+		BuffettIndustryAvg:  stock.BuffettScore.IndustryAvgScore,
+		DuPont:              dupont.String(),
+		ROELeverageShare:    dupont.LeverageShare,
+		PiotroskiFScore:     qualityScoreString(stock.PiotroskiFScore),
+		AltmanZScore:        qualityScoreString(stock.AltmanZScore),
+		BeneishMScore:       qualityScoreString(stock.BeneishMScore),
 	}
 }
 
+// qualityScoreString 财务质量评分及区间说明，无评分时返回 --
+func qualityScoreString(q *QualityScore) string {
+	if q == nil {
+		return "--"
+	}
+	return fmt.Sprintf("%.2f(%s)", q.Score, q.Zone)
+}
+
 // ExportorDataList 要导出的数据列表
 type ExportorDataList []ExportorData
 
@@ -30,8 +30,12 @@ type Fund struct {
 	IndexCode string `json:"index_code"`
 	// 跟踪标的名称
 	IndexName string `json:"index_name"`
+	// 业绩比较基准
+	Benchmark string `json:"benchmark"`
 	// 购买费率
 	Rate string `json:"rate"`
+	// 费率表
+	Fee FundFeeSchedule `json:"fee"`
 	// 定投状态
 	FixedInvestmentStatus string `json:"fixed_investment_status"`
 	// 波动率
@@ -44,14 +48,26 @@ type Fund struct {
 	Performance fundPerformance `json:"performance"`
 	// 持仓股票
 	Stocks []fundStock `json:"stocks"`
+	// 重仓债券
+	Bonds []fundBond `json:"bonds"`
 	// 基金经理
 	Manager fundManager `json:"manager"`
+	// 全部现任基金经理，共同管理时有多位
+	Managers []fundManager `json:"managers"`
 	// 历史分红送配
 	HistoricalDividends []fundDividend `json:"historical_dividends"`
 	// 资产占比
 	AssetsProportion fundAssetsProportion `json:"assets_proportion"`
-	// 行业占比
+	// 最新报告期行业占比
 	IndustryProportions []fundIndustryProportion `json:"industry_proportions"`
+	// 各报告期行业占比，按公布日期升序
+	IndustryHistory []fundIndustrySnapshot `json:"industry_history"`
+	// 风格漂移
+	StyleDrift FundStyleDrift `json:"style_drift"`
+	// 债券持仓分析
+	BondAnalysis FundBondAnalysis `json:"bond_analysis"`
+	// 综合评分
+	Score FundScore `json:"score"`
 }
 
 // fundIndustryProportion 行业占比
@@ -276,6 +292,8 @@ type fundManager struct {
 	WorkingDays float64 `json:"working_days"`
 	// 管理该基金时间（天）
 	ManageDays float64 `json:"manage_days"`
+	// 任职该基金开始日期
+	StartDate string `json:"start_date"`
 	// 任职回报（%）
 	ManageRepay float64 `json:"manage_repay"`
 	// 年均回报（%）
@@ -348,6 +366,7 @@ func NewFund(ctx context.Context, efund *eastmoney.RespFundInfo) *Fund {
 		EstablishedDate: efund.Jjxq.Datas.Estabdate,
 		IndexCode:       efund.Jjxq.Datas.Indexcode,
 		IndexName:       efund.Jjxq.Datas.Indexname,
+		Benchmark:       efund.Jjxq.Datas.Bench,
 		Rate:            efund.Jjxq.Datas.Rate,
 		Stddev: fundStddev{
 			Year1:  stddev1,
@@ -483,19 +502,36 @@ func NewFund(ctx context.Context, efund *eastmoney.RespFundInfo) *Fund {
 	}
 	fund.Stocks = stocks
 
+	// 重仓债券
+	bonds := []fundBond{}
+	for _, b := range efund.Jjcc.Datas.InverstPosition.Fundboods {
+		bond := fundBond{
+			Code:      b.Zqdm,
+			Name:      b.Zqmc,
+			HoldRatio: interfaceToFloat64(ctx, b.Zjzbl),
+			Kind:      classifyBond(b.Zqmc),
+		}
+		bonds = append(bonds, bond)
+	}
+	fund.Bonds = bonds
+
 	// 基金经理
-	manager := fundManager{}
+	fund.Managers = []fundManager{}
 	if len(efund.Jjjlnew.Datas) > 0 {
 		jjjl := efund.Jjjlnew.Datas[0]
 		if len(jjjl.Manger) > 0 {
-			m := jjjl.Manger[0]
-			manager.ID = m.Mgrid
-			manager.Name = m.Mgrname
-			manager.WorkingDays = interfaceToFloat64(ctx, m.Totaldays)
-			manager.ManageDays = interfaceToFloat64(ctx, m.Days)
-			manager.ManageRepay = interfaceToFloat64(ctx, m.Penavgrowth)
-			manager.YearsAvgRepay = interfaceToFloat64(ctx, m.Yieldse)
-			fund.Manager = manager
+			for _, m := range jjjl.Manger {
+				fund.Managers = append(fund.Managers, fundManager{
+					ID:            m.Mgrid,
+					Name:          m.Mgrname,
+					WorkingDays:   interfaceToFloat64(ctx, m.Totaldays),
+					ManageDays:    interfaceToFloat64(ctx, m.Days),
+					StartDate:     m.Fempdate,
+					ManageRepay:   interfaceToFloat64(ctx, m.Penavgrowth),
+					YearsAvgRepay: interfaceToFloat64(ctx, m.Yieldse),
+				})
+			}
+			fund.Manager = fund.Managers[0]
 		} else {
 			logging.Warnf(ctx, "code:%v jjjlnew manager no data", fund.Code)
 		}
@@ -518,9 +554,11 @@ func NewFund(ctx context.Context, efund *eastmoney.RespFundInfo) *Fund {
 	}
 	fund.HistoricalDividends = dividends
 
-	// 资产占比
-	for _, vlist := range efund.Jjcc.Datas.AssetAllocation {
-		if len(vlist) > 0 {
+	// 资产占比，取最新报告期
+	latestAssetDate := ""
+	for date, vlist := range efund.Jjcc.Datas.AssetAllocation {
+		if len(vlist) > 0 && date > latestAssetDate {
+			latestAssetDate = date
 			v := vlist[0]
 			ap := fundAssetsProportion{
 				PubDate:   v["FSRQ"],
@@ -535,20 +573,23 @@ func NewFund(ctx context.Context, efund *eastmoney.RespFundInfo) *Fund {
 	}
 
 	// 行业占比
-	for date, vlist := range efund.Jjcc.Datas.SectorAllocation {
-		for _, i := range vlist {
-			if i["ZJZBL"] == "0" || i["ZJZBL"] == "--" {
-				continue
-			}
+	fund.IndustryHistory = newFundIndustryHistory(efund.Jjcc.Datas.SectorAllocation)
+	if n := len(fund.IndustryHistory); n > 0 {
+		latest := fund.IndustryHistory[n-1]
+		for _, i := range latest.Industries {
 			ip := fundIndustryProportion{
-				PubDate:  date,
-				Industry: i["HYMC"],
-				Prop:     i["ZJZBL"],
+				PubDate:  latest.PubDate,
+				Industry: i.Industry,
+				Prop:     strconv.FormatFloat(i.Weight, 'f', -1, 64),
 			}
 			fund.IndustryProportions = append(fund.IndustryProportions, ip)
 		}
 	}
 
+	// 风格漂移
+	fund.StyleDrift = fund.CheckStyleDrift(DefaultFundStyleDriftOptions)
+	fund.BondAnalysis = fund.AnalyzeBonds()
+
 	return &fund
 }
 
@@ -585,6 +626,8 @@ const (
 	FundSortTypeMaxRetr135Avg
 	// FundSortTypeSharp135Avg 按1，3，5年夏普比率平均值排序
 	FundSortTypeSharp135Avg
+	// FundSortTypeScore 按综合评分排序
+	FundSortTypeScore
 )
 
 // Sort 排序
@@ -642,6 +685,10 @@ func (f FundList) Sort(st FundSortType) {
 		sort.Slice(f, func(i, j int) bool {
 			return f[i].MaxRetracement.Avg135 < f[j].MaxRetracement.Avg135
 		})
+	case FundSortTypeScore:
+		sort.Slice(f, func(i, j int) bool {
+			return f[i].Score.Total > f[j].Score.Total
+		})
 	}
 }
 
@@ -671,107 +718,56 @@ func (f FundList) Types() (types []string) {
 // ParamFundListFilter Filter 参数
 type ParamFundListFilter struct {
 	// 类型
-	Types []string `json:"types"                    form:"types"`
+	Types []string `json:"types"                    form:"types"                    mapstructure:"types"`
 	// 基金规模最小值（亿）
-	MinScale float64 `json:"min_scale"                form:"min_scale"`
+	MinScale float64 `json:"min_scale"                form:"min_scale"                mapstructure:"min_scale"`
 	// 基金规模最大值（亿）
-	MaxScale float64 `json:"max_scale"                form:"max_scale"`
+	MaxScale float64 `json:"max_scale"                form:"max_scale"                mapstructure:"max_scale"`
 	// 基金经理管理该基金最低年限
-	MinManagerYears float64 `json:"min_manager_years"        form:"min_manager_years"`
+	MinManagerYears float64 `json:"min_manager_years"        form:"min_manager_years"        mapstructure:"min_manager_years"`
 	// 最近一年收益率排名比
-	Year1RankRatio float64 `json:"year_1_rank_ratio"        form:"year_1_rank_ratio"`
+	Year1RankRatio float64 `json:"year_1_rank_ratio"        form:"year_1_rank_ratio"        mapstructure:"year_1_rank_ratio"`
 	// 今年来、最近两年、最近三年、最近五年收益率排名比
-	ThisYear235RankRatio float64 `json:"this_year_235_rank_ratio" form:"this_year_235_rank_ratio"`
+	ThisYear235RankRatio float64 `json:"this_year_235_rank_ratio" form:"this_year_235_rank_ratio" mapstructure:"this_year_235_rank_ratio"`
 	// 最近六月收益率排名比
-	Month6RankRatio float64 `json:"month_6_rank_ratio"       form:"month_6_rank_ratio"`
+	Month6RankRatio float64 `json:"month_6_rank_ratio"       form:"month_6_rank_ratio"       mapstructure:"month_6_rank_ratio"`
 	// 最近三月收益率排名比
-	Month3RankRatio float64 `json:"month_3_rank_ratio"       form:"month_3_rank_ratio"`
+	Month3RankRatio float64 `json:"month_3_rank_ratio"       form:"month_3_rank_ratio"       mapstructure:"month_3_rank_ratio"`
 	// 1,3,5年波动率平均值的最大值
-	Max135AvgStddev float64 `json:"max_135_avg_stddev"       form:"max_135_avg_stddev"`
+	Max135AvgStddev float64 `json:"max_135_avg_stddev"       form:"max_135_avg_stddev"       mapstructure:"max_135_avg_stddev"`
 	// 1,3,5年夏普比率平均值的最小值
-	Min135AvgSharp float64 `json:"min_135_avg_sharp"        form:"min_135_avg_sharp"`
+	Min135AvgSharp float64 `json:"min_135_avg_sharp"        form:"min_135_avg_sharp"        mapstructure:"min_135_avg_sharp"`
 	// 1,3,5年最大回撤率平均值的最大值
-	Max135AvgRetr float64 `json:"max_135_avg_retr"         form:"max_135_avg_retr"`
+	Max135AvgRetr float64 `json:"max_135_avg_retr"         form:"max_135_avg_retr"         mapstructure:"max_135_avg_retr"`
 	// 最低成立年限
-	MinEstabYears float64 `json:"min_estab_years"          form:"min_estab_years"`
+	MinEstabYears float64 `json:"min_estab_years"          form:"min_estab_years"          mapstructure:"min_estab_years"`
+	// 杠杆率上限（%）
+	MaxLeverage float64 `json:"max_leverage"             form:"max_leverage"             mapstructure:"max_leverage"`
+	// 可转债占重仓债券比例上限（%）
+	MaxConvertibleRatio float64 `json:"max_convertible_ratio"    form:"max_convertible_ratio"    mapstructure:"max_convertible_ratio"`
+	// 前五大重仓债券占净值比例上限（%）
+	MaxBondTop5Ratio float64 `json:"max_bond_top5_ratio"      form:"max_bond_top5_ratio"      mapstructure:"max_bond_top5_ratio"`
+	// 购买费率上限（%）
+	MaxPurchaseRate float64 `json:"max_purchase_rate"        form:"max_purchase_rate"        mapstructure:"max_purchase_rate"`
+	// 管理费、托管费及销售服务费合计上限（%/年）
+	MaxAnnualFeeRate float64 `json:"max_annual_fee_rate"      form:"max_annual_fee_rate"      mapstructure:"max_annual_fee_rate"`
 }
 
 // Filter 按参数过滤
 func (f FundList) Filter(ctx context.Context, p ParamFundListFilter) FundList {
 	results := FundList{}
 	for _, fund := range f {
-		switch {
-		case p.MinEstabYears > 0 && fund.EstabYears(ctx) > 0 && fund.EstabYears(ctx) < p.MinEstabYears:
-			// 排除成立年限不达标的
-			continue
-		case p.Year1RankRatio > 0 && fund.Performance.Year1RankRatio > p.Year1RankRatio:
-			// 最近1年排名大于前百分比的排除
-			continue
-		case p.ThisYear235RankRatio > 0 && (fund.Performance.Year2RankRatio > p.ThisYear235RankRatio ||
-			fund.Performance.Year3RankRatio > p.ThisYear235RankRatio ||
-			fund.Performance.Year5RankRatio > p.ThisYear235RankRatio ||
-			fund.Performance.ThisYearRankRatio > p.ThisYear235RankRatio):
-			// 最近2,3,5以及今年来排名大于前百分比的排除
-			continue
-		case p.Month6RankRatio > 0 && fund.Performance.Month6RankRatio > p.Month6RankRatio:
-			// 最近6个月排名大于前百分比的排除
-			continue
-		case p.Month3RankRatio > 0 && fund.Performance.Month3RankRatio > p.Month3RankRatio:
-			// 最近3个月排名大于前百分比的排除
-			continue
-		case len(p.Types) > 0 && !goutils.IsStrInSlice(fund.Type, p.Types):
-			// 指定类型时，基金类型不在指定列表则跳过
-			continue
-		case p.MinScale > 0 && fund.NetAssetsScale < p.MinScale*100000000:
-			// 指定最小规模时，基金规模不能小于该值
-			continue
-		case p.MaxScale > 0 && fund.NetAssetsScale > p.MaxScale*100000000:
-			// 指定最大规模时，基金规模不能大于该值
-			continue
-		case p.MinManagerYears > 0 && (fund.Manager.ManageDays/365) < p.MinManagerYears:
-			// 指定基金经理管理该基金最低年限时，基金经理任职年数不能小于该值
-			continue
-		case p.Max135AvgStddev > 0 && fund.Stddev.Avg135 > p.Max135AvgStddev:
-			// 波动率平均值大于指定值时跳过
-			continue
-		case p.Max135AvgRetr > 0 && fund.MaxRetracement.Avg135 > p.Max135AvgRetr:
-			// 最大回撤率平均值大于指定值时跳过
-			continue
-		case p.Min135AvgSharp > 0 && fund.Sharp.Avg135 < p.Min135AvgSharp:
-			// 夏普比率平均值小于指定值时跳过
-			continue
+		if p.Match(ctx, *fund) {
+			results = append(results, fund)
 		}
-		results = append(results, fund)
 	}
 	return results
 }
 
-// Is4433 判断是否满足4433法则
+// Is4433 判断是否满足4433法则，阈值使用规则集配置中的 4433 规则集
 func (f Fund) Is4433(ctx context.Context) bool {
-	// 没有5年数据则不满足
-	if f.Performance.Year5ProfitRatio == 0 || f.Performance.Year5RankNum == 0 {
-		return false
-	}
-	quarterRatio := float64(1) / float64(4) * 100.0
-	oneThirdRatio := float64(1) / float64(3) * 100.0
-	// 最近1年收益率排名在同类型基金的前四分之一；
-	if f.Performance.Year1RankRatio > quarterRatio {
-		return false
-	}
-	// 最近2年、3年、5年及今年以来收益率排名均在同类型基金的前四分之一；
-	if f.Performance.Year2RankRatio > quarterRatio || f.Performance.Year3RankRatio > quarterRatio || f.Performance.Year5RankRatio > quarterRatio ||
-		f.Performance.ThisYearRankRatio > quarterRatio {
-		return false
-	}
-	// 最近6个月收益率排名在同类型基金的前三分之一；
-	if f.Performance.Month6RankRatio > oneThirdRatio {
-		return false
-	}
-	// 最近3个月收益率排名在同类型基金的前三分之一；
-	if f.Performance.Month3RankRatio > oneThirdRatio {
-		return false
-	}
-	return true
+	rs, _ := GetFundRuleSet(FundRuleSetName4433)
+	return rs.Match(ctx, f)
 }
 
 // NetAssetsScaleHuman 净资产数字转换为亿、万单位
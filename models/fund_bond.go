@@ -0,0 +1,256 @@
+// 债券基金分析：重仓债券集中度、可转债占比、利率债信用债分类、杠杆率及回撤修复时间
+
+package models
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/axiaoxin-com/investool/datacenter/eastmoney"
+)
+
+// 债券类别
+const (
+	// BondKindRate 利率债：国债、地方政府债、政策性金融债
+	BondKindRate = "利率债"
+	// BondKindCredit 信用债
+	BondKindCredit = "信用债"
+	// BondKindConvertible 可转债及可交换债
+	BondKindConvertible = "可转债"
+	// BondKindCD 同业存单
+	BondKindCD = "同业存单"
+)
+
+// fundBond 重仓债券
+type fundBond struct {
+	// 债券代码
+	Code string `json:"code"`
+	// 债券名称
+	Name string `json:"name"`
+	// 持仓占净值比例（%）
+	HoldRatio float64 `json:"hold_ratio"`
+	// 债券类别
+	Kind string `json:"kind"`
+}
+
+var (
+	// 政策性金融债及国债
+	rateBondRegexp = regexp.MustCompile(`国债|国开|进出|农发|政金|央票|地方政府`)
+	// 地方政府债，如 21江苏债15
+	localGovBondRegexp = regexp.MustCompile(`(北京|天津|上海|重庆|河北|山西|辽宁|吉林|黑龙江|江苏|浙江|安徽|福建|江西|山东|河南|湖北|湖南|广东|海南|四川|贵州|云南|陕西|甘肃|青海|内蒙|广西|西藏|宁夏|新疆|大连|青岛|宁波|厦门|深圳)(债|专项|再融)`)
+)
+
+// classifyBond 按债券名称判断债券类别
+func classifyBond(name string) string {
+	switch {
+	case strings.Contains(name, "CD") || strings.Contains(name, "存单"):
+		return BondKindCD
+	case strings.Contains(name, "转债") || strings.Contains(name, "EB") || strings.Contains(name, "可交换"):
+		return BondKindConvertible
+	case rateBondRegexp.MatchString(name) || localGovBondRegexp.MatchString(name):
+		return BondKindRate
+	default:
+		return BondKindCredit
+	}
+}
+
+// IsBond 是否为债券型基金
+func (f Fund) IsBond() bool {
+	return strings.Contains(f.Type, "债券")
+}
+
+// FundBondAnalysis 债券基金持仓分析
+type FundBondAnalysis struct {
+	// 股票占净值比例（%）
+	StockRatio float64 `json:"stock_ratio"`
+	// 债券占净值比例（%）
+	BondRatio float64 `json:"bond_ratio"`
+	// 现金占净值比例（%）
+	CashRatio float64 `json:"cash_ratio"`
+	// 杠杆率（%），为各类资产占净值比例之和，不足 100% 时为 100
+	Leverage float64 `json:"leverage"`
+	// 披露的重仓债券数量
+	BondCount int `json:"bond_count"`
+	// 重仓债券合计占净值比例（%）
+	TopBondsRatio float64 `json:"top_bonds_ratio"`
+	// 第一大重仓债券占净值比例（%）
+	Top1Ratio float64 `json:"top1_ratio"`
+	// 前五大重仓债券占净值比例（%）
+	Top5Ratio float64 `json:"top5_ratio"`
+	// 重仓债券集中度 HHI，按各债券占重仓债券合计的比例计算
+	HHI float64 `json:"hhi"`
+	// 利率债占重仓债券比例（%）
+	RateRatio float64 `json:"rate_ratio"`
+	// 信用债占重仓债券比例（%）
+	CreditRatio float64 `json:"credit_ratio"`
+	// 可转债占重仓债券比例（%）
+	ConvertibleRatio float64 `json:"convertible_ratio"`
+	// 同业存单占重仓债券比例（%）
+	CDRatio float64 `json:"cd_ratio"`
+	// 持仓风格: 利率债为主 信用债为主 可转债为主 同业存单为主 混合，无重仓债券时为空
+	Style string `json:"style"`
+	// 提示
+	Warnings []string `json:"warnings"`
+}
+
+// AnalyzeBonds 按重仓债券及资产占比分析债券持仓
+func (f Fund) AnalyzeBonds() FundBondAnalysis {
+	a := FundBondAnalysis{Leverage: 100, Warnings: []string{}}
+	total := 0.0
+	for _, ratio := range []string{f.AssetsProportion.Stock, f.AssetsProportion.Bond, f.AssetsProportion.Cash, f.AssetsProportion.Other} {
+		if v, ok := parseFundRate(ratio); ok {
+			total += v
+		}
+	}
+	a.StockRatio, _ = parseFundRate(f.AssetsProportion.Stock)
+	a.BondRatio, _ = parseFundRate(f.AssetsProportion.Bond)
+	a.CashRatio, _ = parseFundRate(f.AssetsProportion.Cash)
+	if total > a.Leverage {
+		a.Leverage = total
+	}
+
+	bonds := make([]fundBond, 0, len(f.Bonds))
+	for _, b := range f.Bonds {
+		if b.HoldRatio > 0 {
+			bonds = append(bonds, b)
+		}
+	}
+	sort.SliceStable(bonds, func(i, j int) bool {
+		return bonds[i].HoldRatio > bonds[j].HoldRatio
+	})
+	a.BondCount = len(bonds)
+	kinds := map[string]float64{}
+	for i, b := range bonds {
+		a.TopBondsRatio += b.HoldRatio
+		if i < 5 {
+			a.Top5Ratio += b.HoldRatio
+		}
+		kind := b.Kind
+		if kind == "" {
+			kind = classifyBond(b.Name)
+		}
+		kinds[kind] += b.HoldRatio
+	}
+	if a.TopBondsRatio > 0 {
+		a.Top1Ratio = bonds[0].HoldRatio
+		for _, b := range bonds {
+			share := b.HoldRatio / a.TopBondsRatio
+			a.HHI += share * share
+		}
+		a.RateRatio = kinds[BondKindRate] / a.TopBondsRatio * 100
+		a.CreditRatio = kinds[BondKindCredit] / a.TopBondsRatio * 100
+		a.ConvertibleRatio = kinds[BondKindConvertible] / a.TopBondsRatio * 100
+		a.CDRatio = kinds[BondKindCD] / a.TopBondsRatio * 100
+		a.Style = "混合"
+		for kind, ratio := range map[string]float64{
+			BondKindRate:        a.RateRatio,
+			BondKindCredit:      a.CreditRatio,
+			BondKindConvertible: a.ConvertibleRatio,
+			BondKindCD:          a.CDRatio,
+		} {
+			if ratio >= 60 {
+				a.Style = kind + "为主"
+			}
+		}
+	}
+
+	if a.Leverage > 140 {
+		a.Warnings = append(a.Warnings, fmt.Sprintf("杠杆率%.2f%%超过开放式债基140%%的上限", a.Leverage))
+	}
+	if a.ConvertibleRatio >= 30 {
+		a.Warnings = append(a.Warnings, fmt.Sprintf("可转债占重仓债券%.2f%%，波动接近偏股资产", a.ConvertibleRatio))
+	}
+	if a.CreditRatio >= 60 && a.Top5Ratio >= 50 {
+		a.Warnings = append(a.Warnings, fmt.Sprintf("以信用债为主且前五大债券占净值%.2f%%，信用风险集中", a.Top5Ratio))
+	}
+	return a
+}
+
+// FundDrawdownRecovery 基金历史净值最大回撤及修复时间
+type FundDrawdownRecovery struct {
+	// 开始日期
+	StartDate string `json:"start_date"`
+	// 结束日期
+	EndDate string `json:"end_date"`
+	// 最大回撤（%）
+	MaxDrawdown float64 `json:"max_drawdown"`
+	// 最大回撤开始的前高日期
+	PeakDate string `json:"peak_date"`
+	// 最大回撤谷底日期
+	TroughDate string `json:"trough_date"`
+	// 回到前高的日期，未修复时为空
+	RecoveryDate string `json:"recovery_date"`
+	// 是否已修复
+	Recovered bool `json:"recovered"`
+	// 从谷底修复到前高的自然日天数，未修复时为谷底到结束日期的天数
+	RecoveryDays int `json:"recovery_days"`
+	// 最长的从前高到重新回到前高的自然日天数，未修复时计算到结束日期
+	LongestUnderwaterDays int `json:"longest_underwater_days"`
+}
+
+// daysBetween 两个日期间的自然日天数
+func daysBetween(from, to string) int {
+	f, err1 := time.Parse("2006-01-02", from)
+	t, err2 := time.Parse("2006-01-02", to)
+	if err1 != nil || err2 != nil {
+		return 0
+	}
+	return int(t.Sub(f).Hours() / 24)
+}
+
+// NewFundDrawdownRecovery 按累计净值计算最大回撤及修复时间，history 按日期升序
+func NewFundDrawdownRecovery(history eastmoney.FundNetHistory) FundDrawdownRecovery {
+	r := FundDrawdownRecovery{}
+	if len(history) == 0 {
+		return r
+	}
+	value := func(n eastmoney.FundNet) float64 {
+		if n.AccNav > 0 {
+			return n.AccNav
+		}
+		return n.Nav
+	}
+	r.StartDate = history[0].Date
+	r.EndDate = history[len(history)-1].Date
+	peak, peakDate := value(history[0]), history[0].Date
+	mddPeak, troughIdx := peak, -1
+	for i, n := range history {
+		v := value(n)
+		if v >= peak {
+			if days := daysBetween(peakDate, n.Date); days > r.LongestUnderwaterDays && i > 0 && value(history[i-1]) < peak {
+				r.LongestUnderwaterDays = days
+			}
+			peak, peakDate = v, n.Date
+			continue
+		}
+		if dd := (peak - v) / peak * 100; dd > r.MaxDrawdown {
+			r.MaxDrawdown = dd
+			r.PeakDate = peakDate
+			r.TroughDate = n.Date
+			mddPeak, troughIdx = peak, i
+		}
+	}
+	if days := daysBetween(peakDate, r.EndDate); value(history[len(history)-1]) < peak && days > r.LongestUnderwaterDays {
+		r.LongestUnderwaterDays = days
+	}
+	if troughIdx < 0 {
+		r.Recovered = true
+		return r
+	}
+	for _, n := range history[troughIdx+1:] {
+		if value(n) >= mddPeak {
+			r.Recovered = true
+			r.RecoveryDate = n.Date
+			break
+		}
+	}
+	if r.Recovered {
+		r.RecoveryDays = daysBetween(r.TroughDate, r.RecoveryDate)
+	} else {
+		r.RecoveryDays = daysBetween(r.TroughDate, r.EndDate)
+	}
+	return r
+}
@@ -0,0 +1,112 @@
+package models
+
+import (
+	"context"
+	"testing"
+
+	"github.com/axiaoxin-com/investool/datacenter/eastmoney"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClassifyBond(t *testing.T) {
+	cases := map[string]string{
+		"22国开05":      BondKindRate,
+		"21附息国债17":    BondKindRate,
+		"22进出10":      BondKindRate,
+		"21江苏债15":     BondKindRate,
+		"20万科01":      BondKindCredit,
+		"22中石油MTN001": BondKindCredit,
+		"21招商银行二级01":  BondKindCredit,
+		"兴业转债":        BondKindConvertible,
+		"21国君EB":      BondKindConvertible,
+		"22浦发银行CD123": BondKindCD,
+	}
+	for name, kind := range cases {
+		require.Equal(t, kind, classifyBond(name), name)
+	}
+}
+
+func TestFundAnalyzeBonds(t *testing.T) {
+	fund := Fund{
+		Type:             "债券型-长债",
+		AssetsProportion: fundAssetsProportion{Stock: "0%", Bond: "125.5%", Cash: "3%", Other: "--%"},
+		Bonds: []fundBond{
+			{Name: "20万科01", HoldRatio: 10, Kind: BondKindCredit},
+			{Name: "22国开05", HoldRatio: 30, Kind: BondKindRate},
+			{Name: "兴业转债", HoldRatio: 5},
+			{Name: "21江苏债15", HoldRatio: 5, Kind: BondKindRate},
+		},
+	}
+	require.True(t, fund.IsBond())
+	a := fund.AnalyzeBonds()
+	require.InDelta(t, 128.5, a.Leverage, 1e-9)
+	require.Equal(t, 4, a.BondCount)
+	require.InDelta(t, 50, a.TopBondsRatio, 1e-9)
+	require.InDelta(t, 30, a.Top1Ratio, 1e-9)
+	require.InDelta(t, 50, a.Top5Ratio, 1e-9)
+	require.InDelta(t, 70, a.RateRatio, 1e-9)
+	require.InDelta(t, 20, a.CreditRatio, 1e-9)
+	require.InDelta(t, 10, a.ConvertibleRatio, 1e-9)
+	require.InDelta(t, 0.6*0.6+0.2*0.2+0.1*0.1*2, a.HHI, 1e-9)
+	require.Equal(t, "利率债为主", a.Style)
+	require.Empty(t, a.Warnings)
+
+	// 资产占比不足 100% 时杠杆率为 100，无重仓债券时没有持仓风格
+	a = Fund{AssetsProportion: fundAssetsProportion{Stock: "90%", Bond: "--%", Cash: "5%"}}.AnalyzeBonds()
+	require.Equal(t, 100.0, a.Leverage)
+	require.Equal(t, "", a.Style)
+
+	fund.AssetsProportion.Bond = "150%"
+	fund.Bonds = []fundBond{{Name: "兴业转债", HoldRatio: 20}, {Name: "20万科01", HoldRatio: 10}}
+	a = fund.AnalyzeBonds()
+	require.Equal(t, "可转债为主", a.Style)
+	require.Len(t, a.Warnings, 2)
+}
+
+func TestFundBondRuleConditions(t *testing.T) {
+	ctx := context.Background()
+	fund := Fund{
+		Type:             "债券型-混合债",
+		EstablishedDate:  "--",
+		AssetsProportion: fundAssetsProportion{Bond: "110%"},
+		Bonds:            []fundBond{{Name: "兴业转债", HoldRatio: 30}, {Name: "22国开05", HoldRatio: 70}},
+	}
+	p := ParamFundListFilter{MaxLeverage: 140, MaxConvertibleRatio: 20, MaxBondTop5Ratio: 80}
+	conds := p.Conditions(ctx, fund)
+	require.Len(t, conds, 3)
+	require.True(t, conds[0].Passed)
+	require.False(t, conds[1].Passed)
+	require.Equal(t, "30.00%", conds[1].Actual)
+	require.False(t, conds[2].Passed)
+	require.False(t, p.Match(ctx, fund))
+}
+
+func TestNewFundDrawdownRecovery(t *testing.T) {
+	history := eastmoney.FundNetHistory{
+		{Date: "2022-01-03", AccNav: 1.0},
+		{Date: "2022-01-04", AccNav: 1.1},
+		{Date: "2022-01-05", AccNav: 0.99},
+		{Date: "2022-01-10", AccNav: 1.05},
+		{Date: "2022-01-20", AccNav: 1.1},
+		{Date: "2022-01-21", AccNav: 1.08},
+		{Date: "2022-01-31", Nav: 1.09},
+	}
+	r := NewFundDrawdownRecovery(history)
+	require.InDelta(t, 10, r.MaxDrawdown, 1e-9)
+	require.Equal(t, "2022-01-04", r.PeakDate)
+	require.Equal(t, "2022-01-05", r.TroughDate)
+	require.True(t, r.Recovered)
+	require.Equal(t, "2022-01-20", r.RecoveryDate)
+	require.Equal(t, 15, r.RecoveryDays)
+	require.Equal(t, 16, r.LongestUnderwaterDays)
+
+	// 未修复时计算到结束日期
+	history = append(history, eastmoney.FundNet{Date: "2022-03-01", AccNav: 0.88})
+	r = NewFundDrawdownRecovery(history)
+	require.InDelta(t, 20, r.MaxDrawdown, 1e-9)
+	require.Equal(t, "2022-01-20", r.PeakDate)
+	require.False(t, r.Recovered)
+	require.Equal(t, 0, r.RecoveryDays)
+	require.Equal(t, 40, r.LongestUnderwaterDays)
+	require.Equal(t, FundDrawdownRecovery{}, NewFundDrawdownRecovery(nil))
+}
@@ -0,0 +1,319 @@
+// 基金费率解析、持有成本计算及 A/C 份额对比
+
+package models
+
+import (
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/axiaoxin-com/investool/datacenter/eastmoney"
+)
+
+// FundFeeTier 申购费率档位
+type FundFeeTier struct {
+	// 适用金额下限（元），含
+	MinAmount float64 `json:"min_amount"`
+	// 适用金额上限（元），不含，0 表示无上限
+	MaxAmount float64 `json:"max_amount"`
+	// 原费率（%）
+	SourceRate float64 `json:"source_rate"`
+	// 实际费率（%），有优惠费率时为优惠费率
+	Rate float64 `json:"rate"`
+	// 每笔固定费用（元），大于 0 时不按费率收取
+	Fixed float64 `json:"fixed"`
+}
+
+// FundRedemptionTier 赎回费率档位
+type FundRedemptionTier struct {
+	// 持有天数下限，含
+	MinDays int `json:"min_days"`
+	// 持有天数上限，不含，0 表示无上限
+	MaxDays int `json:"max_days"`
+	// 赎回费率（%）
+	Rate float64 `json:"rate"`
+}
+
+// FundFeeSchedule 基金费率表
+type FundFeeSchedule struct {
+	// 前端申购费率，按金额升序
+	Subscription []FundFeeTier `json:"subscription"`
+	// 赎回费率，按持有天数升序
+	Redemption []FundRedemptionTier `json:"redemption"`
+	// 管理费率（%/年）
+	ManagementRate float64 `json:"management_rate"`
+	// 托管费率（%/年）
+	CustodyRate float64 `json:"custody_rate"`
+	// 销售服务费率（%/年）
+	SalesServiceRate float64 `json:"sales_service_rate"`
+	// 是否获取到费率数据
+	Loaded bool `json:"loaded"`
+}
+
+// FundFeeSchedules 基金费率表，key 为基金代码
+type FundFeeSchedules map[string]FundFeeSchedule
+
+// FillFees 按基金代码为 funds 中的基金设置费率表
+func (fees FundFeeSchedules) FillFees(funds map[string]*Fund) {
+	for code, fund := range funds {
+		if fee, ok := fees[code]; ok && fund != nil {
+			fund.Fee = fee
+		}
+	}
+}
+
+// SetFees 按基金代码为列表中的基金设置费率表
+func (l FundList) SetFees(fees FundFeeSchedules) {
+	for _, fund := range l {
+		if fee, ok := fees[fund.Code]; ok {
+			fund.Fee = fee
+		}
+	}
+}
+
+var (
+	fundFeeRangeRegexp = regexp.MustCompile(`(大于等于|大于|小于等于|小于)\s*([\d.]+)\s*(万元|亿元|元|天|日|个月|月|年)`)
+	fundFeeRateRegexp  = regexp.MustCompile(`([\d.]+)\s*%`)
+	fundFeeFixedRegexp = regexp.MustCompile(`每笔\s*([\d.]+)\s*元`)
+)
+
+// fundFeeUnits 金额及期限单位换算为元或天
+var fundFeeUnits = map[string]float64{
+	"元":  1,
+	"万元": 10000,
+	"亿元": 100000000,
+	"天":  1,
+	"日":  1,
+	"月":  30,
+	"个月": 30,
+	"年":  365,
+}
+
+// parseFundFeeRange 解析适用金额或期限文本，返回下限（含）和上限（不含，0 表示无上限），如 大于等于7天，小于1年 返回 7 365
+func parseFundFeeRange(text string) (min, max float64) {
+	for _, m := range fundFeeRangeRegexp.FindAllStringSubmatch(text, -1) {
+		v, err := strconv.ParseFloat(m[2], 64)
+		if err != nil {
+			continue
+		}
+		v *= fundFeeUnits[m[3]]
+		switch m[1] {
+		case "大于等于":
+			min = v
+		case "大于":
+			// 期限按天计，大于 N 天即从 N+1 天起
+			if m[3] == "元" || m[3] == "万元" || m[3] == "亿元" {
+				min = v
+			} else {
+				min = v + 1
+			}
+		case "小于":
+			max = v
+		case "小于等于":
+			if m[3] == "元" || m[3] == "万元" || m[3] == "亿元" {
+				max = v
+			} else {
+				max = v + 1
+			}
+		}
+	}
+	return
+}
+
+// parseFundFeeRate 解析费率文本，返回原费率、实际费率及每笔固定费用，如 1.50% | 0.15% | 0.15% 返回 1.5 0.15 0
+func parseFundFeeRate(text string) (sourceRate, rate, fixed float64, ok bool) {
+	if m := fundFeeFixedRegexp.FindStringSubmatch(text); len(m) == 2 {
+		fixed, _ = strconv.ParseFloat(m[1], 64)
+		return 0, 0, fixed, true
+	}
+	matched := fundFeeRateRegexp.FindAllStringSubmatch(text, -1)
+	if len(matched) == 0 {
+		return 0, 0, 0, false
+	}
+	sourceRate, _ = strconv.ParseFloat(matched[0][1], 64)
+	rate = sourceRate
+	if len(matched) > 1 {
+		rate, _ = strconv.ParseFloat(matched[1][1], 64)
+	}
+	return sourceRate, rate, 0, true
+}
+
+// parseFundAnnualRate 解析运作费率，如 0.25%（每年） 返回 0.25，--- 返回 0
+func parseFundAnnualRate(text string) float64 {
+	if m := fundFeeRateRegexp.FindStringSubmatch(text); len(m) == 2 {
+		v, _ := strconv.ParseFloat(m[1], 64)
+		return v
+	}
+	return 0
+}
+
+// NewFundFeeSchedule 解析基金费率页面数据
+func NewFundFeeSchedule(info *eastmoney.FundFeeInfo) FundFeeSchedule {
+	s := FundFeeSchedule{Subscription: []FundFeeTier{}, Redemption: []FundRedemptionTier{}}
+	if info == nil {
+		return s
+	}
+	s.Loaded = true
+	s.ManagementRate = parseFundAnnualRate(info.ManagementRate)
+	s.CustodyRate = parseFundAnnualRate(info.CustodyRate)
+	s.SalesServiceRate = parseFundAnnualRate(info.SalesServiceRate)
+	for _, row := range info.Subscription {
+		sourceRate, rate, fixed, ok := parseFundFeeRate(row.Rate)
+		if !ok {
+			continue
+		}
+		min, max := parseFundFeeRange(row.Amount)
+		s.Subscription = append(s.Subscription, FundFeeTier{
+			MinAmount:  min,
+			MaxAmount:  max,
+			SourceRate: sourceRate,
+			Rate:       rate,
+			Fixed:      fixed,
+		})
+	}
+	for _, row := range info.Redemption {
+		_, rate, _, ok := parseFundFeeRate(row.Rate)
+		if !ok {
+			continue
+		}
+		min, max := parseFundFeeRange(row.Period)
+		s.Redemption = append(s.Redemption, FundRedemptionTier{
+			MinDays: int(min),
+			MaxDays: int(max),
+			Rate:    rate,
+		})
+	}
+	return s
+}
+
+// AnnualRate 管理费、托管费及销售服务费合计（%/年）
+func (s FundFeeSchedule) AnnualRate() float64 {
+	return s.ManagementRate + s.CustodyRate + s.SalesServiceRate
+}
+
+// SubscriptionTier 返回申购金额适用的申购费率档位，没有匹配档位时返回 false
+func (s FundFeeSchedule) SubscriptionTier(amount float64) (FundFeeTier, bool) {
+	for _, t := range s.Subscription {
+		if amount >= t.MinAmount && (t.MaxAmount == 0 || amount < t.MaxAmount) {
+			return t, true
+		}
+	}
+	return FundFeeTier{}, false
+}
+
+// RedemptionRate 返回持有天数适用的赎回费率（%），没有匹配档位时为 0
+func (s FundFeeSchedule) RedemptionRate(days int) float64 {
+	for _, t := range s.Redemption {
+		if days >= t.MinDays && (t.MaxDays == 0 || days < t.MaxDays) {
+			return t.Rate
+		}
+	}
+	return 0
+}
+
+// FundCost 持有期总成本
+type FundCost struct {
+	// 申购金额（元）
+	Amount float64 `json:"amount"`
+	// 持有天数
+	Days int `json:"days"`
+	// 申购费（元）
+	SubscriptionFee float64 `json:"subscription_fee"`
+	// 持有期管理费、托管费及销售服务费（元）
+	AnnualFee float64 `json:"annual_fee"`
+	// 赎回费（元）
+	RedemptionFee float64 `json:"redemption_fee"`
+	// 总成本（元）
+	Total float64 `json:"total"`
+	// 总成本占申购金额比例（%）
+	TotalRate float64 `json:"total_rate"`
+	// 年化成本（%）
+	AnnualizedRate float64 `json:"annualized_rate"`
+}
+
+// Cost 计算申购 amount 元、持有 days 天的总成本，不考虑净值涨跌
+// 申购费按前端外扣法: 净申购金额 = 申购金额 / (1 + 申购费率)，运作费用按净申购金额逐日计提，赎回费按扣除运作费用后的金额计算
+func (s FundFeeSchedule) Cost(amount float64, days int) FundCost {
+	c := FundCost{Amount: amount, Days: days}
+	if amount <= 0 {
+		return c
+	}
+	if t, ok := s.SubscriptionTier(amount); ok {
+		if t.Fixed > 0 {
+			c.SubscriptionFee = math.Min(t.Fixed, amount)
+		} else {
+			c.SubscriptionFee = amount - amount/(1+t.Rate/100)
+		}
+	}
+	net := amount - c.SubscriptionFee
+	c.AnnualFee = net * s.AnnualRate() / 100 * float64(days) / 365
+	c.RedemptionFee = (net - c.AnnualFee) * s.RedemptionRate(days) / 100
+	c.Total = c.SubscriptionFee + c.AnnualFee + c.RedemptionFee
+	c.TotalRate = c.Total / amount * 100
+	if days > 0 {
+		c.AnnualizedRate = c.TotalRate * 365 / float64(days)
+	}
+	return c
+}
+
+// FundShareClassCost 同一持有天数下 A/C 份额的成本
+type FundShareClassCost struct {
+	// 持有天数
+	Days int `json:"days"`
+	// A 类份额成本
+	A FundCost `json:"a"`
+	// C 类份额成本
+	C FundCost `json:"c"`
+	// 成本更低的份额: A C，相同时为空
+	Cheaper string `json:"cheaper"`
+}
+
+// FundShareClassComparison A/C 份额持有成本对比
+type FundShareClassComparison struct {
+	// 申购金额（元）
+	Amount float64 `json:"amount"`
+	// 各持有天数的成本
+	Costs []FundShareClassCost `json:"costs"`
+	// A 类份额总成本开始不高于 C 类的持有天数，10 年内都更高时为 -1
+	BreakEvenDays int `json:"break_even_days"`
+}
+
+// FundShareClassCompareDays A/C 份额对比的持有天数
+var FundShareClassCompareDays = []int{7, 30, 90, 180, 365, 730, 1095}
+
+// fundShareClassMaxDays 计算 A/C 份额成本平衡点的最长持有天数
+const fundShareClassMaxDays = 3650
+
+// CompareShareClasses 对比申购 amount 元时 A/C 份额在不同持有天数下的总成本及成本平衡点
+func CompareShareClasses(a, c FundFeeSchedule, amount float64) FundShareClassComparison {
+	result := FundShareClassComparison{Amount: amount, Costs: []FundShareClassCost{}, BreakEvenDays: -1}
+	for _, days := range FundShareClassCompareDays {
+		cost := FundShareClassCost{Days: days, A: a.Cost(amount, days), C: c.Cost(amount, days)}
+		switch {
+		case cost.A.Total < cost.C.Total:
+			cost.Cheaper = "A"
+		case cost.A.Total > cost.C.Total:
+			cost.Cheaper = "C"
+		}
+		result.Costs = append(result.Costs, cost)
+	}
+	for days := 1; days <= fundShareClassMaxDays; days++ {
+		if a.Cost(amount, days).Total <= c.Cost(amount, days).Total {
+			result.BreakEvenDays = days
+			break
+		}
+	}
+	return result
+}
+
+// ShareClassBaseName 去掉基金名称末尾的份额类别字母，如 易方达蓝筹精选混合A 返回 易方达蓝筹精选混合 A
+func ShareClassBaseName(name string) (base, class string) {
+	name = strings.TrimSpace(name)
+	for _, suffix := range []string{"A", "C"} {
+		if strings.HasSuffix(name, suffix) {
+			return strings.TrimSuffix(name, suffix), suffix
+		}
+	}
+	return name, ""
+}
@@ -0,0 +1,155 @@
+package models
+
+import (
+	"context"
+	"testing"
+
+	"github.com/axiaoxin-com/investool/datacenter/eastmoney"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	testFundFeeInfoA = &eastmoney.FundFeeInfo{
+		ManagementRate:   "1.20%（每年）",
+		CustodyRate:      "0.20%（每年）",
+		SalesServiceRate: "---（每年）",
+		Subscription: []eastmoney.FundFeeRow{
+			{Amount: "小于100万元", Period: "---", Rate: "1.50% | 0.15% | 0.15%"},
+			{Amount: "大于等于100万元，小于500万元", Period: "---", Rate: "1.00% | 0.10% | 0.10%"},
+			{Amount: "大于等于500万元", Period: "---", Rate: "每笔1000元"},
+		},
+		Redemption: []eastmoney.FundFeeRow{
+			{Amount: "---", Period: "小于7天", Rate: "1.50%"},
+			{Amount: "---", Period: "大于等于7天，小于1年", Rate: "0.50%"},
+			{Amount: "---", Period: "大于等于1年，小于2年", Rate: "0.25%"},
+			{Amount: "---", Period: "大于等于2年", Rate: "0.00%"},
+		},
+	}
+	testFundFeeInfoC = &eastmoney.FundFeeInfo{
+		ManagementRate:   "1.20%（每年）",
+		CustodyRate:      "0.20%（每年）",
+		SalesServiceRate: "0.40%（每年）",
+		Subscription: []eastmoney.FundFeeRow{
+			{Amount: "---", Period: "---", Rate: "0.00%"},
+		},
+		Redemption: []eastmoney.FundFeeRow{
+			{Amount: "---", Period: "小于7天", Rate: "1.50%"},
+			{Amount: "---", Period: "大于等于7天，小于30天", Rate: "0.50%"},
+			{Amount: "---", Period: "大于等于30天", Rate: "0.00%"},
+		},
+	}
+)
+
+func TestParseFundFeeRange(t *testing.T) {
+	min, max := parseFundFeeRange("大于等于7天，小于1年")
+	require.Equal(t, 7.0, min)
+	require.Equal(t, 365.0, max)
+	min, max = parseFundFeeRange("小于100万元")
+	require.Equal(t, 0.0, min)
+	require.Equal(t, 1000000.0, max)
+	min, max = parseFundFeeRange("大于等于500万元")
+	require.Equal(t, 5000000.0, min)
+	require.Equal(t, 0.0, max)
+	min, max = parseFundFeeRange("大于6个月，小于等于1年")
+	require.Equal(t, 181.0, min)
+	require.Equal(t, 366.0, max)
+	min, max = parseFundFeeRange("---")
+	require.Equal(t, 0.0, min)
+	require.Equal(t, 0.0, max)
+}
+
+func TestParseFundFeeRate(t *testing.T) {
+	source, rate, fixed, ok := parseFundFeeRate("1.50% | 0.15% | 0.15%")
+	require.True(t, ok)
+	require.Equal(t, 1.5, source)
+	require.Equal(t, 0.15, rate)
+	require.Equal(t, 0.0, fixed)
+	_, _, fixed, ok = parseFundFeeRate("每笔1000元")
+	require.True(t, ok)
+	require.Equal(t, 1000.0, fixed)
+	_, _, _, ok = parseFundFeeRate("---")
+	require.False(t, ok)
+	require.Equal(t, 0.25, parseFundAnnualRate("0.25%（每年）"))
+	require.Equal(t, 0.0, parseFundAnnualRate("---（每年）"))
+}
+
+func TestFundFeeScheduleCost(t *testing.T) {
+	a := NewFundFeeSchedule(testFundFeeInfoA)
+	require.True(t, a.Loaded)
+	require.Len(t, a.Subscription, 3)
+	require.Len(t, a.Redemption, 4)
+	require.InDelta(t, 1.4, a.AnnualRate(), 1e-9)
+	require.Equal(t, 1.5, a.RedemptionRate(6))
+	require.Equal(t, 0.5, a.RedemptionRate(7))
+	require.Equal(t, 0.25, a.RedemptionRate(365))
+	require.Equal(t, 0.0, a.RedemptionRate(1000))
+
+	cost := a.Cost(10000, 365)
+	require.InDelta(t, 14.9775, cost.SubscriptionFee, 1e-4)
+	require.InDelta(t, 139.7903, cost.AnnualFee, 1e-4)
+	require.InDelta(t, 24.6131, cost.RedemptionFee, 1e-4)
+	require.InDelta(t, 179.3809, cost.Total, 1e-4)
+	require.InDelta(t, 1.7938, cost.TotalRate, 1e-4)
+	require.InDelta(t, 1.7938, cost.AnnualizedRate, 1e-4)
+
+	// 大额申购按每笔固定费用收取
+	cost = a.Cost(6000000, 30)
+	require.Equal(t, 1000.0, cost.SubscriptionFee)
+	require.InDelta(t, 6902.9589, cost.AnnualFee, 1e-4)
+
+	require.Equal(t, FundCost{Amount: 0, Days: 30}, a.Cost(0, 30))
+}
+
+func TestCompareShareClasses(t *testing.T) {
+	a := NewFundFeeSchedule(testFundFeeInfoA)
+	c := NewFundFeeSchedule(testFundFeeInfoC)
+	result := CompareShareClasses(a, c, 10000)
+	require.Len(t, result.Costs, len(FundShareClassCompareDays))
+	for _, cost := range result.Costs {
+		if cost.Days < 365 {
+			require.Equal(t, "C", cost.Cheaper, cost.Days)
+		} else {
+			require.Equal(t, "A", cost.Cheaper, cost.Days)
+		}
+	}
+	require.Equal(t, 365, result.BreakEvenDays)
+	require.Equal(t, -1, CompareShareClasses(a, NewFundFeeSchedule(nil), 10000).BreakEvenDays)
+}
+
+func TestShareClassBaseName(t *testing.T) {
+	base, class := ShareClassBaseName("易方达蓝筹精选混合A")
+	require.Equal(t, "易方达蓝筹精选混合", base)
+	require.Equal(t, "A", class)
+	base, class = ShareClassBaseName("招商中证白酒指数(LOF)")
+	require.Equal(t, "招商中证白酒指数(LOF)", base)
+	require.Equal(t, "", class)
+}
+
+func TestFundFeeConditions(t *testing.T) {
+	ctx := context.Background()
+	fund := Fund{Rate: "0.15%", Fee: NewFundFeeSchedule(testFundFeeInfoC)}
+	p := ParamFundListFilter{MaxPurchaseRate: 0.1, MaxAnnualFeeRate: 1.5}
+	conds := p.Conditions(ctx, fund)
+	require.Len(t, conds, 2)
+	require.False(t, conds[0].Passed)
+	require.False(t, conds[1].Passed)
+	require.False(t, p.Match(ctx, fund))
+
+	// 无费率数据时不排除
+	require.True(t, p.Match(ctx, Fund{}))
+	p = ParamFundListFilter{MaxPurchaseRate: 0.15, MaxAnnualFeeRate: 1.8}
+	require.True(t, p.Match(ctx, fund))
+}
+
+func TestFundListSetFees(t *testing.T) {
+	fee := NewFundFeeSchedule(testFundFeeInfoC)
+	l := FundList{&Fund{Code: "005827"}, &Fund{Code: "110011"}}
+	l.SetFees(FundFeeSchedules{"005827": fee})
+	require.Equal(t, fee, l[0].Fee)
+	require.False(t, l[1].Fee.Loaded)
+
+	funds := map[string]*Fund{"005827": {Code: "005827"}, "110011": {Code: "110011"}}
+	FundFeeSchedules{"005827": fee}.FillFees(funds)
+	require.Equal(t, fee, funds["005827"].Fee)
+	require.False(t, funds["110011"].Fee.Loaded)
+}
@@ -0,0 +1,384 @@
+// 基金经理对比：现任基金按规模加权汇总业绩、综合评分及共同管理基金的任职重叠
+
+package models
+
+import (
+	"sort"
+
+	"github.com/axiaoxin-com/investool/datacenter/eastmoney"
+)
+
+// FundManagerFund 基金经理现任管理的基金
+type FundManagerFund struct {
+	// 基金代码
+	Code string `json:"code"`
+	// 基金名称
+	Name string `json:"name"`
+	// 基金类型
+	Type string `json:"type"`
+	// 基金规模（亿）
+	Scale float64 `json:"scale"`
+	// 该经理任职开始日期
+	StartDate string `json:"start_date"`
+	// 该经理管理天数
+	ManageDays float64 `json:"manage_days"`
+	// 该经理任职回报（%）
+	ManageRepay float64 `json:"manage_repay"`
+	// 近1年收益率（%）
+	Year1ProfitRatio float64 `json:"year_1_profit_ratio"`
+	// 近1年同类排名百分比
+	Year1RankRatio float64 `json:"year_1_rank_ratio"`
+	// 近3年收益率（%）
+	Year3ProfitRatio float64 `json:"year_3_profit_ratio"`
+	// 近3年同类排名百分比
+	Year3RankRatio float64 `json:"year_3_rank_ratio"`
+	// 1、3、5年最大回撤均值（%）
+	MaxRetr135 float64 `json:"max_retr_135"`
+	// 1、3、5年夏普比率均值
+	Sharp135 float64 `json:"sharp_135"`
+	// 现任基金经理人数
+	ManagerCount int `json:"manager_count"`
+}
+
+// FundManagerTenureOverlap 共同管理基金时与其他基金经理的任职重叠
+type FundManagerTenureOverlap struct {
+	// 基金代码
+	FundCode string `json:"fund_code"`
+	// 基金名称
+	FundName string `json:"fund_name"`
+	// 共同管理的基金经理 ID
+	CoManagerID string `json:"co_manager_id"`
+	// 共同管理的基金经理
+	CoManagerName string `json:"co_manager_name"`
+	// 本人任职开始日期
+	StartDate string `json:"start_date"`
+	// 共同管理的基金经理任职开始日期
+	CoStartDate string `json:"co_start_date"`
+	// 共同管理天数，为两人管理天数的较小值
+	OverlapDays float64 `json:"overlap_days"`
+}
+
+// FundManagerAggregate 现任基金按规模加权的业绩汇总，无数据的基金不计入对应指标
+type FundManagerAggregate struct {
+	// 获取到数据的现任基金数
+	FundCount int `json:"fund_count"`
+	// 独自管理的基金数
+	SoloFundCount int `json:"solo_fund_count"`
+	// 现任基金总规模（亿）
+	TotalScale float64 `json:"total_scale"`
+	// 加权任职回报（%）
+	ManageRepay float64 `json:"manage_repay"`
+	// 加权近1年收益率（%）
+	Year1ProfitRatio float64 `json:"year_1_profit_ratio"`
+	// 加权近1年同类排名百分比
+	Year1RankRatio float64 `json:"year_1_rank_ratio"`
+	// 加权近3年收益率（%）
+	Year3ProfitRatio float64 `json:"year_3_profit_ratio"`
+	// 加权近3年同类排名百分比
+	Year3RankRatio float64 `json:"year_3_rank_ratio"`
+	// 加权1、3、5年最大回撤均值（%）
+	MaxRetr135 float64 `json:"max_retr_135"`
+	// 加权1、3、5年夏普比率均值
+	Sharp135 float64 `json:"sharp_135"`
+}
+
+// FundManagerScore 基金经理综合评分
+type FundManagerScore struct {
+	// 总分（100分）
+	Total float64 `json:"total"`
+	// 在对比经理中的排名，从 1 开始
+	Rank int `json:"rank"`
+	// 各组成项得分，满分、得分比例含义同基金综合评分
+	Components []FundScoreComponent `json:"components"`
+}
+
+// FundManagerProfile 基金经理对比信息
+type FundManagerProfile struct {
+	eastmoney.FundManagerInfo
+	// 现任基金，按规模降序
+	Funds []FundManagerFund `json:"funds"`
+	// 现任基金加权业绩
+	Aggregate FundManagerAggregate `json:"aggregate"`
+	// 共同管理基金的任职重叠
+	Overlaps []FundManagerTenureOverlap `json:"overlaps"`
+	// 综合评分
+	Score FundManagerScore `json:"score"`
+}
+
+// fundManagerOf 返回基金现任经理中 ID 对应的经理
+func fundManagerOf(fund *Fund, id string) (fundManager, bool) {
+	for _, m := range fund.Managers {
+		if m.ID == id {
+			return m, true
+		}
+	}
+	if fund.Manager.ID == id {
+		return fund.Manager, true
+	}
+	return fundManager{}, false
+}
+
+// fundManagerWeighted 按基金规模加权计算指标均值，规模为 0 或指标无数据的基金不计入
+func fundManagerWeighted(funds []FundManagerFund, metric func(f FundManagerFund) (float64, bool)) (float64, bool) {
+	sum, weight := 0.0, 0.0
+	for _, f := range funds {
+		v, ok := metric(f)
+		if !ok || f.Scale <= 0 {
+			continue
+		}
+		sum += v * f.Scale
+		weight += f.Scale
+	}
+	if weight == 0 {
+		return 0, false
+	}
+	return sum / weight, true
+}
+
+// fundManagerFundMetrics 加权汇总的基金指标，同类排名百分比为 0 时表示无该周期数据
+var fundManagerFundMetrics = map[string]func(f FundManagerFund) (float64, bool){
+	"manage_repay":  func(f FundManagerFund) (float64, bool) { return f.ManageRepay, f.ManageDays > 0 },
+	"year_1_profit": func(f FundManagerFund) (float64, bool) { return f.Year1ProfitRatio, f.Year1RankRatio > 0 },
+	"year_1_rank":   func(f FundManagerFund) (float64, bool) { return f.Year1RankRatio, f.Year1RankRatio > 0 },
+	"year_3_profit": func(f FundManagerFund) (float64, bool) { return f.Year3ProfitRatio, f.Year3RankRatio > 0 },
+	"year_3_rank":   func(f FundManagerFund) (float64, bool) { return f.Year3RankRatio, f.Year3RankRatio > 0 },
+	"max_retr_135":  func(f FundManagerFund) (float64, bool) { return f.MaxRetr135, f.MaxRetr135 != 0 },
+	"sharp_135":     func(f FundManagerFund) (float64, bool) { return f.Sharp135, f.Sharp135 != 0 },
+}
+
+// NewFundManagerProfile 按基金经理现任基金的详情汇总业绩及任职重叠，funds 中没有的基金不计入
+func NewFundManagerProfile(info eastmoney.FundManagerInfo, funds map[string]*Fund) *FundManagerProfile {
+	p := &FundManagerProfile{
+		FundManagerInfo: info,
+		Funds:           []FundManagerFund{},
+		Overlaps:        []FundManagerTenureOverlap{},
+	}
+	for _, code := range info.FundCodes {
+		fund, exists := funds[code]
+		if !exists || fund == nil {
+			continue
+		}
+		m, _ := fundManagerOf(fund, info.ID)
+		f := FundManagerFund{
+			Code:             fund.Code,
+			Name:             fund.Name,
+			Type:             fund.Type,
+			Scale:            fund.NetAssetsScale / 100000000,
+			StartDate:        m.StartDate,
+			ManageDays:       m.ManageDays,
+			ManageRepay:      m.ManageRepay,
+			Year1ProfitRatio: fund.Performance.Year1ProfitRatio,
+			Year1RankRatio:   fund.Performance.Year1RankRatio,
+			Year3ProfitRatio: fund.Performance.Year3ProfitRatio,
+			Year3RankRatio:   fund.Performance.Year3RankRatio,
+			MaxRetr135:       fund.MaxRetracement.Avg135,
+			Sharp135:         fund.Sharp.Avg135,
+			ManagerCount:     len(fund.Managers),
+		}
+		p.Funds = append(p.Funds, f)
+		p.Aggregate.TotalScale += f.Scale
+		if f.ManagerCount <= 1 {
+			p.Aggregate.SoloFundCount++
+		}
+		for _, co := range fund.Managers {
+			if co.ID == info.ID {
+				continue
+			}
+			overlap := m.ManageDays
+			if co.ManageDays < overlap {
+				overlap = co.ManageDays
+			}
+			p.Overlaps = append(p.Overlaps, FundManagerTenureOverlap{
+				FundCode:      fund.Code,
+				FundName:      fund.Name,
+				CoManagerID:   co.ID,
+				CoManagerName: co.Name,
+				StartDate:     m.StartDate,
+				CoStartDate:   co.StartDate,
+				OverlapDays:   overlap,
+			})
+		}
+	}
+	sort.SliceStable(p.Funds, func(i, j int) bool {
+		return p.Funds[i].Scale > p.Funds[j].Scale
+	})
+	p.Aggregate.FundCount = len(p.Funds)
+	a := &p.Aggregate
+	for name, target := range map[string]*float64{
+		"manage_repay":  &a.ManageRepay,
+		"year_1_profit": &a.Year1ProfitRatio,
+		"year_1_rank":   &a.Year1RankRatio,
+		"year_3_profit": &a.Year3ProfitRatio,
+		"year_3_rank":   &a.Year3RankRatio,
+		"max_retr_135":  &a.MaxRetr135,
+		"sharp_135":     &a.Sharp135,
+	} {
+		*target, _ = fundManagerWeighted(p.Funds, fundManagerFundMetrics[name])
+	}
+	return p
+}
+
+// fundManagerScoreMetric 计算基金经理评分指标，返回指标值及数据是否充足
+type fundManagerScoreMetric func(p *FundManagerProfile) (float64, bool)
+
+// fundManagerScoreMetrics 可在基金经理评分中使用的指标，现任基金指标按规模加权
+var fundManagerScoreMetrics = map[string]fundManagerScoreMetric{
+	// 年化回报（%）
+	"yieldse": func(p *FundManagerProfile) (float64, bool) {
+		return p.Yieldse, p.Yieldse != 0
+	},
+	// 从业年限
+	"working_years": func(p *FundManagerProfile) (float64, bool) {
+		return p.WorkingYears, p.WorkingYears > 0
+	},
+	// 获奖数
+	"award_num": func(p *FundManagerProfile) (float64, bool) {
+		return float64(p.AwardNum), true
+	},
+	// 现任基金总规模（亿）
+	"scale": func(p *FundManagerProfile) (float64, bool) {
+		return p.CurrentFundScale, p.CurrentFundScale > 0
+	},
+	// 现任基金近3年同类排名百分比，无近3年数据时使用近1年
+	"rank": func(p *FundManagerProfile) (float64, bool) {
+		if v, ok := fundManagerWeighted(p.Funds, fundManagerFundMetrics["year_3_rank"]); ok {
+			return v, true
+		}
+		return fundManagerWeighted(p.Funds, fundManagerFundMetrics["year_1_rank"])
+	},
+	// 现任基金1、3、5年最大回撤均值（%）
+	"max_retr_135": func(p *FundManagerProfile) (float64, bool) {
+		return fundManagerWeighted(p.Funds, fundManagerFundMetrics["max_retr_135"])
+	},
+	// 现任基金1、3、5年夏普比率均值
+	"sharp_135": func(p *FundManagerProfile) (float64, bool) {
+		return fundManagerWeighted(p.Funds, fundManagerFundMetrics["sharp_135"])
+	},
+}
+
+// DefaultFundManagerScoreModel 默认基金经理综合评分模型
+// 年化回报、从业年限、获奖数在全部基金经理中归一化，现任基金加权指标在对比的基金经理中归一化
+var DefaultFundManagerScoreModel = []FundScoreComponentModel{
+	{Name: "yieldse", Label: "年化回报", Weight: 25, Metric: "yieldse", Direction: FundScoreDirectionHigher},
+	{Name: "rank", Label: "现任基金同类排名", Weight: 25, Metric: "rank", Curve: curve(true, 0, 1, 100, 0)},
+	{Name: "max_retr", Label: "现任基金最大回撤", Weight: 15, Metric: "max_retr_135", Direction: FundScoreDirectionLower, Missing: 0.5},
+	{Name: "sharp", Label: "现任基金夏普比率", Weight: 10, Metric: "sharp_135", Direction: FundScoreDirectionHigher, Missing: 0.5},
+	{Name: "working_years", Label: "从业年限", Weight: 15, Metric: "working_years", Direction: FundScoreDirectionHigher},
+	{Name: "award", Label: "获奖数", Weight: 5, Metric: "award_num", Direction: FundScoreDirectionHigher},
+	{Name: "scale", Label: "管理规模", Weight: 5, Metric: "scale", Curve: curve(false, 0, 0, 10, 0.5, 50, 1, 500, 0.7, 1000, 0.5), Missing: 0.5},
+}
+
+// ScoreFundManagers 计算对比的基金经理综合评分，population 为全部基金经理，用于归一化年化回报等列表指标
+func ScoreFundManagers(profiles []*FundManagerProfile, population eastmoney.FundManagerInfoList, model []FundScoreComponentModel) {
+	compared := map[string]bool{}
+	for _, p := range profiles {
+		compared[p.ID] = true
+	}
+	// 归一化的对比范围：未参与对比的基金经理只有列表指标
+	peerProfiles := []*FundManagerProfile{}
+	for _, info := range population {
+		if info != nil && !compared[info.ID] {
+			peerProfiles = append(peerProfiles, &FundManagerProfile{FundManagerInfo: *info})
+		}
+	}
+	peerProfiles = append(peerProfiles, profiles...)
+	totalWeight := 0.0
+	for _, c := range model {
+		totalWeight += c.Weight
+	}
+	for _, p := range profiles {
+		score := FundManagerScore{Components: []FundScoreComponent{}}
+		for _, c := range model {
+			metric := fundManagerScoreMetrics[c.Metric]
+			if metric == nil {
+				continue
+			}
+			v, ok := metric(p)
+			comp := FundScoreComponent{Name: c.Name, Label: c.Label, Weight: c.Weight, Value: v, Missing: !ok}
+			switch {
+			case !ok:
+				comp.Credit = c.Missing
+			case len(c.Curve.Points) > 0:
+				comp.Credit = c.Curve.Credit(v)
+			default:
+				peers := []float64{}
+				for _, other := range peerProfiles {
+					if pv, pok := metric(other); pok {
+						peers = append(peers, pv)
+					}
+				}
+				comp.Credit = percentileCredit(v, peers, c.Direction)
+			}
+			comp.Score = comp.Credit * c.Weight
+			score.Total += comp.Score
+			score.Components = append(score.Components, comp)
+		}
+		if totalWeight > 0 {
+			score.Total = score.Total / totalWeight * 100
+		}
+		p.Score = score
+	}
+	ranked := append([]*FundManagerProfile{}, profiles...)
+	sort.SliceStable(ranked, func(i, j int) bool {
+		return ranked[i].Score.Total > ranked[j].Score.Total
+	})
+	for i, p := range ranked {
+		p.Score.Rank = i + 1
+	}
+}
+
+// FundManagerSharedFund 被多位对比的基金经理共同管理的基金
+type FundManagerSharedFund struct {
+	// 基金代码
+	Code string `json:"code"`
+	// 基金名称
+	Name string `json:"name"`
+	// 共同管理的基金经理
+	Managers []string `json:"managers"`
+	// 共同管理天数，为各经理管理天数的最小值
+	OverlapDays float64 `json:"overlap_days"`
+}
+
+// FundManagerComparison 基金经理对比结果
+type FundManagerComparison struct {
+	// 对比的基金经理，按请求顺序
+	Managers []*FundManagerProfile `json:"managers"`
+	// 被多位对比的基金经理共同管理的基金
+	SharedFunds []FundManagerSharedFund `json:"shared_funds"`
+}
+
+// CompareFundManagers 汇总对比多位基金经理的现任基金业绩、综合评分及共同管理的基金
+func CompareFundManagers(infos []eastmoney.FundManagerInfo, funds map[string]*Fund, population eastmoney.FundManagerInfoList) FundManagerComparison {
+	result := FundManagerComparison{Managers: []*FundManagerProfile{}, SharedFunds: []FundManagerSharedFund{}}
+	holders := map[string][]*FundManagerProfile{}
+	codes := []string{}
+	for _, info := range infos {
+		p := NewFundManagerProfile(info, funds)
+		result.Managers = append(result.Managers, p)
+		for _, f := range p.Funds {
+			if len(holders[f.Code]) == 0 {
+				codes = append(codes, f.Code)
+			}
+			holders[f.Code] = append(holders[f.Code], p)
+		}
+	}
+	ScoreFundManagers(result.Managers, population, DefaultFundManagerScoreModel)
+
+	sort.Strings(codes)
+	for _, code := range codes {
+		if len(holders[code]) < 2 {
+			continue
+		}
+		shared := FundManagerSharedFund{Code: code, Name: funds[code].Name, Managers: []string{}, OverlapDays: -1}
+		for _, p := range holders[code] {
+			shared.Managers = append(shared.Managers, p.Name)
+			for _, f := range p.Funds {
+				if f.Code == code && (shared.OverlapDays < 0 || f.ManageDays < shared.OverlapDays) {
+					shared.OverlapDays = f.ManageDays
+				}
+			}
+		}
+		result.SharedFunds = append(result.SharedFunds, shared)
+	}
+	return result
+}
@@ -0,0 +1,75 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/axiaoxin-com/investool/datacenter/eastmoney"
+	"github.com/stretchr/testify/require"
+)
+
+func testFundManagerFunds() map[string]*Fund {
+	x := &Fund{Code: "000001", Name: "X", Type: "混合型", NetAssetsScale: 10 * 100000000,
+		Managers: []fundManager{
+			{ID: "a", Name: "A", ManageDays: 1000, StartDate: "2019-01-01"},
+			{ID: "b", Name: "B", ManageDays: 400, StartDate: "2020-11-01"},
+		},
+	}
+	x.Performance.Year1RankRatio, x.Performance.Year1ProfitRatio = 10, 30
+	x.Performance.Year3RankRatio, x.Performance.Year3ProfitRatio = 20, 60
+	x.MaxRetracement.Avg135, x.Sharp.Avg135 = 20, 1
+	y := &Fund{Code: "000002", Name: "Y", Type: "混合型", NetAssetsScale: 30 * 100000000,
+		Managers: []fundManager{{ID: "a", Name: "A", ManageDays: 500}},
+	}
+	y.Performance.Year1RankRatio, y.Performance.Year1ProfitRatio = 50, 10
+	y.MaxRetracement.Avg135, y.Sharp.Avg135 = 10, 0.5
+	z := &Fund{Code: "000003", Name: "Z", Type: "股票型", NetAssetsScale: 20 * 100000000,
+		Managers: []fundManager{{ID: "b", Name: "B", ManageDays: 800}},
+	}
+	z.Performance.Year1RankRatio, z.Performance.Year3RankRatio = 30, 40
+	return map[string]*Fund{x.Code: x, y.Code: y, z.Code: z}
+}
+
+func TestNewFundManagerProfile(t *testing.T) {
+	info := eastmoney.FundManagerInfo{ID: "a", Name: "A", FundCodes: []string{"000001", "000002", "999999"}}
+	p := NewFundManagerProfile(info, testFundManagerFunds())
+	require.Len(t, p.Funds, 2)
+	require.Equal(t, "000002", p.Funds[0].Code)
+	require.Equal(t, "2019-01-01", p.Funds[1].StartDate)
+	require.Equal(t, 2, p.Aggregate.FundCount)
+	require.Equal(t, 1, p.Aggregate.SoloFundCount)
+	require.InDelta(t, 40, p.Aggregate.TotalScale, 1e-9)
+	require.InDelta(t, 40, p.Aggregate.Year1RankRatio, 1e-9)
+	// 只有 X 有近3年数据
+	require.InDelta(t, 20, p.Aggregate.Year3RankRatio, 1e-9)
+	require.InDelta(t, 12.5, p.Aggregate.MaxRetr135, 1e-9)
+	require.Equal(t, []FundManagerTenureOverlap{{
+		FundCode: "000001", FundName: "X", CoManagerID: "b", CoManagerName: "B",
+		StartDate: "2019-01-01", CoStartDate: "2020-11-01", OverlapDays: 400,
+	}}, p.Overlaps)
+}
+
+func TestCompareFundManagers(t *testing.T) {
+	a := eastmoney.FundManagerInfo{ID: "a", Name: "A", Yieldse: 20, WorkingYears: 8, FundCodes: []string{"000001", "000002"}}
+	b := eastmoney.FundManagerInfo{ID: "b", Name: "B", Yieldse: 15, WorkingYears: 4, FundCodes: []string{"000001", "000003"}}
+	c := eastmoney.FundManagerInfo{ID: "c", Name: "C", Yieldse: 5, WorkingYears: 2}
+	population := eastmoney.FundManagerInfoList{&a, &b, &c}
+	result := CompareFundManagers([]eastmoney.FundManagerInfo{a, b}, testFundManagerFunds(), population)
+	require.Len(t, result.Managers, 2)
+	require.Equal(t, []FundManagerSharedFund{
+		{Code: "000001", Name: "X", Managers: []string{"A", "B"}, OverlapDays: 400},
+	}, result.SharedFunds)
+
+	pa, pb := result.Managers[0], result.Managers[1]
+	components := map[string]FundScoreComponent{}
+	for _, comp := range pa.Score.Components {
+		components[comp.Name] = comp
+	}
+	// 年化回报在 A、B、C 中最高
+	require.InDelta(t, 1, components["yieldse"].Credit, 1e-9)
+	// 现任基金加权近3年排名前 20%
+	require.InDelta(t, 0.8, components["rank"].Credit, 1e-9)
+	require.InDelta(t, 33.3333, pb.Score.Components[1].Value, 1e-4)
+	require.Equal(t, 1, pa.Score.Rank)
+	require.Equal(t, 2, pb.Score.Rank)
+	require.True(t, pa.Score.Total > pb.Score.Total)
+}
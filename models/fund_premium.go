@@ -0,0 +1,135 @@
+// 场内基金（ETF、LOF）溢价率及跟踪误差
+
+package models
+
+import (
+	"math"
+
+	"github.com/axiaoxin-com/investool/datacenter/eastmoney"
+)
+
+// DefaultFundPremiumThreshold 默认溢价率提示阈值（%）
+const DefaultFundPremiumThreshold = 3.0
+
+// FundPremiumPoint 单日溢价率
+type FundPremiumPoint struct {
+	// 日期
+	Date string `json:"date"`
+	// 场内收盘价
+	Price float64 `json:"price"`
+	// 单位净值
+	Nav float64 `json:"nav"`
+	// 溢价率（%），负数为折价
+	Premium float64 `json:"premium"`
+}
+
+// FundPremiumHistory 历史溢价率统计
+type FundPremiumHistory struct {
+	// 每日溢价率，按日期升序
+	Points []FundPremiumPoint `json:"points"`
+	// 最新一日
+	Latest FundPremiumPoint `json:"latest"`
+	// 平均溢价率（%）
+	AvgPremium float64 `json:"avg_premium"`
+	// 最高溢价率（%）
+	MaxPremium float64 `json:"max_premium"`
+	// 最高溢价率日期
+	MaxPremiumDate string `json:"max_premium_date"`
+	// 最低溢价率（%），负数为最大折价
+	MinPremium float64 `json:"min_premium"`
+	// 最低溢价率日期
+	MinPremiumDate string `json:"min_premium_date"`
+	// 溢价率超过阈值的天数
+	DaysAboveThreshold int `json:"days_above_threshold"`
+}
+
+// NewFundPremiumHistory 按日期匹配场内收盘价与单位净值计算历史溢价率，threshold 为溢价率提示阈值（%）
+func NewFundPremiumHistory(prices eastmoney.IndexKlineList, navs eastmoney.FundNetHistory, threshold float64) FundPremiumHistory {
+	h := FundPremiumHistory{Points: []FundPremiumPoint{}}
+	navMap := map[string]float64{}
+	for _, n := range navs {
+		if n.Nav > 0 {
+			navMap[n.Date] = n.Nav
+		}
+	}
+	sum := 0.0
+	for _, p := range prices {
+		nav, ok := navMap[p.Date]
+		if !ok || p.Close <= 0 {
+			continue
+		}
+		point := FundPremiumPoint{Date: p.Date, Price: p.Close, Nav: nav, Premium: (p.Close/nav - 1) * 100}
+		if len(h.Points) == 0 || point.Premium > h.MaxPremium {
+			h.MaxPremium, h.MaxPremiumDate = point.Premium, point.Date
+		}
+		if len(h.Points) == 0 || point.Premium < h.MinPremium {
+			h.MinPremium, h.MinPremiumDate = point.Premium, point.Date
+		}
+		if point.Premium > threshold {
+			h.DaysAboveThreshold++
+		}
+		sum += point.Premium
+		h.Points = append(h.Points, point)
+	}
+	if n := len(h.Points); n > 0 {
+		h.Latest = h.Points[n-1]
+		h.AvgPremium = sum / float64(n)
+	}
+	return h
+}
+
+// FundTrackingError 基金相对跟踪指数的跟踪误差
+type FundTrackingError struct {
+	// 参与计算的交易日数
+	Days int `json:"days"`
+	// 年化跟踪误差（%），为日收益率差的标准差乘以 250 的平方根
+	TrackingError float64 `json:"tracking_error"`
+	// 日均收益率差（%）
+	AvgDiff float64 `json:"avg_diff"`
+	// 区间基金净值收益率（%）
+	FundReturn float64 `json:"fund_return"`
+	// 区间指数收益率（%）
+	IndexReturn float64 `json:"index_return"`
+}
+
+// NewFundTrackingError 计算基金净值日增长率与指数日涨跌幅之差的年化标准差，navs 和 index 均按日期升序
+func NewFundTrackingError(navs eastmoney.FundNetHistory, index eastmoney.IndexKlineList) FundTrackingError {
+	t := FundTrackingError{}
+	indexReturns := map[string]float64{}
+	for i := 1; i < len(index); i++ {
+		if index[i-1].Close > 0 {
+			indexReturns[index[i].Date] = (index[i].Close/index[i-1].Close - 1) * 100
+		}
+	}
+	diffs := []float64{}
+	fundCum, indexCum := 1.0, 1.0
+	// 第一条净值的日增长率相对于区间之前的净值，不计入
+	for i := 1; i < len(navs); i++ {
+		ir, ok := indexReturns[navs[i].Date]
+		if !ok {
+			continue
+		}
+		fr := navs[i].ChangeRatio
+		diffs = append(diffs, fr-ir)
+		fundCum *= 1 + fr/100
+		indexCum *= 1 + ir/100
+	}
+	t.Days = len(diffs)
+	if t.Days < 2 {
+		return t
+	}
+	sum := 0.0
+	for _, d := range diffs {
+		sum += d
+	}
+	t.AvgDiff = sum / float64(t.Days)
+	variance := 0.0
+	for _, d := range diffs {
+		variance += (d - t.AvgDiff) * (d - t.AvgDiff)
+	}
+	variance /= float64(t.Days - 1)
+	t.TrackingError = math.Sqrt(variance) * math.Sqrt(250)
+	t.FundReturn = (fundCum - 1) * 100
+	t.IndexReturn = (indexCum - 1) * 100
+	return t
+}
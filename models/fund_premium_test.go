@@ -0,0 +1,58 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/axiaoxin-com/investool/datacenter/eastmoney"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewFundPremiumHistory(t *testing.T) {
+	prices := eastmoney.IndexKlineList{
+		{Date: "2021-06-01", Close: 1.1},
+		{Date: "2021-06-02", Close: 1.0},
+		{Date: "2021-06-03", Close: 1.05},
+		{Date: "2021-06-04", Close: 1.2},
+	}
+	navs := eastmoney.FundNetHistory{
+		{Date: "2021-06-01", Nav: 1},
+		{Date: "2021-06-02", Nav: 1},
+		{Date: "2021-06-03", Nav: 1},
+	}
+	h := NewFundPremiumHistory(prices, navs, 3)
+	require.Len(t, h.Points, 3)
+	require.InDelta(t, 10, h.MaxPremium, 1e-9)
+	require.Equal(t, "2021-06-01", h.MaxPremiumDate)
+	require.InDelta(t, 0, h.MinPremium, 1e-9)
+	require.Equal(t, "2021-06-02", h.MinPremiumDate)
+	require.InDelta(t, 5, h.AvgPremium, 1e-9)
+	require.Equal(t, 2, h.DaysAboveThreshold)
+	require.Equal(t, "2021-06-03", h.Latest.Date)
+
+	h = NewFundPremiumHistory(nil, navs, 3)
+	require.Empty(t, h.Points)
+	require.Equal(t, FundPremiumPoint{}, h.Latest)
+}
+
+func TestNewFundTrackingError(t *testing.T) {
+	navs := eastmoney.FundNetHistory{
+		{Date: "2021-06-01", ChangeRatio: 5},
+		{Date: "2021-06-02", ChangeRatio: 1},
+		{Date: "2021-06-03", ChangeRatio: 2},
+		{Date: "2021-06-04", ChangeRatio: -1},
+	}
+	index := eastmoney.IndexKlineList{
+		{Date: "2021-06-01", Close: 100},
+		{Date: "2021-06-02", Close: 101},
+		{Date: "2021-06-03", Close: 102.01},
+		{Date: "2021-06-04", Close: 100.9899},
+	}
+	te := NewFundTrackingError(navs, index)
+	require.Equal(t, 3, te.Days)
+	require.InDelta(t, 1.0/3, te.AvgDiff, 1e-6)
+	require.InDelta(t, 9.1287, te.TrackingError, 1e-4)
+	require.InDelta(t, 1.9898, te.FundReturn, 1e-4)
+	require.InDelta(t, 0.9899, te.IndexReturn, 1e-4)
+
+	require.Equal(t, FundTrackingError{Days: 1}, NewFundTrackingError(navs[:2], index))
+}
@@ -0,0 +1,440 @@
+// 基金筛选规则集：从配置文件加载 4433、5555、债基等命名规则集，生成各自的基金列表并说明不满足的条件
+
+package models
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"regexp"
+	"strings"
+
+	"github.com/axiaoxin-com/goutils"
+	"github.com/axiaoxin-com/logging"
+	"github.com/spf13/viper"
+)
+
+// FundRuleSetName4433 4433 规则集名称
+const FundRuleSetName4433 = "4433"
+
+// FundCondition 基金筛选条件检测结果
+type FundCondition struct {
+	// 条件名称
+	Name string `json:"name"`
+	// 要求
+	Expect string `json:"expect"`
+	// 实际值
+	Actual string `json:"actual"`
+	// 是否满足
+	Passed bool `json:"passed"`
+}
+
+// Conditions 返回已设置的筛选条件对基金的检测结果，未设置（值为 0 或空）的条件不检测
+func (p ParamFundListFilter) Conditions(ctx context.Context, fund Fund) []FundCondition {
+	conds := []FundCondition{}
+	perf := fund.Performance
+	if p.MinEstabYears > 0 {
+		years := fund.EstabYears(ctx)
+		conds = append(conds, FundCondition{
+			Name:   "成立年限",
+			Expect: fmt.Sprintf(">= %.2f 年", p.MinEstabYears),
+			Actual: fmt.Sprintf("%.2f 年", years),
+			// 无法获取成立时间时不排除
+			Passed: years <= 0 || years >= p.MinEstabYears,
+		})
+	}
+	if p.Year1RankRatio > 0 {
+		conds = append(conds, FundCondition{
+			Name:   "近1年收益率排名",
+			Expect: fmt.Sprintf("前 %.2f%%", p.Year1RankRatio),
+			Actual: fmt.Sprintf("前 %.2f%%", perf.Year1RankRatio),
+			Passed: perf.Year1RankRatio <= p.Year1RankRatio,
+		})
+	}
+	if p.ThisYear235RankRatio > 0 {
+		conds = append(conds, FundCondition{
+			Name:   "今年来、近2年、3年、5年收益率排名",
+			Expect: fmt.Sprintf("均在前 %.2f%%", p.ThisYear235RankRatio),
+			Actual: fmt.Sprintf("前 %.2f%%、%.2f%%、%.2f%%、%.2f%%", perf.ThisYearRankRatio, perf.Year2RankRatio, perf.Year3RankRatio, perf.Year5RankRatio),
+			Passed: perf.ThisYearRankRatio <= p.ThisYear235RankRatio && perf.Year2RankRatio <= p.ThisYear235RankRatio &&
+				perf.Year3RankRatio <= p.ThisYear235RankRatio && perf.Year5RankRatio <= p.ThisYear235RankRatio,
+		})
+	}
+	if p.Month6RankRatio > 0 {
+		conds = append(conds, FundCondition{
+			Name:   "近6月收益率排名",
+			Expect: fmt.Sprintf("前 %.2f%%", p.Month6RankRatio),
+			Actual: fmt.Sprintf("前 %.2f%%", perf.Month6RankRatio),
+			Passed: perf.Month6RankRatio <= p.Month6RankRatio,
+		})
+	}
+	if p.Month3RankRatio > 0 {
+		conds = append(conds, FundCondition{
+			Name:   "近3月收益率排名",
+			Expect: fmt.Sprintf("前 %.2f%%", p.Month3RankRatio),
+			Actual: fmt.Sprintf("前 %.2f%%", perf.Month3RankRatio),
+			Passed: perf.Month3RankRatio <= p.Month3RankRatio,
+		})
+	}
+	if len(p.Types) > 0 {
+		conds = append(conds, FundCondition{
+			Name:   "基金类型",
+			Expect: strings.Join(p.Types, "、"),
+			Actual: fund.Type,
+			Passed: goutils.IsStrInSlice(fund.Type, p.Types),
+		})
+	}
+	if p.MinScale > 0 {
+		conds = append(conds, FundCondition{
+			Name:   "基金规模下限",
+			Expect: fmt.Sprintf(">= %.2f 亿", p.MinScale),
+			Actual: fund.NetAssetsScaleHuman(),
+			Passed: fund.NetAssetsScale >= p.MinScale*100000000,
+		})
+	}
+	if p.MaxScale > 0 {
+		conds = append(conds, FundCondition{
+			Name:   "基金规模上限",
+			Expect: fmt.Sprintf("<= %.2f 亿", p.MaxScale),
+			Actual: fund.NetAssetsScaleHuman(),
+			Passed: fund.NetAssetsScale <= p.MaxScale*100000000,
+		})
+	}
+	if p.MinManagerYears > 0 {
+		years := fund.Manager.ManageDays / 365
+		conds = append(conds, FundCondition{
+			Name:   "基金经理任职年限",
+			Expect: fmt.Sprintf(">= %.2f 年", p.MinManagerYears),
+			Actual: fmt.Sprintf("%.2f 年", years),
+			Passed: years >= p.MinManagerYears,
+		})
+	}
+	if p.Max135AvgStddev > 0 {
+		conds = append(conds, FundCondition{
+			Name:   "1、3、5年波动率均值",
+			Expect: fmt.Sprintf("<= %.2f%%", p.Max135AvgStddev),
+			Actual: fmt.Sprintf("%.2f%%", fund.Stddev.Avg135),
+			Passed: fund.Stddev.Avg135 <= p.Max135AvgStddev,
+		})
+	}
+	if p.Max135AvgRetr > 0 {
+		conds = append(conds, FundCondition{
+			Name:   "1、3、5年最大回撤均值",
+			Expect: fmt.Sprintf("<= %.2f%%", p.Max135AvgRetr),
+			Actual: fmt.Sprintf("%.2f%%", fund.MaxRetracement.Avg135),
+			Passed: fund.MaxRetracement.Avg135 <= p.Max135AvgRetr,
+		})
+	}
+	if p.Min135AvgSharp > 0 {
+		conds = append(conds, FundCondition{
+			Name:   "1、3、5年夏普比率均值",
+			Expect: fmt.Sprintf(">= %.2f", p.Min135AvgSharp),
+			Actual: fmt.Sprintf("%.2f", fund.Sharp.Avg135),
+			Passed: fund.Sharp.Avg135 >= p.Min135AvgSharp,
+		})
+	}
+	if p.MaxLeverage > 0 || p.MaxConvertibleRatio > 0 || p.MaxBondTop5Ratio > 0 {
+		bond := fund.AnalyzeBonds()
+		if p.MaxLeverage > 0 {
+			conds = append(conds, FundCondition{
+				Name:   "杠杆率",
+				Expect: fmt.Sprintf("<= %.2f%%", p.MaxLeverage),
+				Actual: fmt.Sprintf("%.2f%%", bond.Leverage),
+				Passed: bond.Leverage <= p.MaxLeverage,
+			})
+		}
+		if p.MaxConvertibleRatio > 0 {
+			conds = append(conds, FundCondition{
+				Name:   "可转债占重仓债券比例",
+				Expect: fmt.Sprintf("<= %.2f%%", p.MaxConvertibleRatio),
+				Actual: fmt.Sprintf("%.2f%%", bond.ConvertibleRatio),
+				Passed: bond.ConvertibleRatio <= p.MaxConvertibleRatio,
+			})
+		}
+		if p.MaxBondTop5Ratio > 0 {
+			conds = append(conds, FundCondition{
+				Name:   "前五大重仓债券占净值比例",
+				Expect: fmt.Sprintf("<= %.2f%%", p.MaxBondTop5Ratio),
+				Actual: fmt.Sprintf("%.2f%%", bond.Top5Ratio),
+				Passed: bond.Top5Ratio <= p.MaxBondTop5Ratio,
+			})
+		}
+	}
+	if p.MaxPurchaseRate > 0 {
+		rate, ok := parseFundRate(fund.Rate)
+		conds = append(conds, FundCondition{
+			Name:   "购买费率",
+			Expect: fmt.Sprintf("<= %.2f%%", p.MaxPurchaseRate),
+			Actual: fund.Rate,
+			// 无法获取购买费率时不排除
+			Passed: !ok || rate <= p.MaxPurchaseRate,
+		})
+	}
+	if p.MaxAnnualFeeRate > 0 {
+		rate := fund.Fee.AnnualRate()
+		conds = append(conds, FundCondition{
+			Name:   "管理费、托管费及销售服务费合计",
+			Expect: fmt.Sprintf("<= %.2f%%/年", p.MaxAnnualFeeRate),
+			Actual: fmt.Sprintf("%.2f%%/年", rate),
+			// 无法获取费率时不排除
+			Passed: !fund.Fee.Loaded || rate <= p.MaxAnnualFeeRate,
+		})
+	}
+	return conds
+}
+
+// Match 判断基金是否满足全部已设置的筛选条件
+func (p ParamFundListFilter) Match(ctx context.Context, fund Fund) bool {
+	for _, cond := range p.Conditions(ctx, fund) {
+		if !cond.Passed {
+			return false
+		}
+	}
+	return true
+}
+
+// FundRuleSet 基金筛选规则集
+type FundRuleSet struct {
+	// 规则集名称，用于页面地址及列表文件名
+	Name string `json:"name"           mapstructure:"name"`
+	// 规则集描述
+	Desc string `json:"desc"           mapstructure:"desc"`
+	// 要求有近5年业绩数据
+	RequireYear5 bool `json:"require_year_5" mapstructure:"require_year_5"`
+	// 基金类型包含任一关键词，如: 债券
+	TypeKeywords []string `json:"type_keywords"  mapstructure:"type_keywords"`
+	// 列表默认排序
+	Sort FundSortType `json:"sort"           mapstructure:"sort"`
+	// 筛选条件
+	Filter ParamFundListFilter `json:"filter"         mapstructure:"filter"`
+}
+
+// fundRuleSetNameRegexp 规则集名称只允许字母、数字、下划线和短横线
+var fundRuleSetNameRegexp = regexp.MustCompile(`^[0-9A-Za-z_-]+$`)
+
+// Validate 校验规则集
+func (rs FundRuleSet) Validate() error {
+	if !fundRuleSetNameRegexp.MatchString(rs.Name) {
+		return fmt.Errorf("invalid fund rule set name %q", rs.Name)
+	}
+	return nil
+}
+
+// Explain 返回规则集各条件对基金的检测结果
+func (rs FundRuleSet) Explain(ctx context.Context, fund Fund) []FundCondition {
+	conds := []FundCondition{}
+	if rs.RequireYear5 {
+		conds = append(conds, FundCondition{
+			Name:   "近5年业绩数据",
+			Expect: "有",
+			Actual: fmt.Sprintf("收益率 %.2f%% 排名 %.0f", fund.Performance.Year5ProfitRatio, fund.Performance.Year5RankNum),
+			Passed: fund.Performance.Year5ProfitRatio != 0 && fund.Performance.Year5RankNum != 0,
+		})
+	}
+	if len(rs.TypeKeywords) > 0 {
+		passed := false
+		for _, k := range rs.TypeKeywords {
+			if k != "" && strings.Contains(fund.Type, k) {
+				passed = true
+				break
+			}
+		}
+		conds = append(conds, FundCondition{
+			Name:   "基金类型关键词",
+			Expect: "包含 " + strings.Join(rs.TypeKeywords, " 或 "),
+			Actual: fund.Type,
+			Passed: passed,
+		})
+	}
+	return append(conds, rs.Filter.Conditions(ctx, fund)...)
+}
+
+// Match 判断基金是否满足规则集
+func (rs FundRuleSet) Match(ctx context.Context, fund Fund) bool {
+	for _, cond := range rs.Explain(ctx, fund) {
+		if !cond.Passed {
+			return false
+		}
+	}
+	return true
+}
+
+// Apply 返回基金列表中满足规则集的基金，按规则集默认排序
+func (rs FundRuleSet) Apply(ctx context.Context, funds FundList) FundList {
+	results := FundList{}
+	for _, fund := range funds {
+		if fund != nil && rs.Match(ctx, *fund) {
+			results = append(results, fund)
+		}
+	}
+	results.Sort(rs.Sort)
+	return results
+}
+
+// ListFilename 规则集基金列表数据文件
+func (rs FundRuleSet) ListFilename() string {
+	return fmt.Sprintf("./fund_%s_list.json", rs.Name)
+}
+
+var (
+	// DefaultFundRuleSets 默认基金筛选规则集
+	DefaultFundRuleSets = []FundRuleSet{
+		{
+			Name:         FundRuleSetName4433,
+			Desc:         "近1年及今年来、近2、3、5年收益率排名前1/4，近6月、3月排名前1/3",
+			RequireYear5: true,
+			Sort:         FundSortTypeWeek,
+			Filter: ParamFundListFilter{
+				Year1RankRatio:       100.0 / 4,
+				ThisYear235RankRatio: 100.0 / 4,
+				Month6RankRatio:      100.0 / 3,
+				Month3RankRatio:      100.0 / 3,
+			},
+		},
+		{
+			Name:         "5555",
+			Desc:         "近1年及今年来、近2、3、5年、近6月、3月收益率排名均在前1/5，基金经理任职满3年",
+			RequireYear5: true,
+			Sort:         FundSortTypeWeek,
+			Filter: ParamFundListFilter{
+				Year1RankRatio:       100.0 / 5,
+				ThisYear235RankRatio: 100.0 / 5,
+				Month6RankRatio:      100.0 / 5,
+				Month3RankRatio:      100.0 / 5,
+				MinManagerYears:      3,
+				MinScale:             2,
+			},
+		},
+		{
+			Name:         "bond",
+			Desc:         "债券基金：1、3、5年最大回撤均值不超过3%，夏普比率均值不低于1，规模不低于2亿，杠杆率不超过140%，可转债占重仓债券不超过20%",
+			TypeKeywords: []string{"债券"},
+			Sort:         FundSortTypeSharp135Avg,
+			Filter: ParamFundListFilter{
+				MinEstabYears:       3,
+				MinScale:            2,
+				MinManagerYears:     2,
+				Max135AvgRetr:       3,
+				Min135AvgSharp:      1,
+				MaxLeverage:         140,
+				MaxConvertibleRatio: 20,
+			},
+		},
+	}
+	// FundRuleSets 已加载的基金筛选规则集，按配置顺序
+	FundRuleSets = DefaultFundRuleSets
+	// FundRuleSetsFilename 基金筛选规则集配置文件
+	FundRuleSetsFilename = "./fund_rules.toml"
+	// FundRuleSetLists 各规则集的基金列表，key 为规则集名称
+	FundRuleSetLists = map[string]FundList{}
+)
+
+// LoadFundRuleSets 从 toml/yaml 文件的 fund_rule_sets 配置加载规则集
+func LoadFundRuleSets(filename string) ([]FundRuleSet, error) {
+	v := viper.New()
+	v.SetConfigFile(filename)
+	if err := v.ReadInConfig(); err != nil {
+		return nil, err
+	}
+	sets := []FundRuleSet{}
+	if err := v.UnmarshalKey("fund_rule_sets", &sets); err != nil {
+		return nil, err
+	}
+	if len(sets) == 0 {
+		return nil, errors.New("empty fund rule sets in " + filename)
+	}
+	names := map[string]bool{}
+	for _, rs := range sets {
+		if err := rs.Validate(); err != nil {
+			return nil, err
+		}
+		if names[rs.Name] {
+			return nil, fmt.Errorf("duplicate fund rule set %s in %s", rs.Name, filename)
+		}
+		names[rs.Name] = true
+	}
+	return sets, nil
+}
+
+// InitFundRuleSets 加载基金筛选规则集配置文件，失败时保留当前规则集
+func InitFundRuleSets() error {
+	sets, err := LoadFundRuleSets(FundRuleSetsFilename)
+	if err != nil {
+		return err
+	}
+	FundRuleSets = sets
+	return nil
+}
+
+// GetFundRuleSet 按名称返回规则集，未配置 4433 时使用默认的 4433 规则集
+func GetFundRuleSet(name string) (FundRuleSet, bool) {
+	for _, rs := range FundRuleSets {
+		if rs.Name == name {
+			return rs, true
+		}
+	}
+	if name == FundRuleSetName4433 {
+		return DefaultFundRuleSets[0], true
+	}
+	return FundRuleSet{}, false
+}
+
+// FundRuleSetsWith4433 返回需要更新基金列表的规则集，未配置 4433 时追加默认的 4433 规则集，保证 Fund4433List 始终更新
+func FundRuleSetsWith4433() []FundRuleSet {
+	sets := append([]FundRuleSet{}, FundRuleSets...)
+	for _, rs := range sets {
+		if rs.Name == FundRuleSetName4433 {
+			return sets
+		}
+	}
+	rs, _ := GetFundRuleSet(FundRuleSetName4433)
+	return append(sets, rs)
+}
+
+// setFundRuleSetList 更新规则集基金列表，4433 同时更新 Fund4433List
+func setFundRuleSetList(name string, fundlist FundList) {
+	lists := map[string]FundList{}
+	for k, v := range FundRuleSetLists {
+		lists[k] = v
+	}
+	lists[name] = fundlist
+	FundRuleSetLists = lists
+	if name == FundRuleSetName4433 {
+		Fund4433List = fundlist
+		Fund4433TypeList = fundlist.Types()
+	}
+}
+
+// InitFundRuleSetLists 从 json 文件加载各规则集的基金列表，文件不存在时按规则集从全量基金列表筛选
+func InitFundRuleSetLists() error {
+	var errs []string
+	for _, rs := range FundRuleSetsWith4433() {
+		fundlist := FundList{}
+		b, err := ioutil.ReadFile(rs.ListFilename())
+		if err == nil {
+			err = json.Unmarshal(b, &fundlist)
+		}
+		if err != nil {
+			logging.Warnf(nil, "load fund rule set %s list error:%v, filter from all funds", rs.Name, err)
+			fundlist = rs.Apply(context.Background(), FundAllList)
+			if len(FundAllList) == 0 {
+				errs = append(errs, fmt.Sprintf("%s: %v", rs.Name, err))
+			}
+		}
+		fundlist.Sort(rs.Sort)
+		setFundRuleSetList(rs.Name, fundlist)
+	}
+	if len(errs) > 0 {
+		return errors.New("init fund rule set lists error: " + strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// UpdateFundRuleSetList 按规则集从全量基金列表筛选并更新基金列表
+func UpdateFundRuleSetList(ctx context.Context, rs FundRuleSet) FundList {
+	fundlist := rs.Apply(ctx, FundAllList)
+	setFundRuleSetList(rs.Name, fundlist)
+	return fundlist
+}
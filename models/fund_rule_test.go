@@ -0,0 +1,147 @@
+package models
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func testRuleFund(t *testing.T, s string) *Fund {
+	fund := &Fund{}
+	require.Nil(t, json.Unmarshal([]byte(s), fund))
+	return fund
+}
+
+const testRuleFund4433 = `{"code":"000001","name":"A","type":"混合型-偏股","established_date":"--","net_assets_scale":1000000000,
+"performance":{"year_1_rank_ratio":10,"year_2_rank_ratio":20,"year_3_rank_ratio":15,"year_5_rank_ratio":24,"this_year_rank_ratio":5,
+"month_6_rank_ratio":30,"month_3_rank_ratio":22,"year_5_profit_ratio":80,"year_5_rank_num":12},
+"manager":{"manage_days":730},"max_retracement":{"avg_135":12},"sharp":{"avg_135":0.8}}`
+
+func TestFundRuleSetExplain(t *testing.T) {
+	ctx := context.Background()
+	fund := testRuleFund(t, testRuleFund4433)
+	rs, exists := GetFundRuleSet(FundRuleSetName4433)
+	require.True(t, exists)
+	require.True(t, rs.Match(ctx, *fund))
+	require.True(t, fund.Is4433(ctx))
+
+	// 5555 不满足：近3、6月排名及基金经理任职年限
+	strict := DefaultFundRuleSets[1]
+	conds := strict.Explain(ctx, *fund)
+	failed := []string{}
+	for _, cond := range conds {
+		if !cond.Passed {
+			failed = append(failed, cond.Name)
+		}
+	}
+	require.Equal(t, []string{"今年来、近2年、3年、5年收益率排名", "近6月收益率排名", "近3月收益率排名", "基金经理任职年限"}, failed)
+	require.False(t, strict.Match(ctx, *fund))
+
+	// 没有5年数据不满足 4433
+	fund.Performance.Year5RankNum = 0
+	require.False(t, fund.Is4433(ctx))
+	conds = rs.Explain(ctx, *fund)
+	require.Equal(t, "近5年业绩数据", conds[0].Name)
+	require.False(t, conds[0].Passed)
+}
+
+func TestFundRuleSetTypeKeywords(t *testing.T) {
+	ctx := context.Background()
+	bond := DefaultFundRuleSets[2]
+	fund := testRuleFund(t, `{"code":"000002","type":"债券型-长债","established_date":"--","net_assets_scale":500000000,
+"manager":{"manage_days":1095},"max_retracement":{"avg_135":1.5},"sharp":{"avg_135":1.8}}`)
+	require.True(t, bond.Match(ctx, *fund))
+
+	fund.Type = "混合型-偏债"
+	require.False(t, bond.Match(ctx, *fund))
+	fund.Type = "债券型-混合债"
+	fund.MaxRetracement.Avg135 = 4
+	require.False(t, bond.Match(ctx, *fund))
+}
+
+func TestFundRuleSetApply(t *testing.T) {
+	ctx := context.Background()
+	a := testRuleFund(t, testRuleFund4433)
+	b := testRuleFund(t, testRuleFund4433)
+	b.Code = "000003"
+	b.Performance.Year1RankRatio = 30
+	c := testRuleFund(t, testRuleFund4433)
+	c.Code = "000004"
+	rs := DefaultFundRuleSets[0]
+	rs.Sort = FundSortTypeSharp135Avg
+	c.Sharp.Avg135 = 2
+	result := rs.Apply(ctx, FundList{a, b, nil, c})
+	require.Len(t, result, 2)
+	require.Equal(t, "000004", result[0].Code)
+	require.Equal(t, "000001", result[1].Code)
+
+	// Filter 与 Conditions 结果一致
+	require.Len(t, FundList{a, b, c}.Filter(ctx, rs.Filter), 2)
+}
+
+func TestLoadFundRuleSets(t *testing.T) {
+	sets, err := LoadFundRuleSets("../fund_rules.toml")
+	require.Nil(t, err)
+	require.Len(t, sets, len(DefaultFundRuleSets))
+	for i, rs := range sets {
+		require.Equal(t, DefaultFundRuleSets[i].Name, rs.Name)
+		require.Equal(t, DefaultFundRuleSets[i].RequireYear5, rs.RequireYear5)
+		require.Equal(t, DefaultFundRuleSets[i].TypeKeywords, rs.TypeKeywords)
+		require.Equal(t, DefaultFundRuleSets[i].Sort, rs.Sort)
+		require.Equal(t, DefaultFundRuleSets[i].Filter, rs.Filter)
+		require.Equal(t, "./fund_"+rs.Name+"_list.json", rs.ListFilename())
+	}
+	require.Equal(t, Fund4433ListFilename, sets[0].ListFilename())
+
+	dir := t.TempDir()
+	filename := filepath.Join(dir, "rules.toml")
+	require.Nil(t, os.WriteFile(filename, []byte(`
+[[fund_rule_sets]]
+    name = "my rule"
+`), 0644))
+	_, err = LoadFundRuleSets(filename)
+	require.NotNil(t, err)
+
+	require.Nil(t, os.WriteFile(filename, []byte(`
+[[fund_rule_sets]]
+    name = "index"
+    [fund_rule_sets.filter]
+        types = ["指数型-股票"]
+        max_135_avg_stddev = 25
+[[fund_rule_sets]]
+    name = "index"
+`), 0644))
+	_, err = LoadFundRuleSets(filename)
+	require.NotNil(t, err)
+
+	require.Nil(t, os.WriteFile(filename, []byte(`
+[[fund_rule_sets]]
+    name = "index"
+    [fund_rule_sets.filter]
+        types = ["指数型-股票"]
+        max_135_avg_stddev = 25
+`), 0644))
+	sets, err = LoadFundRuleSets(filename)
+	require.Nil(t, err)
+	require.Equal(t, []string{"指数型-股票"}, sets[0].Filter.Types)
+	require.Equal(t, 25.0, sets[0].Filter.Max135AvgStddev)
+}
+
+func TestFundRuleSetsWith4433(t *testing.T) {
+	origin := FundRuleSets
+	defer func() { FundRuleSets = origin }()
+
+	FundRuleSets = []FundRuleSet{{Name: "index"}}
+	sets := FundRuleSetsWith4433()
+	require.Len(t, sets, 2)
+	require.Equal(t, "index", sets[0].Name)
+	require.Equal(t, FundRuleSetName4433, sets[1].Name)
+	require.Len(t, FundRuleSets, 1)
+
+	FundRuleSets = DefaultFundRuleSets
+	require.Len(t, FundRuleSetsWith4433(), len(DefaultFundRuleSets))
+}
@@ -0,0 +1,327 @@
+// 基金综合评分：收益排名、夏普比率、回撤、波动率、基金经理任职、规模及费率按同类型基金归一化后加权得分，评分模型可通过配置文件定义
+
+package models
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+// 组成项在同类型基金中的归一化方向
+const (
+	// FundScoreDirectionHigher 指标值越大越好
+	FundScoreDirectionHigher = "higher"
+	// FundScoreDirectionLower 指标值越小越好
+	FundScoreDirectionLower = "lower"
+)
+
+// FundScore 基金综合评分
+type FundScore struct {
+	// 总分（100分）
+	Total float64 `json:"total"`
+	// 同类型基金中的排名，从 1 开始，未评分时为 0
+	TypeRank int `json:"type_rank"`
+	// 参与排名的同类型基金数
+	TypeCount int `json:"type_count"`
+	// 各组成项得分
+	Components []FundScoreComponent `json:"components"`
+}
+
+// FundScoreComponent 基金综合评分组成项得分
+type FundScoreComponent struct {
+	// 组成项标识
+	Name string `json:"name"`
+	// 组成项名称
+	Label string `json:"label"`
+	// 满分
+	Weight float64 `json:"weight"`
+	// 指标值
+	Value float64 `json:"value"`
+	// 指标是否缺失
+	Missing bool `json:"missing"`
+	// 得分比例 0-1，按同类型归一化时为优于同类基金的比例
+	Credit float64 `json:"credit"`
+	// 得分
+	Score float64 `json:"score"`
+}
+
+// FundScoreComponentModel 基金综合评分组成项定义
+type FundScoreComponentModel struct {
+	// 组成项标识
+	Name string `json:"name"      mapstructure:"name"`
+	// 组成项名称
+	Label string `json:"label"     mapstructure:"label"`
+	// 满分
+	Weight float64 `json:"weight"    mapstructure:"weight"`
+	// 指标名称，见 fundScoreMetrics
+	Metric string `json:"metric"    mapstructure:"metric"`
+	// 同类型归一化方向: higher 越大越好 lower 越小越好
+	Direction string `json:"direction" mapstructure:"direction"`
+	// 得分曲线，设置时按曲线计算得分比例，不按同类型归一化
+	Curve ScoreCurve `json:"curve"     mapstructure:"curve"`
+	// 指标缺失时的得分比例
+	Missing float64 `json:"missing"   mapstructure:"missing"`
+}
+
+// FundScoreModel 基金综合评分模型，总分按各组成项满分之和换算为 100 分制
+type FundScoreModel struct {
+	// 评分组成项
+	Components []FundScoreComponentModel `json:"components" mapstructure:"components"`
+	// 同类型中有该指标的基金数少于该值时，按全部基金归一化
+	MinPeers int `json:"min_peers"  mapstructure:"min_peers"`
+}
+
+// DefaultFundScoreModel 默认基金综合评分模型，fund_score.toml 不存在时使用
+var DefaultFundScoreModel = FundScoreModel{
+	Components: []FundScoreComponentModel{
+		{Name: "rank", Label: "收益排名", Weight: 30, Metric: "rank_avg", Direction: FundScoreDirectionLower},
+		{Name: "sharp", Label: "夏普比率", Weight: 15, Metric: "sharp_135", Direction: FundScoreDirectionHigher},
+		{Name: "max_retr", Label: "最大回撤", Weight: 15, Metric: "max_retr_135", Direction: FundScoreDirectionLower},
+		{Name: "stddev", Label: "波动率", Weight: 10, Metric: "stddev_135", Direction: FundScoreDirectionLower},
+		{Name: "manager", Label: "基金经理任职", Weight: 10, Metric: "manager_years", Direction: FundScoreDirectionHigher},
+		{Name: "scale", Label: "规模", Weight: 10, Metric: "scale", Curve: curve(false, 0, 0, 1, 0.5, 2, 1, 50, 0.7, 100, 0.4), Missing: 0.5},
+		{Name: "fee", Label: "费率", Weight: 10, Metric: "fee", Direction: FundScoreDirectionLower, Missing: 0.5},
+	},
+	MinPeers: 5,
+}
+
+var (
+	// FundScoreModelInUse 当前使用的基金综合评分模型
+	FundScoreModelInUse = DefaultFundScoreModel
+	// FundScoreModelFilename 基金综合评分模型配置文件
+	FundScoreModelFilename = "./fund_score.toml"
+)
+
+// fundScoreMetric 计算评分指标，返回指标值及数据是否充足
+type fundScoreMetric func(f *Fund) (float64, bool)
+
+// parseFundRate 解析 0.15% 格式的费率
+func parseFundRate(rate string) (float64, bool) {
+	v, err := strconv.ParseFloat(strings.TrimSuffix(strings.TrimSpace(rate), "%"), 64)
+	if err != nil {
+		return 0, false
+	}
+	return v, true
+}
+
+// fundScoreMetrics 可在评分模型中使用的指标
+var fundScoreMetrics = map[string]fundScoreMetric{
+	// 近3月、6月、1年、2年、3年、5年及今年来收益率同类排名比的均值，无数据的周期不计入
+	"rank_avg": func(f *Fund) (float64, bool) {
+		p := f.Performance
+		ratios := []float64{}
+		for _, r := range []float64{p.Month3RankRatio, p.Month6RankRatio, p.Year1RankRatio, p.Year2RankRatio, p.Year3RankRatio, p.Year5RankRatio, p.ThisYearRankRatio} {
+			if r > 0 {
+				ratios = append(ratios, r)
+			}
+		}
+		return avg(ratios), len(ratios) > 0
+	},
+	"rank_1y": func(f *Fund) (float64, bool) {
+		return f.Performance.Year1RankRatio, f.Performance.Year1RankRatio > 0
+	},
+	"rank_3y": func(f *Fund) (float64, bool) {
+		return f.Performance.Year3RankRatio, f.Performance.Year3RankRatio > 0
+	},
+	"rank_5y": func(f *Fund) (float64, bool) {
+		return f.Performance.Year5RankRatio, f.Performance.Year5RankRatio > 0
+	},
+	"sharp_135": func(f *Fund) (float64, bool) {
+		s := f.Sharp
+		return s.Avg135, s.Year1 != 0 || s.Year3 != 0 || s.Year5 != 0
+	},
+	"max_retr_135": func(f *Fund) (float64, bool) {
+		r := f.MaxRetracement
+		return r.Avg135, r.Year1 != 0 || r.Year3 != 0 || r.Year5 != 0
+	},
+	"stddev_135": func(f *Fund) (float64, bool) {
+		s := f.Stddev
+		return s.Avg135, s.Year1 != 0 || s.Year3 != 0 || s.Year5 != 0
+	},
+	"manager_years": func(f *Fund) (float64, bool) {
+		return f.Manager.ManageDays / 365, f.Manager.ManageDays > 0
+	},
+	// 基金规模（亿）
+	"scale": func(f *Fund) (float64, bool) {
+		return f.NetAssetsScale / 100000000, f.NetAssetsScale > 0
+	},
+	// 购买费率（%）
+	"fee": func(f *Fund) (float64, bool) {
+		return parseFundRate(f.Rate)
+	},
+}
+
+// Validate 校验评分模型
+func (m FundScoreModel) Validate() error {
+	if len(m.Components) == 0 {
+		return errors.New("fund score model without components")
+	}
+	for _, c := range m.Components {
+		if c.Name == "" || c.Weight < 0 {
+			return fmt.Errorf("fund score component %q: invalid name or weight", c.Name)
+		}
+		if _, exists := fundScoreMetrics[c.Metric]; !exists {
+			return fmt.Errorf("fund score component %s: unknown metric %q", c.Name, c.Metric)
+		}
+		if len(c.Curve.Points) == 0 && c.Direction != FundScoreDirectionHigher && c.Direction != FundScoreDirectionLower {
+			return fmt.Errorf("fund score component %s: invalid direction %q", c.Name, c.Direction)
+		}
+		for i := 1; i < len(c.Curve.Points); i++ {
+			if c.Curve.Points[i].Value < c.Curve.Points[i-1].Value {
+				return fmt.Errorf("fund score component %s: curve is not sorted", c.Name)
+			}
+		}
+	}
+	return nil
+}
+
+// LoadFundScoreModel 从 toml/yaml 文件的 fund_score 配置加载评分模型
+func LoadFundScoreModel(filename string) (FundScoreModel, error) {
+	v := viper.New()
+	v.SetConfigFile(filename)
+	if err := v.ReadInConfig(); err != nil {
+		return FundScoreModel{}, err
+	}
+	m := FundScoreModel{}
+	if err := v.UnmarshalKey("fund_score", &m); err != nil {
+		return m, err
+	}
+	return m, m.Validate()
+}
+
+// InitFundScoreModel 加载基金综合评分模型配置文件，失败时保留当前模型
+func InitFundScoreModel() error {
+	m, err := LoadFundScoreModel(FundScoreModelFilename)
+	if err != nil {
+		return err
+	}
+	FundScoreModelInUse = m
+	return nil
+}
+
+// percentileCredit 返回 v 优于 values 中其他值的比例，相等的值计一半，values 包含 v 自身
+func percentileCredit(v float64, values []float64, direction string) float64 {
+	if len(values) <= 1 {
+		return 0.5
+	}
+	better := 0.0
+	for _, other := range values {
+		switch {
+		case other == v:
+			better += 0.5
+		case direction == FundScoreDirectionLower && v < other, direction == FundScoreDirectionHigher && v > other:
+			better++
+		}
+	}
+	// 去掉与自身比较的一半
+	return (better - 0.5) / float64(len(values)-1)
+}
+
+// Score 计算基金列表中每只基金的综合评分，各组成项按基金类型归一化，结果写入 Fund.Score
+func (m FundScoreModel) Score(funds FundList) {
+	type metricValue struct {
+		value float64
+		ok    bool
+	}
+	values := make([][]metricValue, len(m.Components))
+	// 组成项 -> 基金类型 -> 有数据的指标值
+	byType := make([]map[string][]float64, len(m.Components))
+	all := make([][]float64, len(m.Components))
+	for i, c := range m.Components {
+		values[i] = make([]metricValue, len(funds))
+		byType[i] = map[string][]float64{}
+		metric := fundScoreMetrics[c.Metric]
+		for j, fund := range funds {
+			if fund == nil || metric == nil {
+				continue
+			}
+			v, ok := metric(fund)
+			values[i][j] = metricValue{value: v, ok: ok}
+			if ok {
+				byType[i][fund.Type] = append(byType[i][fund.Type], v)
+				all[i] = append(all[i], v)
+			}
+		}
+	}
+
+	totalWeight := 0.0
+	for _, c := range m.Components {
+		totalWeight += c.Weight
+	}
+	for j, fund := range funds {
+		if fund == nil {
+			continue
+		}
+		score := FundScore{Components: []FundScoreComponent{}}
+		for i, c := range m.Components {
+			mv := values[i][j]
+			comp := FundScoreComponent{Name: c.Name, Label: c.Label, Weight: c.Weight, Value: mv.value, Missing: !mv.ok}
+			switch {
+			case !mv.ok:
+				comp.Credit = c.Missing
+			case len(c.Curve.Points) > 0:
+				comp.Credit = c.Curve.Credit(mv.value)
+			default:
+				peers := byType[i][fund.Type]
+				if len(peers) < m.MinPeers {
+					peers = all[i]
+				}
+				comp.Credit = percentileCredit(mv.value, peers, c.Direction)
+			}
+			comp.Score = comp.Credit * c.Weight
+			score.Total += comp.Score
+			score.Components = append(score.Components, comp)
+		}
+		if totalWeight > 0 {
+			score.Total = score.Total / totalWeight * 100
+		}
+		fund.Score = score
+	}
+
+	// 同类型排名
+	typeFunds := map[string]FundList{}
+	for _, fund := range funds {
+		if fund != nil {
+			typeFunds[fund.Type] = append(typeFunds[fund.Type], fund)
+		}
+	}
+	for _, list := range typeFunds {
+		sort.SliceStable(list, func(a, b int) bool {
+			return list[a].Score.Total > list[b].Score.Total
+		})
+		for rank, fund := range list {
+			fund.Score.TypeRank = rank + 1
+			fund.Score.TypeCount = len(list)
+		}
+	}
+}
+
+// CalcScores 使用当前评分模型计算基金列表的综合评分
+func (f FundList) CalcScores() {
+	FundScoreModelInUse.Score(f)
+}
+
+// FillScores 将列表中同代码基金的综合评分填充到 funds，列表中没有的基金保持未评分
+func (f FundList) FillScores(funds map[string]*Fund) {
+	for _, fund := range f {
+		if fund == nil {
+			continue
+		}
+		if target, exists := funds[fund.Code]; exists && target != nil {
+			target.Score = fund.Score
+		}
+	}
+}
+
+// ScoreHuman 综合评分及同类排名描述
+func (s FundScore) ScoreHuman() string {
+	if s.TypeRank == 0 {
+		return "--"
+	}
+	return fmt.Sprintf("%.1f（同类 %d/%d）", s.Total, s.TypeRank, s.TypeCount)
+}
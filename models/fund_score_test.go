@@ -0,0 +1,109 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPercentileCredit(t *testing.T) {
+	values := []float64{1, 2, 3, 4, 5}
+	require.Equal(t, 1.0, percentileCredit(5, values, FundScoreDirectionHigher))
+	require.Equal(t, 0.0, percentileCredit(5, values, FundScoreDirectionLower))
+	require.Equal(t, 0.5, percentileCredit(3, values, FundScoreDirectionHigher))
+	require.Equal(t, 0.5, percentileCredit(2, []float64{2, 2, 2}, FundScoreDirectionLower))
+	require.Equal(t, 0.5, percentileCredit(2, []float64{2}, FundScoreDirectionLower))
+}
+
+func TestFundScoreModelScore(t *testing.T) {
+	m := FundScoreModel{
+		Components: []FundScoreComponentModel{
+			{Name: "sharp", Label: "夏普比率", Weight: 50, Metric: "sharp_135", Direction: FundScoreDirectionHigher},
+			{Name: "scale", Label: "规模", Weight: 30, Metric: "scale", Curve: curve(false, 0, 0, 2, 1), Missing: 0.5},
+			{Name: "fee", Label: "费率", Weight: 20, Metric: "fee", Direction: FundScoreDirectionLower, Missing: 0.5},
+		},
+		MinPeers: 2,
+	}
+	require.Nil(t, m.Validate())
+	fund := func(code, typ string, sharp, scale float64, rate string) *Fund {
+		f := &Fund{Code: code, Type: typ, NetAssetsScale: scale * 100000000, Rate: rate}
+		f.Sharp.Year1, f.Sharp.Avg135 = sharp, sharp
+		return f
+	}
+	a := fund("a", "股票型", 1.5, 10, "0.15%")
+	b := fund("b", "股票型", 0.5, 1, "0.12%")
+	c := fund("c", "股票型", 1.0, 0, "--")
+	// 债券型基金只与同类型比较
+	d := fund("d", "债券型", 0.8, 5, "0.08%")
+	e := fund("e", "债券型", 0.3, 5, "0.08%")
+	funds := FundList{a, b, c, d, e}
+	m.Score(funds)
+
+	require.Len(t, a.Score.Components, 3)
+	require.Equal(t, 1.0, a.Score.Components[0].Credit)
+	require.Equal(t, 0.0, b.Score.Components[0].Credit)
+	require.Equal(t, 0.5, c.Score.Components[0].Credit)
+	require.Equal(t, 1.0, d.Score.Components[0].Credit)
+	require.Equal(t, 0.0, e.Score.Components[0].Credit)
+
+	// 规模按曲线得分，缺失时为 missing
+	require.Equal(t, 1.0, a.Score.Components[1].Credit)
+	require.Equal(t, 0.0, b.Score.Components[1].Credit)
+	require.True(t, c.Score.Components[1].Missing)
+	require.Equal(t, 0.5, c.Score.Components[1].Credit)
+
+	// 费率 a 与 b 比较，c 缺失
+	require.Equal(t, 0.0, a.Score.Components[2].Credit)
+	require.Equal(t, 1.0, b.Score.Components[2].Credit)
+	require.Equal(t, 0.5, c.Score.Components[2].Credit)
+
+	require.InDelta(t, 80, a.Score.Total, 1e-9)
+	require.InDelta(t, 20, b.Score.Total, 1e-9)
+	require.InDelta(t, 50, c.Score.Total, 1e-9)
+	require.Equal(t, 1, a.Score.TypeRank)
+	require.Equal(t, 3, a.Score.TypeCount)
+	require.Equal(t, 3, b.Score.TypeRank)
+	require.Equal(t, 1, d.Score.TypeRank)
+	require.Equal(t, 2, e.Score.TypeCount)
+	require.Equal(t, "80.0（同类 1/3）", a.Score.ScoreHuman())
+	require.Equal(t, "--", FundScore{}.ScoreHuman())
+
+	require.InDelta(t, 90, d.Score.Total, 1e-9)
+	searched := map[string]*Fund{"a": {Code: "a"}, "x": {Code: "x"}}
+	funds.FillScores(searched)
+	require.Equal(t, a.Score, searched["a"].Score)
+	require.Equal(t, 0, searched["x"].Score.TypeRank)
+	funds.Sort(FundSortTypeScore)
+	require.Equal(t, []string{"d", "a", "c", "e", "b"}, []string{funds[0].Code, funds[1].Code, funds[2].Code, funds[3].Code, funds[4].Code})
+}
+
+func TestFundScoreModelMinPeers(t *testing.T) {
+	m := FundScoreModel{
+		Components: []FundScoreComponentModel{
+			{Name: "manager", Weight: 1, Metric: "manager_years", Direction: FundScoreDirectionHigher},
+		},
+		MinPeers: 3,
+	}
+	a := &Fund{Code: "a", Type: "QDII"}
+	a.Manager.ManageDays = 730
+	b := &Fund{Code: "b", Type: "股票型"}
+	b.Manager.ManageDays = 365
+	c := &Fund{Code: "c", Type: "股票型"}
+	c.Manager.ManageDays = 1095
+	m.Score(FundList{a, b, c})
+	// QDII 同类不足 3 只，按全部基金归一化
+	require.Equal(t, 0.5, a.Score.Components[0].Credit)
+	require.InDelta(t, 2, a.Score.Components[0].Value, 1e-9)
+}
+
+func TestLoadFundScoreModel(t *testing.T) {
+	m, err := LoadFundScoreModel("../fund_score.toml")
+	require.Nil(t, err)
+	require.Equal(t, DefaultFundScoreModel, m)
+
+	bad := DefaultFundScoreModel
+	bad.Components = []FundScoreComponentModel{{Name: "x", Weight: 1, Metric: "unknown", Direction: FundScoreDirectionLower}}
+	require.NotNil(t, bad.Validate())
+	bad.Components = []FundScoreComponentModel{{Name: "x", Weight: 1, Metric: "fee"}}
+	require.NotNil(t, bad.Validate())
+}
@@ -0,0 +1,262 @@
+// 基金风格漂移及行业轮动检测
+
+package models
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// fundIndustryWeight 行业占净值比例
+type fundIndustryWeight struct {
+	// 行业名
+	Industry string `json:"industry"`
+	// 占净值比例（%）
+	Weight float64 `json:"weight"`
+}
+
+// fundIndustrySnapshot 单个报告期的行业配置
+type fundIndustrySnapshot struct {
+	// 公布日期
+	PubDate string `json:"pub_date"`
+	// 行业占比，按占比降序
+	Industries []fundIndustryWeight `json:"industries"`
+}
+
+// shares 各行业占行业配置合计的比例，合计为 1
+func (s fundIndustrySnapshot) shares() map[string]float64 {
+	total := 0.0
+	for _, i := range s.Industries {
+		total += i.Weight
+	}
+	shares := map[string]float64{}
+	if total <= 0 {
+		return shares
+	}
+	for _, i := range s.Industries {
+		shares[i.Industry] += i.Weight / total
+	}
+	return shares
+}
+
+// FundStyleQuarter 单个报告期的行业集中度及换手
+type FundStyleQuarter struct {
+	// 公布日期
+	PubDate string `json:"pub_date"`
+	// 行业集中度 HHI，按各行业占行业配置合计的比例计算，取值 0-1
+	HHI float64 `json:"hhi"`
+	// 第一大行业
+	TopIndustry string `json:"top_industry"`
+	// 第一大行业占行业配置合计的比例（%）
+	TopWeight float64 `json:"top_weight"`
+	// 相对上一报告期的行业换手率（%），第一个报告期为 0
+	Turnover float64 `json:"turnover"`
+}
+
+// FundStyleDrift 基金风格漂移检测结果
+type FundStyleDrift struct {
+	// 各报告期行业集中度及换手，按公布日期升序
+	Quarters []FundStyleQuarter `json:"quarters"`
+	// 最新报告期行业集中度 HHI
+	HHI float64 `json:"hhi"`
+	// 之前各报告期平均行业集中度 HHI
+	AvgHHI float64 `json:"avg_hhi"`
+	// 最新报告期行业换手率（%）
+	Turnover float64 `json:"turnover"`
+	// 最新报告期行业配置相对之前各期平均配置的偏离（%）
+	Drift float64 `json:"drift"`
+	// 业绩比较基准
+	Benchmark string `json:"benchmark"`
+	// 业绩比较基准中的股票指数权重（%），无法解析时为 -1
+	BenchmarkStockRatio float64 `json:"benchmark_stock_ratio"`
+	// 最新股票仓位（%）
+	StockRatio float64 `json:"stock_ratio"`
+	// 股票仓位与基准股票权重之差（%）
+	StockRatioDrift float64 `json:"stock_ratio_drift"`
+	// 风格漂移提示
+	Warnings []string `json:"warnings"`
+}
+
+// FundStyleDriftOptions 风格漂移检测阈值
+type FundStyleDriftOptions struct {
+	// 之前各期第一大行业占比均不高于该值（%）视为均衡配置
+	BalancedMaxTopWeight float64
+	// 最新第一大行业占比不低于该值（%）视为押注单一行业
+	ConcentratedTopWeight float64
+	// 最新行业换手率提示阈值（%）
+	MaxTurnover float64
+	// 行业配置偏离提示阈值（%）
+	MaxDrift float64
+	// 股票仓位偏离基准提示阈值（%）
+	MaxStockRatioDrift float64
+}
+
+// DefaultFundStyleDriftOptions 默认风格漂移检测阈值
+var DefaultFundStyleDriftOptions = FundStyleDriftOptions{
+	BalancedMaxTopWeight:  35,
+	ConcentratedTopWeight: 60,
+	MaxTurnover:           40,
+	MaxDrift:              40,
+	MaxStockRatioDrift:    20,
+}
+
+var (
+	benchmarkRatioRegexp = regexp.MustCompile(`(\d+(?:\.\d+)?)\s*%`)
+	// 业绩比较基准中不属于股票的部分
+	benchmarkNonStockKeywords = []string{"债", "存款", "利率", "货币", "回购", "票据", "黄金", "Shibor", "SHIBOR"}
+)
+
+// parseBenchmarkStockRatio 解析业绩比较基准中股票指数的权重（%），如 沪深300指数收益率×80%+中债综合指数收益率×20% 返回 80
+func parseBenchmarkStockRatio(bench string) (float64, bool) {
+	bench = strings.TrimSpace(bench)
+	if bench == "" || bench == "--" {
+		return 0, false
+	}
+	terms := strings.FieldsFunc(bench, func(r rune) bool {
+		return r == '+' || r == '＋'
+	})
+	ratio, matched := 0.0, false
+	for _, term := range terms {
+		isStock := true
+		for _, kw := range benchmarkNonStockKeywords {
+			if strings.Contains(term, kw) {
+				isStock = false
+				break
+			}
+		}
+		w := 100.0
+		if m := benchmarkRatioRegexp.FindStringSubmatch(term); len(m) == 2 {
+			w, _ = strconv.ParseFloat(m[1], 64)
+		} else if len(terms) > 1 {
+			continue
+		}
+		matched = true
+		if isStock && strings.Contains(term, "指数") {
+			ratio += w
+		}
+	}
+	return ratio, matched
+}
+
+// industryTurnover 两个报告期行业配置的换手率（%），为各行业比例差的绝对值之和的一半
+func industryTurnover(a, b map[string]float64) float64 {
+	sum := 0.0
+	for k, v := range a {
+		sum += math.Abs(v - b[k])
+	}
+	for k, v := range b {
+		if _, ok := a[k]; !ok {
+			sum += math.Abs(v)
+		}
+	}
+	return sum / 2 * 100
+}
+
+// newFundIndustryHistory 按公布日期升序整理各报告期行业占比，占比为 0 或无数据的行业不计入
+func newFundIndustryHistory(sectors map[string][]map[string]string) []fundIndustrySnapshot {
+	history := []fundIndustrySnapshot{}
+	for date, vlist := range sectors {
+		snapshot := fundIndustrySnapshot{PubDate: date}
+		for _, i := range vlist {
+			w, err := strconv.ParseFloat(i["ZJZBL"], 64)
+			if err != nil || w == 0 {
+				continue
+			}
+			snapshot.Industries = append(snapshot.Industries, fundIndustryWeight{Industry: i["HYMC"], Weight: w})
+		}
+		if len(snapshot.Industries) == 0 {
+			continue
+		}
+		sort.SliceStable(snapshot.Industries, func(i, j int) bool {
+			return snapshot.Industries[i].Weight > snapshot.Industries[j].Weight
+		})
+		history = append(history, snapshot)
+	}
+	sort.Slice(history, func(i, j int) bool {
+		return history[i].PubDate < history[j].PubDate
+	})
+	return history
+}
+
+// CheckStyleDrift 按各报告期行业配置及业绩比较基准检测基金风格漂移
+func (f Fund) CheckStyleDrift(opts FundStyleDriftOptions) FundStyleDrift {
+	result := FundStyleDrift{
+		Quarters:            []FundStyleQuarter{},
+		Benchmark:           f.Benchmark,
+		BenchmarkStockRatio: -1,
+		Warnings:            []string{},
+	}
+	shares := []map[string]float64{}
+	for i, snapshot := range f.IndustryHistory {
+		s := snapshot.shares()
+		q := FundStyleQuarter{PubDate: snapshot.PubDate}
+		for industry, share := range s {
+			q.HHI += share * share
+			if share*100 > q.TopWeight || (share*100 == q.TopWeight && industry < q.TopIndustry) {
+				q.TopWeight = share * 100
+				q.TopIndustry = industry
+			}
+		}
+		if i > 0 {
+			q.Turnover = industryTurnover(shares[i-1], s)
+		}
+		shares = append(shares, s)
+		result.Quarters = append(result.Quarters, q)
+	}
+
+	if n := len(result.Quarters); n > 0 {
+		latest := result.Quarters[n-1]
+		result.HHI = latest.HHI
+		result.Turnover = latest.Turnover
+		if n > 1 {
+			// 之前各期的平均配置
+			avg := map[string]float64{}
+			balanced := true
+			for i, q := range result.Quarters[:n-1] {
+				result.AvgHHI += q.HHI / float64(n-1)
+				for industry, share := range shares[i] {
+					avg[industry] += share / float64(n-1)
+				}
+				if q.TopWeight > opts.BalancedMaxTopWeight {
+					balanced = false
+				}
+			}
+			result.Drift = industryTurnover(avg, shares[n-1])
+			if balanced && latest.TopWeight >= opts.ConcentratedTopWeight {
+				result.Warnings = append(result.Warnings, fmt.Sprintf("行业配置突变：此前各期第一大行业占比均不超过%.0f%%，%s %s占比%.2f%%",
+					opts.BalancedMaxTopWeight, latest.PubDate, latest.TopIndustry, latest.TopWeight))
+			}
+			if latest.Turnover >= opts.MaxTurnover {
+				result.Warnings = append(result.Warnings, fmt.Sprintf("行业换手率较高：%s 相对上期行业换手%.2f%%", latest.PubDate, latest.Turnover))
+			}
+			if result.Drift >= opts.MaxDrift {
+				result.Warnings = append(result.Warnings, fmt.Sprintf("行业风格漂移：%s 行业配置相对之前各期平均偏离%.2f%%", latest.PubDate, result.Drift))
+			}
+		}
+	}
+
+	// 没有业绩比较基准的指数基金按跟踪标的 95% 计算
+	ratio, ok := parseBenchmarkStockRatio(f.Benchmark)
+	if !ok && f.IndexName != "" && f.IndexName != "--" {
+		result.Benchmark = f.IndexName
+		ratio, ok = 95, true
+	}
+	stock, hasStock := parseFundRate(f.AssetsProportion.Stock)
+	if ok {
+		result.BenchmarkStockRatio = ratio
+	}
+	if hasStock {
+		result.StockRatio = stock
+	}
+	if ok && hasStock {
+		result.StockRatioDrift = stock - ratio
+		if math.Abs(result.StockRatioDrift) >= opts.MaxStockRatioDrift {
+			result.Warnings = append(result.Warnings, fmt.Sprintf("股票仓位偏离业绩比较基准：基准股票权重%.0f%%，最新股票仓位%.2f%%", ratio, stock))
+		}
+	}
+	return result
+}
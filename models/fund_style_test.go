@@ -0,0 +1,81 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseBenchmarkStockRatio(t *testing.T) {
+	cases := []struct {
+		bench string
+		ratio float64
+		ok    bool
+	}{
+		{"沪深300指数收益率×80%+中债综合指数收益率×20%", 80, true},
+		{"中证800指数收益率*60%+恒生指数收益率*20%+中债综合全价指数收益率*20%", 80, true},
+		{"中证白酒指数收益率×95%＋银行活期存款利率(税后)×5%", 95, true},
+		{"中债总财富(总值)指数收益率", 0, true},
+		{"沪深300指数收益率", 100, true},
+		{"--", 0, false},
+	}
+	for _, c := range cases {
+		ratio, ok := parseBenchmarkStockRatio(c.bench)
+		require.Equal(t, c.ok, ok, c.bench)
+		require.InDelta(t, c.ratio, ratio, 1e-9, c.bench)
+	}
+}
+
+func TestNewFundIndustryHistory(t *testing.T) {
+	history := newFundIndustryHistory(map[string][]map[string]string{
+		"2022-06-30": {{"HYMC": "金融业", "ZJZBL": "10"}, {"HYMC": "制造业", "ZJZBL": "70"}, {"HYMC": "采矿业", "ZJZBL": "--"}},
+		"2022-03-31": {{"HYMC": "制造业", "ZJZBL": "0"}},
+		"2021-12-31": {{"HYMC": "制造业", "ZJZBL": "20"}},
+	})
+	require.Len(t, history, 2)
+	require.Equal(t, "2021-12-31", history[0].PubDate)
+	require.Equal(t, "制造业", history[1].Industries[0].Industry)
+	require.Equal(t, 70.0, history[1].Industries[0].Weight)
+}
+
+func TestFundCheckStyleDrift(t *testing.T) {
+	balanced := []fundIndustryWeight{{"制造业", 30}, {"金融业", 25}, {"信息技术", 25}, {"医药", 20}}
+	fund := Fund{
+		Benchmark: "沪深300指数收益率×80%+中债综合指数收益率×20%",
+		IndustryHistory: []fundIndustrySnapshot{
+			{PubDate: "2021-12-31", Industries: balanced},
+			{PubDate: "2022-03-31", Industries: balanced},
+			{PubDate: "2022-06-30", Industries: []fundIndustryWeight{{"医药", 72}, {"制造业", 8}}},
+		},
+	}
+	fund.AssetsProportion.Stock = "80%"
+	r := fund.CheckStyleDrift(DefaultFundStyleDriftOptions)
+	require.Len(t, r.Quarters, 3)
+	require.InDelta(t, 0.3*0.3+0.25*0.25*2+0.2*0.2, r.AvgHHI, 1e-9)
+	require.InDelta(t, 0, r.Quarters[1].Turnover, 1e-9)
+	require.Equal(t, "医药", r.Quarters[2].TopIndustry)
+	require.InDelta(t, 90, r.Quarters[2].TopWeight, 1e-9)
+	require.InDelta(t, 0.9*0.9+0.1*0.1, r.HHI, 1e-9)
+	// 医药 20%->90%，制造业 30%->10%，金融业、信息技术各 25%->0
+	require.InDelta(t, 70, r.Turnover, 1e-9)
+	require.InDelta(t, 70, r.Drift, 1e-9)
+	require.Equal(t, 80.0, r.BenchmarkStockRatio)
+	require.InDelta(t, 0, r.StockRatioDrift, 1e-9)
+	require.Len(t, r.Warnings, 3)
+	require.Contains(t, r.Warnings[0], "行业配置突变")
+
+	// 此前已经集中配置时不提示突变，仓位偏离基准时提示
+	fund.IndustryHistory[0].Industries = []fundIndustryWeight{{"医药", 80}, {"制造业", 20}}
+	fund.AssetsProportion.Stock = "50%"
+	r = fund.CheckStyleDrift(DefaultFundStyleDriftOptions)
+	require.NotContains(t, r.Warnings[0], "行业配置突变")
+	require.InDelta(t, -30, r.StockRatioDrift, 1e-9)
+	require.Contains(t, r.Warnings[len(r.Warnings)-1], "股票仓位偏离业绩比较基准")
+
+	// 指数基金没有业绩比较基准时按跟踪标的计算
+	r = Fund{IndexName: "中证白酒", AssetsProportion: fundAssetsProportion{Stock: "--%"}}.CheckStyleDrift(DefaultFundStyleDriftOptions)
+	require.Equal(t, "中证白酒", r.Benchmark)
+	require.Equal(t, 95.0, r.BenchmarkStockRatio)
+	require.Empty(t, r.Warnings)
+	require.Empty(t, r.Quarters)
+}
@@ -25,6 +25,10 @@ var (
 	Fund4433List FundList
 	// FundManagers 基金经理列表
 	FundManagers eastmoney.FundManagerInfoList
+	// IndustryBuffettScoreTable 各行业全部成分股的巴菲特评分均值
+	IndustryBuffettScoreTable = IndustryBuffettScores{}
+	// FundFeeTable 基金费率表，key 为基金代码
+	FundFeeTable = FundFeeSchedules{}
 	// SyncFundTime 基金数据同步时间
 	SyncFundTime = time.Now()
 	// RawFundAllListFilename api返回的原始结果
@@ -39,6 +43,10 @@ var (
 	FundTypeListFilename = "./fund_type_list.json"
 	// FundManagersFilename 基金经理数据文件
 	FundManagersFilename = "./fund_managers.json"
+	// IndustryBuffettScoresFilename 行业巴菲特评分均值数据文件
+	IndustryBuffettScoresFilename = "./industry_buffett_scores.json"
+	// FundFeesFilename 基金费率表数据文件
+	FundFeesFilename = "./fund_fees.json"
 	// AAACompanyBondSyl AAA公司债当期收益率
 	AAACompanyBondSyl = -1.0 // datacenter.ChinaBond.QueryAAACompanyBondSyl(context.Background())
 )
@@ -48,10 +56,19 @@ func InitGlobalVars() {
 	if err := InitIndustryList(); err != nil {
 		logging.Error(nil, "init models global vars error:"+err.Error())
 	}
+	if err := InitFundScoreModel(); err != nil {
+		logging.Warn(nil, "init fund score model error, use default model:"+err.Error())
+	}
+	if err := InitFundFees(); err != nil {
+		logging.Error(nil, "init models global vars error:"+err.Error())
+	}
 	if err := InitFundAllList(); err != nil {
 		logging.Error(nil, "init models global vars error:"+err.Error())
 	}
-	if err := InitFund4433List(); err != nil {
+	if err := InitFundRuleSets(); err != nil {
+		logging.Warn(nil, "init fund rule sets error, use default rule sets:"+err.Error())
+	}
+	if err := InitFundRuleSetLists(); err != nil {
 		logging.Error(nil, "init models global vars error:"+err.Error())
 	}
 	if err := InitFundTypeList(); err != nil {
@@ -60,6 +77,9 @@ func InitGlobalVars() {
 	if err := InitFundManagers(); err != nil {
 		logging.Error(nil, "init models global vars error:"+err.Error())
 	}
+	if err := InitIndustryBuffettScores(); err != nil {
+		logging.Error(nil, "init models global vars error:"+err.Error())
+	}
 	// 更新同步时间
 	SyncFundTime = time.Now()
 }
@@ -79,20 +99,11 @@ func InitFundAllList() error {
 	if err != nil {
 		return err
 	}
-	return json.Unmarshal(fundlist, &FundAllList)
-}
-
-// InitFund4433List 从json文件加载基金列表
-func InitFund4433List() error {
-	fundlist, err := ioutil.ReadFile(Fund4433ListFilename)
-	if err != nil {
-		return err
-	}
-	if err := json.Unmarshal(fundlist, &Fund4433List); err != nil {
+	if err := json.Unmarshal(fundlist, &FundAllList); err != nil {
 		return err
 	}
-	Fund4433List.Sort(FundSortTypeWeek)
-	Fund4433TypeList = Fund4433List.Types()
+	FundAllList.SetFees(FundFeeTable)
+	FundAllList.CalcScores()
 	return nil
 }
 
@@ -113,3 +124,31 @@ func InitFundManagers() error {
 	}
 	return json.Unmarshal(m, &FundManagers)
 }
+
+// InitIndustryBuffettScores 从json文件加载行业巴菲特评分均值
+func InitIndustryBuffettScores() error {
+	b, err := ioutil.ReadFile(IndustryBuffettScoresFilename)
+	if err != nil {
+		return err
+	}
+	scores := IndustryBuffettScores{}
+	if err := json.Unmarshal(b, &scores); err != nil {
+		return err
+	}
+	IndustryBuffettScoreTable = scores
+	return nil
+}
+
+// InitFundFees 从json文件加载基金费率表
+func InitFundFees() error {
+	b, err := ioutil.ReadFile(FundFeesFilename)
+	if err != nil {
+		return err
+	}
+	fees := FundFeeSchedules{}
+	if err := json.Unmarshal(b, &fees); err != nil {
+		return err
+	}
+	FundFeeTable = fees
+	return nil
+}
@@ -4,6 +4,7 @@ package models
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"math"
 	"sort"
@@ -15,25 +16,10 @@ import (
 	"github.com/axiaoxin-com/investool/datacenter/eastmoney"
 	"github.com/axiaoxin-com/investool/datacenter/eniu"
 	"github.com/axiaoxin-com/investool/datacenter/zszx"
+	"github.com/axiaoxin-com/investool/valuation"
 	"github.com/axiaoxin-com/logging"
 )
 
-// BuffettScore 巴菲特评分结构体
-type BuffettScore struct {
-	ROEScore          float64 `json:"roe_score"`           // ROE评分（20分）
-	CashFlowScore     float64 `json:"cash_flow_score"`     // 自由现金流评分（15分）
-	ProfitGrowthScore float64 `json:"profit_growth_score"` // 利润增长评分（15分）
-	DebtRatioScore    float64 `json:"debt_ratio_score"`    // 负债率评分（10分）
-	MoatScore         float64 `json:"moat_score"`          // 护城河评分（10分）
-	ManagementScore   float64 `json:"management_score"`    // 管理层评分（10分）
-	ValuationScore    float64 `json:"valuation_score"`     // 估值评分（15分）
-	TotalScore        float64 `json:"total_score"`         // 总分（100分）
-	ScoreDescription  string  `json:"score_description"`   // 评分说明
-	RDScore           float64 `json:"rd_score"`            // 研发投入评分（5分）
-	DividendScore     float64 `json:"dividend_score"`      // 分红评分（5分）
-	RepurchaseScore   float64 `json:"repurchase_score"`    // 回购评分（5分）
-}
-
 // Stock 接口返回的股票信息结构
 type Stock struct {
 	// 东方财富接口返回的基本信息
@@ -50,6 +36,12 @@ type Stock struct {
 	PriceSpace float64 `json:"price_space"`
 	// 按改进算法计算的去年合理价格：用于验证算法准确性
 	LastYearRightPrice float64 `json:"last_year_right_price"`
+	// 合理价估值过程说明，未计算时为 nil
+	RightPriceExplanation *valuation.Explanation `json:"right_price_explanation"`
+	// 自由现金流折现估值，无法估值时为 nil
+	DCF *valuation.DCFResult `json:"dcf"`
+	// 股利折现估值，不适用时为 nil
+	DDM *valuation.DDMResult `json:"ddm"`
 	// 历史股价
 	HistoricalPrice eniu.RespHistoricalStockPrice `json:"historical_price"`
 	// 历史波动率
@@ -70,6 +62,10 @@ type Stock struct {
 	JZPG eastmoney.JZPG `json:"jzpg"`
 	// PEG=PE/净利润复合增长率
 	PEG float64 `json:"peg"`
+	// 滚动市盈率 TTM PE=股价/TTM EPS，无法计算时为 -1
+	TTMPE float64 `json:"ttm_pe"`
+	// TTM PEG=TTM PE/TTM 净利润同比增长率，无法计算时为 -1
+	TTMPEG float64 `json:"ttm_peg"`
 	// 历史利润表
 	HistoricalGincomeList eastmoney.GincomeDataList `json:"historical_gincome_list"`
 	// 本业营收比=营业利润/(营业利润+营业外收入)
@@ -86,12 +82,24 @@ type Stock struct {
 	NetcashFinance float64 `json:"netcash_finance"`
 	// 自由现金流
 	NetcashFree float64 `json:"netcash_free"`
+	// 历史资产负债表
+	HistoricalBalanceList eastmoney.BalanceDataList `json:"historical_balance_list"`
+	// Piotroski F-Score 财务健康评分，数据不足时为 nil
+	PiotroskiFScore *QualityScore `json:"piotroski_f_score"`
+	// Altman Z-Score 破产风险评分，数据不足时为 nil
+	AltmanZScore *QualityScore `json:"altman_z_score"`
+	// Beneish M-Score 财务操纵评分，数据不足时为 nil
+	BeneishMScore *QualityScore `json:"beneish_m_score"`
 	// 十大流通股东
 	FreeHoldersTop10 eastmoney.FreeHolderList `json:"free_holders_top_10"`
 	// 主力资金净流入
 	MainMoneyNetInflows zszx.NetInflowList `json:"main_money_net_inflows"`
 	// 巴菲特评分
 	BuffettScore BuffettScore `json:"buffett_score"`
+	// 数据快照时间，为零值时表示最新数据
+	AsOfDate time.Time `json:"as_of_date"`
+	// 各项数据的获取结果，key 为数据项名称
+	Enrichments map[string]EnrichmentResult `json:"enrichments"`
 }
 
 // GetPrice 返回股价，没开盘时可能是字符串"-"，此时返回最近历史股价，无历史价则返回 -1
@@ -133,8 +141,15 @@ func (s StockList) SortByPriceSpace() {
 
 // NewStock 创建 Stock 对象
 func NewStock(ctx context.Context, baseInfo eastmoney.StockInfo) (Stock, error) {
+	return NewStockWithOptions(ctx, baseInfo, DefaultNewStockOptions)
+}
+
+// NewStockWithOptions 按选项创建 Stock 对象，只获取选项中指定的数据，各项数据的获取结果记录在 Enrichments 中
+func NewStockWithOptions(ctx context.Context, baseInfo eastmoney.StockInfo, opts NewStockOptions) (Stock, error) {
 	s := Stock{
 		BaseInfo: baseInfo,
+		TTMPE:    -1,
+		TTMPEG:   -1,
 	}
 
 	// PEG 改进计算
@@ -172,144 +187,96 @@ func NewStock(ctx context.Context, baseInfo eastmoney.StockInfo) (Stock, error)
 	}
 	price := s.GetPrice()
 
+	enrichments := opts.Enrichments.withDependencies()
+	results := map[string]EnrichmentResult{}
+	var mu sync.Mutex
+	setResult := func(e Enrichment, r EnrichmentResult) {
+		mu.Lock()
+		results[e.String()] = r
+		mu.Unlock()
+	}
+	record := func(e Enrichment, err error) {
+		if err != nil {
+			setResult(e, EnrichmentResult{Status: EnrichmentStatusFailed, Error: err.Error()})
+			return
+		}
+		setResult(e, EnrichmentResult{Status: EnrichmentStatusOK})
+	}
 	var wg sync.WaitGroup
+	// enrich 并发获取 e 对应的数据并记录获取结果，未指定的数据记录为跳过
+	enrich := func(e Enrichment, fetch func(ctx context.Context, s *Stock) error) {
+		if !enrichments.Has(e) {
+			setResult(e, EnrichmentResult{Status: EnrichmentStatusSkipped})
+			return
+		}
+		wg.Add(1)
+		go func(ctx context.Context, s *Stock) {
+			defer wg.Done()
+			record(e, fetch(ctx, s))
+		}(ctx, &s)
+	}
+
 	// 获取财报
-	wg.Add(1)
-	go func(ctx context.Context, s *Stock) {
-		defer wg.Done()
+	enrich(EnrichFinaMain, func(ctx context.Context, s *Stock) error {
 		logging.Info(ctx, "开始获取历史财务数据")
 		hf, err := datacenter.EastMoney.QueryHistoricalFinaMainData(ctx, s.BaseInfo.Secucode)
 		if err != nil {
 			logging.Error(ctx, "NewStock QueryHistoricalFinaMainData err:"+err.Error())
-			return
+			if enrichments.Has(EnrichPE) {
+				record(EnrichPE, errors.New("historical fina main data unavailable"))
+			}
+			return err
 		}
 		if len(hf) == 0 {
 			logging.Error(ctx, "HistoricalFinaMainData is empty")
-			return
+			err := errors.New("historical fina main data is empty")
+			if enrichments.Has(EnrichPE) {
+				record(EnrichPE, err)
+			}
+			return err
 		}
 		logging.Info(ctx, fmt.Sprintf("获取到历史财务数据，数据条数: %d, 最新报告期: %s", len(hf), hf[0].ReportDate))
 		s.HistoricalFinaMainData = hf
+		s.calcTTMValuation(ctx, price)
+		if !enrichments.Has(EnrichPE) {
+			return nil
+		}
 
 		// 历史市盈率 && 合理价格
 		peList, err := datacenter.EastMoney.QueryHistoricalPEList(ctx, s.BaseInfo.Secucode)
 		if err != nil {
 			logging.Error(ctx, "NewStock QueryHistoricalPEList err:"+err.Error())
-			return
+			record(EnrichPE, err)
+			return nil
 		}
 		s.HistoricalPEList = peList
+		record(EnrichPE, nil)
 
 		// 合理价格判断
-		// 去年年报
-		lastYearReport := s.HistoricalFinaMainData.GetReport(ctx, time.Now().Year()-1, eastmoney.FinaReportTypeYear)
-		beforeLastYearReport := s.HistoricalFinaMainData.GetReport(ctx, time.Now().Year()-2, eastmoney.FinaReportTypeYear)
-		thisYear := time.Now().Year()
-		thisYearAvgRevIncrRatio := s.HistoricalFinaMainData.GetAvgRevenueIncreasingRatioByYear(ctx, thisYear)
-		lastYearAvgRevIncrRatio := s.HistoricalFinaMainData.GetAvgRevenueIncreasingRatioByYear(ctx, thisYear-1)
-		// nil fix: 新的一年刚开始时，上一年的年报还没披露，年份数据全部-1，保证有数据返回
-		if lastYearReport == nil {
-			logging.Debug(ctx, "NewStock get last year report nil, use before last year report")
-			lastYearReport = beforeLastYearReport
-			beforeLastYearReport = s.HistoricalFinaMainData.GetReport(ctx, time.Now().Year()-3, eastmoney.FinaReportTypeYear)
-			thisYearAvgRevIncrRatio = s.HistoricalFinaMainData.GetAvgRevenueIncreasingRatioByYear(ctx, thisYear-1)
-			lastYearAvgRevIncrRatio = s.HistoricalFinaMainData.GetAvgRevenueIncreasingRatioByYear(ctx, thisYear-2)
-		}
-		// pe 中位数
-		peMidVal, err := peList.GetMidValue(ctx)
-		if err != nil {
-			logging.Error(ctx, "NewStock GetMidValue err:"+err.Error())
-			return
-		}
-
-		// 改进的合理价计算
-		// 1. 使用多年EPS平均值，避免单年爆发增长的影响
-		epsHistory := s.HistoricalFinaMainData.ValueList(ctx, eastmoney.ValueListTypeEPS, 3, eastmoney.FinaReportTypeYear)
-		var baseEPS float64
-		if len(epsHistory) >= 3 {
-			// 使用近3年EPS的平均值作为基准
-			sum := 0.0
-			for _, eps := range epsHistory {
-				sum += eps
-			}
-			baseEPS = sum / float64(len(epsHistory))
-			logging.Debugf(ctx, "Using 3-year average EPS: %v, history: %v", baseEPS, epsHistory)
-		} else {
-			// 数据不足时使用去年EPS
-			baseEPS = lastYearReport.Epsjb
-			logging.Debugf(ctx, "Using last year EPS: %v", baseEPS)
-		}
-
-		// 2. 对增长率进行上限限制，避免过度乐观
-		adjustedGrowthRatio := thisYearAvgRevIncrRatio
-		const maxGrowthRate = 50.0 // 最大增长率限制为50%
-		if adjustedGrowthRatio > maxGrowthRate {
-			adjustedGrowthRatio = maxGrowthRate
-			logging.Debugf(ctx, "Growth rate capped from %v%% to %v%%", thisYearAvgRevIncrRatio, maxGrowthRate)
-		}
-
-		// 3. 检测爆发增长并进行调整
-		var explosiveGrowthAdjustment float64 = 1.0
-		if len(epsHistory) >= 2 {
-			// 计算去年相对于前年的EPS增长率
-			lastYearGrowth := (epsHistory[0] - epsHistory[1]) / epsHistory[1] * 100
-			if lastYearGrowth > 100 { // 如果去年增长超过100%，认为是爆发增长
-				// 对爆发增长进行折扣处理
-				explosiveGrowthAdjustment = 0.7 // 70%的折扣
-				logging.Debugf(ctx, "Explosive growth detected: %v%%, applying adjustment factor: %v", lastYearGrowth, explosiveGrowthAdjustment)
-			}
-		}
-
-		// 4. 计算改进后的合理价
-		s.RightPrice = peMidVal * (baseEPS * (1 + adjustedGrowthRatio/100.0)) * explosiveGrowthAdjustment
-		s.PriceSpace = (s.RightPrice - price) / price * 100
-
-		// 5. 计算去年的合理价（用于验证算法准确性）
-		var lastYearBaseEPS float64
-		if len(epsHistory) >= 3 {
-			// 使用前年和大前年的EPS平均值
-			lastYearBaseEPS = (epsHistory[1] + epsHistory[2]) / 2.0
-		} else {
-			lastYearBaseEPS = beforeLastYearReport.Epsjb
-		}
-
-		lastYearAdjustedGrowthRatio := lastYearAvgRevIncrRatio
-		if lastYearAdjustedGrowthRatio > maxGrowthRate {
-			lastYearAdjustedGrowthRatio = maxGrowthRate
-		}
-
-		s.LastYearRightPrice = peMidVal * (lastYearBaseEPS * (1 + lastYearAdjustedGrowthRatio/100.0))
-
-		// 6. 异常值检测和日志记录
-		if math.IsNaN(s.RightPrice) || math.IsInf(s.RightPrice, 0) || s.RightPrice <= 0 {
-			logging.Warnf(ctx, "Invalid RightPrice calculated: %v, using fallback calculation", s.RightPrice)
-			// 回退到简单计算
-			s.RightPrice = peMidVal * lastYearReport.Epsjb
-			s.PriceSpace = (s.RightPrice - price) / price * 100
-		}
-
-		logging.Debugf(ctx, "RightPrice calculation - BaseEPS: %v, AdjustedGrowth: %v%%, ExplosiveAdjustment: %v, FinalPrice: %v",
-			baseEPS, adjustedGrowthRatio, explosiveGrowthAdjustment, s.RightPrice)
-	}(ctx, &s)
+		s.calcRightPrice(ctx, time.Now(), price, opts.Valuation)
+		return nil
+	})
+	if !enrichments.Has(EnrichPE) {
+		setResult(EnrichPE, EnrichmentResult{Status: EnrichmentStatusSkipped})
+	}
 
 	// 获取综合估值
-	wg.Add(1)
-	go func(ctx context.Context, s *Stock) {
-		defer wg.Done()
+	enrich(EnrichValuation, func(ctx context.Context, s *Stock) error {
 		valMap, err := datacenter.EastMoney.QueryValuationStatus(ctx, s.BaseInfo.Secucode)
 		if err != nil {
 			logging.Error(ctx, "NewStock QueryValuationStatus err:"+err.Error())
-			return
+			return err
 		}
 		s.ValuationMap = valMap
-	}(ctx, &s)
+		return nil
+	})
 
 	// 历史股价 && 波动率
-	wg.Add(1)
-	go func(ctx context.Context, s *Stock) {
-		defer wg.Done()
+	enrich(EnrichPrice, func(ctx context.Context, s *Stock) error {
 		hisPrice, err := datacenter.Eniu.QueryHistoricalStockPrice(ctx, s.BaseInfo.Secucode)
 		if err != nil {
 			logging.Error(ctx, "NewStock QueryHistoricalStockPrice err:"+err.Error())
-			return
+			return err
 		}
 		s.HistoricalPrice = hisPrice
 
@@ -317,133 +284,119 @@ func NewStock(ctx context.Context, baseInfo eastmoney.StockInfo) (Stock, error)
 		hv, err := hisPrice.HistoricalVolatility(ctx, "YEAR")
 		if err != nil {
 			logging.Error(ctx, "NewStock HistoricalVolatility err:"+err.Error())
-			return
+			return err
 		}
 		s.HistoricalVolatility = hv
-	}(ctx, &s)
+		return nil
+	})
 
 	// 公司资料
-	wg.Add(1)
-	go func(ctx context.Context, s *Stock) {
-		defer wg.Done()
+	enrich(EnrichCompanyProfile, func(ctx context.Context, s *Stock) error {
 		cp, err := datacenter.EastMoney.QueryCompanyProfile(ctx, s.BaseInfo.Secucode)
 		if err != nil {
 			logging.Error(ctx, "NewStock QueryCompanyProfile err:"+err.Error())
-			return
+			return err
 		}
 		s.CompanyProfile = cp
-	}(ctx, &s)
+		return nil
+	})
 
 	// 最新财报预约披露时间
-	wg.Add(1)
-	go func(ctx context.Context, s *Stock) {
-		defer wg.Done()
+	enrich(EnrichPublishDate, func(ctx context.Context, s *Stock) error {
 		finaPubDateList, err := datacenter.EastMoney.QueryFinaPublishDateList(ctx, s.BaseInfo.SecurityCode)
 		if err != nil {
 			logging.Error(ctx, "NewStock QueryFinaPublishDateList err:"+err.Error())
-			return
+			return err
 		}
 		if len(finaPubDateList) > 0 {
 			s.FinaAppointPublishDate = finaPubDateList[0].AppointPublishDate
 			s.FinaActualPublishDate = finaPubDateList[0].ActualPublishDate
 			s.FinaReportDate = finaPubDateList[0].ReportDate
 		}
-	}(ctx, &s)
+		return nil
+	})
 
 	// 机构评级统计
-	wg.Add(1)
-	go func(ctx context.Context, s *Stock) {
-		defer wg.Done()
+	enrich(EnrichOrgRating, func(ctx context.Context, s *Stock) error {
 		orgRatings, err := datacenter.EastMoney.QueryOrgRating(ctx, s.BaseInfo.Secucode)
 		if err != nil {
 			logging.Debug(ctx, "NewStock QueryOrgRating err:"+err.Error())
-			return
+			return err
 		}
 		s.OrgRatingList = orgRatings
-	}(ctx, &s)
+		return nil
+	})
 
 	// 盈利预测
-	wg.Add(1)
-	go func(ctx context.Context, s *Stock) {
-		defer wg.Done()
+	enrich(EnrichProfitPredict, func(ctx context.Context, s *Stock) error {
 		pps, err := datacenter.EastMoney.QueryProfitPredict(ctx, s.BaseInfo.Secucode)
 		if err != nil {
 			logging.Debug(ctx, "NewStock QueryProfitPredict err:"+err.Error())
-			return
+			return err
 		}
 		s.ProfitPredictList = pps
-	}(ctx, &s)
+		return nil
+	})
 
 	// 价值评估
-	wg.Add(1)
-	go func(ctx context.Context, s *Stock) {
-		defer wg.Done()
+	enrich(EnrichJZPG, func(ctx context.Context, s *Stock) error {
 		jzpg, err := datacenter.EastMoney.QueryJiaZhiPingGu(ctx, s.BaseInfo.Secucode)
 		if err != nil {
 			logging.Debug(ctx, "NewStock QueryJiaZhiPingGu err:"+err.Error())
-			return
+			return err
 		}
 		s.JZPG = jzpg
-	}(ctx, &s)
+		return nil
+	})
 
 	// 利润表数据
-	wg.Add(1)
-	go func(ctx context.Context, s *Stock) {
-		defer wg.Done()
+	enrich(EnrichGincome, func(ctx context.Context, s *Stock) error {
 		gincomeList, err := datacenter.EastMoney.QueryFinaGincomeData(ctx, s.BaseInfo.Secucode)
 		if err != nil {
 			logging.Error(ctx, "NewStock QueryFinaGincomeData err:"+err.Error())
-			return
+			return err
 		}
 		s.HistoricalGincomeList = gincomeList
-		if len(s.HistoricalGincomeList) > 0 {
-			// 本业营收比
-			gincome := s.HistoricalGincomeList[0]
-			s.BYYSRatio = gincome.OperateProfit / (gincome.OperateProfit + gincome.NonbusinessIncome)
-			// 审计意见
-			s.FinaReportOpinion = gincome.OpinionType
-		}
-	}(ctx, &s)
+		s.setGincomeFields()
+		return nil
+	})
 
 	// 现金流量表数据
-	wg.Add(1)
-	go func(ctx context.Context, s *Stock) {
-		defer wg.Done()
+	enrich(EnrichCashflow, func(ctx context.Context, s *Stock) error {
 		cashflow, err := datacenter.EastMoney.QueryFinaCashflowData(ctx, s.BaseInfo.Secucode)
 		if err != nil {
 			logging.Error(ctx, "NewStock QueryFinaCashflowData err:"+err.Error())
-			return
+			return err
 		}
 		s.HistoricalCashflowList = cashflow
-		if len(s.HistoricalCashflowList) > 0 {
-			cf := s.HistoricalCashflowList[0]
-			s.NetcashOperate = cf.NetcashOperate
-			s.NetcashInvest = cf.NetcashInvest
-			s.NetcashFinance = cf.NetcashFinance
-			if cf.NetcashInvest < 0 {
-				s.NetcashFree = s.NetcashOperate + s.NetcashInvest
-			} else {
-				s.NetcashFree = s.NetcashOperate - s.NetcashInvest
-			}
+		s.setCashflowFields()
+		return nil
+	})
+
+	// 资产负债表数据
+	enrich(EnrichBalance, func(ctx context.Context, s *Stock) error {
+		balance, err := datacenter.EastMoney.QueryFinaBalanceData(ctx, s.BaseInfo.Secucode)
+		if err != nil {
+			logging.Error(ctx, "NewStock QueryFinaBalanceData err:"+err.Error())
+			return err
 		}
-	}(ctx, &s)
+		s.HistoricalBalanceList = balance
+		return nil
+	})
 
 	// 获取前10大流通股东
-	wg.Add(1)
-	go func(ctx context.Context, s *Stock) {
-		defer wg.Done()
+	enrich(EnrichFreeHolders, func(ctx context.Context, s *Stock) error {
 		holders, err := datacenter.EastMoney.QueryFreeHolders(ctx, s.BaseInfo.Secucode)
 		if err != nil {
 			logging.Error(ctx, "NewStock QueryFreeHolders err:"+err.Error())
-			return
+			return err
 		}
 		s.FreeHoldersTop10 = holders
-	}(ctx, &s)
+		return nil
+	})
 
 	// 获取最近60日的主力资金净流入
-	wg.Add(1)
-	go func(ctx context.Context, s *Stock) {
-		defer wg.Done()
+	enrich(EnrichMainMoney, func(ctx context.Context, s *Stock) error {
 		now := time.Now()
 		end := now.Format("2006-01-02")
 		d, _ := time.ParseDuration("-1440h")
@@ -451,13 +404,17 @@ func NewStock(ctx context.Context, baseInfo eastmoney.StockInfo) (Stock, error)
 		inflows, err := datacenter.Zszx.QueryMainMoneyNetInflows(ctx, s.BaseInfo.Secucode, start, end)
 		if err != nil {
 			logging.Error(ctx, "NewStock QueryMainMoneyNetInflows err:"+err.Error())
-			return
+			return err
 		}
 		s.MainMoneyNetInflows = inflows
-	}(ctx, &s)
+		return nil
+	})
 
 	// 等待所有goroutine完成
 	wg.Wait()
+	s.Enrichments = results
+	s.calcIntrinsicValues(ctx, opts.DCF, opts.DDM)
+	s.calcQualityScores(ctx)
 
 	// 计算巴菲特评分
 	s.BuffettScore = s.calculateBuffettScore(ctx)
@@ -465,470 +422,217 @@ func NewStock(ctx context.Context, baseInfo eastmoney.StockInfo) (Stock, error)
 	return s, nil
 }
 
-// calculateBuffettScore 计算巴菲特评分
-func (s *Stock) calculateBuffettScore(ctx context.Context) BuffettScore {
-	// 1. ROE评分（20分）
-	s.calculateROEScore(ctx)
-
-	// 2. 现金流评分（15分）
-	s.calculateCashFlowScore(ctx)
-
-	// 3. 利润增长评分（15分）
-	s.calculateProfitGrowthScore(ctx)
-
-	// 4. 负债率评分（10分）
-	s.calculateDebtRatioScore(ctx)
-
-	// 5. 护城河评分（10分）
-	s.calculateMoatScore(ctx)
-
-	// 6. 管理层评分（10分）
-	s.calculateManagementScore(ctx)
-
-	// 7. 估值评分（15分）
-	s.calculateValuationScore(ctx)
-
-	// 8. 研发投入评分（5分）
-	s.calculateRDScore(ctx)
-
-	// 9. 分红评分（5分）
-	s.calculateDividendScore(ctx)
-
-	// 10. 回购评分（5分）
-	s.calculateRepurchaseScore(ctx)
-
-	// 计算总分
-	rawTotalScore := s.BuffettScore.ROEScore +
-		s.BuffettScore.CashFlowScore +
-		s.BuffettScore.ProfitGrowthScore +
-		s.BuffettScore.DebtRatioScore +
-		s.BuffettScore.MoatScore +
-		s.BuffettScore.ManagementScore +
-		s.BuffettScore.ValuationScore +
-		s.BuffettScore.RDScore +
-		s.BuffettScore.DividendScore +
-		s.BuffettScore.RepurchaseScore
-
-	// 将110分制换算成100分制
-	totalScore := rawTotalScore * 100 / 110
-
-	// 生成评分说明
-	var desc strings.Builder
-	desc.WriteString(fmt.Sprintf("总分(100分): %.1f (原始得分: %.1f)\n\n", totalScore, rawTotalScore))
-	desc.WriteString(fmt.Sprintf("ROE(20分): %.1f\n", s.BuffettScore.ROEScore))
-	desc.WriteString(fmt.Sprintf("现金流(15分): %.1f\n", s.BuffettScore.CashFlowScore))
-	desc.WriteString(fmt.Sprintf("利润增长(15分): %.1f\n", s.BuffettScore.ProfitGrowthScore))
-	desc.WriteString(fmt.Sprintf("负债率(10分): %.1f\n", s.BuffettScore.DebtRatioScore))
-	desc.WriteString(fmt.Sprintf("护城河(10分): %.1f\n", s.BuffettScore.MoatScore))
-	desc.WriteString(fmt.Sprintf("管理层(10分): %.1f\n", s.BuffettScore.ManagementScore))
-	desc.WriteString(fmt.Sprintf("估值(15分): %.1f\n", s.BuffettScore.ValuationScore))
-	desc.WriteString(fmt.Sprintf("  PE: %.1f\n", s.BaseInfo.PE))
-	desc.WriteString(fmt.Sprintf("  PEG: %.1f\n\n", s.PEG))
-	desc.WriteString(fmt.Sprintf("研发投入(5分): %.1f\n", s.BuffettScore.RDScore))
-	desc.WriteString(fmt.Sprintf("分红(5分): %.1f\n", s.BuffettScore.DividendScore))
-	desc.WriteString(fmt.Sprintf("回购(5分): %.1f", s.BuffettScore.RepurchaseScore))
-
-	s.BuffettScore.ScoreDescription = desc.String()
-	s.BuffettScore.TotalScore = totalScore
-	return s.BuffettScore
-}
-
-// calculateROEScore 计算ROE评分
-func (s *Stock) calculateROEScore(ctx context.Context) {
-	logging.Infof(ctx, "开始计算ROE评分，HistoricalFinaMainData长度: %d", len(s.HistoricalFinaMainData))
-
-	// 获取近5年ROE数据
-	roeList := s.HistoricalFinaMainData.ValueList(ctx, eastmoney.ValueListTypeROE, 5, eastmoney.FinaReportTypeYear)
-	logging.Infof(ctx, "获取到的ROE列表长度: %d, 数据: %+v", len(roeList), roeList)
-
-	if len(roeList) == 0 {
-		logging.Info(ctx, "ROE评分: ROE列表为空，得分0分")
-		s.BuffettScore.ROEScore = 0
-		return
-	}
-
-	if len(roeList) < 5 {
-		logging.Infof(ctx, "ROE评分: ROE列表长度(%d)小于5，得分0分", len(roeList))
-		s.BuffettScore.ROEScore = 0
-		return
-	}
-
-	// 计算平均ROE和波动率
-	var sumROE float64
-	for _, roe := range roeList {
-		sumROE += roe
-	}
-	avgROE := sumROE / float64(len(roeList))
-	logging.Infof(ctx, "ROE评分: 平均ROE: %.2f%%", avgROE)
-
-	// 计算ROE波动率
-	var variance float64
-	for _, roe := range roeList {
-		variance += math.Pow(roe-avgROE, 2)
-	}
-	volatility := math.Sqrt(variance/float64(len(roeList))) / avgROE
-	logging.Infof(ctx, "ROE评分: ROE波动率: %.2f", volatility)
-
-	// 根据ROE和波动率评分
-	score := 0.0
-	if avgROE >= 20 {
-		score = 20
-		logging.Info(ctx, "ROE评分: 平均ROE>=20，得20分")
-	} else if avgROE >= 15 {
-		score = 15
-		logging.Info(ctx, "ROE评分: 平均ROE>=15，得15分")
-	} else {
-		score = (avgROE / 15) * 15
-		logging.Infof(ctx, "ROE评分: 平均ROE<15，按比例得%.2f分", score)
-	}
-
-	// 根据波动率扣分
-	if volatility > 0.3 {
-		score *= 0.8 // 波动大扣20%分数
-		logging.Infof(ctx, "ROE评分: 波动率>0.3，扣除20%%分数，最终得分%.2f分", score)
-	} else {
-		logging.Infof(ctx, "ROE评分: 波动率<=0.3，不扣分，最终得分%.2f分", score)
+// setGincomeFields 根据最新一期利润表设置本业营收比及审计意见
+func (s *Stock) setGincomeFields() {
+	s.BYYSRatio = 0
+	s.FinaReportOpinion = ""
+	if len(s.HistoricalGincomeList) > 0 {
+		// 本业营收比
+		gincome := s.HistoricalGincomeList[0]
+		s.BYYSRatio = gincome.OperateProfit / (gincome.OperateProfit + gincome.NonbusinessIncome)
+		// 审计意见
+		s.FinaReportOpinion = gincome.OpinionType
 	}
-
-	s.BuffettScore.ROEScore = score
-	logging.Infof(ctx, "ROE评分计算完成，最终得分: %.2f分", score)
 }
 
-// calculateCashFlowScore 计算现金流评分
-func (s *Stock) calculateCashFlowScore(ctx context.Context) {
-	if len(s.HistoricalCashflowList) < 3 {
-		s.BuffettScore.CashFlowScore = 0
-		return
-	}
-
-	operatePositiveCount := 0
-	freePositiveCount := 0
-	for i := 0; i < 3 && i < len(s.HistoricalCashflowList); i++ {
-		cf := s.HistoricalCashflowList[i]
-		if cf.NetcashOperate > 0 {
-			operatePositiveCount++
-		}
-		// 计算自由现金流
-		var freeCashFlow float64
+// setCashflowFields 根据最新一期现金流量表设置现金流量净额
+func (s *Stock) setCashflowFields() {
+	s.NetcashOperate, s.NetcashInvest, s.NetcashFinance, s.NetcashFree = 0, 0, 0, 0
+	if len(s.HistoricalCashflowList) > 0 {
+		cf := s.HistoricalCashflowList[0]
+		s.NetcashOperate = cf.NetcashOperate
+		s.NetcashInvest = cf.NetcashInvest
+		s.NetcashFinance = cf.NetcashFinance
 		if cf.NetcashInvest < 0 {
-			freeCashFlow = cf.NetcashOperate + cf.NetcashInvest
+			s.NetcashFree = s.NetcashOperate + s.NetcashInvest
 		} else {
-			freeCashFlow = cf.NetcashOperate - cf.NetcashInvest
-		}
-		if freeCashFlow > 0 {
-			freePositiveCount++
+			s.NetcashFree = s.NetcashOperate - s.NetcashInvest
 		}
 	}
-
-	// 经营现金流和自由现金流各占一半分数
-	operateScore := 0.0
-	switch operatePositiveCount {
-	case 3:
-		operateScore = 7.5
-	case 2:
-		operateScore = 5.0
-	case 1:
-		operateScore = 2.5
-	}
-
-	freeScore := 0.0
-	switch freePositiveCount {
-	case 3:
-		freeScore = 7.5
-	case 2:
-		freeScore = 5.0
-	case 1:
-		freeScore = 2.5
-	}
-
-	s.BuffettScore.CashFlowScore = operateScore + freeScore
 }
 
-// calculateProfitGrowthScore 计算利润增长评分
-func (s *Stock) calculateProfitGrowthScore(ctx context.Context) {
-	if len(s.HistoricalFinaMainData) < 5 {
-		s.BuffettScore.ProfitGrowthScore = 0
-		return
+// ReferenceDate 股票数据的参考时间，AsOf 快照返回快照时间，否则返回当前时间
+func (s Stock) ReferenceDate() time.Time {
+	if s.AsOfDate.IsZero() {
+		return time.Now()
 	}
+	return s.AsOfDate
+}
 
-	// 获取近5年净利润数据
-	profitList := s.HistoricalFinaMainData.ValueList(ctx, eastmoney.ValueListTypeNetProfit, 5, eastmoney.FinaReportTypeYear)
-	if len(profitList) < 5 {
-		s.BuffettScore.ProfitGrowthScore = 0
-		return
+// AsOf 返回截至 date 的股票数据快照：忽略当时尚未发布的财报、之后的股价及市盈率，
+// 并据此重新计算股价、PE、ROE、ROA、净利润复合增长率、市值、合理价、波动率、现金流等衍生指标。
+// 股息率无历史数据，标记为 -1；行业等其他基本信息仍为最新数据
+func (s Stock) AsOf(ctx context.Context, date time.Time) Stock {
+	p := s
+	p.AsOfDate = date
+	p.HistoricalFinaMainData = s.HistoricalFinaMainData.AsOf(ctx, date)
+	p.HistoricalPEList = s.HistoricalPEList.AsOf(ctx, date)
+	p.HistoricalPrice = s.HistoricalPrice.AsOf(date)
+	p.HistoricalGincomeList = s.HistoricalGincomeList.AsOf(ctx, date)
+	p.HistoricalCashflowList = s.HistoricalCashflowList.AsOf(ctx, date)
+	p.HistoricalBalanceList = s.HistoricalBalanceList.AsOf(ctx, date)
+	p.setGincomeFields()
+	p.setCashflowFields()
+
+	// 按当时股价调整股价、市值及 PE
+	curPrice := s.GetPrice()
+	price := p.HistoricalPrice.LatestPrice()
+	p.BaseInfo.NewPrice = price
+	if curPrice > 0 && price > 0 {
+		p.BaseInfo.TotalMarketCap = s.BaseInfo.TotalMarketCap * price / curPrice
+	}
+	if report := p.HistoricalFinaMainData.LatestYearReport(ctx); report != nil && report.Epsjb > 0 {
+		p.BaseInfo.PE = price / report.Epsjb
+	}
+	// 按当时已发布的财报回溯 ROE、ROA 及净利润复合增长率，无法计算时为 -1
+	p.BaseInfo.RoeWeight, p.BaseInfo.ROA = -1, -1
+	if report := p.HistoricalFinaMainData.CurrentReport(ctx); report != nil {
+		p.BaseInfo.RoeWeight = report.Roejq
+		p.BaseInfo.ROA = report.Zzcjll
+	}
+	p.BaseInfo.NetprofitGrowthrate3Y = -1
+	if cagr, ok := p.HistoricalFinaMainData.ParentNetprofitCAGR(ctx, 3); ok {
+		p.BaseInfo.NetprofitGrowthrate3Y = cagr
+	}
+	p.BaseInfo.Zxgxl = -1
+	p.PEG = -1
+	if p.BaseInfo.NetprofitGrowthrate3Y > 0 {
+		p.PEG = p.BaseInfo.PE / p.BaseInfo.NetprofitGrowthrate3Y
+	}
+	p.calcTTMValuation(ctx, price)
+
+	p.HistoricalVolatility = 0
+	if hv, err := p.HistoricalPrice.HistoricalVolatility(ctx, "YEAR"); err == nil {
+		p.HistoricalVolatility = hv
+	} else {
+		logging.Warn(ctx, "Stock AsOf HistoricalVolatility err:"+err.Error())
 	}
 
-	// 计算逐年增长率
-	growthCount := 0
-	volatilitySum := 0.0
-	for i := 0; i < len(profitList)-1; i++ {
-		if profitList[i] > profitList[i+1] {
-			growthCount++
-		}
-		if i > 0 {
-			// 计算增长率波动
-			growth1 := (profitList[i] - profitList[i+1]) / math.Abs(profitList[i+1])
-			growth2 := (profitList[i-1] - profitList[i]) / math.Abs(profitList[i])
-			volatilitySum += math.Abs(growth1 - growth2)
+	p.RightPrice, p.PriceSpace, p.LastYearRightPrice = 0, 0, 0
+	p.calcRightPrice(ctx, date, price, s.valuationOptions())
+	p.DCF, p.DDM = nil, nil
+	if s.DCF != nil {
+		if dcf, err := p.DCFValue(ctx, s.DCF.Options); err == nil {
+			p.DCF = &dcf
 		}
 	}
-
-	// 根据增长次数和波动性评分
-	score := float64(growthCount) * 3
-	if volatilitySum > 0.5 {
-		score *= 0.8 // 波动大扣20%分数
-	}
-
-	s.BuffettScore.ProfitGrowthScore = score
+	p.calcQualityScores(ctx)
+	p.BuffettScore = p.calculateBuffettScore(ctx)
+	return p
 }
 
-// calculateDebtRatioScore 计算负债率评分
-func (s *Stock) calculateDebtRatioScore(ctx context.Context) {
-	if len(s.HistoricalFinaMainData) == 0 {
-		s.BuffettScore.DebtRatioScore = 0
+// calcTTMValuation 按最新一期财报的 TTM 数据计算 TTM PE 和 TTM PEG，无法计算时为 -1
+func (s *Stock) calcTTMValuation(ctx context.Context, price float64) {
+	s.TTMPE, s.TTMPEG = -1, -1
+	eps, ok := s.HistoricalFinaMainData.TTMValue(ctx, eastmoney.ValueListTypeEPS)
+	if !ok || eps <= 0 || price <= 0 {
 		return
 	}
-
-	// 获取最新负债率
-	debtRatio := s.HistoricalFinaMainData[0].Zcfzl
-
-	switch {
-	case debtRatio < 30:
-		s.BuffettScore.DebtRatioScore = 10
-	case debtRatio < 50:
-		s.BuffettScore.DebtRatioScore = 8
-	case debtRatio < 70:
-		s.BuffettScore.DebtRatioScore = 5
-	default:
-		s.BuffettScore.DebtRatioScore = 0
+	s.TTMPE = price / eps
+	if yoy, ok := s.HistoricalFinaMainData.TTMYoY(ctx, eastmoney.ValueListTypeNetProfit); ok && yoy > 0 {
+		s.TTMPEG = s.TTMPE / yoy
 	}
 }
 
-// calculateValuationScore 计算估值评分
-func (s *Stock) calculateValuationScore(ctx context.Context) {
-	score := 0.0
-
-	// PE估值评分
-	switch {
-	case s.BaseInfo.PE < 10:
-		score = 15
-	case s.BaseInfo.PE < 15:
-		score = 12
-	case s.BaseInfo.PE < 20:
-		score = 8
-	case s.BaseInfo.PE < 30:
-		score = 5
-	default:
-		score = 0
-	}
-
-	// PEG估值加分
-	if s.PEG > 0 && s.PEG < 1 {
-		score = math.Max(score, 15) // PEG<1时至少得12分
+// calcRightPrice 以 asOf 为当前时间按估值参数 opts 计算合理价格、合理价差及去年合理价格，财报及历史市盈率需已按 asOf 过滤
+func (s *Stock) calcRightPrice(ctx context.Context, asOf time.Time, price float64, opts valuation.Options) {
+	expl, err := valuation.RightPrice(ctx, valuation.Input{
+		FinaMainData: s.HistoricalFinaMainData,
+		PEList:       s.HistoricalPEList,
+		AsOf:         asOf,
+		Price:        price,
+	}, opts)
+	s.RightPriceExplanation = &expl
+	if err != nil {
+		logging.Error(ctx, "calcRightPrice err:"+err.Error())
+		return
 	}
-
-	s.BuffettScore.ValuationScore = score
+	s.RightPrice = expl.RightPrice
+	s.PriceSpace = expl.PriceSpace
+	s.LastYearRightPrice = expl.LastYearRightPrice
 }
 
-// calculateMoatScore 计算护城河评分
-func (s *Stock) calculateMoatScore(ctx context.Context) {
-	// 默认给5分
-	score := 5.0
-
-	// 基于行业给分
-	industry := s.BaseInfo.Industry
-	switch industry {
-	case "食品饮料", "医药生物", "家用电器", "银行", "保险":
-		score = 8 // 这些行业通常有较强的护城河
-	case "建筑", "采掘", "农林牧渔":
-		score = 3 // 这些行业通常护城河较弱
+// shares 总股本，按总市值/股价计算，无法计算时返回 0
+func (s Stock) shares() float64 {
+	price := s.GetPrice()
+	if price <= 0 {
+		return 0
 	}
-
-	s.BuffettScore.MoatScore = math.Min(10, score) // 最高10分
+	return s.BaseInfo.TotalMarketCap / price
 }
 
-// calculateManagementScore 计算管理层评分
-func (s *Stock) calculateManagementScore(ctx context.Context) {
-	score := 5.0 // 基础分
-
-	// 检查分红情况
-	if len(s.HistoricalFinaMainData) >= 3 {
-		// 暂时固定为7.5分,因为缺少分红数据
-		score = 7.5
-	}
-
-	// 检查股份回购情况
-	// 暂时固定为7.5分,因为缺少回购数据
-	score = 7.5
-
-	s.BuffettScore.ManagementScore = math.Min(10, score) // 最高10分
+// DCFValue 按历年年报自由现金流计算 DCF 估值，折现率默认使用中债 AAA 公司债收益率+风险溢价
+func (s Stock) DCFValue(ctx context.Context, opts valuation.DCFOptions) (valuation.DCFResult, error) {
+	return valuation.DCF(ctx, valuation.DCFInput{
+		FCFList:      s.HistoricalCashflowList.YearFreeCashflowList(ctx, opts.WithDefaults().FCFYears),
+		Shares:       s.shares(),
+		Price:        s.GetPrice(),
+		RiskFreeRate: AAACompanyBondSyl,
+	}, opts)
 }
 
-// calculateRDScore 计算研发投入评分
-func (s *Stock) calculateRDScore(ctx context.Context) {
-	if len(s.HistoricalGincomeList) == 0 {
-		s.BuffettScore.RDScore = 0
-		return
-	}
-
-	// 获取最近三年的研发投入数据
-	var rdExpenses []float64
-	var revenues []float64
-	count := 0
-
-	for i := 0; i < len(s.HistoricalGincomeList) && count < 3; i++ {
-		// 只统计年报数据
-		if s.HistoricalGincomeList[i].ReportType != eastmoney.FinaReportTypeYear {
-			continue
-		}
-		rdExpenses = append(rdExpenses, s.HistoricalGincomeList[i].ResearchExpense)
-		revenues = append(revenues, s.HistoricalGincomeList[i].TotalOperateIncome)
-		count++
-	}
-
-	if len(rdExpenses) == 0 {
-		s.BuffettScore.RDScore = 0
-		return
-	}
-
-	// 1. 计算研发投入占营收比例（最近一年）
-	rdRatio := rdExpenses[0] / revenues[0] * 100
-
-	// 2. 计算研发投入增长率
-	var rdGrowth float64
-	if len(rdExpenses) >= 2 {
-		rdGrowth = (rdExpenses[0] - rdExpenses[1]) / math.Abs(rdExpenses[1]) * 100
-	}
-
-	// 3. 评分规则：
-	// - 研发投入占营收比例 >= 5%: 3分
-	// - 研发投入占营收比例 >= 3%: 2分
-	// - 研发投入占营收比例 >= 1%: 1分
-	// - 研发投入同比增长 >= 30%: 2分
-	// - 研发投入同比增长 >= 10%: 1分
-	score := 0.0
-
-	if rdRatio >= 5 {
-		score += 3
-	} else if rdRatio >= 3 {
-		score += 2
-	} else if rdRatio >= 1 {
-		score += 1
-	}
-
-	if rdGrowth >= 30 {
-		score += 2
-	} else if rdGrowth >= 10 {
-		score += 1
+// DDMValue 按最新股息率及年报 EPS 计算股利折现估值。
+// 股息率只有最新数据，按历史时间点检测时不适用
+func (s Stock) DDMValue(ctx context.Context, opts valuation.DDMOptions) (valuation.DDMResult, error) {
+	if !s.AsOfDate.IsZero() {
+		return valuation.DDMResult{Options: opts.WithDefaults()}, errors.New("股息率无历史数据，不适用股利折现模型")
 	}
-
-	s.BuffettScore.RDScore = score
-	logging.Infof(ctx, "[%s] 研发投入评分：%.1f分 (投入占比:%.2f%%, 同比增长:%.2f%%)",
-		s.BaseInfo.SecurityNameAbbr, score, rdRatio, rdGrowth)
+	price := s.GetPrice()
+	eps := 0.0
+	if report := s.HistoricalFinaMainData.LatestYearReport(ctx); report != nil {
+		eps = report.Epsjb
+	}
+	return valuation.DDM(ctx, valuation.DDMInput{
+		DPS:          price * s.BaseInfo.Zxgxl / 100,
+		EPS:          eps,
+		Price:        price,
+		RiskFreeRate: AAACompanyBondSyl,
+	}, opts)
 }
 
-// calculateDividendScore 计算分红评分
-func (s *Stock) calculateDividendScore(ctx context.Context) {
-	if len(s.HistoricalCashflowList) == 0 {
-		s.BuffettScore.DividendScore = 0
-		return
-	}
-
-	// 获取最近三年的分红数据
-	var dividends []float64
-	var profits []float64
-	count := 0
-
-	for i := 0; i < len(s.HistoricalCashflowList) && count < 3; i++ {
-		// 只统计年报数据
-		if s.HistoricalCashflowList[i].ReportType != eastmoney.FinaReportTypeYear {
-			continue
-		}
-		dividends = append(dividends, s.HistoricalCashflowList[i].AssignDividendPorfit)
-		// 从对应的利润表中获取净利润数据
-		if i < len(s.HistoricalGincomeList) {
-			profits = append(profits, s.HistoricalGincomeList[i].ParentNetprofit)
-		}
-		count++
-	}
-
-	if len(dividends) == 0 || len(profits) == 0 {
-		s.BuffettScore.DividendScore = 0
-		return
-	}
-
-	// 1. 计算最近一年的分红率
-	dividendRatio := dividends[0] / profits[0] * 100
-
-	// 2. 计算连续分红年数
-	continuousYears := 0
-	for _, dividend := range dividends {
-		if dividend <= 0 {
-			break
-		}
-		continuousYears++
+// calcIntrinsicValues 计算 DCF 及 DDM 估值，无法估值时对应字段为 nil
+func (s *Stock) calcIntrinsicValues(ctx context.Context, dcfOpts valuation.DCFOptions, ddmOpts valuation.DDMOptions) {
+	s.DCF, s.DDM = nil, nil
+	if dcf, err := s.DCFValue(ctx, dcfOpts); err != nil {
+		logging.Debug(ctx, "calcIntrinsicValues DCF err:"+err.Error())
+	} else {
+		s.DCF = &dcf
 	}
-
-	// 3. 评分规则：
-	// - 分红率 >= 50%: 3分
-	// - 分红率 >= 30%: 2分
-	// - 分红率 >= 10%: 1分
-	// - 连续分红3年: 2分
-	// - 连续分红2年: 1分
-	score := 0.0
-
-	if dividendRatio >= 50 {
-		score += 3
-	} else if dividendRatio >= 30 {
-		score += 2
-	} else if dividendRatio >= 10 {
-		score += 1
+	if ddm, err := s.DDMValue(ctx, ddmOpts); err != nil {
+		logging.Debug(ctx, "calcIntrinsicValues DDM err:"+err.Error())
+	} else {
+		s.DDM = &ddm
 	}
+}
 
-	if continuousYears >= 3 {
-		score += 2
-	} else if continuousYears >= 2 {
-		score += 1
+// valuationOptions 返回上次计算合理价使用的估值参数，未计算过时返回默认参数
+func (s Stock) valuationOptions() valuation.Options {
+	if s.RightPriceExplanation == nil {
+		return valuation.DefaultOptions
 	}
-
-	s.BuffettScore.DividendScore = score
-	logging.Infof(ctx, "[%s] 分红评分：%.1f分 (分红率:%.2f%%, 连续分红年数:%d)",
-		s.BaseInfo.SecurityNameAbbr, score, dividendRatio, continuousYears)
+	return s.RightPriceExplanation.Options
 }
 
-// calculateRepurchaseScore 计算回购评分
-func (s *Stock) calculateRepurchaseScore(ctx context.Context) {
-	// 暂时固定为5分,因为缺少回购数据
-	s.BuffettScore.RepurchaseScore = 5.0
+// Revalue 按估值参数 opts 重新计算合理价，历史市盈率未获取成功时返回原数据
+func (s Stock) Revalue(ctx context.Context, opts valuation.Options) Stock {
+	if s.EnrichmentStatus(EnrichPE) != EnrichmentStatusOK {
+		return s
+	}
+	p := s
+	p.RightPrice, p.PriceSpace, p.LastYearRightPrice = 0, 0, 0
+	p.calcRightPrice(ctx, s.ReferenceDate(), s.GetPrice(), opts)
+	return p
 }
 
+// String 巴菲特评分说明
 func (s *Stock) String() string {
 	var sb strings.Builder
-
-	rawTotalScore := s.BuffettScore.ROEScore +
-		s.BuffettScore.CashFlowScore +
-		s.BuffettScore.ProfitGrowthScore +
-		s.BuffettScore.DebtRatioScore +
-		s.BuffettScore.MoatScore +
-		s.BuffettScore.ManagementScore +
-		s.BuffettScore.ValuationScore +
-		s.BuffettScore.RDScore +
-		s.BuffettScore.DividendScore +
-		s.BuffettScore.RepurchaseScore
-
-	sb.WriteString(fmt.Sprintf("总分: %.1f分 (原始得分: %.1f)\n", s.BuffettScore.TotalScore, rawTotalScore))
-	sb.WriteString(fmt.Sprintf("总分(100分): %.1f (原始得分: %.1f)\n", s.BuffettScore.TotalScore, rawTotalScore))
-	sb.WriteString(fmt.Sprintf("ROE(20分): %.1f\n", s.BuffettScore.ROEScore))
-	sb.WriteString(fmt.Sprintf("现金流(15分): %.1f\n", s.BuffettScore.CashFlowScore))
-	sb.WriteString(fmt.Sprintf("利润增长(15分): %.1f\n", s.BuffettScore.ProfitGrowthScore))
-	sb.WriteString(fmt.Sprintf("负债率(10分): %.1f\n", s.BuffettScore.DebtRatioScore))
-	sb.WriteString(fmt.Sprintf("护城河(10分): %.1f\n", s.BuffettScore.MoatScore))
-	sb.WriteString(fmt.Sprintf("管理层(10分): %.1f\n", s.BuffettScore.ManagementScore))
-	sb.WriteString(fmt.Sprintf("估值(15分): %.1f\n", s.BuffettScore.ValuationScore))
-	sb.WriteString(fmt.Sprintf("PE: %.1f  PEG: %.1f\n", s.BaseInfo.PE, s.PEG))
-	sb.WriteString(fmt.Sprintf("研发投入(5分): %.1f\n", s.BuffettScore.RDScore))
-	sb.WriteString(fmt.Sprintf("分红(5分): %.1f\n", s.BuffettScore.DividendScore))
-	sb.WriteString(fmt.Sprintf("回购(5分): %.1f\n", s.BuffettScore.RepurchaseScore))
-
+	sb.WriteString(fmt.Sprintf("总分(100分): %.1f\n", s.BuffettScore.TotalScore))
+	if s.BuffettScore.IndustryStockCount > 0 {
+		sb.WriteString(fmt.Sprintf("行业平均(%s,%d只): %.1f\n", s.BaseInfo.Industry, s.BuffettScore.IndustryStockCount, s.BuffettScore.IndustryAvgScore))
+	}
+	for _, c := range s.BuffettScore.Components {
+		sb.WriteString(fmt.Sprintf("%s(%.0f分): %.1f\n", c.Label, c.Weight, c.Score))
+	}
 	return sb.String()
 }
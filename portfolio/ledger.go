@@ -0,0 +1,374 @@
+// Package portfolio 持仓账本：记录买入、卖出、分红、拆股交易并计算持仓成本和盈亏
+package portfolio
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// TransactionType 交易类型
+type TransactionType string
+
+const (
+	// TransactionBuy 买入
+	TransactionBuy TransactionType = "buy"
+	// TransactionSell 卖出
+	TransactionSell TransactionType = "sell"
+	// TransactionDividend 现金分红
+	TransactionDividend TransactionType = "dividend"
+	// TransactionSplit 拆股或送转股
+	TransactionSplit TransactionType = "split"
+)
+
+// 成本计算方式
+const (
+	// CostMethodAverage 移动加权平均成本
+	CostMethodAverage = "average"
+	// CostMethodFIFO 先进先出成本
+	CostMethodFIFO = "fifo"
+)
+
+// DateLayout 交易日期格式
+const DateLayout = "2006-01-02"
+
+// sharesEpsilon 股数比较的误差
+const sharesEpsilon = 1e-6
+
+// Transaction 交易记录
+type Transaction struct {
+	// 交易编号，由账本生成
+	ID int `json:"id"`
+	// 交易日期 2006-01-02
+	Date string `json:"date"`
+	// 交易类型
+	Type TransactionType `json:"type"`
+	// 股票代码
+	Code string `json:"code"`
+	// 股票名称
+	Name string `json:"name"`
+	// 买入或卖出股数
+	Shares float64 `json:"shares"`
+	// 成交价
+	Price float64 `json:"price"`
+	// 手续费、印花税等交易费用
+	Fee float64 `json:"fee"`
+	// 分红到账现金总额（税后）
+	Amount float64 `json:"amount"`
+	// 拆股或送转后每 1 股变为的股数，如 10 送 5 为 1.5
+	Ratio float64 `json:"ratio"`
+	// 备注
+	Note string `json:"note"`
+}
+
+// stockCodeRegexp 6 位数字股票代码
+var stockCodeRegexp = regexp.MustCompile(`^(?i:sh|sz|bj)?(\d{6})(?i:\.(?:sh|sz|bj))?$`)
+
+// NormalizeCode 将股票代码统一为 6 位数字代码，如 sh600000、600000.SH 返回 600000，无法识别时返回去掉首尾空白的代码
+func NormalizeCode(code string) string {
+	code = strings.TrimSpace(code)
+	if m := stockCodeRegexp.FindStringSubmatch(code); m != nil {
+		return m[1]
+	}
+	return code
+}
+
+// Validate 校验交易记录
+func (t Transaction) Validate() error {
+	if _, err := time.Parse(DateLayout, t.Date); err != nil {
+		return fmt.Errorf("invalid transaction date %q", t.Date)
+	}
+	if t.Code == "" {
+		return errors.New("transaction without code")
+	}
+	if t.Fee < 0 {
+		return fmt.Errorf("invalid transaction fee %v", t.Fee)
+	}
+	switch t.Type {
+	case TransactionBuy, TransactionSell:
+		if t.Shares <= 0 || t.Price <= 0 {
+			return fmt.Errorf("%s transaction requires positive shares and price", t.Type)
+		}
+	case TransactionDividend:
+		if t.Amount <= 0 {
+			return errors.New("dividend transaction requires positive amount")
+		}
+	case TransactionSplit:
+		if t.Ratio <= 0 {
+			return errors.New("split transaction requires positive ratio")
+		}
+	default:
+		return fmt.Errorf("invalid transaction type %q", t.Type)
+	}
+	return nil
+}
+
+// Lot 先进先出的持仓批次
+type Lot struct {
+	// 买入日期
+	Date string `json:"date"`
+	// 剩余股数
+	Shares float64 `json:"shares"`
+	// 每股成本（含费用）
+	Cost float64 `json:"cost"`
+}
+
+// Position 单只股票的持仓及盈亏
+type Position struct {
+	// 股票代码
+	Code string `json:"code"`
+	// 股票名称
+	Name string `json:"name"`
+	// 持有股数，已清仓为 0
+	Shares float64 `json:"shares"`
+	// 移动加权平均每股成本
+	AvgCost float64 `json:"avg_cost"`
+	// 先进先出剩余批次每股成本
+	FIFOCost float64 `json:"fifo_cost"`
+	// 先进先出剩余批次
+	Lots []Lot `json:"lots"`
+	// 按账户成本方式计算的持仓成本
+	CostBasis float64 `json:"cost_basis"`
+	// 按账户成本方式计算的已实现盈亏
+	RealizedPL float64 `json:"realized_pl"`
+	// 按平均成本计算的已实现盈亏
+	RealizedPLAverage float64 `json:"realized_pl_average"`
+	// 按先进先出计算的已实现盈亏
+	RealizedPLFIFO float64 `json:"realized_pl_fifo"`
+	// 累计分红收入
+	Dividend float64 `json:"dividend"`
+	// 现价，未知时为 0
+	Price float64 `json:"price"`
+	// 市值，现价未知时为 0
+	MarketValue float64 `json:"market_value"`
+	// 浮动盈亏，现价未知时为 0
+	UnrealizedPL float64 `json:"unrealized_pl"`
+	// 浮动盈亏比例（%）
+	UnrealizedPLRatio float64 `json:"unrealized_pl_ratio"`
+	// 总盈亏=已实现盈亏+浮动盈亏+分红
+	TotalPL float64 `json:"total_pl"`
+}
+
+// IsOpen 是否仍有持仓
+func (p Position) IsOpen() bool {
+	return p.Shares > sharesEpsilon
+}
+
+// setPrice 按现价计算市值及浮动盈亏
+func (p *Position) setPrice(price float64) {
+	p.Price = price
+	p.MarketValue, p.UnrealizedPL, p.UnrealizedPLRatio = 0, 0, 0
+	if price > 0 && p.IsOpen() {
+		p.MarketValue = p.Shares * price
+		p.UnrealizedPL = p.MarketValue - p.CostBasis
+		if p.CostBasis > 0 {
+			p.UnrealizedPLRatio = p.UnrealizedPL / p.CostBasis * 100
+		}
+	}
+	p.TotalPL = p.RealizedPL + p.UnrealizedPL + p.Dividend
+}
+
+// Account 持仓账户
+type Account struct {
+	// 账户名称
+	Name string `json:"name"`
+	// 账户描述
+	Desc string `json:"desc"`
+	// 成本计算方式: average 或 fifo，为空时为 average
+	CostMethod string `json:"cost_method"`
+	// 交易记录
+	Transactions []Transaction `json:"transactions"`
+}
+
+// Validate 校验账户
+func (a Account) Validate() error {
+	if a.Name == "" {
+		return errors.New("account without name")
+	}
+	if a.CostMethod != "" && a.CostMethod != CostMethodAverage && a.CostMethod != CostMethodFIFO {
+		return fmt.Errorf("invalid cost method %q", a.CostMethod)
+	}
+	return nil
+}
+
+// SortedTransactions 按交易日期排序的交易记录，同一天的按记录顺序
+func (a Account) SortedTransactions() []Transaction {
+	txs := append([]Transaction{}, a.Transactions...)
+	sort.SliceStable(txs, func(i, j int) bool {
+		return txs[i].Date < txs[j].Date
+	})
+	return txs
+}
+
+// Positions 按交易记录计算各股票的持仓，包括已清仓的股票，按首次交易顺序排列。
+// 卖出股数超过持仓或对未持有的股票分红、拆股时返回错误
+func (a Account) Positions() ([]Position, error) {
+	positions := []*Position{}
+	index := map[string]*Position{}
+	for _, tx := range a.SortedTransactions() {
+		if err := tx.Validate(); err != nil {
+			return nil, fmt.Errorf("transaction %d: %w", tx.ID, err)
+		}
+		p, exists := index[tx.Code]
+		if !exists {
+			if tx.Type != TransactionBuy {
+				return nil, fmt.Errorf("transaction %d: %s %s without position", tx.ID, tx.Type, tx.Code)
+			}
+			p = &Position{Code: tx.Code, Lots: []Lot{}}
+			index[tx.Code] = p
+			positions = append(positions, p)
+		}
+		if tx.Name != "" {
+			p.Name = tx.Name
+		}
+		if err := p.apply(tx); err != nil {
+			return nil, fmt.Errorf("transaction %d: %w", tx.ID, err)
+		}
+	}
+	result := []Position{}
+	for _, p := range positions {
+		p.FIFOCost = 0
+		lotsCost := 0.0
+		for _, lot := range p.Lots {
+			lotsCost += lot.Shares * lot.Cost
+		}
+		if p.IsOpen() {
+			p.FIFOCost = lotsCost / p.Shares
+		}
+		p.CostBasis, p.RealizedPL = p.Shares*p.AvgCost, p.RealizedPLAverage
+		if a.CostMethod == CostMethodFIFO {
+			p.CostBasis, p.RealizedPL = lotsCost, p.RealizedPLFIFO
+		}
+		p.setPrice(0)
+		result = append(result, *p)
+	}
+	return result, nil
+}
+
+// apply 将一笔交易记入持仓
+func (p *Position) apply(tx Transaction) error {
+	switch tx.Type {
+	case TransactionBuy:
+		cost := tx.Shares*tx.Price + tx.Fee
+		p.AvgCost = (p.AvgCost*p.Shares + cost) / (p.Shares + tx.Shares)
+		p.Shares += tx.Shares
+		p.Lots = append(p.Lots, Lot{Date: tx.Date, Shares: tx.Shares, Cost: cost / tx.Shares})
+	case TransactionSell:
+		if tx.Shares > p.Shares+sharesEpsilon {
+			return fmt.Errorf("sell %v shares of %s exceeds holding %v", tx.Shares, p.Code, p.Shares)
+		}
+		proceeds := tx.Shares*tx.Price - tx.Fee
+		p.RealizedPLAverage += proceeds - tx.Shares*p.AvgCost
+		fifoCost, remain := 0.0, tx.Shares
+		for remain > sharesEpsilon && len(p.Lots) > 0 {
+			n := math.Min(remain, p.Lots[0].Shares)
+			fifoCost += n * p.Lots[0].Cost
+			remain -= n
+			p.Lots[0].Shares -= n
+			if p.Lots[0].Shares <= sharesEpsilon {
+				p.Lots = p.Lots[1:]
+			}
+		}
+		p.RealizedPLFIFO += proceeds - fifoCost
+		p.Shares -= tx.Shares
+		if !p.IsOpen() {
+			p.Shares, p.AvgCost, p.Lots = 0, 0, []Lot{}
+		}
+	case TransactionDividend:
+		if !p.IsOpen() {
+			return fmt.Errorf("dividend of %s without holding", p.Code)
+		}
+		p.Dividend += tx.Amount - tx.Fee
+	case TransactionSplit:
+		if !p.IsOpen() {
+			return fmt.Errorf("split of %s without holding", p.Code)
+		}
+		p.Shares *= tx.Ratio
+		p.AvgCost /= tx.Ratio
+		for i := range p.Lots {
+			p.Lots[i].Shares *= tx.Ratio
+			p.Lots[i].Cost /= tx.Ratio
+		}
+	}
+	return nil
+}
+
+// Summary 账户汇总
+type Summary struct {
+	// 账户名称
+	Account string `json:"account"`
+	// 成本计算方式
+	CostMethod string `json:"cost_method"`
+	// 各股票持仓
+	Positions []Position `json:"positions"`
+	// 总市值
+	MarketValue float64 `json:"market_value"`
+	// 持仓总成本
+	CostBasis float64 `json:"cost_basis"`
+	// 已实现盈亏
+	RealizedPL float64 `json:"realized_pl"`
+	// 浮动盈亏
+	UnrealizedPL float64 `json:"unrealized_pl"`
+	// 分红收入
+	Dividend float64 `json:"dividend"`
+	// 总盈亏
+	TotalPL float64 `json:"total_pl"`
+	// 缺少现价的股票代码
+	MissingPrices []string `json:"missing_prices"`
+}
+
+// Summarize 按现价 prices（key 为股票代码）汇总账户持仓及盈亏
+func (a Account) Summarize(prices map[string]float64) (Summary, error) {
+	method := a.CostMethod
+	if method == "" {
+		method = CostMethodAverage
+	}
+	s := Summary{Account: a.Name, CostMethod: method, MissingPrices: []string{}}
+	positions, err := a.Positions()
+	if err != nil {
+		return s, err
+	}
+	for i := range positions {
+		p := &positions[i]
+		if p.IsOpen() && prices[p.Code] <= 0 {
+			s.MissingPrices = append(s.MissingPrices, p.Code)
+		}
+		p.setPrice(prices[p.Code])
+		s.MarketValue += p.MarketValue
+		s.CostBasis += p.CostBasis
+		s.RealizedPL += p.RealizedPL
+		s.UnrealizedPL += p.UnrealizedPL
+		s.Dividend += p.Dividend
+		s.TotalPL += p.TotalPL
+	}
+	s.Positions = positions
+	return s, nil
+}
+
+// OpenPositions 仍有持仓的股票
+func (s Summary) OpenPositions() []Position {
+	result := []Position{}
+	for _, p := range s.Positions {
+		if p.IsOpen() {
+			result = append(result, p)
+		}
+	}
+	return result
+}
+
+// Codes 账户交易过的股票代码
+func (a Account) Codes() []string {
+	codes := []string{}
+	seen := map[string]bool{}
+	for _, tx := range a.Transactions {
+		if !seen[tx.Code] {
+			seen[tx.Code] = true
+			codes = append(codes, tx.Code)
+		}
+	}
+	return codes
+}
@@ -0,0 +1,100 @@
+package portfolio
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func testAccount(method string) Account {
+	return Account{
+		Name:       "test",
+		CostMethod: method,
+		Transactions: []Transaction{
+			{ID: 1, Date: "2022-01-05", Type: TransactionBuy, Code: "600519", Name: "贵州茅台", Shares: 100, Price: 10, Fee: 5},
+			{ID: 3, Date: "2022-03-01", Type: TransactionSell, Code: "600519", Shares: 150, Price: 14, Fee: 10},
+			{ID: 2, Date: "2022-02-01", Type: TransactionBuy, Code: "600519", Shares: 100, Price: 12, Fee: 5},
+			{ID: 4, Date: "2022-06-01", Type: TransactionDividend, Code: "600519", Amount: 50},
+			{ID: 5, Date: "2022-07-01", Type: TransactionSplit, Code: "600519", Ratio: 2},
+			{ID: 6, Date: "2022-01-10", Type: TransactionBuy, Code: "000001", Shares: 100, Price: 5},
+			{ID: 7, Date: "2022-02-10", Type: TransactionSell, Code: "000001", Shares: 100, Price: 4},
+		},
+	}
+}
+
+func TestAccountPositions(t *testing.T) {
+	positions, err := testAccount("").Positions()
+	require.Nil(t, err)
+	require.Len(t, positions, 2)
+
+	p := positions[0]
+	require.Equal(t, "贵州茅台", p.Name)
+	require.Equal(t, 100.0, p.Shares)
+	// 平均成本 (1005+1205)/200=11.05，卖出 150 股盈亏 2090-1657.5
+	require.InDelta(t, 432.5, p.RealizedPLAverage, 1e-9)
+	// 先进先出成本 1005+50*12.05
+	require.InDelta(t, 2090-1005-602.5, p.RealizedPLFIFO, 1e-9)
+	require.Equal(t, p.RealizedPLAverage, p.RealizedPL)
+	// 拆股后每股成本减半
+	require.InDelta(t, 11.05/2, p.AvgCost, 1e-9)
+	require.InDelta(t, 12.05/2, p.FIFOCost, 1e-9)
+	require.Len(t, p.Lots, 1)
+	require.Equal(t, 50.0, p.Dividend)
+	require.InDelta(t, 552.5, p.CostBasis, 1e-9)
+
+	require.False(t, positions[1].IsOpen())
+	require.Equal(t, -100.0, positions[1].RealizedPL)
+
+	positions, err = testAccount(CostMethodFIFO).Positions()
+	require.Nil(t, err)
+	require.InDelta(t, 602.5, positions[0].CostBasis, 1e-9)
+	require.Equal(t, positions[0].RealizedPLFIFO, positions[0].RealizedPL)
+}
+
+func TestAccountPositionsError(t *testing.T) {
+	a := testAccount("")
+	a.Transactions = append(a.Transactions, Transaction{ID: 8, Date: "2022-08-01", Type: TransactionSell, Code: "600519", Shares: 300, Price: 10})
+	_, err := a.Positions()
+	require.Error(t, err)
+
+	a = Account{Transactions: []Transaction{{ID: 1, Date: "2022-08-01", Type: TransactionDividend, Code: "600519", Amount: 10}}}
+	_, err = a.Positions()
+	require.Error(t, err)
+
+	require.Error(t, Transaction{Date: "2022/01/01", Type: TransactionBuy, Code: "1", Shares: 1, Price: 1}.Validate())
+	require.Error(t, Transaction{Date: "2022-01-01", Type: "gift", Code: "1"}.Validate())
+	require.Error(t, Account{Name: "x", CostMethod: "lifo"}.Validate())
+}
+
+func TestAccountSummarize(t *testing.T) {
+	s, err := testAccount("").Summarize(map[string]float64{"600519": 7})
+	require.Nil(t, err)
+	require.Equal(t, CostMethodAverage, s.CostMethod)
+	require.InDelta(t, 700, s.MarketValue, 1e-9)
+	require.InDelta(t, 700-552.5, s.UnrealizedPL, 1e-9)
+	require.InDelta(t, 432.5-100, s.RealizedPL, 1e-9)
+	require.InDelta(t, s.RealizedPL+s.UnrealizedPL+50, s.TotalPL, 1e-9)
+	require.Len(t, s.OpenPositions(), 1)
+	require.Empty(t, s.MissingPrices)
+
+	s, err = testAccount("").Summarize(nil)
+	require.Nil(t, err)
+	require.Equal(t, []string{"600519"}, s.MissingPrices)
+	require.Equal(t, 0.0, s.UnrealizedPL)
+}
+
+func TestNormalizeCode(t *testing.T) {
+	cases := map[string]string{
+		"600000":    "600000",
+		" 600000 ":  "600000",
+		"sh600000":  "600000",
+		"SZ000001":  "000001",
+		"600000.SH": "600000",
+		"000001.sz": "000001",
+		"hk00700":   "hk00700",
+		"6000001":   "6000001",
+	}
+	for code, want := range cases {
+		require.Equal(t, want, NormalizeCode(code), code)
+	}
+}
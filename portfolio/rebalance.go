@@ -0,0 +1,285 @@
+// 按目标仓位生成调仓交易单，按 A 股每手股数取整并计算交易费用
+
+package portfolio
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+)
+
+// 交易方向
+const (
+	// SideBuy 买入
+	SideBuy = "buy"
+	// SideSell 卖出
+	SideSell = "sell"
+)
+
+// FeeOptions 交易费用参数
+type FeeOptions struct {
+	// 佣金费率，买卖双向收取
+	CommissionRate float64 `json:"commission_rate"   form:"commission_rate"`
+	// 单笔最低佣金（元）
+	MinCommission float64 `json:"min_commission"    form:"min_commission"`
+	// 印花税率，仅卖出收取
+	StampDutyRate float64 `json:"stamp_duty_rate"   form:"stamp_duty_rate"`
+	// 过户费率，买卖双向收取
+	TransferFeeRate float64 `json:"transfer_fee_rate" form:"transfer_fee_rate"`
+}
+
+// DefaultFeeOptions 默认交易费用：佣金万 2.5 最低 5 元，印花税千 0.5，过户费十万分之 1
+var DefaultFeeOptions = FeeOptions{
+	CommissionRate:  0.00025,
+	MinCommission:   5,
+	StampDutyRate:   0.0005,
+	TransferFeeRate: 0.00001,
+}
+
+// TradeFee 交易费用明细
+type TradeFee struct {
+	// 佣金
+	Commission float64 `json:"commission"`
+	// 印花税
+	StampDuty float64 `json:"stamp_duty"`
+	// 过户费
+	TransferFee float64 `json:"transfer_fee"`
+	// 合计
+	Total float64 `json:"total"`
+}
+
+// Fee 计算成交金额 amount 的交易费用
+func (o FeeOptions) Fee(side string, amount float64) TradeFee {
+	f := TradeFee{}
+	if amount <= 0 {
+		return f
+	}
+	f.Commission = math.Max(amount*o.CommissionRate, o.MinCommission)
+	f.TransferFee = amount * o.TransferFeeRate
+	if side == SideSell {
+		f.StampDuty = amount * o.StampDutyRate
+	}
+	f.Total = f.Commission + f.StampDuty + f.TransferFee
+	return f
+}
+
+// RebalanceOptions 调仓参数
+type RebalanceOptions struct {
+	// 每手股数
+	LotSize int `json:"lot_size"      form:"lot_size"`
+	// 不交易区间：持仓权重与目标权重相差不超过该值（百分点）时不调整
+	NoTradeBand float64 `json:"no_trade_band" form:"no_trade_band"`
+	// 可用现金（元）
+	Cash float64 `json:"cash"          form:"cash"`
+	// 交易费用
+	Fees FeeOptions `json:"fees"`
+}
+
+// DefaultRebalanceOptions 默认调仓参数
+var DefaultRebalanceOptions = RebalanceOptions{
+	LotSize:     100,
+	NoTradeBand: 2,
+	Fees:        DefaultFeeOptions,
+}
+
+// RebalanceTarget 调仓标的的当前持仓及目标
+type RebalanceTarget struct {
+	// 股票代码
+	Code string `json:"code"`
+	// 股票名称
+	Name string `json:"name"`
+	// 当前持股数
+	Shares float64 `json:"shares"`
+	// 现价
+	Price float64 `json:"price"`
+	// 目标持仓金额（元）
+	TargetAmount float64 `json:"target_amount"`
+}
+
+// Trade 调仓交易
+type Trade struct {
+	// 股票代码
+	Code string `json:"code"`
+	// 股票名称
+	Name string `json:"name"`
+	// 交易方向
+	Side string `json:"side"`
+	// 交易股数
+	Shares float64 `json:"shares"`
+	// 参考价
+	Price float64 `json:"price"`
+	// 成交金额
+	Amount float64 `json:"amount"`
+	// 交易费用
+	Fee TradeFee `json:"fee"`
+	// 说明
+	Note string `json:"note"`
+}
+
+// RebalanceHolding 调仓前后的持仓
+type RebalanceHolding struct {
+	// 股票代码
+	Code string `json:"code"`
+	// 股票名称
+	Name string `json:"name"`
+	// 调仓前股数
+	SharesBefore float64 `json:"shares_before"`
+	// 调仓后股数
+	SharesAfter float64 `json:"shares_after"`
+	// 调仓前权重（%）
+	WeightBefore float64 `json:"weight_before"`
+	// 调仓后权重（%）
+	WeightAfter float64 `json:"weight_after"`
+	// 目标权重（%）
+	TargetWeight float64 `json:"target_weight"`
+	// 是否在不交易区间内
+	InBand bool `json:"in_band"`
+}
+
+// RebalancePlan 调仓方案
+type RebalancePlan struct {
+	// 调仓参数
+	Options RebalanceOptions `json:"options"`
+	// 交易单，先卖后买
+	Trades []Trade `json:"trades"`
+	// 调仓前后持仓
+	Holdings []RebalanceHolding `json:"holdings"`
+	// 调仓前总资产（持仓市值+现金）
+	TotalValue float64 `json:"total_value"`
+	// 调仓后现金
+	CashAfter float64 `json:"cash_after"`
+	// 调仓后现金权重（%）
+	CashWeightAfter float64 `json:"cash_weight_after"`
+	// 交易费用合计
+	TotalFee float64 `json:"total_fee"`
+	// 现金不足等未能完全调整的说明
+	Notes []string `json:"notes"`
+}
+
+// Rebalance 按目标持仓金额生成调仓交易单。
+// 权重偏离不超过不交易区间的股票不调整；先卖出再用卖出所得及可用现金买入，买入股数按每手股数向下取整且含费用不超过现金；
+// 目标为 0 时全部卖出（包括不足一手的零股），其余卖出按每手股数取整
+func Rebalance(targets []RebalanceTarget, opts RebalanceOptions) (RebalancePlan, error) {
+	if opts.LotSize <= 0 {
+		opts.LotSize = DefaultRebalanceOptions.LotSize
+	}
+	plan := RebalancePlan{Options: opts, Trades: []Trade{}, Holdings: []RebalanceHolding{}, Notes: []string{}}
+	if opts.Cash < 0 {
+		return plan, errors.New("negative cash")
+	}
+	plan.TotalValue = opts.Cash
+	for _, t := range targets {
+		if t.Price <= 0 {
+			return plan, fmt.Errorf("%s without price", t.Code)
+		}
+		if t.Shares < 0 || t.TargetAmount < 0 {
+			return plan, fmt.Errorf("%s with negative shares or target", t.Code)
+		}
+		plan.TotalValue += t.Shares * t.Price
+	}
+	if plan.TotalValue <= 0 {
+		return plan, errors.New("empty portfolio")
+	}
+
+	lot := float64(opts.LotSize)
+	cash := opts.Cash
+	for _, t := range targets {
+		h := RebalanceHolding{
+			Code:         t.Code,
+			Name:         t.Name,
+			SharesBefore: t.Shares,
+			SharesAfter:  t.Shares,
+			WeightBefore: t.Shares * t.Price / plan.TotalValue * 100,
+			TargetWeight: t.TargetAmount / plan.TotalValue * 100,
+		}
+		h.InBand = math.Abs(h.WeightBefore-h.TargetWeight) <= opts.NoTradeBand && t.TargetAmount > 0
+		plan.Holdings = append(plan.Holdings, h)
+	}
+
+	// 卖出
+	for i, t := range targets {
+		h := &plan.Holdings[i]
+		excess := t.Shares*t.Price - t.TargetAmount
+		if h.InBand || excess <= 0 {
+			continue
+		}
+		shares := math.Floor(excess/t.Price/lot) * lot
+		note := ""
+		if t.TargetAmount == 0 {
+			shares, note = t.Shares, "清仓"
+		}
+		shares = math.Min(shares, t.Shares)
+		if shares <= 0 {
+			continue
+		}
+		trade := newTrade(t, SideSell, shares, opts.Fees, note)
+		cash += trade.Amount - trade.Fee.Total
+		h.SharesAfter -= shares
+		plan.Trades = append(plan.Trades, trade)
+	}
+
+	// 买入，缺口大的优先
+	buys := []int{}
+	for i, t := range targets {
+		if !plan.Holdings[i].InBand && t.TargetAmount-t.Shares*t.Price > 0 {
+			buys = append(buys, i)
+		}
+	}
+	sort.SliceStable(buys, func(a, b int) bool {
+		ta, tb := targets[buys[a]], targets[buys[b]]
+		return ta.TargetAmount-ta.Shares*ta.Price > tb.TargetAmount-tb.Shares*tb.Price
+	})
+	for _, i := range buys {
+		t, h := targets[i], &plan.Holdings[i]
+		shares := math.Floor((t.TargetAmount-t.Shares*t.Price)/t.Price/lot) * lot
+		want := shares
+		for shares > 0 {
+			amount := shares * t.Price
+			if amount+opts.Fees.Fee(SideBuy, amount).Total <= cash {
+				break
+			}
+			shares -= lot
+		}
+		if shares < want {
+			plan.Notes = append(plan.Notes, fmt.Sprintf("%s 现金不足，计划买入 %.0f 股，实际买入 %.0f 股", t.Code, want, math.Max(shares, 0)))
+		}
+		if shares <= 0 {
+			continue
+		}
+		trade := newTrade(t, SideBuy, shares, opts.Fees, "")
+		cash -= trade.Amount + trade.Fee.Total
+		h.SharesAfter += shares
+		plan.Trades = append(plan.Trades, trade)
+	}
+
+	// 调仓后权重按扣除费用后的总资产计算
+	totalAfter := cash
+	for i, t := range targets {
+		totalAfter += plan.Holdings[i].SharesAfter * t.Price
+	}
+	for i, t := range targets {
+		plan.Holdings[i].WeightAfter = plan.Holdings[i].SharesAfter * t.Price / totalAfter * 100
+	}
+	for _, trade := range plan.Trades {
+		plan.TotalFee += trade.Fee.Total
+	}
+	plan.CashAfter = cash
+	plan.CashWeightAfter = cash / totalAfter * 100
+	return plan, nil
+}
+
+// newTrade 创建交易并计算费用
+func newTrade(t RebalanceTarget, side string, shares float64, fees FeeOptions, note string) Trade {
+	amount := shares * t.Price
+	return Trade{
+		Code:   t.Code,
+		Name:   t.Name,
+		Side:   side,
+		Shares: shares,
+		Price:  t.Price,
+		Amount: amount,
+		Fee:    fees.Fee(side, amount),
+		Note:   note,
+	}
+}
@@ -0,0 +1,79 @@
+package portfolio
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFeeOptionsFee(t *testing.T) {
+	f := DefaultFeeOptions.Fee(SideBuy, 10000)
+	require.Equal(t, 5.0, f.Commission)
+	require.Equal(t, 0.0, f.StampDuty)
+	require.InDelta(t, 5.1, f.Total, 1e-9)
+
+	f = DefaultFeeOptions.Fee(SideSell, 100000)
+	require.InDelta(t, 25, f.Commission, 1e-9)
+	require.InDelta(t, 50, f.StampDuty, 1e-9)
+	require.InDelta(t, 76, f.Total, 1e-9)
+	require.Equal(t, TradeFee{}, DefaultFeeOptions.Fee(SideSell, 0))
+}
+
+func TestRebalance(t *testing.T) {
+	targets := []RebalanceTarget{
+		// 超配，卖出取整到 100 股
+		{Code: "A", Shares: 1000, Price: 10, TargetAmount: 4450},
+		// 清仓，含零股
+		{Code: "B", Shares: 150, Price: 20, TargetAmount: 0},
+		// 在不交易区间内
+		{Code: "C", Shares: 100, Price: 10, TargetAmount: 1100},
+		// 低配，买入
+		{Code: "D", Shares: 0, Price: 5, TargetAmount: 4000},
+	}
+	opts := DefaultRebalanceOptions
+	opts.Cash = 1000
+	plan, err := Rebalance(targets, opts)
+	require.Nil(t, err)
+	require.Equal(t, 15000.0, plan.TotalValue)
+	require.Len(t, plan.Trades, 3)
+
+	require.Equal(t, SideSell, plan.Trades[0].Side)
+	require.Equal(t, 500.0, plan.Trades[0].Shares)
+	require.Equal(t, 150.0, plan.Trades[1].Shares)
+	require.Equal(t, "清仓", plan.Trades[1].Note)
+	require.True(t, plan.Holdings[2].InBand)
+	require.Equal(t, 100.0, plan.Holdings[2].SharesAfter)
+
+	require.Equal(t, SideBuy, plan.Trades[2].Side)
+	require.Equal(t, 800.0, plan.Trades[2].Shares)
+	require.Empty(t, plan.Notes)
+
+	cash := 1000 + 5000 - DefaultFeeOptions.Fee(SideSell, 5000).Total + 3000 - DefaultFeeOptions.Fee(SideSell, 3000).Total - 4000 - DefaultFeeOptions.Fee(SideBuy, 4000).Total
+	require.InDelta(t, cash, plan.CashAfter, 1e-9)
+	weights := plan.CashWeightAfter
+	for _, h := range plan.Holdings {
+		weights += h.WeightAfter
+	}
+	require.InDelta(t, 100, weights, 1e-9)
+	require.InDelta(t, plan.Trades[0].Fee.Total+plan.Trades[1].Fee.Total+plan.Trades[2].Fee.Total, plan.TotalFee, 1e-9)
+}
+
+func TestRebalanceCashConstraint(t *testing.T) {
+	targets := []RebalanceTarget{
+		{Code: "A", Price: 10, TargetAmount: 5000},
+		{Code: "B", Price: 10, TargetAmount: 3000},
+	}
+	opts := DefaultRebalanceOptions
+	opts.Cash = 4000
+	plan, err := Rebalance(targets, opts)
+	require.Nil(t, err)
+	require.Len(t, plan.Trades, 1)
+	require.Equal(t, "A", plan.Trades[0].Code)
+	// 含最低佣金 5 元，400 股需要 4005.04 元，只能买 300 股
+	require.Equal(t, 300.0, plan.Trades[0].Shares)
+	require.Len(t, plan.Notes, 2)
+	require.True(t, plan.CashAfter >= 0)
+
+	_, err = Rebalance([]RebalanceTarget{{Code: "A"}}, opts)
+	require.Error(t, err)
+}
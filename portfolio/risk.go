@@ -0,0 +1,503 @@
+// 基于历史股价的组合风险分析：相关性、波动率、贝塔、VaR/CVaR、最大回撤及行业集中度
+
+package portfolio
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+)
+
+// TradingDaysPerYear 每年交易日数，用于年化波动率
+const TradingDaysPerYear = 250
+
+// PriceSeries 按日期升序排列的收盘价序列，日期格式 2006-01-02
+type PriceSeries struct {
+	Dates  []string  `json:"dates"`
+	Prices []float64 `json:"prices"`
+}
+
+// priceOn 返回 date 当天及之前最近一个交易日的价格，无数据时返回 0
+func (p PriceSeries) priceOn(date string) float64 {
+	idx := sort.Search(len(p.Dates), func(i int) bool {
+		return p.Dates[i] > date
+	}) - 1
+	if idx < 0 || idx >= len(p.Prices) {
+		return 0
+	}
+	return p.Prices[idx]
+}
+
+// lastDate 最后一个交易日
+func (p PriceSeries) lastDate() string {
+	n := len(p.Dates)
+	if len(p.Prices) < n {
+		n = len(p.Prices)
+	}
+	if n == 0 {
+		return ""
+	}
+	return p.Dates[n-1]
+}
+
+// RiskAsset 参与风险分析的持仓
+type RiskAsset struct {
+	// 股票代码
+	Code string `json:"code"`
+	// 股票名称
+	Name string `json:"name"`
+	// 所属行业
+	Industry string `json:"industry"`
+	// 持仓市值
+	MarketValue float64 `json:"market_value"`
+	// 历史股价
+	Prices PriceSeries `json:"-"`
+}
+
+// RiskOptions 风险分析参数
+type RiskOptions struct {
+	// 回看交易日数
+	Days int `json:"days"                  form:"days"`
+	// VaR 置信度（%）
+	Confidence float64 `json:"confidence"            form:"confidence"`
+	// 高相关阈值，相关系数不低于该值的持仓视为同一类风险
+	CorrelationThreshold float64 `json:"correlation_threshold" form:"correlation_threshold"`
+	// 单一行业权重上限（%）
+	IndustryLimit float64 `json:"industry_limit"        form:"industry_limit"`
+}
+
+// DefaultRiskOptions 默认风险分析参数
+var DefaultRiskOptions = RiskOptions{
+	Days:                 TradingDaysPerYear,
+	Confidence:           95,
+	CorrelationThreshold: 0.7,
+	IndustryLimit:        40,
+}
+
+// withDefaults 未设置的参数使用默认值
+func (o RiskOptions) withDefaults() RiskOptions {
+	if o.Days <= 0 {
+		o.Days = DefaultRiskOptions.Days
+	}
+	if o.Confidence <= 0 || o.Confidence >= 100 {
+		o.Confidence = DefaultRiskOptions.Confidence
+	}
+	if o.CorrelationThreshold <= 0 {
+		o.CorrelationThreshold = DefaultRiskOptions.CorrelationThreshold
+	}
+	if o.IndustryLimit <= 0 {
+		o.IndustryLimit = DefaultRiskOptions.IndustryLimit
+	}
+	return o
+}
+
+// AssetRisk 单只持仓的风险指标
+type AssetRisk struct {
+	// 股票代码
+	Code string `json:"code"`
+	// 股票名称
+	Name string `json:"name"`
+	// 所属行业
+	Industry string `json:"industry"`
+	// 持仓权重（%）
+	Weight float64 `json:"weight"`
+	// 区间收益率（%）
+	Return float64 `json:"return"`
+	// 年化波动率（%）
+	Volatility float64 `json:"volatility"`
+	// 相对基准的贝塔
+	Beta float64 `json:"beta"`
+	// 区间最大回撤（%）
+	MaxDrawdown float64 `json:"max_drawdown"`
+	// 对组合波动的贡献占比（%）
+	RiskContribution float64 `json:"risk_contribution"`
+}
+
+// IndustryWeight 行业权重
+type IndustryWeight struct {
+	// 行业
+	Industry string `json:"industry"`
+	// 权重（%）
+	Weight float64 `json:"weight"`
+	// 对组合波动的贡献占比（%）
+	RiskContribution float64 `json:"risk_contribution"`
+	// 行业内持仓代码
+	Codes []string `json:"codes"`
+}
+
+// CorrelatedPair 高相关持仓对
+type CorrelatedPair struct {
+	// 股票代码
+	A string `json:"a"`
+	// 股票代码
+	B string `json:"b"`
+	// 相关系数
+	Correlation float64 `json:"correlation"`
+}
+
+// RiskReport 组合风险分析结果
+type RiskReport struct {
+	// 分析参数
+	Options RiskOptions `json:"options"`
+	// 区间开始日期
+	StartDate string `json:"start_date"`
+	// 区间结束日期
+	EndDate string `json:"end_date"`
+	// 日收益率样本数
+	Observations int `json:"observations"`
+	// 持仓总市值
+	MarketValue float64 `json:"market_value"`
+	// 各持仓风险指标，按权重降序
+	Assets []AssetRisk `json:"assets"`
+	// 相关系数矩阵，顺序与 Assets 一致
+	Correlation [][]float64 `json:"correlation"`
+	// 组合区间收益率（%），按当前权重每日再平衡
+	Return float64 `json:"return"`
+	// 组合年化波动率（%）
+	Volatility float64 `json:"volatility"`
+	// 组合相对基准的贝塔
+	Beta float64 `json:"beta"`
+	// 单日历史 VaR（%），按置信度计算的单日亏损分位数
+	VaR float64 `json:"var"`
+	// 单日历史 CVaR（%），超过 VaR 的亏损均值
+	CVaR float64 `json:"cvar"`
+	// 单日 VaR 金额
+	VaRAmount float64 `json:"var_amount"`
+	// 单日 CVaR 金额
+	CVaRAmount float64 `json:"cvar_amount"`
+	// 组合区间最大回撤（%）
+	MaxDrawdown float64 `json:"max_drawdown"`
+	// 基准区间收益率（%）
+	BenchmarkReturn float64 `json:"benchmark_return"`
+	// 基准年化波动率（%）
+	BenchmarkVolatility float64 `json:"benchmark_volatility"`
+	// 基准区间最大回撤（%）
+	BenchmarkMaxDrawdown float64 `json:"benchmark_max_drawdown"`
+	// 持仓两两相关系数均值
+	AvgCorrelation float64 `json:"avg_correlation"`
+	// 分散化比率：持仓波动率加权和 / 组合波动率，越接近 1 分散效果越差
+	DiversificationRatio float64 `json:"diversification_ratio"`
+	// 持仓权重赫芬达尔指数
+	HHI float64 `json:"hhi"`
+	// 有效持仓数 1/HHI
+	EffectiveN float64 `json:"effective_n"`
+	// 行业权重赫芬达尔指数
+	IndustryHHI float64 `json:"industry_hhi"`
+	// 行业权重，按权重降序
+	Industries []IndustryWeight `json:"industries"`
+	// 高相关持仓对，按相关系数降序
+	CorrelatedPairs []CorrelatedPair `json:"correlated_pairs"`
+	// 风险提示
+	Warnings []string `json:"warnings"`
+}
+
+// AnalyzeRisk 使用持仓历史股价及基准指数计算组合风险。
+// 以基准交易日为日历，取最近 Days 个交易日中全部持仓都有股价的日期计算日收益率，组合按当前市值权重计算
+func AnalyzeRisk(assets []RiskAsset, benchmark PriceSeries, opts RiskOptions) (RiskReport, error) {
+	opts = opts.withDefaults()
+	report := RiskReport{
+		Options:         opts,
+		Assets:          []AssetRisk{},
+		Correlation:     [][]float64{},
+		Industries:      []IndustryWeight{},
+		CorrelatedPairs: []CorrelatedPair{},
+		Warnings:        []string{},
+	}
+	if len(assets) == 0 {
+		return report, errors.New("empty holdings")
+	}
+	for _, a := range assets {
+		if a.MarketValue <= 0 {
+			return report, fmt.Errorf("%s without market value", a.Code)
+		}
+		report.MarketValue += a.MarketValue
+	}
+	// 按权重降序
+	assets = append([]RiskAsset{}, assets...)
+	sort.SliceStable(assets, func(i, j int) bool {
+		return assets[i].MarketValue > assets[j].MarketValue
+	})
+	weights := make([]float64, len(assets))
+	for i, a := range assets {
+		weights[i] = a.MarketValue / report.MarketValue
+	}
+
+	dates := riskDates(assets, benchmark, opts.Days)
+	if len(dates) < 21 {
+		return report, fmt.Errorf("历史股价数据不足，仅有 %d 个共同交易日", len(dates))
+	}
+	report.StartDate, report.EndDate = dates[0], dates[len(dates)-1]
+	report.Observations = len(dates) - 1
+
+	returns := make([][]float64, len(assets))
+	for i, a := range assets {
+		returns[i] = dailyReturns(a.Prices, dates)
+	}
+	benchReturns := dailyReturns(benchmark, dates)
+	portReturns := make([]float64, report.Observations)
+	for t := range portReturns {
+		for i := range assets {
+			portReturns[t] += weights[i] * returns[i][t]
+		}
+	}
+
+	// 协方差矩阵
+	n := len(assets)
+	cov := make([][]float64, n)
+	for i := range cov {
+		cov[i] = make([]float64, n)
+		for j := range cov[i] {
+			if j < i {
+				cov[i][j] = cov[j][i]
+				continue
+			}
+			cov[i][j] = covariance(returns[i], returns[j])
+		}
+	}
+	portVar := 0.0
+	marginal := make([]float64, n)
+	for i := range cov {
+		for j := range cov[i] {
+			marginal[i] += cov[i][j] * weights[j]
+		}
+		portVar += weights[i] * marginal[i]
+	}
+	benchVar := covariance(benchReturns, benchReturns)
+	annualize := math.Sqrt(TradingDaysPerYear) * 100
+
+	report.Return = (cumulative(portReturns) - 1) * 100
+	report.Volatility = math.Sqrt(portVar) * annualize
+	report.MaxDrawdown = maxDrawdown(portReturns)
+	report.BenchmarkReturn = (cumulative(benchReturns) - 1) * 100
+	report.BenchmarkVolatility = math.Sqrt(benchVar) * annualize
+	report.BenchmarkMaxDrawdown = maxDrawdown(benchReturns)
+	if benchVar > 0 {
+		report.Beta = covariance(portReturns, benchReturns) / benchVar
+	}
+	report.VaR, report.CVaR = historicalVaR(portReturns, opts.Confidence)
+	report.VaRAmount = report.VaR / 100 * report.MarketValue
+	report.CVaRAmount = report.CVaR / 100 * report.MarketValue
+
+	weightedVol := 0.0
+	for i, a := range assets {
+		r := AssetRisk{
+			Code:        a.Code,
+			Name:        a.Name,
+			Industry:    a.Industry,
+			Weight:      weights[i] * 100,
+			Return:      (cumulative(returns[i]) - 1) * 100,
+			Volatility:  math.Sqrt(cov[i][i]) * annualize,
+			MaxDrawdown: maxDrawdown(returns[i]),
+		}
+		if benchVar > 0 {
+			r.Beta = covariance(returns[i], benchReturns) / benchVar
+		}
+		if portVar > 0 {
+			r.RiskContribution = weights[i] * marginal[i] / portVar * 100
+		}
+		weightedVol += weights[i] * r.Volatility
+		report.HHI += weights[i] * weights[i]
+		report.Assets = append(report.Assets, r)
+	}
+	if report.Volatility > 0 {
+		report.DiversificationRatio = weightedVol / report.Volatility
+	}
+	report.EffectiveN = 1 / report.HHI
+
+	// 相关系数矩阵
+	pairSum, pairCount := 0.0, 0
+	for i := range cov {
+		row := make([]float64, n)
+		for j := range cov[i] {
+			row[j] = correlation(cov, i, j)
+			if j > i {
+				pairSum += row[j]
+				pairCount++
+				if row[j] >= opts.CorrelationThreshold {
+					report.CorrelatedPairs = append(report.CorrelatedPairs, CorrelatedPair{A: assets[i].Code, B: assets[j].Code, Correlation: row[j]})
+				}
+			}
+		}
+		report.Correlation = append(report.Correlation, row)
+	}
+	if pairCount > 0 {
+		report.AvgCorrelation = pairSum / float64(pairCount)
+	}
+	sort.SliceStable(report.CorrelatedPairs, func(i, j int) bool {
+		return report.CorrelatedPairs[i].Correlation > report.CorrelatedPairs[j].Correlation
+	})
+
+	// 行业集中度
+	industries := map[string]*IndustryWeight{}
+	for _, r := range report.Assets {
+		name := r.Industry
+		if name == "" {
+			name = "未知"
+		}
+		iw, exists := industries[name]
+		if !exists {
+			iw = &IndustryWeight{Industry: name, Codes: []string{}}
+			industries[name] = iw
+		}
+		iw.Weight += r.Weight
+		iw.RiskContribution += r.RiskContribution
+		iw.Codes = append(iw.Codes, r.Code)
+	}
+	for _, iw := range industries {
+		report.Industries = append(report.Industries, *iw)
+		report.IndustryHHI += (iw.Weight / 100) * (iw.Weight / 100)
+	}
+	sort.SliceStable(report.Industries, func(i, j int) bool {
+		if report.Industries[i].Weight == report.Industries[j].Weight {
+			return report.Industries[i].Industry < report.Industries[j].Industry
+		}
+		return report.Industries[i].Weight > report.Industries[j].Weight
+	})
+
+	report.Warnings = riskWarnings(report)
+	return report, nil
+}
+
+// riskWarnings 集中度及相关性风险提示
+func riskWarnings(r RiskReport) []string {
+	warnings := []string{}
+	for _, iw := range r.Industries {
+		if iw.Weight > r.Options.IndustryLimit {
+			warnings = append(warnings, fmt.Sprintf("行业 %s 权重 %.1f%% 超过 %.0f%%", iw.Industry, iw.Weight, r.Options.IndustryLimit))
+		}
+	}
+	for _, p := range r.CorrelatedPairs {
+		warnings = append(warnings, fmt.Sprintf("%s 与 %s 相关系数 %.2f，走势高度一致", p.A, p.B, p.Correlation))
+	}
+	if len(r.Assets) > 1 && r.AvgCorrelation >= r.Options.CorrelationThreshold {
+		warnings = append(warnings, fmt.Sprintf("持仓平均相关系数 %.2f，组合实际上是同一个押注", r.AvgCorrelation))
+	}
+	for _, a := range r.Assets {
+		if len(r.Assets) > 1 && a.RiskContribution > 50 {
+			warnings = append(warnings, fmt.Sprintf("%s 权重 %.1f%% 但贡献了 %.1f%% 的组合波动", a.Code, a.Weight, a.RiskContribution))
+		}
+	}
+	if len(r.Assets) > 1 && r.EffectiveN < 3 {
+		warnings = append(warnings, fmt.Sprintf("有效持仓数仅 %.1f", r.EffectiveN))
+	}
+	return warnings
+}
+
+// riskDates 以基准交易日为日历，返回最近 days+1 个全部持仓都有股价的交易日，
+// 不晚于最早停止更新的股价序列的最后交易日
+func riskDates(assets []RiskAsset, benchmark PriceSeries, days int) []string {
+	end := benchmark.lastDate()
+	for _, a := range assets {
+		if last := a.Prices.lastDate(); last < end {
+			end = last
+		}
+	}
+	dates := []string{}
+	for i := len(benchmark.Dates) - 1; i >= 0 && len(dates) <= days; i-- {
+		date := benchmark.Dates[i]
+		if i >= len(benchmark.Prices) || date > end {
+			continue
+		}
+		if benchmark.Prices[i] <= 0 {
+			break
+		}
+		complete := true
+		for _, a := range assets {
+			if a.Prices.priceOn(date) <= 0 {
+				complete = false
+				break
+			}
+		}
+		if !complete {
+			break
+		}
+		dates = append(dates, date)
+	}
+	// 转为升序
+	for i, j := 0, len(dates)-1; i < j; i, j = i+1, j-1 {
+		dates[i], dates[j] = dates[j], dates[i]
+	}
+	return dates
+}
+
+// dailyReturns 按日历计算日收益率
+func dailyReturns(p PriceSeries, dates []string) []float64 {
+	returns := make([]float64, 0, len(dates))
+	prev := p.priceOn(dates[0])
+	for _, date := range dates[1:] {
+		price := p.priceOn(date)
+		returns = append(returns, price/prev-1)
+		prev = price
+	}
+	return returns
+}
+
+// mean 均值
+func mean(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	sum := 0.0
+	for _, v := range values {
+		sum += v
+	}
+	return sum / float64(len(values))
+}
+
+// covariance 样本协方差
+func covariance(x, y []float64) float64 {
+	if len(x) < 2 || len(x) != len(y) {
+		return 0
+	}
+	mx, my := mean(x), mean(y)
+	sum := 0.0
+	for i := range x {
+		sum += (x[i] - mx) * (y[i] - my)
+	}
+	return sum / float64(len(x)-1)
+}
+
+// correlation 由协方差矩阵计算相关系数
+func correlation(cov [][]float64, i, j int) float64 {
+	d := math.Sqrt(cov[i][i] * cov[j][j])
+	if d == 0 {
+		return 0
+	}
+	return cov[i][j] / d
+}
+
+// cumulative 累计净值
+func cumulative(returns []float64) float64 {
+	nav := 1.0
+	for _, r := range returns {
+		nav *= 1 + r
+	}
+	return nav
+}
+
+// maxDrawdown 日收益率序列的最大回撤（%）
+func maxDrawdown(returns []float64) float64 {
+	nav, peak, mdd := 1.0, 1.0, 0.0
+	for _, r := range returns {
+		nav *= 1 + r
+		peak = math.Max(peak, nav)
+		mdd = math.Max(mdd, (peak-nav)/peak*100)
+	}
+	return mdd
+}
+
+// historicalVaR 历史模拟法计算单日 VaR 及 CVaR（%），亏损为正数
+func historicalVaR(returns []float64, confidence float64) (float64, float64) {
+	if len(returns) == 0 {
+		return 0, 0
+	}
+	sorted := append([]float64{}, returns...)
+	sort.Float64s(sorted)
+	idx := int(math.Floor(float64(len(sorted)) * (1 - confidence/100)))
+	if idx >= len(sorted) {
+		idx = len(sorted) - 1
+	}
+	tail := sorted[:idx+1]
+	return math.Max(-sorted[idx]*100, 0), math.Max(-mean(tail)*100, 0)
+}
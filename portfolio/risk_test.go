@@ -0,0 +1,89 @@
+package portfolio
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// testSeries 按日收益率序列生成价格序列，首日价格为 base
+func testSeries(base float64, returns []float64) PriceSeries {
+	p := PriceSeries{}
+	day := time.Date(2022, 1, 1, 0, 0, 0, 0, time.Local)
+	price := base
+	for i := 0; i <= len(returns); i++ {
+		if i > 0 {
+			price *= 1 + returns[i-1]
+		}
+		p.Dates = append(p.Dates, day.AddDate(0, 0, i).Format(DateLayout))
+		p.Prices = append(p.Prices, price)
+	}
+	return p
+}
+
+func TestAnalyzeRisk(t *testing.T) {
+	benchReturns := []float64{}
+	inverse := []float64{}
+	for i := 0; i < 60; i++ {
+		r := 0.01 * math.Sin(float64(i))
+		benchReturns = append(benchReturns, r)
+		inverse = append(inverse, -r)
+	}
+	benchmark := testSeries(3000, benchReturns)
+	assets := []RiskAsset{
+		{Code: "A", Industry: "银行", MarketValue: 5000, Prices: testSeries(10, benchReturns)},
+		{Code: "B", Industry: "银行", MarketValue: 3000, Prices: testSeries(20, benchReturns)},
+		{Code: "C", Industry: "白酒", MarketValue: 2000, Prices: testSeries(30, inverse)},
+	}
+	opts := DefaultRiskOptions
+	opts.Days = 30
+	r, err := AnalyzeRisk(assets, benchmark, opts)
+	require.Nil(t, err)
+	require.Equal(t, 30, r.Observations)
+	require.Equal(t, "2022-03-02", r.EndDate)
+	require.Equal(t, 10000.0, r.MarketValue)
+
+	require.Equal(t, "A", r.Assets[0].Code)
+	require.InDelta(t, 50, r.Assets[0].Weight, 1e-9)
+	require.InDelta(t, 1, r.Assets[0].Beta, 1e-9)
+	require.InDelta(t, -1, r.Assets[2].Beta, 1e-9)
+	require.InDelta(t, 1, r.Correlation[0][1], 1e-9)
+	require.InDelta(t, -1, r.Correlation[0][2], 1e-9)
+	// 组合每日收益为基准的 0.6 倍
+	require.InDelta(t, 0.6, r.Beta, 1e-9)
+	require.InDelta(t, r.BenchmarkVolatility*0.6, r.Volatility, 1e-9)
+	require.InDelta(t, 100, r.Assets[0].RiskContribution+r.Assets[1].RiskContribution+r.Assets[2].RiskContribution, 1e-9)
+	require.True(t, r.VaR > 0)
+	require.True(t, r.CVaR >= r.VaR)
+	require.InDelta(t, r.VaR*100, r.VaRAmount, 1e-9)
+	require.True(t, r.MaxDrawdown > 0)
+
+	require.Len(t, r.CorrelatedPairs, 1)
+	require.Equal(t, "银行", r.Industries[0].Industry)
+	require.InDelta(t, 80, r.Industries[0].Weight, 1e-9)
+	require.InDelta(t, 0.68, r.IndustryHHI, 1e-9)
+	require.InDelta(t, 1/0.38, r.EffectiveN, 1e-9)
+	require.NotEmpty(t, r.Warnings)
+}
+
+func TestAnalyzeRiskError(t *testing.T) {
+	_, err := AnalyzeRisk(nil, PriceSeries{}, DefaultRiskOptions)
+	require.Error(t, err)
+
+	short := testSeries(10, []float64{0.01, 0.02})
+	_, err = AnalyzeRisk([]RiskAsset{{Code: "A", MarketValue: 1, Prices: short}}, short, DefaultRiskOptions)
+	require.Error(t, err)
+}
+
+func TestHistoricalVaR(t *testing.T) {
+	returns := []float64{}
+	for i := 1; i <= 100; i++ {
+		returns = append(returns, float64(i-50)/1000)
+	}
+	v, cv := historicalVaR(returns, 95)
+	require.InDelta(t, 4.4, v, 1e-9)
+	require.InDelta(t, 4.65, cv, 1e-9)
+	require.InDelta(t, 10, maxDrawdown([]float64{0.1, -0.1, 0.05}), 1e-9)
+}
@@ -0,0 +1,585 @@
+// 基金定投回测：按周、双周或月定期买入，支持按估值分位调整定投金额、红利再投资及申购赎回费，计算 IRR、收益率、回撤及现金流
+
+package portfolio
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 定投频率
+const (
+	// SIPFrequencyWeekly 每周
+	SIPFrequencyWeekly = "weekly"
+	// SIPFrequencyBiweekly 每两周
+	SIPFrequencyBiweekly = "biweekly"
+	// SIPFrequencyMonthly 每月
+	SIPFrequencyMonthly = "monthly"
+)
+
+// 定投现金流类型
+const (
+	// SIPFlowBuy 定投买入
+	SIPFlowBuy = "buy"
+	// SIPFlowSkip 估值过高暂停定投
+	SIPFlowSkip = "skip"
+	// SIPFlowDividend 现金分红
+	SIPFlowDividend = "dividend"
+	// SIPFlowReinvest 红利再投资
+	SIPFlowReinvest = "reinvest"
+	// SIPFlowSplit 份额折算
+	SIPFlowSplit = "split"
+	// SIPFlowRedeem 期末赎回
+	SIPFlowRedeem = "redeem"
+)
+
+// NavPoint 基金单日净值
+type NavPoint struct {
+	// 净值日期
+	Date string `json:"date"`
+	// 单位净值
+	Nav float64 `json:"nav"`
+	// 每份派现金（元），当日为除息日
+	Dividend float64 `json:"dividend"`
+	// 份额折算比例，每份折算为多少份，无折算时为 0
+	SplitRatio float64 `json:"split_ratio"`
+}
+
+// ValuationPoint 估值数据，如指数 PE
+type ValuationPoint struct {
+	// 日期
+	Date string `json:"date"`
+	// 估值
+	Value float64 `json:"value"`
+}
+
+// ParseValuations 解析每行为 日期,估值 的 CSV 估值数据，首行为表头时跳过
+func ParseValuations(r io.Reader) ([]ValuationPoint, error) {
+	points := []ValuationPoint{}
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+		fields := strings.Split(text, ",")
+		if len(fields) < 2 {
+			return nil, fmt.Errorf("line %d: invalid valuation %q", line, text)
+		}
+		date := strings.TrimSpace(fields[0])
+		value, err := strconv.ParseFloat(strings.TrimSpace(fields[1]), 64)
+		if _, derr := time.Parse(DateLayout, date); derr != nil || err != nil {
+			if line == 1 {
+				continue
+			}
+			return nil, fmt.Errorf("line %d: invalid valuation %q", line, text)
+		}
+		points = append(points, ValuationPoint{Date: date, Value: value})
+	}
+	return points, scanner.Err()
+}
+
+// SIPScaleTier 估值分位对应的定投金额倍数
+type SIPScaleTier struct {
+	// 估值分位上限（%），分位不高于该值时使用该倍数
+	MaxPercentile float64 `json:"max_percentile"`
+	// 定投金额倍数，0 表示暂停定投
+	Multiple float64 `json:"multiple"`
+}
+
+// SIPRedemptionTier 按持有天数的赎回费率档位
+type SIPRedemptionTier struct {
+	// 持有天数下限（含）
+	MinDays int `json:"min_days"`
+	// 持有天数上限（不含），0 表示无上限
+	MaxDays int `json:"max_days"`
+	// 赎回费率（%）
+	Rate float64 `json:"rate"`
+}
+
+// SIPOptions 定投回测参数
+type SIPOptions struct {
+	// 开始日期，为空时从第一个净值日期开始
+	StartDate string `json:"start_date"       form:"start_date"`
+	// 结束日期，为空时到最后一个净值日期
+	EndDate string `json:"end_date"         form:"end_date"`
+	// 定投频率: weekly biweekly monthly
+	Frequency string `json:"frequency"        form:"frequency"`
+	// 定投日: 每月几号（1-28），每周或每两周为星期几（1-5），非交易日顺延
+	Day int `json:"day"              form:"day"`
+	// 每期定投金额（元）
+	Amount float64 `json:"amount"           form:"amount"`
+	// 申购费率（%），小于 0 时使用基金当前购买费率
+	PurchaseRate float64 `json:"purchase_rate"    form:"purchase_rate"`
+	// 赎回费率（%），未设置赎回费率档位时期末按市值收取
+	RedemptionRate float64 `json:"redemption_rate"  form:"redemption_rate"`
+	// 赎回费率档位，设置后期末按每笔买入的持有天数收取赎回费
+	RedemptionTiers []SIPRedemptionTier `json:"redemption_tiers"`
+	// 红利再投资，否则现金分红
+	Reinvest bool `json:"reinvest"         form:"reinvest"`
+	// 按估值分位调整定投金额
+	ValuationScaled bool `json:"valuation_scaled" form:"valuation_scaled"`
+	// 估值分位的回看年数
+	ValuationYears float64 `json:"valuation_years"  form:"valuation_years"`
+	// 估值分位对应的定投金额倍数，按分位上限升序
+	ScaleTiers []SIPScaleTier `json:"scale_tiers"`
+}
+
+// DefaultSIPScaleTiers 默认估值分位倍数：低估多投，高估少投，极度高估暂停
+var DefaultSIPScaleTiers = []SIPScaleTier{
+	{MaxPercentile: 20, Multiple: 2},
+	{MaxPercentile: 40, Multiple: 1.5},
+	{MaxPercentile: 60, Multiple: 1},
+	{MaxPercentile: 80, Multiple: 0.5},
+	{MaxPercentile: 100, Multiple: 0},
+}
+
+// DefaultSIPOptions 默认定投回测参数
+var DefaultSIPOptions = SIPOptions{
+	Frequency:      SIPFrequencyMonthly,
+	Day:            1,
+	Amount:         1000,
+	PurchaseRate:   -1,
+	RedemptionRate: 0,
+	Reinvest:       true,
+	ValuationYears: 5,
+	ScaleTiers:     DefaultSIPScaleTiers,
+}
+
+// withDefaults 未设置的参数使用默认值
+func (o SIPOptions) withDefaults() SIPOptions {
+	if o.Frequency == "" {
+		o.Frequency = DefaultSIPOptions.Frequency
+	}
+	if o.Frequency == SIPFrequencyMonthly && (o.Day <= 0 || o.Day > 28) {
+		o.Day = DefaultSIPOptions.Day
+	}
+	if o.Frequency != SIPFrequencyMonthly && (o.Day < 1 || o.Day > 5) {
+		o.Day = 1
+	}
+	if o.Amount <= 0 {
+		o.Amount = DefaultSIPOptions.Amount
+	}
+	if o.PurchaseRate < 0 {
+		o.PurchaseRate = 0
+	}
+	if o.RedemptionRate < 0 {
+		o.RedemptionRate = 0
+	}
+	if o.ValuationYears <= 0 {
+		o.ValuationYears = DefaultSIPOptions.ValuationYears
+	}
+	if len(o.ScaleTiers) == 0 {
+		o.ScaleTiers = DefaultSIPScaleTiers
+	}
+	tiers := make([]SIPScaleTier, len(o.ScaleTiers))
+	copy(tiers, o.ScaleTiers)
+	sort.SliceStable(tiers, func(i, j int) bool {
+		return tiers[i].MaxPercentile < tiers[j].MaxPercentile
+	})
+	o.ScaleTiers = tiers
+	return o
+}
+
+// redemptionRate 持有 days 天适用的赎回费率（%），没有匹配档位时为 0
+func (o SIPOptions) redemptionRate(days int) float64 {
+	for _, t := range o.RedemptionTiers {
+		if days >= t.MinDays && (t.MaxDays == 0 || days < t.MaxDays) {
+			return t.Rate
+		}
+	}
+	return 0
+}
+
+// sipLot 定投买入或红利再投资的份额批次
+type sipLot struct {
+	// 确认日期
+	date time.Time
+	// 份额
+	shares float64
+}
+
+// multiple 估值分位对应的定投金额倍数，高于全部分位上限时使用最后一档
+func (o SIPOptions) multiple(percentile float64) float64 {
+	for _, tier := range o.ScaleTiers {
+		if percentile <= tier.MaxPercentile {
+			return tier.Multiple
+		}
+	}
+	return o.ScaleTiers[len(o.ScaleTiers)-1].Multiple
+}
+
+// SIPCashFlow 定投现金流
+type SIPCashFlow struct {
+	// 日期
+	Date string `json:"date"`
+	// 类型: buy skip dividend reinvest split redeem
+	Type string `json:"type"`
+	// 单位净值
+	Nav float64 `json:"nav"`
+	// 投资者现金流，买入为负，现金分红及赎回为正
+	CashFlow float64 `json:"cash_flow"`
+	// 费用
+	Fee float64 `json:"fee"`
+	// 份额变动
+	Shares float64 `json:"shares"`
+	// 持有份额
+	TotalShares float64 `json:"total_shares"`
+	// 累计投入本金（含申购费）
+	Invested float64 `json:"invested"`
+	// 持仓市值
+	MarketValue float64 `json:"market_value"`
+	// 估值分位（%），未按估值调整时为 0
+	Percentile float64 `json:"percentile"`
+	// 定投金额倍数
+	Multiple float64 `json:"multiple"`
+}
+
+// SIPResult 定投回测结果
+type SIPResult struct {
+	// 回测参数
+	Options SIPOptions `json:"options"`
+	// 第一次定投日期
+	StartDate string `json:"start_date"`
+	// 结束日期
+	EndDate string `json:"end_date"`
+	// 定投扣款次数
+	Periods int `json:"periods"`
+	// 因估值过高暂停的期数
+	SkippedPeriods int `json:"skipped_periods"`
+	// 累计投入本金（含申购费）
+	TotalInvested float64 `json:"total_invested"`
+	// 申购费合计
+	PurchaseFee float64 `json:"purchase_fee"`
+	// 期末赎回费
+	RedemptionFee float64 `json:"redemption_fee"`
+	// 现金分红合计
+	CashDividends float64 `json:"cash_dividends"`
+	// 期末持有份额
+	FinalShares float64 `json:"final_shares"`
+	// 期末单位净值
+	FinalNav float64 `json:"final_nav"`
+	// 期末持仓市值
+	MarketValue float64 `json:"market_value"`
+	// 期末赎回金额与现金分红之和
+	FinalValue float64 `json:"final_value"`
+	// 收益金额
+	Profit float64 `json:"profit"`
+	// 总收益率（%）
+	TotalReturn float64 `json:"total_return"`
+	// 年化内部收益率（%）
+	IRR float64 `json:"irr"`
+	// 平均持仓成本（元/份），不含现金分红
+	AvgCost float64 `json:"avg_cost"`
+	// 第一次定投后基金复权净值的最大回撤（%）
+	MaxDrawdown float64 `json:"max_drawdown"`
+	// 定投账户最大浮亏比例（%），为持仓市值加现金分红相对累计本金的最大亏损
+	MaxLoss float64 `json:"max_loss"`
+	// 最大浮亏日期
+	MaxLossDate string `json:"max_loss_date"`
+	// 现金流明细
+	CashFlows []SIPCashFlow `json:"cash_flows"`
+	// 说明
+	Notes []string `json:"notes"`
+}
+
+// sipSchedule 生成 start 到 end 之间的定投计划日期
+func sipSchedule(start, end time.Time, opts SIPOptions) []time.Time {
+	dates := []time.Time{}
+	switch opts.Frequency {
+	case SIPFrequencyMonthly:
+		t := time.Date(start.Year(), start.Month(), opts.Day, 0, 0, 0, 0, time.UTC)
+		if t.Before(start) {
+			t = t.AddDate(0, 1, 0)
+		}
+		for ; !t.After(end); t = t.AddDate(0, 1, 0) {
+			dates = append(dates, t)
+		}
+	default:
+		step := 7
+		if opts.Frequency == SIPFrequencyBiweekly {
+			step = 14
+		}
+		t := start
+		for int(t.Weekday()) != opts.Day {
+			t = t.AddDate(0, 0, 1)
+		}
+		for ; !t.After(end); t = t.AddDate(0, 0, step) {
+			dates = append(dates, t)
+		}
+	}
+	return dates
+}
+
+// valuationPercentile 返回 date 在回看期内的估值分位（%），数据不足 20 个时返回 false
+func valuationPercentile(points []ValuationPoint, date string, years float64) (float64, bool) {
+	t, err := time.Parse(DateLayout, date)
+	if err != nil {
+		return 0, false
+	}
+	from := t.AddDate(0, 0, -int(years*365)).Format(DateLayout)
+	end := sort.Search(len(points), func(i int) bool {
+		return points[i].Date > date
+	})
+	if end == 0 {
+		return 0, false
+	}
+	current := points[end-1].Value
+	count, below := 0, 0
+	for i := end - 1; i >= 0 && points[i].Date > from; i-- {
+		count++
+		if points[i].Value <= current {
+			below++
+		}
+	}
+	if count < 20 {
+		return 0, false
+	}
+	return float64(below) / float64(count) * 100, true
+}
+
+// xirrFlow 带日期的现金流
+type xirrFlow struct {
+	date   time.Time
+	amount float64
+}
+
+// xirr 计算不定期现金流的年化内部收益率，无解时返回 false
+func xirr(flows []xirrFlow) (float64, bool) {
+	if len(flows) < 2 {
+		return 0, false
+	}
+	first := flows[0].date
+	npv := func(rate float64) float64 {
+		sum := 0.0
+		for _, f := range flows {
+			years := f.date.Sub(first).Hours() / 24 / 365
+			sum += f.amount / math.Pow(1+rate, years)
+		}
+		return sum
+	}
+	low, high := -0.9999, 1.0
+	for npv(high) > 0 && high < 1e6 {
+		high *= 2
+	}
+	if npv(low)*npv(high) > 0 {
+		return 0, false
+	}
+	for i := 0; i < 200; i++ {
+		mid := (low + high) / 2
+		if npv(low)*npv(mid) <= 0 {
+			high = mid
+		} else {
+			low = mid
+		}
+	}
+	return (low + high) / 2, true
+}
+
+// BacktestSIP 按净值历史回测定投计划，navs 按日期升序，valuations 为空且按估值调整时使用基金复权净值的分位代替估值分位
+func BacktestSIP(navs []NavPoint, valuations []ValuationPoint, opts SIPOptions) (SIPResult, error) {
+	opts = opts.withDefaults()
+	result := SIPResult{Options: opts, CashFlows: []SIPCashFlow{}, Notes: []string{}}
+	if opts.Frequency != SIPFrequencyWeekly && opts.Frequency != SIPFrequencyBiweekly && opts.Frequency != SIPFrequencyMonthly {
+		return result, fmt.Errorf("invalid sip frequency %q", opts.Frequency)
+	}
+	if len(navs) == 0 {
+		return result, errors.New("empty nav history")
+	}
+	navs = append([]NavPoint{}, navs...)
+	sort.SliceStable(navs, func(i, j int) bool {
+		return navs[i].Date < navs[j].Date
+	})
+	startDate, endDate := opts.StartDate, opts.EndDate
+	if startDate == "" {
+		startDate = navs[0].Date
+	}
+	if endDate == "" {
+		endDate = navs[len(navs)-1].Date
+	}
+	start, err := time.Parse(DateLayout, startDate)
+	if err != nil {
+		return result, fmt.Errorf("invalid start date %q", startDate)
+	}
+	end, err := time.Parse(DateLayout, endDate)
+	if err != nil {
+		return result, fmt.Errorf("invalid end date %q", endDate)
+	}
+	if end.Before(start) {
+		return result, errors.New("end date is before start date")
+	}
+
+	// 复权净值指数，用于回撤及未提供估值数据时的估值分位
+	adjusted := make([]ValuationPoint, len(navs))
+	for i, n := range navs {
+		if i == 0 || navs[i-1].Nav <= 0 {
+			adjusted[i] = ValuationPoint{Date: n.Date, Value: 1}
+			continue
+		}
+		split := n.SplitRatio
+		if split <= 0 {
+			split = 1
+		}
+		adjusted[i] = ValuationPoint{Date: n.Date, Value: adjusted[i-1].Value * (n.Nav*split + n.Dividend) / navs[i-1].Nav}
+	}
+	if opts.ValuationScaled {
+		if len(valuations) == 0 {
+			valuations = adjusted
+			result.Notes = append(result.Notes, "未提供估值数据，使用基金复权净值在回看期内的分位代替估值分位")
+		} else {
+			valuations = append([]ValuationPoint{}, valuations...)
+			sort.SliceStable(valuations, func(i, j int) bool {
+				return valuations[i].Date < valuations[j].Date
+			})
+		}
+	}
+
+	// 定投计划日期顺延到之后的第一个净值日期
+	buyDates := map[string]bool{}
+	for _, t := range sipSchedule(start, end, opts) {
+		date := t.Format(DateLayout)
+		idx := sort.Search(len(navs), func(i int) bool {
+			return navs[i].Date >= date
+		})
+		if idx < len(navs) && navs[idx].Date <= endDate {
+			buyDates[navs[idx].Date] = true
+		}
+	}
+
+	flows := []xirrFlow{}
+	lots := []sipLot{}
+	shares, invested, cashDividends := 0.0, 0.0, 0.0
+	peak := 0.0
+	result.MaxLoss = 0
+	var last *NavPoint
+	for i := range navs {
+		n := navs[i]
+		if n.Date < startDate || n.Date > endDate || n.Nav <= 0 {
+			continue
+		}
+		t, err := time.Parse(DateLayout, n.Date)
+		if err != nil {
+			continue
+		}
+		last = &navs[i]
+		if shares > 0 && n.SplitRatio > 0 {
+			before := shares
+			shares *= n.SplitRatio
+			for j := range lots {
+				lots[j].shares *= n.SplitRatio
+			}
+			result.CashFlows = append(result.CashFlows, SIPCashFlow{
+				Date: n.Date, Type: SIPFlowSplit, Nav: n.Nav, Shares: shares - before,
+				TotalShares: shares, Invested: invested, MarketValue: shares * n.Nav,
+			})
+		}
+		if shares > 0 && n.Dividend > 0 {
+			cash := shares * n.Dividend
+			flow := SIPCashFlow{Date: n.Date, Nav: n.Nav, Invested: invested}
+			if opts.Reinvest {
+				flow.Type = SIPFlowReinvest
+				flow.Shares = cash / n.Nav
+				shares += flow.Shares
+				lots = append(lots, sipLot{date: t, shares: flow.Shares})
+			} else {
+				flow.Type = SIPFlowDividend
+				flow.CashFlow = cash
+				cashDividends += cash
+				flows = append(flows, xirrFlow{date: t, amount: cash})
+			}
+			flow.TotalShares = shares
+			flow.MarketValue = shares * n.Nav
+			result.CashFlows = append(result.CashFlows, flow)
+		}
+		if buyDates[n.Date] {
+			flow := SIPCashFlow{Date: n.Date, Type: SIPFlowBuy, Nav: n.Nav, Multiple: 1}
+			if opts.ValuationScaled {
+				if pct, ok := valuationPercentile(valuations, n.Date, opts.ValuationYears); ok {
+					flow.Percentile = pct
+					flow.Multiple = opts.multiple(pct)
+				}
+			}
+			amount := opts.Amount * flow.Multiple
+			if amount > 0 {
+				flow.Fee = amount - amount/(1+opts.PurchaseRate/100)
+				flow.Shares = (amount - flow.Fee) / n.Nav
+				flow.CashFlow = -amount
+				shares += flow.Shares
+				lots = append(lots, sipLot{date: t, shares: flow.Shares})
+				invested += amount
+				result.PurchaseFee += flow.Fee
+				result.Periods++
+				flows = append(flows, xirrFlow{date: t, amount: -amount})
+				if result.StartDate == "" {
+					result.StartDate = n.Date
+				}
+			} else {
+				flow.Type = SIPFlowSkip
+				result.SkippedPeriods++
+			}
+			flow.TotalShares = shares
+			flow.Invested = invested
+			flow.MarketValue = shares * n.Nav
+			result.CashFlows = append(result.CashFlows, flow)
+		}
+		if invested <= 0 {
+			continue
+		}
+		// 第一次定投后的复权净值回撤及账户浮亏
+		if v := adjusted[i].Value; v > peak {
+			peak = v
+		} else if dd := (peak - v) / peak * 100; dd > result.MaxDrawdown {
+			result.MaxDrawdown = dd
+		}
+		if loss := (invested - shares*n.Nav - cashDividends) / invested * 100; loss > result.MaxLoss {
+			result.MaxLoss = loss
+			result.MaxLossDate = n.Date
+		}
+	}
+	if result.Periods == 0 || last == nil {
+		return result, errors.New("no sip purchase between start date and end date")
+	}
+
+	endTime, _ := time.Parse(DateLayout, last.Date)
+	result.EndDate = last.Date
+	result.TotalInvested = invested
+	result.CashDividends = cashDividends
+	result.FinalShares = shares
+	result.FinalNav = last.Nav
+	result.MarketValue = shares * last.Nav
+	if len(opts.RedemptionTiers) > 0 {
+		// 红利再投资的份额从再投资日起计算持有天数
+		for _, lot := range lots {
+			days := int(endTime.Sub(lot.date).Hours() / 24)
+			result.RedemptionFee += lot.shares * last.Nav * opts.redemptionRate(days) / 100
+		}
+		result.Notes = append(result.Notes, "赎回费按基金赎回费率档位及每笔份额的持有天数计算")
+	} else {
+		result.RedemptionFee = result.MarketValue * opts.RedemptionRate / 100
+	}
+	proceeds := result.MarketValue - result.RedemptionFee
+	result.FinalValue = proceeds + cashDividends
+	result.Profit = result.FinalValue - invested
+	result.TotalReturn = result.Profit / invested * 100
+	if shares > 0 {
+		result.AvgCost = invested / shares
+	}
+	result.CashFlows = append(result.CashFlows, SIPCashFlow{
+		Date: last.Date, Type: SIPFlowRedeem, Nav: last.Nav, CashFlow: proceeds, Fee: result.RedemptionFee,
+		Shares: -shares, Invested: invested, MarketValue: result.MarketValue,
+	})
+	flows = append(flows, xirrFlow{date: endTime, amount: proceeds})
+	if irr, ok := xirr(flows); ok {
+		result.IRR = irr * 100
+	} else {
+		result.Notes = append(result.Notes, "现金流无法计算内部收益率")
+	}
+	return result, nil
+}
@@ -0,0 +1,189 @@
+package portfolio
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// testNavs 从 2022-01-03 起每个工作日一个净值
+func testNavs(navs ...float64) []NavPoint {
+	points := []NavPoint{}
+	day := time.Date(2022, 1, 3, 0, 0, 0, 0, time.UTC)
+	for _, nav := range navs {
+		for day.Weekday() == time.Saturday || day.Weekday() == time.Sunday {
+			day = day.AddDate(0, 0, 1)
+		}
+		points = append(points, NavPoint{Date: day.Format(DateLayout), Nav: nav})
+		day = day.AddDate(0, 0, 1)
+	}
+	return points
+}
+
+func TestSIPSchedule(t *testing.T) {
+	start := time.Date(2022, 1, 15, 0, 0, 0, 0, time.UTC)
+	end := time.Date(2022, 4, 10, 0, 0, 0, 0, time.UTC)
+	opts := SIPOptions{Frequency: SIPFrequencyMonthly, Day: 10}.withDefaults()
+	dates := sipSchedule(start, end, opts)
+	require.Len(t, dates, 3)
+	require.Equal(t, "2022-02-10", dates[0].Format(DateLayout))
+	require.Equal(t, "2022-04-10", dates[2].Format(DateLayout))
+
+	opts = SIPOptions{Frequency: SIPFrequencyBiweekly, Day: 3}.withDefaults()
+	dates = sipSchedule(start, end, opts)
+	// 2022-01-19 为周三
+	require.Equal(t, "2022-01-19", dates[0].Format(DateLayout))
+	require.Equal(t, "2022-02-02", dates[1].Format(DateLayout))
+	require.Len(t, dates, 6)
+}
+
+func TestBacktestSIP(t *testing.T) {
+	navs := testNavs(1, 1, 1, 1, 1, 2, 2, 2, 2, 2, 0.5, 0.5, 0.5, 0.5, 0.5, 1)
+	opts := SIPOptions{
+		Frequency:      SIPFrequencyWeekly,
+		Day:            1,
+		Amount:         1000,
+		RedemptionRate: 0,
+	}
+	r, err := BacktestSIP(navs, nil, opts)
+	require.Nil(t, err)
+	require.Equal(t, 4, r.Periods)
+	require.Equal(t, "2022-01-03", r.StartDate)
+	require.Equal(t, "2022-01-24", r.EndDate)
+	require.InDelta(t, 4000, r.TotalInvested, 1e-9)
+	// 1000 + 500 + 2000 + 1000 份
+	require.InDelta(t, 4500, r.FinalShares, 1e-9)
+	require.InDelta(t, 4500, r.FinalValue, 1e-9)
+	require.InDelta(t, 12.5, r.TotalReturn, 1e-9)
+	require.True(t, r.IRR > 0)
+	require.InDelta(t, 75, r.MaxDrawdown, 1e-9)
+	// 2022-01-17 投入 3000，市值 1750
+	require.InDelta(t, (3000-1750)/3000.0*100, r.MaxLoss, 1e-9)
+	require.Equal(t, "2022-01-17", r.MaxLossDate)
+	require.Equal(t, SIPFlowRedeem, r.CashFlows[len(r.CashFlows)-1].Type)
+
+	// 申购费前端收取，赎回费按期末市值收取
+	opts.PurchaseRate = 1.5
+	opts.RedemptionRate = 0.5
+	r, err = BacktestSIP(navs, nil, opts)
+	require.Nil(t, err)
+	require.InDelta(t, 4500/1.015, r.FinalShares, 1e-9)
+	require.InDelta(t, 4000-4000/1.015, r.PurchaseFee, 1e-9)
+	require.InDelta(t, r.MarketValue*0.005, r.RedemptionFee, 1e-9)
+	require.InDelta(t, r.MarketValue-r.RedemptionFee, r.FinalValue, 1e-9)
+
+	// 按每笔份额的持有天数收取赎回费: 持有 21、14、7 天的份额 0.5%，当天买入的份额 1.5%
+	opts.PurchaseRate = 0
+	opts.RedemptionRate = 0
+	opts.RedemptionTiers = []SIPRedemptionTier{
+		{MinDays: 0, MaxDays: 7, Rate: 1.5},
+		{MinDays: 7, MaxDays: 30, Rate: 0.5},
+		{MinDays: 30, Rate: 0},
+	}
+	r, err = BacktestSIP(navs, nil, opts)
+	require.Nil(t, err)
+	require.InDelta(t, 3500*0.005+1000*0.015, r.RedemptionFee, 1e-9)
+	require.InDelta(t, 4500-r.RedemptionFee, r.FinalValue, 1e-9)
+	require.Contains(t, r.Notes, "赎回费按基金赎回费率档位及每笔份额的持有天数计算")
+
+	_, err = BacktestSIP(navs, nil, SIPOptions{Frequency: "daily"})
+	require.NotNil(t, err)
+	_, err = BacktestSIP(navs, nil, SIPOptions{StartDate: "2023-01-01"})
+	require.NotNil(t, err)
+}
+
+func TestBacktestSIPDividend(t *testing.T) {
+	navs := testNavs(1, 1, 0.9, 0.9, 0.45)
+	navs[2].Dividend = 0.1
+	navs[4].SplitRatio = 2
+	opts := SIPOptions{Frequency: SIPFrequencyWeekly, Day: 1, Amount: 900, Reinvest: true}
+	r, err := BacktestSIP(navs, nil, opts)
+	require.Nil(t, err)
+	require.Equal(t, 1, r.Periods)
+	// 900 份再投资 90 元得 100 份，折算后 2000 份
+	require.InDelta(t, 2000, r.FinalShares, 1e-9)
+	require.InDelta(t, 900, r.MarketValue, 1e-9)
+	require.InDelta(t, 0, r.TotalReturn, 1e-9)
+	require.InDelta(t, 0, r.MaxDrawdown, 1e-9)
+
+	// 折算后的份额仍按原批次计算赎回费
+	opts.RedemptionTiers = []SIPRedemptionTier{{MinDays: 0, MaxDays: 3, Rate: 1.5}, {MinDays: 3, Rate: 0.5}}
+	r, err = BacktestSIP(navs, nil, opts)
+	require.Nil(t, err)
+	// 1800 份持有 4 天，200 份再投资份额持有 2 天
+	require.InDelta(t, 1800*0.45*0.005+200*0.45*0.015, r.RedemptionFee, 1e-9)
+	opts.RedemptionTiers = nil
+
+	opts.Reinvest = false
+	r, err = BacktestSIP(navs, nil, opts)
+	require.Nil(t, err)
+	require.InDelta(t, 1800, r.FinalShares, 1e-9)
+	require.InDelta(t, 90, r.CashDividends, 1e-9)
+	require.InDelta(t, 900, r.FinalValue, 1e-9)
+	require.Equal(t, SIPFlowDividend, r.CashFlows[1].Type)
+	require.Equal(t, SIPFlowSplit, r.CashFlows[2].Type)
+}
+
+func TestBacktestSIPValuationScaled(t *testing.T) {
+	values := []ValuationPoint{}
+	navs := []NavPoint{}
+	day := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < 120; i++ {
+		date := day.AddDate(0, 0, i).Format(DateLayout)
+		navs = append(navs, NavPoint{Date: date, Nav: 1})
+		// 前 60 天估值上升，之后回落到最低
+		v := float64(i)
+		if i >= 60 {
+			v = -float64(i)
+		}
+		values = append(values, ValuationPoint{Date: date, Value: v})
+	}
+	opts := SIPOptions{
+		StartDate:       "2022-02-28",
+		Frequency:       SIPFrequencyMonthly,
+		Day:             28,
+		Amount:          1000,
+		ValuationScaled: true,
+	}
+	r, err := BacktestSIP(navs, values, opts)
+	require.Nil(t, err)
+	// 02-28 估值处于最高，暂停定投；03-28、04-28 估值处于最低，加倍定投
+	require.Equal(t, 1, r.SkippedPeriods)
+	require.Equal(t, 2, r.Periods)
+	require.Equal(t, SIPFlowSkip, r.CashFlows[0].Type)
+	require.InDelta(t, 100, r.CashFlows[0].Percentile, 1e-9)
+	require.InDelta(t, 4000, r.TotalInvested, 1e-9)
+	require.Equal(t, 2.0, r.CashFlows[1].Multiple)
+
+	// 未提供估值时使用复权净值分位并说明
+	for i := range navs {
+		navs[i].Nav = 200 + values[i].Value
+	}
+	r, err = BacktestSIP(navs, nil, opts)
+	require.Nil(t, err)
+	require.Equal(t, 1, r.SkippedPeriods)
+	require.Equal(t, 2, r.Periods)
+	require.Len(t, r.Notes, 1)
+}
+
+func TestXIRR(t *testing.T) {
+	start := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	rate, ok := xirr([]xirrFlow{
+		{date: start, amount: -1000},
+		{date: start.AddDate(0, 0, 365), amount: 1100},
+	})
+	require.True(t, ok)
+	require.InDelta(t, 0.1, rate, 1e-6)
+	_, ok = xirr([]xirrFlow{{date: start, amount: -1000}, {date: start.AddDate(1, 0, 0), amount: -1}})
+	require.False(t, ok)
+}
+
+func TestParseValuations(t *testing.T) {
+	points, err := ParseValuations(strings.NewReader("date,pe\n2022-01-04, 12.5\n\n2022-01-05,13\n"))
+	require.Nil(t, err)
+	require.Equal(t, []ValuationPoint{{Date: "2022-01-04", Value: 12.5}, {Date: "2022-01-05", Value: 13}}, points)
+	_, err = ParseValuations(strings.NewReader("2022-01-04,12.5\n2022-01-05,x\n"))
+	require.NotNil(t, err)
+}
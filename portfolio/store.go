@@ -0,0 +1,211 @@
+// 持仓账本的本地 json 文件存储
+
+package portfolio
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// LedgerFilename 持仓账本文件
+var LedgerFilename = "./portfolio.json"
+
+// ErrAccountNotFound 账户不存在
+var ErrAccountNotFound = errors.New("account not found")
+
+// storeMutex 串行化账本文件的读写
+var storeMutex sync.Mutex
+
+// Ledger 持仓账本
+type Ledger struct {
+	Accounts []Account `json:"accounts"`
+}
+
+// account 返回账户在账本中的位置，不存在时返回 -1
+func (l Ledger) account(name string) int {
+	for i, a := range l.Accounts {
+		if a.Name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// Store 持仓账本存储
+type Store struct {
+	Filename string
+}
+
+// NewStore 创建持仓账本存储，filename 为空时使用 LedgerFilename
+func NewStore(filename string) Store {
+	if filename == "" {
+		filename = LedgerFilename
+	}
+	return Store{Filename: filename}
+}
+
+// load 读取账本，文件不存在时返回空账本
+func (s Store) load() (Ledger, error) {
+	l := Ledger{Accounts: []Account{}}
+	b, err := ioutil.ReadFile(s.Filename)
+	if os.IsNotExist(err) {
+		return l, nil
+	}
+	if err != nil {
+		return l, err
+	}
+	if err := json.Unmarshal(b, &l); err != nil {
+		return l, fmt.Errorf("parse %s error: %w", s.Filename, err)
+	}
+	return l, nil
+}
+
+// save 写入账本，先写临时文件再替换，避免写入中断损坏账本
+func (s Store) save(l Ledger) error {
+	b, err := json.MarshalIndent(l, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp, err := ioutil.TempFile(filepath.Dir(s.Filename), ".portfolio-*.json")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(b); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), s.Filename)
+}
+
+// update 读取账本并在 fn 成功后写回
+func (s Store) update(fn func(l *Ledger) error) error {
+	storeMutex.Lock()
+	defer storeMutex.Unlock()
+	l, err := s.load()
+	if err != nil {
+		return err
+	}
+	if err := fn(&l); err != nil {
+		return err
+	}
+	return s.save(l)
+}
+
+// Accounts 返回全部账户
+func (s Store) Accounts() ([]Account, error) {
+	storeMutex.Lock()
+	defer storeMutex.Unlock()
+	l, err := s.load()
+	return l.Accounts, err
+}
+
+// Account 返回指定名称的账户
+func (s Store) Account(name string) (Account, error) {
+	storeMutex.Lock()
+	defer storeMutex.Unlock()
+	l, err := s.load()
+	if err != nil {
+		return Account{}, err
+	}
+	i := l.account(name)
+	if i < 0 {
+		return Account{}, fmt.Errorf("%w: %s", ErrAccountNotFound, name)
+	}
+	return l.Accounts[i], nil
+}
+
+// AddAccount 新建账户
+func (s Store) AddAccount(a Account) error {
+	if err := a.Validate(); err != nil {
+		return err
+	}
+	if a.Transactions == nil {
+		a.Transactions = []Transaction{}
+	}
+	for i := range a.Transactions {
+		a.Transactions[i].Code = NormalizeCode(a.Transactions[i].Code)
+	}
+	return s.update(func(l *Ledger) error {
+		if l.account(a.Name) >= 0 {
+			return fmt.Errorf("account %s already exists", a.Name)
+		}
+		l.Accounts = append(l.Accounts, a)
+		return nil
+	})
+}
+
+// DeleteAccount 删除账户及其交易记录
+func (s Store) DeleteAccount(name string) error {
+	return s.update(func(l *Ledger) error {
+		i := l.account(name)
+		if i < 0 {
+			return fmt.Errorf("%w: %s", ErrAccountNotFound, name)
+		}
+		l.Accounts = append(l.Accounts[:i], l.Accounts[i+1:]...)
+		return nil
+	})
+}
+
+// AddTransaction 向账户添加交易记录，股票代码统一为 6 位数字代码，返回分配了编号的交易记录。
+// 添加后持仓无法计算（如卖出超过持仓）时不保存
+func (s Store) AddTransaction(name string, tx Transaction) (Transaction, error) {
+	tx.Code = NormalizeCode(tx.Code)
+	if err := tx.Validate(); err != nil {
+		return tx, err
+	}
+	err := s.update(func(l *Ledger) error {
+		i := l.account(name)
+		if i < 0 {
+			return fmt.Errorf("%w: %s", ErrAccountNotFound, name)
+		}
+		a := l.Accounts[i]
+		tx.ID = 1
+		for _, t := range a.Transactions {
+			if t.ID >= tx.ID {
+				tx.ID = t.ID + 1
+			}
+		}
+		a.Transactions = append(append([]Transaction{}, a.Transactions...), tx)
+		if _, err := a.Positions(); err != nil {
+			return err
+		}
+		l.Accounts[i] = a
+		return nil
+	})
+	return tx, err
+}
+
+// DeleteTransaction 删除账户中指定编号的交易记录，删除后持仓无法计算时不保存
+func (s Store) DeleteTransaction(name string, id int) error {
+	return s.update(func(l *Ledger) error {
+		i := l.account(name)
+		if i < 0 {
+			return fmt.Errorf("%w: %s", ErrAccountNotFound, name)
+		}
+		a := l.Accounts[i]
+		txs := []Transaction{}
+		for _, t := range a.Transactions {
+			if t.ID != id {
+				txs = append(txs, t)
+			}
+		}
+		if len(txs) == len(a.Transactions) {
+			return fmt.Errorf("transaction %d not found in %s", id, name)
+		}
+		a.Transactions = txs
+		if _, err := a.Positions(); err != nil {
+			return err
+		}
+		l.Accounts[i] = a
+		return nil
+	})
+}
@@ -0,0 +1,48 @@
+package portfolio
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestStore(t *testing.T) {
+	s := NewStore(filepath.Join(t.TempDir(), "portfolio.json"))
+	accounts, err := s.Accounts()
+	require.Nil(t, err)
+	require.Empty(t, accounts)
+
+	require.Nil(t, s.AddAccount(Account{Name: "main"}))
+	require.Error(t, s.AddAccount(Account{Name: "main"}))
+
+	tx, err := s.AddTransaction("main", Transaction{Date: "2022-01-05", Type: TransactionBuy, Code: "600519", Shares: 100, Price: 10})
+	require.Nil(t, err)
+	require.Equal(t, 1, tx.ID)
+	// 代码统一为 6 位数字代码，与买入记录合并计算持仓
+	tx, err = s.AddTransaction("main", Transaction{Date: "2022-02-05", Type: TransactionSell, Code: " sh600519", Shares: 50, Price: 12})
+	require.Nil(t, err)
+	require.Equal(t, 2, tx.ID)
+	require.Equal(t, "600519", tx.Code)
+
+	// 卖出超过持仓不保存
+	_, err = s.AddTransaction("main", Transaction{Date: "2022-03-05", Type: TransactionSell, Code: "600519", Shares: 100, Price: 12})
+	require.Error(t, err)
+	// 删除买入后卖出无持仓，不保存
+	require.Error(t, s.DeleteTransaction("main", 1))
+
+	a, err := s.Account("main")
+	require.Nil(t, err)
+	require.Len(t, a.Transactions, 2)
+
+	require.Nil(t, s.DeleteTransaction("main", 2))
+	require.Error(t, s.DeleteTransaction("main", 2))
+
+	_, err = s.Account("other")
+	require.True(t, errors.Is(err, ErrAccountNotFound))
+	require.Nil(t, s.DeleteAccount("main"))
+	accounts, err = s.Accounts()
+	require.Nil(t, err)
+	require.Empty(t, accounts)
+}
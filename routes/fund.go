@@ -3,7 +3,11 @@
 package routes
 
 import (
+	"errors"
+	"fmt"
 	"net/http"
+	"strconv"
+	"strings"
 	"sync"
 
 	"github.com/axiaoxin-com/goutils"
@@ -69,6 +73,8 @@ func FundIndex(c *gin.Context) {
 		"AllFundCount":  len(models.FundAllList),
 		"Fund4433Count": totalCount,
 		"FundTypes":     models.Fund4433TypeList,
+		"RuleSetNames":  core.RuleSetNames(),
+		"FundRuleSets":  models.FundRuleSets,
 	}
 	c.HTML(http.StatusOK, "fund_index.html", data)
 	return
@@ -165,6 +171,8 @@ type ParamFundCheck struct {
 	Max135AvgRetr float64 `json:"max_135_avg_retr"         form:"max_135_avg_retr"`
 	// 是否检测持仓个股
 	CheckStocks bool `json:"check_stocks"             form:"check_stocks"`
+	// 是否计算债券型基金近3年最大回撤修复时间
+	CheckBondRecovery bool `json:"check_bond_recovery"      form:"check_bond_recovery"`
 	// 股票检测参数
 	StockCheckerOptions core.CheckerOptions
 }
@@ -222,15 +230,24 @@ func FundCheck(c *gin.Context) {
 		c.JSON(http.StatusOK, data)
 		return
 	}
+	// 综合评分需要同类型基金数据，使用同步的全量基金列表中的评分
+	models.FundAllList.FillScores(funds)
+	// 费率表不随基金数据获取，使用单独同步的费率表
+	models.FundFeeTable.FillFees(funds)
+	bondRecoveries := map[string]models.FundDrawdownRecovery{}
+	if p.CheckBondRecovery && len(funds) <= 50 {
+		bondRecoveries = core.BondFundsDrawdownRecovery(c, funds, 3)
+	}
 
 	if !p.CheckStocks {
 		data := gin.H{
-			"Env":       viper.GetString("env"),
-			"HostURL":   viper.GetString("server.host_url"),
-			"Version":   version.Version,
-			"PageTitle": "InvesTool | 基金 | 基金检测",
-			"Funds":     funds,
-			"Param":     p,
+			"Env":            viper.GetString("env"),
+			"HostURL":        viper.GetString("server.host_url"),
+			"Version":        version.Version,
+			"PageTitle":      "InvesTool | 基金 | 基金检测",
+			"Funds":          funds,
+			"BondRecoveries": bondRecoveries,
+			"Param":          p,
 		}
 		c.JSON(http.StatusOK, data)
 		return
@@ -277,6 +294,7 @@ func FundCheck(c *gin.Context) {
 		"PageTitle":         "InvesTool | 基金 | 基金检测",
 		"Funds":             funds,
 		"StockCheckResults": stockCheckResults,
+		"BondRecoveries":    bondRecoveries,
 		"Param":             p,
 	}
 	c.JSON(http.StatusOK, data)
@@ -286,6 +304,8 @@ func FundCheck(c *gin.Context) {
 // ParamFundSimilarity FundSimilarity 请求参数
 type ParamFundSimilarity struct {
 	Codes string `json:"codes" form:"codes"`
+	// 相近基金聚类阈值，权重重合度（%）
+	Threshold float64 `json:"threshold" form:"threshold"`
 }
 
 // FundSimilarity 基金持仓相似度
@@ -315,7 +335,7 @@ func FundSimilarity(c *gin.Context) {
 	}
 	codeList := goutils.SplitStringFields(p.Codes)
 	checker := core.NewChecker(c, core.DefaultCheckerOptions)
-	result, err := checker.GetFundStocksSimilarity(c, codeList)
+	result, overlap, err := checker.GetFundSimilarity(c, codeList, p.Threshold)
 	if err != nil {
 		data := gin.H{
 			"Env":       viper.GetString("env"),
@@ -333,6 +353,8 @@ func FundSimilarity(c *gin.Context) {
 		"Version":   version.Version,
 		"PageTitle": "InvesTool | 基金 | 持仓相似度",
 		"Result":    result,
+		"Overlap":   overlap,
+		"Param":     p,
 	}
 	c.HTML(http.StatusOK, "fund_similarity.html", data)
 	return
@@ -451,3 +473,338 @@ func FundManagers(c *gin.Context) {
 	c.HTML(http.StatusOK, "fund_managers.html", data)
 	return
 }
+
+// ParamFundManagerCompare FundManagerCompare 请求参数
+type ParamFundManagerCompare struct {
+	// 基金经理 ID 或姓名，多个用英文逗号或空格分隔
+	IDs string `json:"ids" form:"ids"`
+}
+
+// FundManagerCompare 基金经理对比
+func FundManagerCompare(c *gin.Context) {
+	data := gin.H{
+		"Env":       viper.GetString("env"),
+		"HostURL":   viper.GetString("server.host_url"),
+		"Version":   version.Version,
+		"PageTitle": "InvesTool | 基金 | 基金经理对比",
+		"Error":     "",
+	}
+	p := ParamFundManagerCompare{}
+	if err := c.ShouldBind(&p); err != nil {
+		data["Error"] = err.Error()
+		c.HTML(http.StatusOK, "fund_managers.html", data)
+		return
+	}
+	data["CompareParams"] = p
+	result, err := core.CompareFundManagers(c, goutils.SplitStringFields(p.IDs))
+	if err != nil {
+		data["Error"] = err.Error()
+		c.HTML(http.StatusOK, "fund_managers.html", data)
+		return
+	}
+	data["Comparison"] = result
+	c.HTML(http.StatusOK, "fund_managers.html", data)
+	return
+}
+
+// ParamFundManagerCompareAPI FundManagerCompareAPI 请求参数
+type ParamFundManagerCompareAPI struct {
+	// 基金经理 ID 或姓名
+	IDs []string `json:"ids" binding:"required"`
+}
+
+// FundManagerCompareAPI 基金经理对比API
+func FundManagerCompareAPI(c *gin.Context) {
+	data := gin.H{
+		"Error":  "",
+		"Result": nil,
+	}
+	p := ParamFundManagerCompareAPI{}
+	if err := c.ShouldBindJSON(&p); err != nil {
+		data["Error"] = err.Error()
+		c.JSON(http.StatusOK, data)
+		return
+	}
+	result, err := core.CompareFundManagers(c, p.IDs)
+	if err != nil {
+		data["Error"] = err.Error()
+		c.JSON(http.StatusOK, data)
+		return
+	}
+	data["Result"] = result
+	c.JSON(http.StatusOK, data)
+	return
+}
+
+// FundOverlapMatrix 基金两两持仓重合度矩阵API
+func FundOverlapMatrix(c *gin.Context) {
+	data := gin.H{
+		"Error":  "",
+		"Result": nil,
+	}
+	p := ParamFundSimilarity{}
+	if err := c.ShouldBind(&p); err != nil {
+		data["Error"] = err.Error()
+		c.JSON(http.StatusOK, data)
+		return
+	}
+	codeList := goutils.SplitStringFields(p.Codes)
+	if len(codeList) == 0 {
+		data["Error"] = "请填写待检测的基金代码"
+		c.JSON(http.StatusOK, data)
+		return
+	}
+	checker := core.NewChecker(c, core.DefaultCheckerOptions)
+	result, err := checker.GetFundOverlapMatrix(c, codeList, p.Threshold)
+	if err != nil {
+		data["Error"] = err.Error()
+		c.JSON(http.StatusOK, data)
+		return
+	}
+	data["Result"] = result
+	c.JSON(http.StatusOK, data)
+	return
+}
+
+// ParamFundExposure FundExposure 请求参数
+type ParamFundExposure struct {
+	// 每行一只基金: 基金代码 投资金额
+	Funds string `json:"funds"         form:"funds"`
+	// 返回的重复持仓数量
+	OverlapLimit int `json:"overlap_limit" form:"overlap_limit"`
+}
+
+// ParamFundExposureAPI FundExposureAPI 请求参数
+type ParamFundExposureAPI struct {
+	Investments  []core.FundInvestment `json:"investments"   binding:"required"`
+	OverlapLimit int                   `json:"overlap_limit"`
+}
+
+// defaultOverlapLimit 默认返回的重复持仓数量
+const defaultOverlapLimit = 20
+
+// parseFundInvestments 解析每行 基金代码 投资金额 格式的文本，代码与金额可用空格、英文逗号或冒号分隔
+func parseFundInvestments(s string) ([]core.FundInvestment, error) {
+	investments := []core.FundInvestment{}
+	for _, line := range strings.Split(s, "\n") {
+		fields := strings.FieldsFunc(line, func(r rune) bool {
+			return r == ' ' || r == '\t' || r == ',' || r == ':' || r == '\r'
+		})
+		if len(fields) == 0 {
+			continue
+		}
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("格式错误: %s", line)
+		}
+		amount, err := strconv.ParseFloat(fields[1], 64)
+		if err != nil {
+			return nil, fmt.Errorf("金额错误: %s", line)
+		}
+		investments = append(investments, core.FundInvestment{Code: fields[0], Amount: amount})
+	}
+	if len(investments) == 0 {
+		return nil, errors.New("请填写基金代码及投资金额")
+	}
+	return investments, nil
+}
+
+// FundExposure 基金组合穿透分析
+func FundExposure(c *gin.Context) {
+	data := gin.H{
+		"Env":       viper.GetString("env"),
+		"HostURL":   viper.GetString("server.host_url"),
+		"Version":   version.Version,
+		"PageTitle": "InvesTool | 基金 | 穿透分析",
+		"Error":     "",
+	}
+	p := ParamFundExposure{}
+	if err := c.ShouldBind(&p); err != nil {
+		data["Error"] = err.Error()
+		c.HTML(http.StatusOK, "fund_exposure.html", data)
+		return
+	}
+	data["Param"] = p
+	investments, err := parseFundInvestments(p.Funds)
+	if err != nil {
+		data["Error"] = err.Error()
+		c.HTML(http.StatusOK, "fund_exposure.html", data)
+		return
+	}
+	if p.OverlapLimit == 0 {
+		p.OverlapLimit = defaultOverlapLimit
+	}
+	result, err := core.GetFundExposure(c, investments, p.OverlapLimit)
+	if err != nil {
+		data["Error"] = err.Error()
+		c.HTML(http.StatusOK, "fund_exposure.html", data)
+		return
+	}
+	data["Result"] = result
+	c.HTML(http.StatusOK, "fund_exposure.html", data)
+	return
+}
+
+// FundExposureAPI 基金组合穿透分析API
+func FundExposureAPI(c *gin.Context) {
+	data := gin.H{
+		"Error":  "",
+		"Result": nil,
+	}
+	p := ParamFundExposureAPI{}
+	if err := c.ShouldBindJSON(&p); err != nil {
+		data["Error"] = err.Error()
+		c.JSON(http.StatusOK, data)
+		return
+	}
+	if p.OverlapLimit == 0 {
+		p.OverlapLimit = defaultOverlapLimit
+	}
+	result, err := core.GetFundExposure(c, p.Investments, p.OverlapLimit)
+	if err != nil {
+		data["Error"] = err.Error()
+		c.JSON(http.StatusOK, data)
+		return
+	}
+	data["Result"] = result
+	c.JSON(http.StatusOK, data)
+	return
+}
+
+// ParamFundRuleSet FundRuleSet 请求参数
+type ParamFundRuleSet struct {
+	ParamFundIndex
+	// 检测不满足条件的基金代码
+	Code string `json:"code" form:"code"`
+}
+
+// fundRuleSetExplain 返回基金在规则集下的条件检测结果，优先使用全量基金列表中的数据
+func fundRuleSetExplain(c *gin.Context, rs models.FundRuleSet, code string) (*models.Fund, []models.FundCondition, error) {
+	var fund *models.Fund
+	for _, f := range models.FundAllList {
+		if f != nil && f.Code == code {
+			fund = f
+			break
+		}
+	}
+	if fund == nil {
+		funds, err := core.NewSearcher(c).SearchFunds(c, []string{code})
+		if err != nil {
+			return nil, nil, err
+		}
+		fund = funds[code]
+	}
+	if fund == nil {
+		return nil, nil, fmt.Errorf("基金 %s 不存在", code)
+	}
+	return fund, rs.Explain(c, *fund), nil
+}
+
+// FundRuleSet 基金规则集列表
+func FundRuleSet(c *gin.Context) {
+	name := c.Param("name")
+	data := gin.H{
+		"Env":          viper.GetString("env"),
+		"HostURL":      viper.GetString("server.host_url"),
+		"Version":      version.Version,
+		"PageTitle":    "InvesTool | 基金 | 规则集 " + name,
+		"Error":        "",
+		"FundRuleSets": models.FundRuleSets,
+	}
+	rs, exists := models.GetFundRuleSet(name)
+	if !exists {
+		data["Error"] = "规则集不存在: " + name
+		c.HTML(http.StatusOK, "fund_ruleset.html", data)
+		return
+	}
+	data["RuleSet"] = rs
+	// 只展示条件要求，成立日期设为 -- 避免解析空日期
+	data["Conditions"] = rs.Explain(c, models.Fund{EstablishedDate: "--"})
+	p := ParamFundRuleSet{
+		ParamFundIndex: ParamFundIndex{
+			PageNum:  1,
+			PageSize: 10,
+			Sort:     int(rs.Sort),
+		},
+	}
+	if err := c.ShouldBind(&p); err != nil {
+		data["Error"] = err.Error()
+		c.HTML(http.StatusOK, "fund_ruleset.html", data)
+		return
+	}
+
+	allList := models.FundRuleSetLists[rs.Name]
+	fundList := make(models.FundList, len(allList))
+	copy(fundList, allList)
+	// 过滤
+	if p.Type != "" {
+		fundList = fundList.FilterByType(p.Type)
+	}
+	// 排序
+	fundList.Sort(models.FundSortType(p.Sort))
+	// 分页
+	totalCount := len(fundList)
+	pagi := goutils.PaginateByPageNumSize(totalCount, p.PageNum, p.PageSize)
+	data["URLPath"] = viper.GetString("server.host_url") + "/fund/rules/" + rs.Name
+	data["FundList"] = fundList[pagi.StartIndex:pagi.EndIndex]
+	data["Pagination"] = pagi
+	data["IndexParam"] = p.ParamFundIndex
+	data["UpdatedAt"] = models.SyncFundTime.Format("2006-01-02 15:04:05")
+	data["AllFundCount"] = len(models.FundAllList)
+	data["RuleSetFundCount"] = len(allList)
+	data["FundTypes"] = allList.Types()
+	data["Code"] = p.Code
+
+	if p.Code != "" {
+		fund, conds, err := fundRuleSetExplain(c, rs, p.Code)
+		if err != nil {
+			data["Error"] = err.Error()
+		} else {
+			data["ExplainFund"] = fund
+			data["Explain"] = conds
+		}
+	}
+	c.HTML(http.StatusOK, "fund_ruleset.html", data)
+	return
+}
+
+// FundRuleSetExplain 基金规则集条件检测API
+func FundRuleSetExplain(c *gin.Context) {
+	data := gin.H{
+		"Error":  "",
+		"Result": nil,
+	}
+	rs, exists := models.GetFundRuleSet(c.Param("name"))
+	if !exists {
+		data["Error"] = "规则集不存在: " + c.Param("name")
+		c.JSON(http.StatusOK, data)
+		return
+	}
+	code := strings.TrimSpace(c.Query("code"))
+	if code == "" {
+		data["Error"] = "请填写基金代码"
+		c.JSON(http.StatusOK, data)
+		return
+	}
+	fund, conds, err := fundRuleSetExplain(c, rs, code)
+	if err != nil {
+		data["Error"] = err.Error()
+		c.JSON(http.StatusOK, data)
+		return
+	}
+	passed := true
+	for _, cond := range conds {
+		if !cond.Passed {
+			passed = false
+			break
+		}
+	}
+	data["Result"] = gin.H{
+		"rule_set":   rs,
+		"code":       fund.Code,
+		"name":       fund.Name,
+		"passed":     passed,
+		"conditions": conds,
+	}
+	c.JSON(http.StatusOK, data)
+	return
+}
@@ -0,0 +1,77 @@
+// 基金持有成本
+
+package routes
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/axiaoxin-com/investool/core"
+	"github.com/gin-gonic/gin"
+)
+
+// ParamFundCost FundCost 请求参数
+type ParamFundCost struct {
+	// 基金代码
+	Code string `json:"code"   form:"code"   binding:"required"`
+	// 申购金额（元）
+	Amount float64 `json:"amount" form:"amount" binding:"required"`
+	// 持有天数
+	Days int `json:"days"   form:"days"`
+}
+
+// FundCost 基金持有成本API，返回费率表及申购金额持有指定天数的总成本
+func FundCost(c *gin.Context) {
+	data := gin.H{
+		"Error":  "",
+		"Result": nil,
+	}
+	p := ParamFundCost{}
+	if err := c.ShouldBind(&p); err != nil {
+		data["Error"] = err.Error()
+		c.JSON(http.StatusOK, data)
+		return
+	}
+	result, err := core.FundTotalCost(c, strings.TrimSpace(p.Code), p.Amount, p.Days)
+	if err != nil {
+		data["Error"] = err.Error()
+		c.JSON(http.StatusOK, data)
+		return
+	}
+	data["Result"] = result
+	c.JSON(http.StatusOK, data)
+	return
+}
+
+// ParamFundShareClassCompare FundShareClassCompare 请求参数
+type ParamFundShareClassCompare struct {
+	// A 类份额基金代码，code_c 为空时可为 A/C 任一份额的代码
+	Code string `json:"code"   form:"code"   binding:"required"`
+	// C 类份额基金代码，为空时按基金名称搜索
+	CodeC string `json:"code_c" form:"code_c"`
+	// 申购金额（元）
+	Amount float64 `json:"amount" form:"amount" binding:"required"`
+}
+
+// FundShareClassCompare 基金 A/C 份额持有成本对比API
+func FundShareClassCompare(c *gin.Context) {
+	data := gin.H{
+		"Error":  "",
+		"Result": nil,
+	}
+	p := ParamFundShareClassCompare{}
+	if err := c.ShouldBind(&p); err != nil {
+		data["Error"] = err.Error()
+		c.JSON(http.StatusOK, data)
+		return
+	}
+	result, err := core.CompareFundShareClasses(c, strings.TrimSpace(p.Code), strings.TrimSpace(p.CodeC), p.Amount)
+	if err != nil {
+		data["Error"] = err.Error()
+		c.JSON(http.StatusOK, data)
+		return
+	}
+	data["Result"] = result
+	c.JSON(http.StatusOK, data)
+	return
+}
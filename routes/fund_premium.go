@@ -0,0 +1,54 @@
+// 场内基金溢价率
+
+package routes
+
+import (
+	"net/http"
+
+	"github.com/axiaoxin-com/goutils"
+	"github.com/axiaoxin-com/investool/core"
+	"github.com/axiaoxin-com/investool/models"
+	"github.com/gin-gonic/gin"
+)
+
+// ParamFundPremium FundPremium 请求参数
+type ParamFundPremium struct {
+	// 场内基金代码，多个用英文逗号或空格分隔
+	Codes string `json:"codes"     form:"codes"     binding:"required"`
+	// 历史溢价率及跟踪误差的计算天数
+	Days int `json:"days"      form:"days"`
+	// 溢价率提示阈值（%）
+	Threshold float64 `json:"threshold" form:"threshold"`
+}
+
+// defaultFundPremiumDays 默认历史溢价率计算天数
+const defaultFundPremiumDays = 90
+
+// FundPremium 场内基金溢价率监控API
+func FundPremium(c *gin.Context) {
+	data := gin.H{
+		"Error":  "",
+		"Result": nil,
+	}
+	p := ParamFundPremium{}
+	if err := c.ShouldBind(&p); err != nil {
+		data["Error"] = err.Error()
+		c.JSON(http.StatusOK, data)
+		return
+	}
+	if p.Days <= 0 {
+		p.Days = defaultFundPremiumDays
+	}
+	if p.Threshold <= 0 {
+		p.Threshold = models.DefaultFundPremiumThreshold
+	}
+	codes := goutils.SplitStringFields(p.Codes)
+	if len(codes) == 0 {
+		data["Error"] = "请填写场内基金代码"
+		c.JSON(http.StatusOK, data)
+		return
+	}
+	data["Result"] = core.FundsPremiumMonitor(c, codes, p.Days, p.Threshold)
+	c.JSON(http.StatusOK, data)
+	return
+}
@@ -0,0 +1,25 @@
+package routes
+
+import (
+	"testing"
+
+	"github.com/axiaoxin-com/investool/core"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseFundInvestments(t *testing.T) {
+	investments, err := parseFundInvestments("161725 10000\r\n\n005827:5000.5\n110011,2000")
+	require.Nil(t, err)
+	require.Equal(t, []core.FundInvestment{
+		{Code: "161725", Amount: 10000},
+		{Code: "005827", Amount: 5000.5},
+		{Code: "110011", Amount: 2000},
+	}, investments)
+
+	_, err = parseFundInvestments("161725")
+	require.Error(t, err)
+	_, err = parseFundInvestments("161725 abc")
+	require.Error(t, err)
+	_, err = parseFundInvestments("")
+	require.Error(t, err)
+}
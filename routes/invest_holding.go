@@ -3,11 +3,14 @@
 package routes
 
 import (
+	"errors"
 	"math"
 	"net/http"
+	"sort"
 
 	"github.com/axiaoxin-com/investool/core"
 	"github.com/axiaoxin-com/investool/models"
+	"github.com/axiaoxin-com/investool/portfolio"
 	"github.com/axiaoxin-com/investool/version"
 	"github.com/gin-gonic/gin"
 	"github.com/spf13/viper"
@@ -76,6 +79,7 @@ func QueryStockDataHandler(c *gin.Context) {
 	// 获取第一个匹配的股票数据
 	var stockData gin.H
 	for _, stock := range stocks {
+		stock.SetIndustryBuffettScore(models.IndustryBuffettScoreTable)
 		stockData = gin.H{
 			"name":          stock.BaseInfo.SecurityNameAbbr,
 			"code":          stock.BaseInfo.Secucode,
@@ -85,18 +89,22 @@ func QueryStockDataHandler(c *gin.Context) {
 			"market_cap":    stock.BaseInfo.TotalMarketCap,
 			"current_price": stock.BaseInfo.NewPrice,
 			"buffett_score": gin.H{
-				"total_score":         stock.BuffettScore.TotalScore,
-				"roe_score":           stock.BuffettScore.ROEScore,
-				"cash_flow_score":     stock.BuffettScore.CashFlowScore,
-				"profit_growth_score": stock.BuffettScore.ProfitGrowthScore,
-				"debt_ratio_score":    stock.BuffettScore.DebtRatioScore,
-				"moat_score":          stock.BuffettScore.MoatScore,
-				"management_score":    stock.BuffettScore.ManagementScore,
-				"valuation_score":     stock.BuffettScore.ValuationScore,
-				"rd_score":            stock.BuffettScore.RDScore,
-				"dividend_score":      stock.BuffettScore.DividendScore,
-				"repurchase_score":    stock.BuffettScore.RepurchaseScore,
-				"score_description":   stock.BuffettScore.ScoreDescription,
+				"total_score":          stock.BuffettScore.TotalScore,
+				"roe_score":            stock.BuffettScore.ROEScore,
+				"cash_flow_score":      stock.BuffettScore.CashFlowScore,
+				"profit_growth_score":  stock.BuffettScore.ProfitGrowthScore,
+				"debt_ratio_score":     stock.BuffettScore.DebtRatioScore,
+				"moat_score":           stock.BuffettScore.MoatScore,
+				"management_score":     stock.BuffettScore.ManagementScore,
+				"valuation_score":      stock.BuffettScore.ValuationScore,
+				"rd_score":             stock.BuffettScore.RDScore,
+				"dividend_score":       stock.BuffettScore.DividendScore,
+				"repurchase_score":     stock.BuffettScore.RepurchaseScore,
+				"score_description":    stock.BuffettScore.ScoreDescription,
+				"components":           stock.BuffettScore.Components,
+				"industry_avg_score":   stock.BuffettScore.IndustryAvgScore,
+				"industry_stock_count": stock.BuffettScore.IndustryStockCount,
+				"vs_industry":          stock.BuffettScore.VsIndustry(),
 			},
 		}
 		break
@@ -219,7 +227,7 @@ func CalculatePositionHandler(c *gin.Context) {
 	return
 }
 
-// PositionDeviationHandler 计算仓位偏离度API
+// PositionDeviationHandler 计算仓位偏离度API，持仓从持仓账本读取
 func PositionDeviationHandler(c *gin.Context) {
 	data := gin.H{
 		"HostURL":   viper.GetString("server.host_url"),
@@ -231,12 +239,13 @@ func PositionDeviationHandler(c *gin.Context) {
 	}
 
 	var req struct {
-		Holdings []struct {
-			StockName string `json:"stock_name" binding:"required"`
-			Shares    int    `json:"shares" binding:"required"`
-			Expect    int    `json:"expect"`
-			Tech      int    `json:"tech"`
-		} `json:"holdings" binding:"required"`
+		// 持仓账户名称
+		Account string `json:"account" binding:"required"`
+		// 各股票的市场预期值和技术面评分，key 为股票代码，未指定时使用中性值
+		Views map[string]struct {
+			Expect int `json:"expect"`
+			Tech   int `json:"tech"`
+		} `json:"views"`
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -245,8 +254,9 @@ func PositionDeviationHandler(c *gin.Context) {
 		return
 	}
 
-	if len(req.Holdings) == 0 {
-		data["Error"] = "持仓列表不能为空"
+	summary, stocksMap, err := loadPortfolioStocks(c, req.Account, nil)
+	if err != nil {
+		data["Error"] = err.Error()
 		c.JSON(http.StatusOK, data)
 		return
 	}
@@ -255,17 +265,16 @@ func PositionDeviationHandler(c *gin.Context) {
 	totalCurrentPosition := 0.0
 	totalTargetPosition := 0.0
 
-	// 使用现有的搜索功能获取股票数据
-	searcher := core.NewSearcher(c)
-
-	for _, holding := range req.Holdings {
-		// 查询股票数据
-		stocksMap, err := searcher.SearchStocks(c, []string{holding.StockName})
-		if err != nil || len(stocksMap) == 0 {
+	for _, holding := range summary.OpenPositions() {
+		stock, exists := stocksMap[holding.Code]
+		if !exists {
 			// 如果查询失败，使用默认值
 			result := gin.H{
-				"stock_name":        holding.StockName,
+				"code":              holding.Code,
+				"stock_name":        holding.Name,
 				"shares":            holding.Shares,
+				"avg_cost":          holding.AvgCost,
+				"cost_basis":        holding.CostBasis,
 				"current_price":     0,
 				"current_amount":    0,
 				"target_amount":     0,
@@ -277,22 +286,12 @@ func PositionDeviationHandler(c *gin.Context) {
 			results = append(results, result)
 			continue
 		}
-
-		// 获取第一个股票数据
-		var stock models.Stock
-		for _, s := range stocksMap {
-			stock = s
-			break
-		}
-		currentPrice := 0.0
-		if price, ok := stock.BaseInfo.NewPrice.(float64); ok {
-			currentPrice = price
-		}
-		currentAmount := (float64(holding.Shares) * currentPrice) / 10000 // 转换为万元
+		currentAmount := holding.MarketValue / 10000 // 转换为万元
 
 		// 计算目标仓位（使用前端传递的市场预期值和技术面评分）
-		expect := holding.Expect
-		tech := holding.Tech
+		view := req.Views[holding.Code]
+		expect := view.Expect
+		tech := view.Tech
 		if expect == 0 {
 			expect = 3 // 默认中性
 		}
@@ -315,9 +314,13 @@ func PositionDeviationHandler(c *gin.Context) {
 		}
 
 		result := gin.H{
-			"stock_name":        holding.StockName,
+			"code":              holding.Code,
+			"stock_name":        stock.BaseInfo.SecurityNameAbbr,
 			"shares":            holding.Shares,
-			"current_price":     currentPrice,
+			"avg_cost":          holding.AvgCost,
+			"cost_basis":        holding.CostBasis,
+			"unrealized_pl":     holding.UnrealizedPL,
+			"current_price":     holding.Price,
 			"current_amount":    currentAmount,
 			"target_amount":     targetAmount,
 			"amount_diff":       amountDiff,
@@ -347,7 +350,10 @@ func PositionDeviationHandler(c *gin.Context) {
 			"total_target_position":   totalTargetPosition,
 			"total_diff":              totalDiff,
 			"total_deviation_percent": totalDeviationPercent,
-			"stock_count":             len(req.Holdings),
+			"stock_count":             len(results),
+			"unrealized_pl":           summary.UnrealizedPL,
+			"realized_pl":             summary.RealizedPL,
+			"dividend":                summary.Dividend,
 		},
 	}
 
@@ -356,6 +362,140 @@ func PositionDeviationHandler(c *gin.Context) {
 	return
 }
 
+// RebalanceHandler 生成调仓交易单API，持仓从持仓账本读取，目标仓位默认按 calculateTargetPosition 计算
+func RebalanceHandler(c *gin.Context) {
+	data := gin.H{
+		"HostURL":   viper.GetString("server.host_url"),
+		"Env":       viper.GetString("env"),
+		"Version":   version.Version,
+		"PageTitle": "InvesTool | 调仓",
+		"Error":     "",
+		"Result":    nil,
+	}
+
+	req := struct {
+		// 持仓账户名称
+		Account string `json:"account" binding:"required"`
+		// 各股票的市场预期值和技术面评分，key 为股票代码
+		Views map[string]struct {
+			Expect int `json:"expect"`
+			Tech   int `json:"tech"`
+		} `json:"views"`
+		// 指定目标持仓金额（元），key 为股票代码，可包含未持有的股票
+		Targets map[string]float64 `json:"targets"`
+		// 调仓参数
+		Options portfolio.RebalanceOptions `json:"options"`
+	}{
+		Options: portfolio.DefaultRebalanceOptions,
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		data["Error"] = "参数错误: " + err.Error()
+		c.JSON(http.StatusOK, data)
+		return
+	}
+
+	extraCodes := []string{}
+	for code := range req.Targets {
+		extraCodes = append(extraCodes, code)
+	}
+	sort.Strings(extraCodes)
+	summary, stocksMap, err := loadPortfolioStocks(c, req.Account, extraCodes)
+	if err != nil {
+		data["Error"] = err.Error()
+		c.JSON(http.StatusOK, data)
+		return
+	}
+
+	targets := []portfolio.RebalanceTarget{}
+	held := map[string]bool{}
+	for _, p := range summary.OpenPositions() {
+		held[p.Code] = true
+		stock, exists := stocksMap[p.Code]
+		if !exists || p.Price <= 0 {
+			data["Error"] = "查询股票数据失败: " + p.Code
+			c.JSON(http.StatusOK, data)
+			return
+		}
+		target, exists := req.Targets[p.Code]
+		if !exists {
+			view := req.Views[p.Code]
+			if view.Expect == 0 {
+				view.Expect = 3 // 默认中性
+			}
+			if view.Tech == 0 {
+				view.Tech = 2 // 默认中性
+			}
+			target = calculateTargetPosition(stock, view.Expect, view.Tech) * 10000 // 万元转元
+		}
+		targets = append(targets, portfolio.RebalanceTarget{
+			Code: p.Code, Name: stock.BaseInfo.SecurityNameAbbr, Shares: p.Shares, Price: p.Price, TargetAmount: target,
+		})
+	}
+	for _, code := range extraCodes {
+		target := req.Targets[code]
+		if held[code] {
+			continue
+		}
+		stock, exists := stocksMap[code]
+		if !exists || stock.GetPrice() <= 0 {
+			data["Error"] = "查询股票数据失败: " + code
+			c.JSON(http.StatusOK, data)
+			return
+		}
+		targets = append(targets, portfolio.RebalanceTarget{
+			Code: code, Name: stock.BaseInfo.SecurityNameAbbr, Price: stock.GetPrice(), TargetAmount: target,
+		})
+	}
+
+	plan, err := portfolio.Rebalance(targets, req.Options)
+	if err != nil {
+		data["Error"] = "生成调仓交易单失败: " + err.Error()
+		c.JSON(http.StatusOK, data)
+		return
+	}
+	data["Result"] = plan
+	c.JSON(http.StatusOK, data)
+	return
+}
+
+// loadPortfolioStocks 读取持仓账户，查询持仓股票及 extraCodes 的股票数据，按最新股价汇总持仓
+func loadPortfolioStocks(c *gin.Context, name string, extraCodes []string) (portfolio.Summary, map[string]models.Stock, error) {
+	account, err := portfolio.NewStore("").Account(name)
+	if err != nil {
+		return portfolio.Summary{}, nil, errors.New("读取持仓账户失败: " + err.Error())
+	}
+	positions, err := account.Positions()
+	if err != nil {
+		return portfolio.Summary{}, nil, errors.New("计算持仓失败: " + err.Error())
+	}
+	codes := []string{}
+	for _, p := range positions {
+		if p.IsOpen() {
+			codes = append(codes, p.Code)
+		}
+	}
+	codes = append(codes, extraCodes...)
+	if len(codes) == 0 {
+		return portfolio.Summary{}, nil, errors.New("持仓列表不能为空")
+	}
+
+	// 使用现有的搜索功能获取股票数据
+	searcher := core.NewSearcher(c)
+	stocksMap, err := searcher.SearchStocks(c, codes)
+	if err != nil {
+		stocksMap = map[string]models.Stock{}
+	}
+	prices := map[string]float64{}
+	for code, stock := range stocksMap {
+		prices[code] = stock.GetPrice()
+	}
+	summary, err := account.Summarize(prices)
+	if err != nil {
+		return summary, nil, errors.New("计算持仓失败: " + err.Error())
+	}
+	return summary, stocksMap, nil
+}
+
 // calculateTargetPosition 计算目标仓位的辅助函数
 func calculateTargetPosition(stock models.Stock, expect, tech int) float64 {
 	pe := stock.BaseInfo.PE
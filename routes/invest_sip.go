@@ -0,0 +1,51 @@
+// 基金定投回测
+
+package routes
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/axiaoxin-com/investool/core"
+	"github.com/axiaoxin-com/investool/portfolio"
+	"github.com/axiaoxin-com/investool/version"
+	"github.com/gin-gonic/gin"
+	"github.com/spf13/viper"
+)
+
+// ParamFundSIP 基金定投回测请求参数
+type ParamFundSIP struct {
+	// 基金代码
+	Code string `json:"code"       binding:"required"`
+	// 指数估值 CSV，每行为 日期,PE
+	Valuations string `json:"valuations"`
+	portfolio.SIPOptions
+}
+
+// InvestSIPHandler 基金定投回测页面
+func InvestSIPHandler(c *gin.Context) {
+	data := gin.H{
+		"Env":       viper.GetString("env"),
+		"HostURL":   viper.GetString("server.host_url"),
+		"Version":   version.Version,
+		"PageTitle": "InvesTool | 基金定投回测",
+		"Error":     "",
+	}
+	c.HTML(http.StatusOK, "invest_sip.html", data)
+}
+
+// FundSIPBacktest 基金定投回测API，按历史净值计算 IRR、收益率、回撤及现金流
+func FundSIPBacktest(c *gin.Context) {
+	p := ParamFundSIP{SIPOptions: portfolio.DefaultSIPOptions}
+	if err := c.ShouldBindJSON(&p); err != nil {
+		portfolioResponse(c, nil, err)
+		return
+	}
+	valuations, err := portfolio.ParseValuations(strings.NewReader(p.Valuations))
+	if err != nil {
+		portfolioResponse(c, nil, err)
+		return
+	}
+	result, err := core.FundSIPBacktest(c, strings.TrimSpace(p.Code), p.SIPOptions, valuations)
+	portfolioResponse(c, result, err)
+}
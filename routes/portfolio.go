@@ -0,0 +1,142 @@
+// 持仓账本
+
+package routes
+
+import (
+	"net/http"
+
+	"github.com/axiaoxin-com/investool/core"
+	"github.com/axiaoxin-com/investool/portfolio"
+	"github.com/axiaoxin-com/investool/version"
+	"github.com/gin-gonic/gin"
+	"github.com/spf13/viper"
+)
+
+// ParamPortfolioAccount 账户请求参数
+type ParamPortfolioAccount struct {
+	Account string `json:"account" form:"account" binding:"required"`
+}
+
+// ParamPortfolioTransaction 添加交易请求参数
+type ParamPortfolioTransaction struct {
+	Account     string                `json:"account"     binding:"required"`
+	Transaction portfolio.Transaction `json:"transaction"`
+}
+
+// ParamPortfolioDeleteTransaction 删除交易请求参数
+type ParamPortfolioDeleteTransaction struct {
+	Account string `json:"account" form:"account" binding:"required"`
+	ID      int    `json:"id"      form:"id"      binding:"required"`
+}
+
+// ParamPortfolioRisk 组合风险分析请求参数
+type ParamPortfolioRisk struct {
+	Account string `form:"account" binding:"required"`
+	portfolio.RiskOptions
+}
+
+// portfolioResponse 返回账本接口结果
+func portfolioResponse(c *gin.Context, result interface{}, err error) {
+	data := gin.H{
+		"Error":  "",
+		"Result": result,
+	}
+	if err != nil {
+		data["Error"] = err.Error()
+	}
+	c.JSON(http.StatusOK, data)
+}
+
+// PortfolioAccounts 账户列表API
+func PortfolioAccounts(c *gin.Context) {
+	accounts, err := portfolio.NewStore("").Accounts()
+	portfolioResponse(c, accounts, err)
+}
+
+// PortfolioAddAccount 新建账户API
+func PortfolioAddAccount(c *gin.Context) {
+	a := portfolio.Account{}
+	if err := c.ShouldBindJSON(&a); err != nil {
+		portfolioResponse(c, nil, err)
+		return
+	}
+	portfolioResponse(c, a, portfolio.NewStore("").AddAccount(a))
+}
+
+// PortfolioDeleteAccount 删除账户API
+func PortfolioDeleteAccount(c *gin.Context) {
+	p := ParamPortfolioAccount{}
+	if err := c.ShouldBind(&p); err != nil {
+		portfolioResponse(c, nil, err)
+		return
+	}
+	portfolioResponse(c, nil, portfolio.NewStore("").DeleteAccount(p.Account))
+}
+
+// PortfolioSummary 账户持仓及盈亏API，使用最新股价计算浮动盈亏
+func PortfolioSummary(c *gin.Context) {
+	p := ParamPortfolioAccount{}
+	if err := c.ShouldBind(&p); err != nil {
+		portfolioResponse(c, nil, err)
+		return
+	}
+	a, err := portfolio.NewStore("").Account(p.Account)
+	if err != nil {
+		portfolioResponse(c, nil, err)
+		return
+	}
+	summary, err := core.PortfolioSummary(c, a)
+	portfolioResponse(c, gin.H{
+		"summary":      summary,
+		"transactions": a.SortedTransactions(),
+	}, err)
+}
+
+// PortfolioAddTransaction 添加交易API
+func PortfolioAddTransaction(c *gin.Context) {
+	p := ParamPortfolioTransaction{}
+	if err := c.ShouldBindJSON(&p); err != nil {
+		portfolioResponse(c, nil, err)
+		return
+	}
+	tx, err := portfolio.NewStore("").AddTransaction(p.Account, p.Transaction)
+	portfolioResponse(c, tx, err)
+}
+
+// PortfolioDeleteTransaction 删除交易API
+func PortfolioDeleteTransaction(c *gin.Context) {
+	p := ParamPortfolioDeleteTransaction{}
+	if err := c.ShouldBind(&p); err != nil {
+		portfolioResponse(c, nil, err)
+		return
+	}
+	portfolioResponse(c, nil, portfolio.NewStore("").DeleteTransaction(p.Account, p.ID))
+}
+
+// InvestRiskHandler 组合风险分析页面
+func InvestRiskHandler(c *gin.Context) {
+	data := gin.H{
+		"Env":       viper.GetString("env"),
+		"HostURL":   viper.GetString("server.host_url"),
+		"Version":   version.Version,
+		"PageTitle": "InvesTool | 组合风险分析",
+		"Error":     "",
+	}
+	c.HTML(http.StatusOK, "invest_risk.html", data)
+}
+
+// PortfolioRisk 组合风险分析API，基于历史股价计算相关性、波动率、贝塔、VaR/CVaR、最大回撤及行业集中度
+func PortfolioRisk(c *gin.Context) {
+	p := ParamPortfolioRisk{}
+	if err := c.ShouldBindQuery(&p); err != nil {
+		portfolioResponse(c, nil, err)
+		return
+	}
+	a, err := portfolio.NewStore("").Account(p.Account)
+	if err != nil {
+		portfolioResponse(c, nil, err)
+		return
+	}
+	report, err := core.PortfolioRisk(c, a, p.RiskOptions)
+	portfolioResponse(c, report, err)
+}
@@ -12,16 +12,37 @@ func Routes(app *gin.Engine) {
 	app.POST("/checker", StockChecker)
 	app.GET("/fund", FundIndex)
 	app.GET("/fund/filter", FundFilter)
+	app.GET("/fund/rules/:name", FundRuleSet)
+	app.GET("/fund/rules/:name/explain", FundRuleSetExplain)
 	app.POST("/fund/check", FundCheck)
 	app.GET("/about", About)
 	app.GET("/comment", Comment)
 	app.GET("/fund/similarity", FundSimilarity)
+	app.GET("/fund/similarity/matrix", FundOverlapMatrix)
+	app.GET("/fund/exposure", FundExposure)
+	app.POST("/fund/exposure", FundExposureAPI)
+	app.GET("/fund/fee", FundCost)
+	app.GET("/fund/fee/compare", FundShareClassCompare)
+	app.GET("/fund/premium", FundPremium)
 	app.GET("/materials", Materials)
 	app.POST("/fund/query_by_stock", QueryFundByStock)
 	app.GET("/fund/managers", FundManagers)
+	app.GET("/fund/managers/compare", FundManagerCompare)
+	app.POST("/fund/managers/compare", FundManagerCompareAPI)
 	app.GET("/invest/holding-calculator", InvestHoldingHandler)
 	app.GET("/invest/stock-analyzer", StockAnalyzerHandler)
 	app.GET("/invest/query-stock", QueryStockDataHandler)
 	app.POST("/invest/calculate-position", CalculatePositionHandler)
 	app.POST("/invest/position-deviation", PositionDeviationHandler)
+	app.POST("/invest/rebalance", RebalanceHandler)
+	app.GET("/invest/portfolio", PortfolioSummary)
+	app.GET("/invest/portfolio/risk", PortfolioRisk)
+	app.GET("/invest/risk", InvestRiskHandler)
+	app.GET("/invest/sip", InvestSIPHandler)
+	app.POST("/invest/sip/backtest", FundSIPBacktest)
+	app.GET("/invest/portfolio/accounts", PortfolioAccounts)
+	app.POST("/invest/portfolio/accounts", PortfolioAddAccount)
+	app.POST("/invest/portfolio/accounts/delete", PortfolioDeleteAccount)
+	app.POST("/invest/portfolio/transactions", PortfolioAddTransaction)
+	app.POST("/invest/portfolio/transactions/delete", PortfolioDeleteTransaction)
 }
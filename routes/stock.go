@@ -27,6 +27,7 @@ func StockIndex(c *gin.Context) {
 		"PageTitle":    "InvesTool | 股票",
 		"Error":        "",
 		"IndustryList": models.StockIndustryList,
+		"RuleSetNames": core.RuleSetNames(),
 	}
 	c.HTML(http.StatusOK, "stock_index.html", data)
 	return
@@ -37,6 +38,9 @@ type ParamStockSelector struct {
 	Filter            eastmoney.Filter
 	CheckerOptions    core.CheckerOptions
 	FilterWithChecker bool `form:"selector_with_checker"`
+	// 评分模式：不过滤检测失败的股票，按综合得分排序
+	ScoreMode    bool `form:"selector_score_mode"`
+	ScoreOptions core.ScoreOptions
 }
 
 // StockSelector 返回基本面筛选结果json
@@ -50,12 +54,38 @@ func StockSelector(c *gin.Context) {
 		"Stocks":    models.StockList{},
 	}
 
-	param := ParamStockSelector{}
+	param := ParamStockSelector{
+		ScoreOptions: core.DefaultScoreOptions,
+	}
 	if err := c.ShouldBind(&param); err != nil {
 		data["Error"] = err.Error()
 		c.JSON(http.StatusOK, data)
 		return
 	}
+	if param.ScoreMode {
+		checker := core.NewChecker(c, param.CheckerOptions)
+		selector := core.NewSelector(c, param.Filter, checker)
+		scores, err := selector.ScoreStocks(c, param.ScoreOptions)
+		if err != nil {
+			data["Error"] = err.Error()
+			c.JSON(http.StatusOK, data)
+			return
+		}
+		dlist := models.ExportorDataList{}
+		scoreDetails := []gin.H{}
+		for _, s := range scores {
+			dlist = append(dlist, models.NewExportorData(c, s.Stock))
+			scoreDetails = append(scoreDetails, gin.H{
+				"score":  s.Score,
+				"passed": s.Passed,
+				"items":  s.Items,
+			})
+		}
+		data["Stocks"] = dlist
+		data["Scores"] = scoreDetails
+		c.JSON(http.StatusOK, data)
+		return
+	}
 	var checker *core.Checker
 	if param.FilterWithChecker {
 		checker = core.NewChecker(c, param.CheckerOptions)
@@ -104,6 +134,28 @@ func StockChecker(c *gin.Context) {
 		c.JSON(http.StatusOK, data)
 		return
 	}
+	if param.CheckerOptions.RuleSet != "" {
+		if _, exists := core.RuleSets[param.CheckerOptions.RuleSet]; !exists {
+			data["Error"] = "检测规则集不存在"
+			c.JSON(http.StatusOK, data)
+			return
+		}
+	}
+	if _, err := param.CheckerOptions.AsOfTime(); err != nil {
+		data["Error"] = "检测时间点格式错误，示例: 2021-06-30"
+		c.JSON(http.StatusOK, data)
+		return
+	}
+	if err := param.CheckerOptions.DCF.Validate(); err != nil {
+		data["Error"] = err.Error()
+		c.JSON(http.StatusOK, data)
+		return
+	}
+	if err := param.CheckerOptions.DDM.Validate(); err != nil {
+		data["Error"] = err.Error()
+		c.JSON(http.StatusOK, data)
+		return
+	}
 	searcher := core.NewSearcher(c)
 	keywords := goutils.SplitStringFields(param.Keyword)
 	if len(keywords) > 50 {
@@ -111,13 +163,13 @@ func StockChecker(c *gin.Context) {
 		c.JSON(http.StatusOK, data)
 		return
 	}
-	stocks, err := searcher.SearchStocks(c, keywords)
+	checker := core.NewChecker(c, param.CheckerOptions)
+	stocks, err := searcher.SearchStocksWithOptions(c, keywords, checker.StockOptions(models.EnrichMainMoney|models.EnrichPublishDate))
 	if err != nil {
 		data["Error"] = err.Error()
 		c.JSON(http.StatusOK, data)
 		return
 	}
-	checker := core.NewChecker(c, param.CheckerOptions)
 	results := []core.CheckResult{}
 	stockNames := []string{}
 	finaReportNames := []string{}
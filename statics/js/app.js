@@ -26,6 +26,26 @@ $(document).ready(function () {
     $("#checker_options").toggle();
   });
 
+  // 检测项描述转换为 html
+  var checkItemDescHTML = function (item) {
+    return $("<div>").text(item.desc).html().split("\n").join("<br/>");
+  };
+
+  // 检测项状态显示符号
+  var checkStatusSymbol = function (status) {
+    switch (status) {
+      case "pass":
+        return "✅";
+      case "fail":
+        return "❌";
+      case "warn":
+        return "⚠️";
+      case "unknown":
+        return "❓";
+    }
+    return "➖";
+  };
+
   var human_float_slice = function (floats, unit) {
     var result = "";
     for (i = 0; i < floats.length; i++) {
@@ -86,7 +106,9 @@ $(document).ready(function () {
                 cm[0] +
                 '.html">' +
                 stock.name +
-                "</a></td>" +
+                "</a>" +
+                (data.Scores ? "<br/>得分:" + data.Scores[i].score.toFixed(1) + (data.Scores[i].passed ? " ✅" : "") : "") +
+                "</td>" +
                 '<td class="hide st_1">' +
                 stock.industry +
                 "</td>" +
@@ -338,18 +360,14 @@ $(document).ready(function () {
                 "</table>" +
                 "</div>"
             );
-            $.each(result, function (k, v) {
-              okdesc = "❌";
-              if (v.ok == "true") {
-                okdesc = "✅";
-              }
+            $.each(result.items, function (k, v) {
               $(`#checker_result_${i} tbody`).append(
                 "<tr><td>" +
-                  k +
+                  v.label +
                   "</td><td>" +
-                  v.desc +
+                  checkItemDescHTML(v) +
                   "</td><td>" +
-                  okdesc +
+                  checkStatusSymbol(v.status) +
                   "</td></tr>"
               );
             });
@@ -493,7 +511,7 @@ $(document).ready(function () {
   });
 
   // 基金字段
-  for (let i = 1; i <= 23; i++) {
+  for (let i = 1; i <= 24; i++) {
     $(`#f${i}`).change(function () {
       $(`.t${i}`).toggleClass("hide");
       if (this.checked) {
@@ -508,6 +526,21 @@ $(document).ready(function () {
     }
   }
 
+  // 基金经理对比
+  $("#fundmgr_compare_btn").click(function () {
+    var ids = $(".fundmgr-compare:checked")
+      .map(function () {
+        return $(this).val();
+      })
+      .get();
+    if (ids.length < 2) {
+      M.toast({ html: "请至少选择 2 位基金经理进行对比" });
+      return;
+    }
+    window.location.href =
+      $(this).data("url") + "?ids=" + encodeURIComponent(ids.join(","));
+  });
+
   // 设置排序图标
   $(".sortable").click(function () {
     var s = $(this).find("a").attr("sort");
@@ -525,6 +558,91 @@ $(document).ready(function () {
   });
 
   // 基金检测提交
+  // 债券基金检测结果行
+  var bondCheckRow = function (fund, recovery) {
+    var b = fund.bond_analysis;
+    var desc =
+      "持仓风格:" +
+      (b.style || "--") +
+      "<br/>利率债:" +
+      b.rate_ratio.toFixed(2) +
+      "% 信用债:" +
+      b.credit_ratio.toFixed(2) +
+      "% 可转债:" +
+      b.convertible_ratio.toFixed(2) +
+      "% 同业存单:" +
+      b.cd_ratio.toFixed(2) +
+      "%<br/>前五大债券占净值:" +
+      b.top5_ratio.toFixed(2) +
+      "% HHI:" +
+      b.hhi.toFixed(3) +
+      "<br/>杠杆率:" +
+      b.leverage.toFixed(2) +
+      "%<br/>" +
+      $.map(fund.bonds || [], function (bond) {
+        return bond.name + "(" + bond.kind + "):" + bond.hold_ratio + "%";
+      }).join("<br/>");
+    if (recovery) {
+      desc +=
+        "<br/>近3年最大回撤:" +
+        recovery.max_drawdown.toFixed(2) +
+        "%（" +
+        recovery.peak_date +
+        " ~ " +
+        recovery.trough_date +
+        "）<br/>" +
+        (recovery.recovered
+          ? "修复用时:" + recovery.recovery_days + "天（" + recovery.recovery_date + "）"
+          : "尚未修复，已" + recovery.recovery_days + "天") +
+        "<br/>最长水下天数:" +
+        recovery.longest_underwater_days +
+        "天";
+    }
+    return (
+      "<tr><td>债券持仓分析</td><td>" +
+      desc +
+      "</td><td>" +
+      ((b.warnings || []).length ? "⚠️ " + b.warnings.join("<br/>⚠️ ") : "✅") +
+      "</td></tr>"
+    );
+  };
+
+  var feeCheckRow = function (fund) {
+    var fee = fund.fee;
+    if (!fee || !fee.loaded) {
+      return "";
+    }
+    var annual =
+      fee.management_rate + fee.custody_rate + fee.sales_service_rate;
+    var desc =
+      "购买费率:" +
+      (fund.rate || "--") +
+      "<br/>管理费:" +
+      fee.management_rate.toFixed(2) +
+      "% 托管费:" +
+      fee.custody_rate.toFixed(2) +
+      "% 销售服务费:" +
+      fee.sales_service_rate.toFixed(2) +
+      "%<br/>" +
+      $.map(fee.redemption || [], function (t) {
+        return (
+          "持有" +
+          t.min_days +
+          (t.max_days ? "~" + t.max_days + "天" : "天以上") +
+          "赎回费:" +
+          t.rate.toFixed(2) +
+          "%"
+        );
+      }).join("<br/>");
+    return (
+      "<tr><td>费率</td><td>" +
+      desc +
+      "</td><td>运作费用合计" +
+      annual.toFixed(2) +
+      "%/年</td></tr>"
+    );
+  };
+
   $("#check_fund_submit_btn").click(function () {
     if ($("#fundcode").val() == "") {
       $("#err_msg").text("请填写基金代码");
@@ -738,7 +856,54 @@ $(document).ready(function () {
               fund.max_retracement.year_5.toFixed(2) +
               "%</td><td>" +
               maxretr_avg135 +
-              "</td></tr></tbody></table>" +
+              "</td></tr><tr><td>综合评分（同类型归一化）</td><td>" +
+              $.map(fund.score.components || [], function (c) {
+                return c.label + ":" + c.score.toFixed(1) + "/" + c.weight;
+              }).join("<br/>") +
+              "</td><td>" +
+              (fund.score.type_rank
+                ? fund.score.total.toFixed(1) +
+                  "（同类 " +
+                  fund.score.type_rank +
+                  "/" +
+                  fund.score.type_count +
+                  "）"
+                : "--") +
+              "</td></tr><tr><td>风格漂移（行业集中度、换手及仓位偏离）</td><td>" +
+              $.map(fund.style_drift.quarters || [], function (q) {
+                return (
+                  q.pub_date +
+                  " " +
+                  q.top_industry +
+                  ":" +
+                  q.top_weight.toFixed(2) +
+                  "% HHI:" +
+                  q.hhi.toFixed(3) +
+                  " 换手:" +
+                  q.turnover.toFixed(2) +
+                  "%"
+                );
+              }).join("<br/>") +
+              "<br/>行业偏离:" +
+              fund.style_drift.drift.toFixed(2) +
+              "%<br/>基准:" +
+              (fund.style_drift.benchmark || "--") +
+              "<br/>股票仓位:" +
+              fund.style_drift.stock_ratio.toFixed(2) +
+              "%" +
+              (fund.style_drift.benchmark_stock_ratio >= 0
+                ? "（基准" + fund.style_drift.benchmark_stock_ratio.toFixed(0) + "%）"
+                : "") +
+              "</td><td>" +
+              ((fund.style_drift.warnings || []).length
+                ? "⚠️ " + fund.style_drift.warnings.join("<br/>⚠️ ")
+                : "✅") +
+              "</td></tr>" +
+              feeCheckRow(fund) +
+              (fund.type.indexOf("债券") >= 0
+                ? bondCheckRow(fund, (data.BondRecoveries || {})[fund.code])
+                : "") +
+              "</tbody></table>" +
               "</div>"
           );
           if (data.StockCheckResults) {
@@ -777,18 +942,14 @@ $(document).ready(function () {
                   "</table>" +
                   "</div>"
               );
-              $.each(result, function (k, v) {
-                okdesc = "❌";
-                if (v.ok == "true") {
-                  okdesc = "✅";
-                }
+              $.each(result.items, function (k, v) {
                 $(`#${fund.code} #checker_result_${i} tbody`).append(
                   "<tr><td>" +
-                    k +
+                    v.label +
                     "</td><td>" +
-                    v.desc +
+                    checkItemDescHTML(v) +
                     "</td><td>" +
-                    okdesc +
+                    checkStatusSymbol(v.status) +
                     "</td></tr>"
                 );
               });
@@ -0,0 +1,193 @@
+// 自由现金流折现模型（DCF）
+
+package valuation
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// DCFOptions 自由现金流折现模型参数，为 0 的参数使用默认值
+type DCFOptions struct {
+	// 折现率（%），为 0 时使用中债收益率+风险溢价
+	DiscountRate float64 `json:"discount_rate"        form:"checker_dcf_discount_rate"`
+	// 风险溢价（%）
+	RiskPremium float64 `json:"risk_premium"         form:"checker_dcf_risk_premium"`
+	// 基准自由现金流取近 n 年年报均值
+	FCFYears int `json:"fcf_years"            form:"checker_dcf_fcf_years"`
+	// 高速增长期年数
+	HighGrowthYears int `json:"high_growth_years"    form:"checker_dcf_high_growth_years"`
+	// 高速增长期增长率（%）
+	HighGrowthRate float64 `json:"high_growth_rate"     form:"checker_dcf_high_growth_rate"`
+	// 稳定增长期年数
+	StableGrowthYears int `json:"stable_growth_years"  form:"checker_dcf_stable_growth_years"`
+	// 稳定增长期增长率（%）
+	StableGrowthRate float64 `json:"stable_growth_rate"   form:"checker_dcf_stable_growth_rate"`
+	// 永续增长率（%）
+	TerminalGrowthRate float64 `json:"terminal_growth_rate" form:"checker_dcf_terminal_growth_rate"`
+}
+
+// errNoShares 无法计算总股本
+var errNoShares = errors.New("总股本无效")
+
+// DefaultDCFOptions 默认 DCF 参数
+var DefaultDCFOptions = DCFOptions{
+	DiscountRate:       0,
+	RiskPremium:        5.0,
+	FCFYears:           3,
+	HighGrowthYears:    5,
+	HighGrowthRate:     10.0,
+	StableGrowthYears:  5,
+	StableGrowthRate:   5.0,
+	TerminalGrowthRate: 2.5,
+}
+
+// WithDefaults 返回将 0 值参数替换为默认值后的参数，折现率为 0 时仍使用中债收益率+风险溢价
+func (o DCFOptions) WithDefaults() DCFOptions {
+	if o.RiskPremium == 0 {
+		o.RiskPremium = DefaultDCFOptions.RiskPremium
+	}
+	if o.FCFYears <= 0 {
+		o.FCFYears = DefaultDCFOptions.FCFYears
+	}
+	if o.HighGrowthYears <= 0 {
+		o.HighGrowthYears = DefaultDCFOptions.HighGrowthYears
+	}
+	if o.HighGrowthRate == 0 {
+		o.HighGrowthRate = DefaultDCFOptions.HighGrowthRate
+	}
+	if o.StableGrowthYears <= 0 {
+		o.StableGrowthYears = DefaultDCFOptions.StableGrowthYears
+	}
+	if o.StableGrowthRate == 0 {
+		o.StableGrowthRate = DefaultDCFOptions.StableGrowthRate
+	}
+	if o.TerminalGrowthRate == 0 {
+		o.TerminalGrowthRate = DefaultDCFOptions.TerminalGrowthRate
+	}
+	return o
+}
+
+// Validate 校验参数，指定的折现率需高于永续增长率
+func (o DCFOptions) Validate() error {
+	o = o.WithDefaults()
+	if o.DiscountRate > 0 && o.DiscountRate <= o.TerminalGrowthRate {
+		return fmt.Errorf("DCF 折现率%.2f%%需高于永续增长率%.2f%%", o.DiscountRate, o.TerminalGrowthRate)
+	}
+	return nil
+}
+
+// stages 增长阶段
+func (o DCFOptions) stages() []growthStage {
+	return []growthStage{
+		{years: o.HighGrowthYears, rate: o.HighGrowthRate},
+		{years: o.StableGrowthYears, rate: o.StableGrowthRate},
+	}
+}
+
+// DCFInput DCF 所需数据
+type DCFInput struct {
+	// 历年年报自由现金流（元），最新的在最前面
+	FCFList []float64
+	// 总股本
+	Shares float64
+	// 当前股价
+	Price float64
+	// 无风险收益率（%），为 0 时使用 DefaultRiskFreeRate
+	RiskFreeRate float64
+}
+
+// DCFResult DCF 估值结果
+type DCFResult struct {
+	// 使用的参数
+	Options DCFOptions `json:"options"`
+	// 折现率（%）
+	DiscountRate float64 `json:"discount_rate"`
+	// 参与计算的历年自由现金流，最新的在最前面
+	FCFHistory []float64 `json:"fcf_history"`
+	// 基准自由现金流
+	BaseFCF float64 `json:"base_fcf"`
+	// 各年预测自由现金流
+	ProjectedFCF []float64 `json:"projected_fcf"`
+	// 预测期现值之和
+	PresentValue float64 `json:"present_value"`
+	// 终值现值
+	TerminalValue float64 `json:"terminal_value"`
+	// 股权价值=预测期现值+终值现值
+	EquityValue float64 `json:"equity_value"`
+	// 每股价值
+	ValuePerShare float64 `json:"value_per_share"`
+	// 当前股价
+	Price float64 `json:"price"`
+	// 安全边际（%）=(每股价值-股价)/每股价值
+	MarginOfSafety float64 `json:"margin_of_safety"`
+	// 折现率与永续增长率的敏感性分析
+	Sensitivity SensitivityTable `json:"sensitivity"`
+}
+
+// Lines DCF 估值过程的文字说明
+func (r DCFResult) Lines() []string {
+	return []string{
+		fmt.Sprintf("基准自由现金流:%.2f亿(近%d年均值)", r.BaseFCF/100000000, len(r.FCFHistory)),
+		fmt.Sprintf("折现率:%.2f%%,增长:%d年%.2f%%+%d年%.2f%%,永续增长率:%.2f%%",
+			r.DiscountRate,
+			r.Options.HighGrowthYears, r.Options.HighGrowthRate,
+			r.Options.StableGrowthYears, r.Options.StableGrowthRate,
+			r.Options.TerminalGrowthRate),
+		fmt.Sprintf("预测期现值:%.2f亿,终值现值:%.2f亿", r.PresentValue/100000000, r.TerminalValue/100000000),
+		fmt.Sprintf("每股价值:%.2f,安全边际:%.2f%%", r.ValuePerShare, r.MarginOfSafety),
+	}
+}
+
+// DCF 按历年自由现金流均值分高速增长、稳定增长、永续增长三阶段折现计算每股价值，
+// 未考虑净现金或净负债，自由现金流为负时无法估值
+func DCF(ctx context.Context, in DCFInput, opts DCFOptions) (DCFResult, error) {
+	opts = opts.WithDefaults()
+	result := DCFResult{
+		Options:      opts,
+		DiscountRate: resolveDiscountRate(opts.DiscountRate, in.RiskFreeRate, opts.RiskPremium),
+		FCFHistory:   []float64{},
+		Price:        in.Price,
+	}
+	if err := opts.Validate(); err != nil {
+		return result, err
+	}
+	if in.Shares <= 0 {
+		return result, errNoShares
+	}
+	for i, fcf := range in.FCFList {
+		if i >= opts.FCFYears {
+			break
+		}
+		result.FCFHistory = append(result.FCFHistory, fcf)
+		result.BaseFCF += fcf
+	}
+	if len(result.FCFHistory) == 0 {
+		return result, errors.New("无年报自由现金流数据")
+	}
+	result.BaseFCF /= float64(len(result.FCFHistory))
+	if result.BaseFCF <= 0 {
+		return result, fmt.Errorf("自由现金流均值为负:%.2f", result.BaseFCF)
+	}
+
+	valueFn := func(discountRate, terminalGrowth float64) (float64, error) {
+		d, err := discount(result.BaseFCF, discountRate, opts.stages(), terminalGrowth)
+		if err != nil {
+			return 0, err
+		}
+		return (d.presentValue + d.terminalValue) / in.Shares, nil
+	}
+	d, err := discount(result.BaseFCF, result.DiscountRate, opts.stages(), opts.TerminalGrowthRate)
+	if err != nil {
+		return result, err
+	}
+	result.ProjectedFCF = d.projected
+	result.PresentValue = d.presentValue
+	result.TerminalValue = d.terminalValue
+	result.EquityValue = d.presentValue + d.terminalValue
+	result.ValuePerShare = result.EquityValue / in.Shares
+	result.MarginOfSafety = marginOfSafety(result.ValuePerShare, in.Price)
+	result.Sensitivity = newSensitivityTable(result.DiscountRate, opts.TerminalGrowthRate, valueFn)
+	return result, nil
+}
@@ -0,0 +1,72 @@
+package valuation
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDCF(t *testing.T) {
+	in := DCFInput{
+		FCFList:      []float64{120, 100, 80, 10},
+		Shares:       10,
+		Price:        10,
+		RiskFreeRate: 3,
+	}
+	opts := DCFOptions{
+		RiskPremium:        7,
+		FCFYears:           3,
+		HighGrowthYears:    1,
+		HighGrowthRate:     10,
+		StableGrowthYears:  1,
+		StableGrowthRate:   10,
+		TerminalGrowthRate: 1,
+	}
+	r, err := DCF(_ctx, in, opts)
+	require.Nil(t, err)
+	require.Equal(t, 10.0, r.DiscountRate)
+	require.Equal(t, 100.0, r.BaseFCF)
+	require.Equal(t, []float64{120, 100, 80}, r.FCFHistory)
+	require.InDelta(t, 110, r.ProjectedFCF[0], 1e-9)
+	require.InDelta(t, 121, r.ProjectedFCF[1], 1e-9)
+	// 110/1.1 + 121/1.21
+	require.InDelta(t, 200, r.PresentValue, 1e-9)
+	// 121*1.01/0.09/1.21
+	require.InDelta(t, 101/0.09, r.TerminalValue, 1e-9)
+	require.InDelta(t, (200+101/0.09)/10, r.ValuePerShare, 1e-9)
+	require.Len(t, r.Sensitivity.DiscountRates, 5)
+	require.InDelta(t, r.ValuePerShare, r.Sensitivity.Values[2][2], 1e-9)
+	// 折现率越高估值越低
+	require.Greater(t, r.Sensitivity.Values[1][2], r.Sensitivity.Values[3][2])
+	require.NotEmpty(t, r.Lines())
+
+	r, err = DCF(_ctx, in, DCFOptions{})
+	require.Nil(t, err)
+	require.Equal(t, DefaultDCFOptions, r.Options)
+	require.False(t, math.IsNaN(r.ValuePerShare))
+
+	// 只指定部分参数时其余参数使用默认值
+	r, err = DCF(_ctx, in, DCFOptions{DiscountRate: 9})
+	require.Nil(t, err)
+	require.Equal(t, 9.0, r.DiscountRate)
+	require.Equal(t, DefaultDCFOptions.FCFYears, r.Options.FCFYears)
+	require.Equal(t, DefaultDCFOptions.HighGrowthYears, r.Options.HighGrowthYears)
+	require.Equal(t, DefaultDCFOptions.TerminalGrowthRate, r.Options.TerminalGrowthRate)
+	require.Nil(t, DCFOptions{DiscountRate: 9}.Validate())
+
+	in.FCFList = []float64{-10, 5}
+	_, err = DCF(_ctx, in, opts)
+	require.NotNil(t, err)
+
+	in.FCFList = []float64{100}
+	opts.DiscountRate = 2
+	opts.TerminalGrowthRate = 3
+	_, err = DCF(_ctx, in, opts)
+	require.NotNil(t, err)
+	require.NotNil(t, opts.Validate())
+
+	in.Shares = 0
+	_, err = DCF(_ctx, in, DCFOptions{})
+	require.Equal(t, errNoShares, err)
+}
@@ -0,0 +1,156 @@
+// 股利折现模型（DDM）
+
+package valuation
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// DDMOptions 股利折现模型参数，为 0 的参数使用默认值
+type DDMOptions struct {
+	// 折现率（%），为 0 时使用中债收益率+风险溢价
+	DiscountRate float64 `json:"discount_rate"        form:"checker_ddm_discount_rate"`
+	// 风险溢价（%）
+	RiskPremium float64 `json:"risk_premium"         form:"checker_ddm_risk_premium"`
+	// 股利增长期年数
+	GrowthYears int `json:"growth_years"         form:"checker_ddm_growth_years"`
+	// 股利增长期增长率（%）
+	GrowthRate float64 `json:"growth_rate"          form:"checker_ddm_growth_rate"`
+	// 永续增长率（%）
+	TerminalGrowthRate float64 `json:"terminal_growth_rate" form:"checker_ddm_terminal_growth_rate"`
+	// 最低分红率（%），低于该值时不适用股利折现模型
+	MinPayoutRatio float64 `json:"min_payout_ratio"     form:"checker_ddm_min_payout_ratio"`
+}
+
+// DefaultDDMOptions 默认 DDM 参数
+var DefaultDDMOptions = DDMOptions{
+	DiscountRate:       0,
+	RiskPremium:        4.0,
+	GrowthYears:        5,
+	GrowthRate:         5.0,
+	TerminalGrowthRate: 2.0,
+	MinPayoutRatio:     40.0,
+}
+
+// WithDefaults 返回将 0 值参数替换为默认值后的参数，折现率为 0 时仍使用中债收益率+风险溢价
+func (o DDMOptions) WithDefaults() DDMOptions {
+	if o.RiskPremium == 0 {
+		o.RiskPremium = DefaultDDMOptions.RiskPremium
+	}
+	if o.GrowthYears <= 0 {
+		o.GrowthYears = DefaultDDMOptions.GrowthYears
+	}
+	if o.GrowthRate == 0 {
+		o.GrowthRate = DefaultDDMOptions.GrowthRate
+	}
+	if o.TerminalGrowthRate == 0 {
+		o.TerminalGrowthRate = DefaultDDMOptions.TerminalGrowthRate
+	}
+	if o.MinPayoutRatio == 0 {
+		o.MinPayoutRatio = DefaultDDMOptions.MinPayoutRatio
+	}
+	return o
+}
+
+// Validate 校验参数，指定的折现率需高于永续增长率
+func (o DDMOptions) Validate() error {
+	o = o.WithDefaults()
+	if o.DiscountRate > 0 && o.DiscountRate <= o.TerminalGrowthRate {
+		return fmt.Errorf("DDM 折现率%.2f%%需高于永续增长率%.2f%%", o.DiscountRate, o.TerminalGrowthRate)
+	}
+	return nil
+}
+
+// DDMInput DDM 所需数据
+type DDMInput struct {
+	// 每股股利
+	DPS float64
+	// 每股收益
+	EPS float64
+	// 当前股价
+	Price float64
+	// 无风险收益率（%），为 0 时使用 DefaultRiskFreeRate
+	RiskFreeRate float64
+}
+
+// DDMResult DDM 估值结果
+type DDMResult struct {
+	// 使用的参数
+	Options DDMOptions `json:"options"`
+	// 折现率（%）
+	DiscountRate float64 `json:"discount_rate"`
+	// 每股股利
+	DPS float64 `json:"dps"`
+	// 分红率（%）
+	PayoutRatio float64 `json:"payout_ratio"`
+	// 各年预测每股股利
+	ProjectedDPS []float64 `json:"projected_dps"`
+	// 增长期股利现值之和
+	PresentValue float64 `json:"present_value"`
+	// 终值现值
+	TerminalValue float64 `json:"terminal_value"`
+	// 每股价值
+	ValuePerShare float64 `json:"value_per_share"`
+	// 当前股价
+	Price float64 `json:"price"`
+	// 安全边际（%）=(每股价值-股价)/每股价值
+	MarginOfSafety float64 `json:"margin_of_safety"`
+	// 折现率与永续增长率的敏感性分析
+	Sensitivity SensitivityTable `json:"sensitivity"`
+}
+
+// Lines DDM 估值过程的文字说明
+func (r DDMResult) Lines() []string {
+	return []string{
+		fmt.Sprintf("每股股利:%.4f,分红率:%.2f%%", r.DPS, r.PayoutRatio),
+		fmt.Sprintf("折现率:%.2f%%,增长:%d年%.2f%%,永续增长率:%.2f%%",
+			r.DiscountRate, r.Options.GrowthYears, r.Options.GrowthRate, r.Options.TerminalGrowthRate),
+		fmt.Sprintf("每股价值:%.2f,安全边际:%.2f%%", r.ValuePerShare, r.MarginOfSafety),
+	}
+}
+
+// DDM 按两阶段股利折现模型计算每股价值，只适用于分红率不低于 MinPayoutRatio 的高分红股票
+func DDM(ctx context.Context, in DDMInput, opts DDMOptions) (DDMResult, error) {
+	opts = opts.WithDefaults()
+	result := DDMResult{
+		Options:      opts,
+		DiscountRate: resolveDiscountRate(opts.DiscountRate, in.RiskFreeRate, opts.RiskPremium),
+		DPS:          in.DPS,
+		Price:        in.Price,
+	}
+	if err := opts.Validate(); err != nil {
+		return result, err
+	}
+	if in.DPS <= 0 {
+		return result, errors.New("无分红")
+	}
+	if in.EPS <= 0 {
+		return result, errors.New("每股收益为负，不适用股利折现模型")
+	}
+	result.PayoutRatio = in.DPS / in.EPS * 100
+	if result.PayoutRatio < opts.MinPayoutRatio {
+		return result, fmt.Errorf("分红率%.2f%%低于%.2f%%，不适用股利折现模型", result.PayoutRatio, opts.MinPayoutRatio)
+	}
+
+	stages := []growthStage{{years: opts.GrowthYears, rate: opts.GrowthRate}}
+	valueFn := func(discountRate, terminalGrowth float64) (float64, error) {
+		d, err := discount(in.DPS, discountRate, stages, terminalGrowth)
+		if err != nil {
+			return 0, err
+		}
+		return d.presentValue + d.terminalValue, nil
+	}
+	d, err := discount(in.DPS, result.DiscountRate, stages, opts.TerminalGrowthRate)
+	if err != nil {
+		return result, err
+	}
+	result.ProjectedDPS = d.projected
+	result.PresentValue = d.presentValue
+	result.TerminalValue = d.terminalValue
+	result.ValuePerShare = d.presentValue + d.terminalValue
+	result.MarginOfSafety = marginOfSafety(result.ValuePerShare, in.Price)
+	result.Sensitivity = newSensitivityTable(result.DiscountRate, opts.TerminalGrowthRate, valueFn)
+	return result, nil
+}
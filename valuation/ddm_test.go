@@ -0,0 +1,49 @@
+package valuation
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDDM(t *testing.T) {
+	in := DDMInput{DPS: 1, EPS: 2, Price: 10}
+	opts := DDMOptions{
+		DiscountRate:       10,
+		GrowthYears:        1,
+		GrowthRate:         5,
+		TerminalGrowthRate: 5,
+		MinPayoutRatio:     40,
+	}
+	r, err := DDM(_ctx, in, opts)
+	require.Nil(t, err)
+	require.Equal(t, 50.0, r.PayoutRatio)
+	// 增长期与永续增长率相同时等同于戈登增长模型: 1*1.05/(0.1-0.05)
+	require.InDelta(t, 21, r.ValuePerShare, 1e-9)
+	require.InDelta(t, 11.0/21*100, r.MarginOfSafety, 1e-9)
+	require.Len(t, r.Sensitivity.Values, 5)
+
+	r, err = DDM(_ctx, in, DDMOptions{})
+	require.Nil(t, err)
+	require.Equal(t, DefaultDDMOptions, r.Options)
+	require.Equal(t, DefaultRiskFreeRate+DefaultDDMOptions.RiskPremium, r.DiscountRate)
+
+	// 只指定部分参数时其余参数使用默认值
+	r, err = DDM(_ctx, in, DDMOptions{DiscountRate: 9})
+	require.Nil(t, err)
+	require.Equal(t, 9.0, r.DiscountRate)
+	require.Equal(t, DefaultDDMOptions.GrowthYears, r.Options.GrowthYears)
+	require.Equal(t, DefaultDDMOptions.GrowthRate, r.Options.GrowthRate)
+	require.Equal(t, DefaultDDMOptions.MinPayoutRatio, r.Options.MinPayoutRatio)
+	require.NotNil(t, DDMOptions{DiscountRate: 2}.Validate())
+	_, err = DDM(_ctx, in, DDMOptions{DiscountRate: 2})
+	require.NotNil(t, err)
+
+	in.DPS = 0.5
+	_, err = DDM(_ctx, in, opts)
+	require.NotNil(t, err)
+
+	in.DPS = 0
+	_, err = DDM(_ctx, in, opts)
+	require.NotNil(t, err)
+}
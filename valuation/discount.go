@@ -0,0 +1,113 @@
+// 现金流折现通用计算
+
+package valuation
+
+import (
+	"fmt"
+	"math"
+)
+
+// DefaultRiskFreeRate 无法获取债券收益率时使用的无风险收益率（%）
+var DefaultRiskFreeRate = 3.0
+
+// resolveDiscountRate 返回折现率（%）：指定了折现率时直接使用，否则为债券收益率+风险溢价
+func resolveDiscountRate(rate, riskFreeRate, riskPremium float64) float64 {
+	if rate > 0 {
+		return rate
+	}
+	if riskFreeRate <= 0 {
+		riskFreeRate = DefaultRiskFreeRate
+	}
+	return riskFreeRate + riskPremium
+}
+
+// growthStage 增长阶段
+type growthStage struct {
+	// 年数
+	years int
+	// 增长率（%）
+	rate float64
+}
+
+// discountResult 现金流折现结果
+type discountResult struct {
+	// 各年预测现金流
+	projected []float64
+	// 预测期现值之和
+	presentValue float64
+	// 终值现值
+	terminalValue float64
+}
+
+// discount 将 base 按各阶段增长率逐年增长后以 discountRate 折现，预测期后按 terminalGrowth 永续增长计算终值，利率单位均为 %
+func discount(base, discountRate float64, stages []growthStage, terminalGrowth float64) (discountResult, error) {
+	r := discountRate / 100
+	g := terminalGrowth / 100
+	if r <= g {
+		return discountResult{}, fmt.Errorf("折现率%.2f%%需高于永续增长率%.2f%%", discountRate, terminalGrowth)
+	}
+	result := discountResult{projected: []float64{}}
+	cf := base
+	t := 0
+	for _, stage := range stages {
+		for i := 0; i < stage.years; i++ {
+			t++
+			cf *= 1 + stage.rate/100
+			result.projected = append(result.projected, cf)
+			result.presentValue += cf / math.Pow(1+r, float64(t))
+		}
+	}
+	result.terminalValue = cf * (1 + g) / (r - g) / math.Pow(1+r, float64(t))
+	return result, nil
+}
+
+// SensitivityTable 敏感性分析表，Values[i][j] 为折现率 DiscountRates[i]、永续增长率 TerminalGrowthRates[j] 时的每股价值，
+// 折现率不高于永续增长率时为 0
+type SensitivityTable struct {
+	// 折现率（%）
+	DiscountRates []float64 `json:"discount_rates"`
+	// 永续增长率（%）
+	TerminalGrowthRates []float64 `json:"terminal_growth_rates"`
+	// 每股价值
+	Values [][]float64 `json:"values"`
+}
+
+// sensitivityDiscountSteps 敏感性分析折现率的偏移（%）
+var sensitivityDiscountSteps = []float64{-2, -1, 0, 1, 2}
+
+// sensitivityGrowthSteps 敏感性分析永续增长率的偏移（%）
+var sensitivityGrowthSteps = []float64{-1, -0.5, 0, 0.5, 1}
+
+// newSensitivityTable 以 discountRate 和 terminalGrowth 为中心，按 valueFn 计算不同折现率和永续增长率下的每股价值
+func newSensitivityTable(discountRate, terminalGrowth float64, valueFn func(discountRate, terminalGrowth float64) (float64, error)) SensitivityTable {
+	table := SensitivityTable{
+		DiscountRates:       []float64{},
+		TerminalGrowthRates: []float64{},
+		Values:              [][]float64{},
+	}
+	for _, step := range sensitivityGrowthSteps {
+		table.TerminalGrowthRates = append(table.TerminalGrowthRates, terminalGrowth+step)
+	}
+	for _, step := range sensitivityDiscountSteps {
+		rate := discountRate + step
+		table.DiscountRates = append(table.DiscountRates, rate)
+		row := []float64{}
+		for _, growth := range table.TerminalGrowthRates {
+			value, err := valueFn(rate, growth)
+			if err != nil {
+				value = 0
+			}
+			row = append(row, value)
+		}
+		table.Values = append(table.Values, row)
+	}
+	return table
+}
+
+// marginOfSafety 安全边际（%）=(每股价值-股价)/每股价值
+func marginOfSafety(value, price float64) float64 {
+	if value <= 0 {
+		return 0
+	}
+	return (value - price) / value * 100
+}
@@ -0,0 +1,236 @@
+// Package valuation 股票估值模型
+package valuation
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/axiaoxin-com/investool/datacenter/eastmoney"
+	"github.com/axiaoxin-com/logging"
+)
+
+// Options 合理价估值参数，为 0 的参数使用默认值
+type Options struct {
+	// 基准 EPS 取近 n 年年报 EPS 均值
+	EPSYears int `json:"eps_years"                  form:"checker_valuation_eps_years"`
+	// 营收增长率上限（%）
+	MaxGrowthRate float64 `json:"max_growth_rate"            form:"checker_valuation_max_growth_rate"`
+	// 上年 EPS 增长率超过该值（%）时视为爆发增长
+	ExplosiveGrowthThreshold float64 `json:"explosive_growth_threshold" form:"checker_valuation_explosive_growth_threshold"`
+	// 爆发增长时合理价的折扣系数
+	ExplosiveGrowthDiscount float64 `json:"explosive_growth_discount"  form:"checker_valuation_explosive_growth_discount"`
+}
+
+// DefaultOptions 默认估值参数
+var DefaultOptions = Options{
+	EPSYears:                 3,
+	MaxGrowthRate:            50.0,
+	ExplosiveGrowthThreshold: 100.0,
+	ExplosiveGrowthDiscount:  0.7,
+}
+
+// WithDefaults 返回将 0 值参数替换为默认值后的参数
+func (o Options) WithDefaults() Options {
+	if o.EPSYears <= 0 {
+		o.EPSYears = DefaultOptions.EPSYears
+	}
+	if o.MaxGrowthRate == 0 {
+		o.MaxGrowthRate = DefaultOptions.MaxGrowthRate
+	}
+	if o.ExplosiveGrowthThreshold == 0 {
+		o.ExplosiveGrowthThreshold = DefaultOptions.ExplosiveGrowthThreshold
+	}
+	if o.ExplosiveGrowthDiscount == 0 {
+		o.ExplosiveGrowthDiscount = DefaultOptions.ExplosiveGrowthDiscount
+	}
+	return o
+}
+
+// Input 估值所需数据，财报及历史市盈率需已按 AsOf 过滤
+type Input struct {
+	// 历史财报
+	FinaMainData eastmoney.HistoricalFinaMainData
+	// 历史市盈率
+	PEList eastmoney.HistoricalPEList
+	// 估值时间点
+	AsOf time.Time
+	// 当前股价
+	Price float64
+}
+
+// Adjustment 估值过程中的调整项
+type Adjustment struct {
+	// 调整项标识: growth_cap 增长率上限, explosive_growth 爆发增长折扣
+	Name string `json:"name"`
+	// 调整说明
+	Desc string `json:"desc"`
+	// 调整前的值
+	Before float64 `json:"before"`
+	// 调整后的值
+	After float64 `json:"after"`
+}
+
+// Explanation 合理价估值过程说明
+type Explanation struct {
+	// 使用的估值参数
+	Options Options `json:"options"`
+	// 当前股价
+	Price float64 `json:"price"`
+	// 基准年报: 2021年报
+	ReportName string `json:"report_name"`
+	// 历史市盈率中位数
+	PEMedian float64 `json:"pe_median"`
+	// 近几年年报 EPS，最新的在最前面
+	EPSHistory []float64 `json:"eps_history"`
+	// 基准 EPS
+	BaseEPS float64 `json:"base_eps"`
+	// 基准 EPS 取值方式说明
+	BaseEPSMethod string `json:"base_eps_method"`
+	// 今年已发布财报的平均营收同比增长率（%）
+	RevenueGrowth float64 `json:"revenue_growth"`
+	// 计算合理价采用的增长率（%）
+	GrowthUsed float64 `json:"growth_used"`
+	// 调整项
+	Adjustments []Adjustment `json:"adjustments"`
+	// 是否回退为 PE 中位数 × 年报 EPS 的简单计算
+	Fallback bool `json:"fallback"`
+	// 回退原因
+	FallbackReason string `json:"fallback_reason"`
+	// 合理价
+	RightPrice float64 `json:"right_price"`
+	// 合理价差（%）
+	PriceSpace float64 `json:"price_space"`
+	// 去年合理价
+	LastYearRightPrice float64 `json:"last_year_right_price"`
+}
+
+// Lines 估值过程的文字说明
+func (e Explanation) Lines() []string {
+	lines := []string{
+		fmt.Sprintf("基准年报:%s", e.ReportName),
+		fmt.Sprintf("PE中位数:%.2f", e.PEMedian),
+		fmt.Sprintf("基准EPS:%.4f(%s)", e.BaseEPS, e.BaseEPSMethod),
+		fmt.Sprintf("营收增长率:%.2f%%,采用:%.2f%%", e.RevenueGrowth, e.GrowthUsed),
+	}
+	for _, a := range e.Adjustments {
+		lines = append(lines, fmt.Sprintf("调整:%s %.2f -> %.2f", a.Desc, a.Before, a.After))
+	}
+	if e.Fallback {
+		lines = append(lines, fmt.Sprintf("回退为PE中位数×年报EPS:%s", e.FallbackReason))
+	}
+	return lines
+}
+
+// reportName 年报名称
+func reportName(r *eastmoney.FinaMainData) string {
+	if r.ReportDateName != "" {
+		return r.ReportDateName
+	}
+	return r.ReportYear + "年报"
+}
+
+// RightPrice 按历史 PE 中位数 × 基准 EPS × (1 + 营收增长率) 估算合理价，并返回估值过程说明。
+// 基准 EPS 取近几年年报 EPS 均值（数据不足时取最新年报 EPS），增长率不超过上限，
+// 上年 EPS 爆发增长时对合理价打折，结果无效时回退为 PE 中位数 × 最新年报 EPS
+func RightPrice(ctx context.Context, in Input, opts Options) (Explanation, error) {
+	opts = opts.WithDefaults()
+	e := Explanation{
+		Options:     opts,
+		Price:       in.Price,
+		Adjustments: []Adjustment{},
+	}
+	data := in.FinaMainData
+	thisYear := in.AsOf.Year()
+	lastYearReport := data.GetReport(ctx, thisYear-1, eastmoney.FinaReportTypeYear)
+	beforeLastYearReport := data.GetReport(ctx, thisYear-2, eastmoney.FinaReportTypeYear)
+	growthYear := thisYear
+	// 新的一年刚开始时，上一年的年报还没披露，使用前年年报
+	if lastYearReport == nil {
+		logging.Debug(ctx, "valuation RightPrice get last year report nil, use before last year report")
+		lastYearReport = beforeLastYearReport
+		beforeLastYearReport = data.GetReport(ctx, thisYear-3, eastmoney.FinaReportTypeYear)
+		growthYear = thisYear - 1
+	}
+	if lastYearReport == nil {
+		return e, errors.New("no published year report as of " + in.AsOf.Format("2006-01-02"))
+	}
+	e.ReportName = reportName(lastYearReport)
+
+	peMidVal, err := in.PEList.GetMidValue(ctx)
+	if err != nil {
+		return e, err
+	}
+	e.PEMedian = peMidVal
+
+	// 使用多年 EPS 平均值，避免单年爆发增长的影响
+	e.EPSHistory = data.ValueList(ctx, eastmoney.ValueListTypeEPS, opts.EPSYears, eastmoney.FinaReportTypeYear)
+	if len(e.EPSHistory) >= opts.EPSYears {
+		sum := 0.0
+		for _, eps := range e.EPSHistory {
+			sum += eps
+		}
+		e.BaseEPS = sum / float64(len(e.EPSHistory))
+		e.BaseEPSMethod = fmt.Sprintf("近%d年年报EPS均值", opts.EPSYears)
+	} else {
+		e.BaseEPS = lastYearReport.Epsjb
+		e.BaseEPSMethod = e.ReportName + "EPS"
+	}
+
+	// 增长率上限，避免过度乐观
+	e.RevenueGrowth = data.GetAvgRevenueIncreasingRatioByYear(ctx, growthYear)
+	e.GrowthUsed = e.RevenueGrowth
+	if e.GrowthUsed > opts.MaxGrowthRate {
+		e.GrowthUsed = opts.MaxGrowthRate
+		e.Adjustments = append(e.Adjustments, Adjustment{
+			Name:   "growth_cap",
+			Desc:   fmt.Sprintf("增长率上限%.2f%%", opts.MaxGrowthRate),
+			Before: e.RevenueGrowth,
+			After:  e.GrowthUsed,
+		})
+	}
+
+	e.RightPrice = peMidVal * e.BaseEPS * (1 + e.GrowthUsed/100.0)
+
+	// 上年 EPS 爆发增长时打折
+	if len(e.EPSHistory) >= 2 && e.EPSHistory[1] > 0 {
+		lastYearGrowth := (e.EPSHistory[0] - e.EPSHistory[1]) / e.EPSHistory[1] * 100
+		if lastYearGrowth > opts.ExplosiveGrowthThreshold {
+			before := e.RightPrice
+			e.RightPrice *= opts.ExplosiveGrowthDiscount
+			e.Adjustments = append(e.Adjustments, Adjustment{
+				Name:   "explosive_growth",
+				Desc:   fmt.Sprintf("上年EPS增长%.2f%%,合理价×%v", lastYearGrowth, opts.ExplosiveGrowthDiscount),
+				Before: before,
+				After:  e.RightPrice,
+			})
+		}
+	}
+
+	// 去年的合理价，用于验证算法准确性
+	var lastYearBaseEPS float64
+	if len(e.EPSHistory) >= opts.EPSYears && len(e.EPSHistory) > 1 {
+		sum := 0.0
+		for _, eps := range e.EPSHistory[1:] {
+			sum += eps
+		}
+		lastYearBaseEPS = sum / float64(len(e.EPSHistory)-1)
+	} else if beforeLastYearReport != nil {
+		lastYearBaseEPS = beforeLastYearReport.Epsjb
+	}
+	lastYearGrowth := math.Min(data.GetAvgRevenueIncreasingRatioByYear(ctx, growthYear-1), opts.MaxGrowthRate)
+	e.LastYearRightPrice = peMidVal * lastYearBaseEPS * (1 + lastYearGrowth/100.0)
+
+	if math.IsNaN(e.RightPrice) || math.IsInf(e.RightPrice, 0) || e.RightPrice <= 0 {
+		e.Fallback = true
+		e.FallbackReason = fmt.Sprintf("估算合理价无效(%v)", e.RightPrice)
+		e.RightPrice = peMidVal * lastYearReport.Epsjb
+	}
+	if in.Price > 0 {
+		e.PriceSpace = (e.RightPrice - in.Price) / in.Price * 100
+	}
+	logging.Debugf(ctx, "valuation RightPrice: %+v", e)
+	return e, nil
+}
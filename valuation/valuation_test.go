@@ -0,0 +1,61 @@
+package valuation
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/axiaoxin-com/investool/datacenter/eastmoney"
+	"github.com/stretchr/testify/require"
+)
+
+var _ctx = context.TODO()
+
+func TestRightPrice(t *testing.T) {
+	in := Input{
+		FinaMainData: eastmoney.HistoricalFinaMainData{
+			{ReportType: eastmoney.FinaReportTypeQ1, ReportYear: "2022", Totaloperaterevetz: 80},
+			{ReportType: eastmoney.FinaReportTypeYear, ReportYear: "2021", ReportDateName: "2021年报", Epsjb: 3, Totaloperaterevetz: 20},
+			{ReportType: eastmoney.FinaReportTypeYear, ReportYear: "2020", ReportDateName: "2020年报", Epsjb: 1},
+			{ReportType: eastmoney.FinaReportTypeYear, ReportYear: "2019", ReportDateName: "2019年报", Epsjb: 2},
+		},
+		PEList: eastmoney.HistoricalPEList{{Value: 8}, {Value: 10}, {Value: 12}},
+		AsOf:   time.Date(2022, 5, 1, 0, 0, 0, 0, time.Local),
+		Price:  20,
+	}
+	e, err := RightPrice(_ctx, in, Options{})
+	require.Nil(t, err)
+	require.Equal(t, DefaultOptions, e.Options)
+	require.Equal(t, "2021年报", e.ReportName)
+	require.Equal(t, 10.0, e.PEMedian)
+	require.Equal(t, 2.0, e.BaseEPS)
+	require.Equal(t, 80.0, e.RevenueGrowth)
+	require.Equal(t, 50.0, e.GrowthUsed)
+	require.Len(t, e.Adjustments, 2)
+	require.Equal(t, "growth_cap", e.Adjustments[0].Name)
+	require.Equal(t, "explosive_growth", e.Adjustments[1].Name)
+	// 10 * 2 * 1.5 * 0.7
+	require.InDelta(t, 21.0, e.RightPrice, 1e-9)
+	require.InDelta(t, 5.0, e.PriceSpace, 1e-9)
+	// 10 * (1+2)/2 * 1.2
+	require.InDelta(t, 18.0, e.LastYearRightPrice, 1e-9)
+	require.False(t, e.Fallback)
+	require.NotEmpty(t, e.Lines())
+
+	e, err = RightPrice(_ctx, in, Options{EPSYears: 1, MaxGrowthRate: 100, ExplosiveGrowthDiscount: 1})
+	require.Nil(t, err)
+	require.Equal(t, 3.0, e.BaseEPS)
+	require.Empty(t, e.Adjustments)
+	require.InDelta(t, 54.0, e.RightPrice, 1e-9)
+
+	// 亏损时回退为 PE 中位数 × 年报 EPS
+	in.FinaMainData[0].Totaloperaterevetz = -200
+	e, err = RightPrice(_ctx, in, DefaultOptions)
+	require.Nil(t, err)
+	require.True(t, e.Fallback)
+	require.Equal(t, 30.0, e.RightPrice)
+
+	in.AsOf = time.Date(2019, 1, 1, 0, 0, 0, 0, time.Local)
+	_, err = RightPrice(_ctx, in, DefaultOptions)
+	require.NotNil(t, err)
+}